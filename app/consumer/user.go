@@ -0,0 +1,86 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	domain "prototype/domain/user"
+	"prototype/domain/user/models"
+	consumerLib "prototype/lib/consumer"
+	"prototype/lib/log"
+	"prototype/lib/pubsub"
+	"prototype/lib/tenant"
+
+	"gorm.io/gorm"
+)
+
+// OffboardedEvent is published by HR once an employee left, the user is
+// found by UserID or else by Email
+type OffboardedEvent struct {
+	TenantID uint   `json:"tenant_id"`
+	UserID   uint   `json:"user_id"`
+	Email    string `json:"email"`
+	Reason   string `json:"reason"`
+}
+
+type UserConsumer struct {
+	userUsecase domain.IUserUsecase
+	log         log.ILogs
+}
+
+func NewUserConsumer(userUsecase domain.IUserUsecase, log log.ILogs) *UserConsumer {
+	return &UserConsumer{
+		userUsecase,
+		log,
+	}
+}
+
+// Offboarded deactivate the user of an OffboardedEvent, a user already
+// deactivated is left as is
+func (handler *UserConsumer) Offboarded(ctx context.Context, message pubsub.Message, payload interface{}) error {
+	event := payload.(OffboardedEvent)
+	if event.TenantID == 0 || (event.UserID == 0 && event.Email == "") {
+		return consumerLib.Permanent(errors.New("tenant and user or email are required"))
+	}
+
+	ctx = tenant.WithID(ctx, event.TenantID)
+
+	id := event.UserID
+	if id == 0 {
+		users, err := handler.userUsecase.Fetch(ctx, models.UserFilter{Email: event.Email})
+		if err != nil {
+			handler.log.Error(ctx, "handler.userUsecase.Fetch Error", err)
+			return err
+		}
+
+		if len(users) == 0 {
+			return consumerLib.Permanent(fmt.Errorf("no user with email %s", event.Email))
+		}
+		id = users[0].ID
+	}
+
+	user, err := handler.userUsecase.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return consumerLib.Permanent(err)
+	}
+
+	if err != nil {
+		handler.log.Error(ctx, "handler.userUsecase.GetByID Error", err)
+		return err
+	}
+
+	if user.Status == models.StatusDeactivated {
+		return nil
+	}
+
+	reason := event.Reason
+	if reason == "" {
+		reason = "offboarded"
+	}
+
+	if _, err = handler.userUsecase.ChangeStatus(ctx, id, models.StatusDeactivated, reason); errors.Is(err, domain.ErrInvalidTransition) {
+		return consumerLib.Permanent(err)
+	}
+
+	return err
+}
@@ -0,0 +1,55 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"prototype/domain/user/mocks"
+	"prototype/domain/user/models"
+	consumerLib "prototype/lib/consumer"
+	"prototype/lib/log"
+	"prototype/lib/pubsub"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestUserConsumer_Offboarded(t *testing.T) {
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("GetByID", mock.Anything, uint(1)).Return(models.User{ID: 1, Status: models.StatusActive}, nil)
+	userUsecase.On("GetByID", mock.Anything, uint(2)).Return(models.User{ID: 2, Status: models.StatusDeactivated}, nil)
+	userUsecase.On("GetByID", mock.Anything, uint(3)).Return(models.User{}, gorm.ErrRecordNotFound)
+	userUsecase.On("Fetch", mock.Anything, models.UserFilter{Email: "gone@mail.com"}).Return([]models.User{{ID: 1}}, nil)
+	userUsecase.On("Fetch", mock.Anything, models.UserFilter{Email: "unknown@mail.com"}).Return([]models.User{}, nil)
+	userUsecase.On("ChangeStatus", mock.Anything, uint(1), models.StatusDeactivated, "offboarded").Return(models.User{}, nil)
+
+	tests := []struct {
+		name          string
+		event         OffboardedEvent
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{name: "by id", event: OffboardedEvent{TenantID: 1, UserID: 1}},
+		{name: "by email", event: OffboardedEvent{TenantID: 1, Email: "gone@mail.com"}},
+		{name: "already deactivated", event: OffboardedEvent{TenantID: 1, UserID: 2}},
+		{name: "missing tenant", event: OffboardedEvent{UserID: 1}, wantErr: true, wantPermanent: true},
+		{name: "unknown email", event: OffboardedEvent{TenantID: 1, Email: "unknown@mail.com"}, wantErr: true, wantPermanent: true},
+		{name: "unknown user", event: OffboardedEvent{TenantID: 1, UserID: 3}, wantErr: true, wantPermanent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewUserConsumer(userUsecase, log.NewLog())
+
+			err := handler.Offboarded(context.Background(), pubsub.Message{}, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserConsumer.Offboarded() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if errors.Is(err, consumerLib.ErrPermanent) != tt.wantPermanent {
+				t.Errorf("UserConsumer.Offboarded() error = %v, wantPermanent %v", err, tt.wantPermanent)
+			}
+		})
+	}
+
+	userUsecase.AssertNumberOfCalls(t, "ChangeStatus", 2)
+}
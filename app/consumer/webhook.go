@@ -0,0 +1,48 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	userModels "prototype/domain/user/models"
+	domain "prototype/domain/webhook"
+	consumerLib "prototype/lib/consumer"
+	"prototype/lib/log"
+	"prototype/lib/outbox"
+	"prototype/lib/pubsub"
+	"prototype/lib/tenant"
+)
+
+type WebhookConsumer struct {
+	webhookUsecase domain.IWebhookUsecase
+	log            log.ILogs
+}
+
+func NewWebhookConsumer(webhookUsecase domain.IWebhookUsecase, log log.ILogs) *WebhookConsumer {
+	return &WebhookConsumer{
+		webhookUsecase,
+		log,
+	}
+}
+
+// UserEvent queue a delivery of a user event to the webhook of its tenant,
+// the payload is posted as published so partner get the versioned event
+func (handler *WebhookConsumer) UserEvent(ctx context.Context, message pubsub.Message, payload interface{}) error {
+	event := payload.(userModels.UserEvent)
+	if event.TenantID == 0 || event.Type == "" {
+		return consumerLib.Permanent(errors.New("tenant and type are required"))
+	}
+
+	ctx = tenant.WithID(ctx, event.TenantID)
+
+	eventID := message.Attributes[outbox.AttributeEventID]
+	if eventID == "" {
+		eventID = message.ID
+	}
+
+	if _, err := handler.webhookUsecase.Dispatch(ctx, eventID, event.Type, message.Data); err != nil {
+		handler.log.Error(ctx, "handler.webhookUsecase.Dispatch Error", err)
+		return err
+	}
+
+	return nil
+}
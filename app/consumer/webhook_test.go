@@ -0,0 +1,69 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	userModels "prototype/domain/user/models"
+	"prototype/domain/webhook/mocks"
+	consumerLib "prototype/lib/consumer"
+	"prototype/lib/log"
+	"prototype/lib/outbox"
+	"prototype/lib/pubsub"
+	"prototype/lib/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookConsumer_UserEvent(t *testing.T) {
+	inTenant := mock.MatchedBy(func(ctx context.Context) bool {
+		id, ok := tenant.FromContext(ctx)
+		return ok && id == 1
+	})
+
+	webhookUsecase := new(mocks.WebhookUsecase)
+	webhookUsecase.On("Dispatch", inTenant, "event-1", userModels.EventUserCreated, []byte(`{"type":"user.created"}`)).Return(1, nil)
+	webhookUsecase.On("Dispatch", inTenant, "message-2", userModels.EventUserDeleted, []byte(`{"type":"user.deleted"}`)).Return(0, nil)
+
+	tests := []struct {
+		name          string
+		message       pubsub.Message
+		event         userModels.UserEvent
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{
+			name:    "by outbox event id",
+			message: pubsub.Message{ID: "message-1", Data: []byte(`{"type":"user.created"}`), Attributes: map[string]string{outbox.AttributeEventID: "event-1"}},
+			event:   userModels.UserEvent{Type: userModels.EventUserCreated, TenantID: 1},
+		},
+		{
+			name:    "by message id",
+			message: pubsub.Message{ID: "message-2", Data: []byte(`{"type":"user.deleted"}`)},
+			event:   userModels.UserEvent{Type: userModels.EventUserDeleted, TenantID: 1},
+		},
+		{
+			name:          "missing tenant",
+			message:       pubsub.Message{ID: "message-3"},
+			event:         userModels.UserEvent{Type: userModels.EventUserCreated},
+			wantErr:       true,
+			wantPermanent: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewWebhookConsumer(webhookUsecase, log.NewLog())
+
+			err := handler.UserEvent(context.Background(), tt.message, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WebhookConsumer.UserEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if errors.Is(err, consumerLib.ErrPermanent) != tt.wantPermanent {
+				t.Errorf("WebhookConsumer.UserEvent() error = %v, wantPermanent %v", err, tt.wantPermanent)
+			}
+		})
+	}
+
+	webhookUsecase.AssertNumberOfCalls(t, "Dispatch", 2)
+}
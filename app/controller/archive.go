@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"net/http"
+	model "prototype/domain/user/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maskedArchive is a UserArchive whose user went through mask
+type maskedArchive struct {
+	model.UserArchive
+	User interface{} `json:"user"`
+}
+
+func (handler *UserController) FetchArchive(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	page, limit := pagination(c)
+
+	archives, total, err := handler.userUsecase.FetchArchive(ctx, page, limit)
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.FetchArchive Error", err)
+
+		return
+	}
+
+	masked := make([]maskedArchive, len(archives))
+	for i, archive := range archives {
+		masked[i] = maskedArchive{archive, handler.mask(ctx, archive.User)}
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, masked, nil)
+	res.SetPagination(page, limit, total)
+}
+
+// RetentionReport run the retention policy of the tenant as a dry run
+func (handler *UserController) RetentionReport(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	report, err := handler.userUsecase.Retention(ctx, true)
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.Retention Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, report, nil)
+}
+
+func (handler *UserController) Restore(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	user, err := handler.userUsecase.Restore(ctx, uint(userIdP))
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.Restore Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, handler.mask(ctx, user), nil)
+}
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	domain "prototype/domain/invitation"
+	model "prototype/domain/invitation/models"
+	userDomain "prototype/domain/user"
+	"prototype/lib/log"
+	"prototype/lib/masking"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationController struct {
+	invitationUsecase domain.IInvitationUsecase
+	masking           masking.Policy
+	log               log.ILogs
+}
+
+func NewInvitationController(invitationUsecase domain.IInvitationUsecase, masking masking.Policy, log log.ILogs) *InvitationController {
+	return &InvitationController{
+		invitationUsecase,
+		masking,
+		log,
+	}
+}
+
+func (handler *InvitationController) Fetch(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	page, limit := pagination(c)
+
+	filter := model.InvitationFilter{Email: c.Query("email")}
+	if status := c.Query("status"); status != "" {
+		filter.Status = strings.Split(status, ",")
+	}
+
+	invitations, total, err := handler.invitationUsecase.Fetch(ctx, filter, page, limit)
+
+	if err != nil {
+
+		statusCode = invitationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.invitationUsecase.Fetch Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, invitations, nil)
+	res.SetPagination(page, limit, total)
+}
+
+func (handler *InvitationController) Create(c *gin.Context) {
+	var (
+		statusCode int
+		request    model.Invitation
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	invitation, err := handler.invitationUsecase.Create(ctx, request)
+
+	if err != nil {
+
+		statusCode = invitationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.invitationUsecase.Create Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, invitation, nil)
+}
+
+func (handler *InvitationController) Resend(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	invitationIdP, err := strconv.Atoi(c.Param("invitation_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('invitation_id')) Error", err)
+
+		return
+	}
+
+	invitation, err := handler.invitationUsecase.Resend(ctx, uint(invitationIdP))
+
+	if err != nil {
+
+		statusCode = invitationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.invitationUsecase.Resend Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, invitation, nil)
+}
+
+func (handler *InvitationController) Revoke(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	invitationIdP, err := strconv.Atoi(c.Param("invitation_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('invitation_id')) Error", err)
+
+		return
+	}
+
+	invitation, err := handler.invitationUsecase.Revoke(ctx, uint(invitationIdP))
+
+	if err != nil {
+
+		statusCode = invitationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.invitationUsecase.Revoke Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, invitation, nil)
+}
+
+// Accept create the invited user, the token is the only credential so the
+// route is reachable anonymously
+func (handler *InvitationController) Accept(c *gin.Context) {
+	var (
+		statusCode int
+		request    model.AcceptRequest
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	user, err := handler.invitationUsecase.Accept(ctx, request)
+
+	if err != nil {
+
+		statusCode = invitationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.invitationUsecase.Accept Error", err)
+
+		return
+	}
+
+	// the caller is the invitee who just became user
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, handler.masking.Mask(masking.RoleSelf, user), nil)
+}
+
+// invitationErrorStatusCode mapping usecase error to http status code
+func invitationErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidRole), errors.Is(err, domain.ErrInvalidStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrInvitationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvitationExpired), errors.Is(err, domain.ErrInvitationNotPending), errors.Is(err, domain.ErrAlreadyInvited), errors.Is(err, userDomain.ErrEmailTaken):
+		return http.StatusConflict
+	default:
+		return organizationErrorStatusCode(err)
+	}
+}
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	domain "prototype/domain/invitation"
+	"prototype/domain/invitation/mocks"
+	"prototype/domain/invitation/models"
+	userDomain "prototype/domain/user"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupInvitation(invitationUsecase domain.IInvitationUsecase) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	handler := &InvitationController{
+		invitationUsecase: invitationUsecase,
+		log:               log.NewLog(),
+	}
+
+	g.POST("/invitation", handler.Create)
+	g.POST("/invitation/accept", handler.Accept)
+	g.DELETE("/invitation/:invitation_id", handler.Revoke)
+
+	return g
+}
+
+func TestInvitationController_Create(t *testing.T) {
+	invitationUsecase := new(mocks.InvitationUsecase)
+	invitationUsecase.On("Create", mock.Anything, models.Invitation{Email: "new@mail.com", Role: userModels.RoleAdmin}).
+		Return(models.Invitation{ID: 1, Email: "new@mail.com", Role: userModels.RoleAdmin, Status: models.StatusPending, TokenHash: "secret"}, nil)
+	invitationUsecase.On("Create", mock.Anything, models.Invitation{Email: "pending@mail.com"}).
+		Return(models.Invitation{}, domain.ErrAlreadyInvited)
+	invitationUsecase.On("Create", mock.Anything, models.Invitation{Email: "taken@mail.com"}).
+		Return(models.Invitation{}, userDomain.ErrEmailTaken)
+	invitationUsecase.On("Create", mock.Anything, models.Invitation{Email: "new@mail.com", Role: "root"}).
+		Return(models.Invitation{}, domain.ErrInvalidRole)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			body:     `{"email": "new@mail.com", "role": "admin"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed already invited",
+			body:     `{"email": "pending@mail.com"}`,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "failed email taken",
+			body:     `{"email": "taken@mail.com"}`,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "failed invalid role",
+			body:     `{"email": "new@mail.com", "role": "root"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "failed invalid email",
+			body:     `{"email": "not-an-email"}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupInvitation(invitationUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("POST", "/invitation", bytes.NewReader([]byte(tt.body)))
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.NotContains(t, w.Body.String(), "secret")
+		})
+	}
+}
+
+func TestInvitationController_Accept(t *testing.T) {
+	invitationUsecase := new(mocks.InvitationUsecase)
+	invitationUsecase.On("Accept", mock.Anything, models.AcceptRequest{Token: "valid", Username: "new"}).
+		Return(userModels.User{ID: 7, Email: "new@mail.com", Role: userModels.RoleAdmin}, nil)
+	invitationUsecase.On("Accept", mock.Anything, models.AcceptRequest{Token: "expired", Username: "new"}).
+		Return(userModels.User{}, domain.ErrInvitationExpired)
+	invitationUsecase.On("Accept", mock.Anything, models.AcceptRequest{Token: "unknown", Username: "new"}).
+		Return(userModels.User{}, domain.ErrInvitationNotFound)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			body:     `{"token": "valid", "username": "new"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed expired",
+			body:     `{"token": "expired", "username": "new"}`,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "failed unknown token",
+			body:     `{"token": "unknown", "username": "new"}`,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "failed missing token",
+			body:     `{"username": "new"}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupInvitation(invitationUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("POST", "/invitation/accept", bytes.NewReader([]byte(tt.body)))
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestInvitationController_Revoke(t *testing.T) {
+	invitationUsecase := new(mocks.InvitationUsecase)
+	invitationUsecase.On("Revoke", mock.Anything, uint(1)).Return(models.Invitation{ID: 1, Status: models.StatusRevoked}, nil)
+	invitationUsecase.On("Revoke", mock.Anything, uint(2)).Return(models.Invitation{}, domain.ErrInvitationNotPending)
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			path:     "/invitation/1",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed already accepted",
+			path:     "/invitation/2",
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "failed invalid id",
+			path:     "/invitation/abc",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupInvitation(invitationUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("DELETE", tt.path, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
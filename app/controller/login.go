@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"net/http"
+	domain "prototype/domain/user/logins"
+	"prototype/lib/log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginController struct {
+	loginUsecase domain.ILoginUsecase
+	log          log.ILogs
+}
+
+func NewLoginController(loginUsecase domain.ILoginUsecase, log log.ILogs) *LoginController {
+	return &LoginController{
+		loginUsecase,
+		log,
+	}
+}
+
+// Fetch return the login history of the user, newest first
+func (handler *LoginController) Fetch(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	page, limit := pagination(c)
+
+	logins, total, err := handler.loginUsecase.Fetch(ctx, uint(userIdP), page, limit)
+
+	if err != nil {
+
+		statusCode = http.StatusInternalServerError
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.loginUsecase.Fetch Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, logins, nil)
+	res.SetPagination(page, limit, total)
+}
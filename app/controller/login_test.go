@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	domain "prototype/domain/user/logins"
+	"prototype/domain/user/logins/mocks"
+	"prototype/domain/user/logins/models"
+	"prototype/lib/log"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupLogin(loginUsecase domain.ILoginUsecase) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	handler := &LoginController{
+		loginUsecase: loginUsecase,
+		log:          log.NewLog(),
+	}
+
+	g.GET("/user/:user_id/logins", handler.Fetch)
+
+	return g
+}
+
+func TestLoginController_Fetch(t *testing.T) {
+	loginUsecase := new(mocks.LoginUsecase)
+	loginUsecase.On("Fetch", mock.Anything, uint(1), 2, 10).Return([]models.Login{
+		{ID: 11, UserID: 1, Method: models.MethodGateway, IP: "10.0.0.1", Success: true},
+	}, int64(11), nil)
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "success",
+			path:     "/user/1/logins?page=2&limit=10",
+			wantCode: http.StatusOK,
+			wantBody: `"total_page":2`,
+		},
+		{
+			name:     "failed invalid id",
+			path:     "/user/abc/logins",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupLogin(loginUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
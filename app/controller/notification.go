@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"prototype/lib/log"
+	"prototype/lib/notify"
+	"prototype/lib/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+type NotificationController struct {
+	hub      *notify.Hub
+	upgrader websocket.Upgrader
+	config   notify.SocketConfig
+	log      log.ILogs
+}
+
+// NewNotificationController accept socket from origins only, without origins
+// the origin must be the host of the request
+func NewNotificationController(hub *notify.Hub, origins []string, config notify.SocketConfig, log log.ILogs) *NotificationController {
+	upgrader := websocket.Upgrader{}
+
+	if len(origins) > 0 {
+		allowed := map[string]bool{}
+		for _, origin := range origins {
+			allowed[origin] = true
+		}
+
+		upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+
+			parsed, err := url.Parse(origin)
+			return err == nil && allowed[parsed.Scheme+"://"+parsed.Host]
+		}
+	}
+
+	return &NotificationController{
+		hub,
+		upgrader,
+		config,
+		log,
+	}
+}
+
+// Connect upgrade the request to the notification socket of the signed in
+// user. The client subscribe to topic with {"type": "subscribe", "topics":
+// [...]} and get each notification as {"type": "notification", ...}, a
+// session revoked is always sent and close the socket
+func (handler *NotificationController) Connect(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	userID, ok := ctx.Value("user-id").(uint)
+	if !ok {
+		err := errors.New("authentication required")
+
+		statusCode = http.StatusUnauthorized
+		res.Set(statusCode, nil, err)
+		handler.log.Warning(ctx, "handler.Connect Rejected", err.Error())
+		c.JSON(statusCode, res)
+
+		return
+	}
+
+	tenantID, _ := tenant.FromContext(ctx)
+
+	conn, err := handler.hub.Register(tenantID, userID)
+	if err != nil {
+
+		statusCode = notificationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Warning(ctx, "handler.hub.Register Error", err.Error())
+		c.JSON(statusCode, res)
+
+		return
+	}
+	defer handler.hub.Unregister(conn)
+
+	// the upgrader answer the client itself when the upgrade fail
+	socket, err := handler.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		handler.log.Warning(ctx, "handler.upgrader.Upgrade Error", err.Error())
+		return
+	}
+
+	notify.Serve(ctx, socket, conn, handler.config, handler.log)
+}
+
+func notificationErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, notify.ErrTooManyConnections):
+		return http.StatusTooManyRequests
+	case errors.Is(err, notify.ErrClosed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
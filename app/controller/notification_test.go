@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"prototype/lib/log"
+	"prototype/lib/notify"
+	"prototype/lib/tenant"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupNotification serve the socket as the user of the User-ID header of
+// tenant 1, the way the authentication middleware would
+func setupNotification(hub *notify.Hub) *httptest.Server {
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	handler := NewNotificationController(hub, nil, notify.SocketConfig{
+		PingInterval: time.Minute,
+		PongWait:     time.Minute,
+		WriteWait:    time.Second,
+		MaxMessage:   1024,
+	}, log.NewLog())
+
+	g.Use(func(c *gin.Context) {
+		ctx := tenant.WithID(c.Request.Context(), 1)
+		if userID, err := strconv.Atoi(c.GetHeader("User-ID")); err == nil {
+			ctx = context.WithValue(ctx, "user-id", uint(userID))
+		}
+		c.Request = c.Request.WithContext(ctx)
+	})
+	g.GET("/notification/socket", handler.Connect)
+
+	return httptest.NewServer(g)
+}
+
+func dialNotification(server *httptest.Server, userID string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	if userID != "" {
+		header.Set("User-ID", userID)
+	}
+
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/notification/socket", header)
+}
+
+func TestNotificationController_Connect(t *testing.T) {
+	hub := notify.NewHub(notify.Config{MaxConnections: 1, SendBuffer: 10})
+	notifier := notify.NewNotifier(hub, nil, "")
+
+	server := setupNotification(hub)
+	defer server.Close()
+
+	_, res, err := dialNotification(server, "")
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	}
+
+	socket, _, err := dialNotification(server, "10")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer socket.Close()
+	socket.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	_, res, err = dialNotification(server, "10")
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	}
+
+	var message notify.ServerMessage
+
+	socket.WriteJSON(notify.ClientMessage{Type: notify.MessageSubscribe, Topics: []string{"role.changed"}})
+	socket.ReadJSON(&message)
+	assert.Equal(t, notify.ServerMessage{Type: notify.MessageSubscribed, Topics: []string{"role.changed"}}, message)
+
+	socket.WriteJSON(notify.ClientMessage{Type: notify.MessagePing})
+	message = notify.ServerMessage{}
+	socket.ReadJSON(&message)
+	assert.Equal(t, notify.MessagePong, message.Type)
+
+	socket.WriteMessage(websocket.TextMessage, []byte("hello"))
+	message = notify.ServerMessage{}
+	socket.ReadJSON(&message)
+	assert.Equal(t, notify.MessageError, message.Type)
+
+	ctx := tenant.WithID(context.Background(), 1)
+	notifier.Notify(ctx, 10, notify.Payload{Topic: "other"})
+	notifier.Notify(ctx, 10, notify.Payload{Topic: "role.changed", Data: gin.H{"role": "admin"}})
+
+	message = notify.ServerMessage{}
+	socket.ReadJSON(&message)
+	if assert.Equal(t, notify.MessageNotification, message.Type) && assert.NotNil(t, message.Notification) {
+		assert.Equal(t, "role.changed", message.Topic)
+		assert.JSONEq(t, `{"role": "admin"}`, string(message.Data))
+	}
+
+	// a revoked session get the notification and the socket is closed
+	notifier.Notify(ctx, 10, notify.Payload{Topic: notify.TopicSessionRevoked})
+
+	message = notify.ServerMessage{}
+	socket.ReadJSON(&message)
+	assert.Equal(t, notify.TopicSessionRevoked, message.Topic)
+
+	_, _, err = socket.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "socket.ReadMessage() error = %v", err)
+}
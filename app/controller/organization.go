@@ -0,0 +1,450 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	domain "prototype/domain/organization"
+	model "prototype/domain/organization/models"
+	"prototype/lib/log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OrganizationController struct {
+	organizationUsecase domain.IOrganizationUsecase
+	log                 log.ILogs
+}
+
+type MemberRequest struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required"`
+}
+
+type MemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+var errAnonymous = errors.New("authentication required")
+
+func NewOrganizationController(organizationUsecase domain.IOrganizationUsecase, log log.ILogs) *OrganizationController {
+	return &OrganizationController{
+		organizationUsecase,
+		log,
+	}
+}
+
+// Create the organization, the caller become its first owner
+func (handler *OrganizationController) Create(c *gin.Context) {
+	var (
+		statusCode int
+		request    model.Organization
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	ownerID, ok := ctx.Value("user-id").(uint)
+	if !ok {
+
+		statusCode = http.StatusUnauthorized
+		res.Set(statusCode, nil, errAnonymous)
+		handler.log.Warning(ctx, "handler.Create Rejected", errAnonymous.Error())
+
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	organization, err := handler.organizationUsecase.Create(ctx, request, ownerID)
+
+	if err != nil {
+
+		statusCode = organizationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.organizationUsecase.Create Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, organization, nil)
+}
+
+func (handler *OrganizationController) GetByID(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	organizationIdP, err := strconv.Atoi(c.Param("organization_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('organization_id')) Error", err)
+
+		return
+	}
+
+	organization, err := handler.organizationUsecase.GetByID(ctx, uint(organizationIdP))
+
+	if err != nil {
+
+		statusCode = organizationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.organizationUsecase.GetByID Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, organization, nil)
+}
+
+func (handler *OrganizationController) Update(c *gin.Context) {
+	var (
+		statusCode int
+		request    model.Organization
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	organizationIdP, err := strconv.Atoi(c.Param("organization_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('organization_id')) Error", err)
+
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	request.ID = uint(organizationIdP)
+
+	organization, err := handler.organizationUsecase.Update(ctx, request)
+
+	if err != nil {
+
+		statusCode = organizationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.organizationUsecase.Update Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, organization, nil)
+}
+
+func (handler *OrganizationController) Delete(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	organizationIdP, err := strconv.Atoi(c.Param("organization_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('organization_id')) Error", err)
+
+		return
+	}
+
+	if err := handler.organizationUsecase.Delete(ctx, uint(organizationIdP)); err != nil {
+
+		statusCode = organizationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.organizationUsecase.Delete Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, nil, nil)
+}
+
+func (handler *OrganizationController) Members(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	organizationIdP, err := strconv.Atoi(c.Param("organization_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('organization_id')) Error", err)
+
+		return
+	}
+
+	page, limit := pagination(c)
+
+	members, total, err := handler.organizationUsecase.Members(ctx, uint(organizationIdP), page, limit)
+
+	if err != nil {
+
+		statusCode = organizationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.organizationUsecase.Members Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, members, nil)
+	res.SetPagination(page, limit, total)
+}
+
+func (handler *OrganizationController) AddMember(c *gin.Context) {
+	var (
+		statusCode int
+		request    MemberRequest
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	organizationIdP, err := strconv.Atoi(c.Param("organization_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('organization_id')) Error", err)
+
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	membership, err := handler.organizationUsecase.AddMember(ctx, uint(organizationIdP), request.UserID, request.Role)
+
+	if err != nil {
+
+		statusCode = organizationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.organizationUsecase.AddMember Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, membership, nil)
+}
+
+func (handler *OrganizationController) ChangeMemberRole(c *gin.Context) {
+	var (
+		statusCode int
+		request    MemberRoleRequest
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	organizationIdP, err := strconv.Atoi(c.Param("organization_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('organization_id')) Error", err)
+
+		return
+	}
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	membership, err := handler.organizationUsecase.ChangeMemberRole(ctx, uint(organizationIdP), uint(userIdP), request.Role)
+
+	if err != nil {
+
+		statusCode = organizationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.organizationUsecase.ChangeMemberRole Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, membership, nil)
+}
+
+func (handler *OrganizationController) RemoveMember(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	organizationIdP, err := strconv.Atoi(c.Param("organization_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('organization_id')) Error", err)
+
+		return
+	}
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	if err := handler.organizationUsecase.RemoveMember(ctx, uint(organizationIdP), uint(userIdP)); err != nil {
+
+		statusCode = organizationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.organizationUsecase.RemoveMember Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, nil, nil)
+}
+
+func (handler *OrganizationController) UserOrganizations(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	page, limit := pagination(c)
+
+	organizations, total, err := handler.organizationUsecase.UserOrganizations(ctx, uint(userIdP), page, limit)
+
+	if err != nil {
+
+		statusCode = organizationErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.organizationUsecase.UserOrganizations Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, organizations, nil)
+	res.SetPagination(page, limit, total)
+}
+
+// organizationErrorStatusCode mapping usecase error to http status code
+func organizationErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidRole):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrInsufficientRole):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrOrganizationNotFound), errors.Is(err, domain.ErrNotMember), errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrSlugTaken), errors.Is(err, domain.ErrAlreadyMember), errors.Is(err, domain.ErrLastOwner):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
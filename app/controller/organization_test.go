@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	domain "prototype/domain/organization"
+	"prototype/domain/organization/mocks"
+	"prototype/domain/organization/models"
+	"prototype/lib/log"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupOrganization(organizationUsecase domain.IOrganizationUsecase) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	// stand in for the authentication middleware
+	g.Use(func(c *gin.Context) {
+		if c.GetHeader("User-ID") == "1" {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), "user-id", uint(1)))
+		}
+	})
+
+	handler := &OrganizationController{
+		organizationUsecase: organizationUsecase,
+		log:                 log.NewLog(),
+	}
+
+	g.POST("/organization", handler.Create)
+	g.GET("/organization/:organization_id/members", handler.Members)
+	g.POST("/organization/:organization_id/members", handler.AddMember)
+	g.DELETE("/organization/:organization_id/members/:user_id", handler.RemoveMember)
+	g.GET("/user/:user_id/organizations", handler.UserOrganizations)
+
+	return g
+}
+
+func TestOrganizationController_Create(t *testing.T) {
+	organizationUsecase := new(mocks.OrganizationUsecase)
+	organizationUsecase.On("Create", mock.Anything, models.Organization{Name: "Acme", Slug: "acme"}, uint(1)).
+		Return(models.Organization{ID: 1, Name: "Acme", Slug: "acme"}, nil)
+	organizationUsecase.On("Create", mock.Anything, models.Organization{Name: "Acme", Slug: "taken"}, uint(1)).
+		Return(models.Organization{}, domain.ErrSlugTaken)
+
+	tests := []struct {
+		name     string
+		userID   string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			userID:   "1",
+			body:     `{"name": "Acme", "slug": "acme"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed slug taken",
+			userID:   "1",
+			body:     `{"name": "Acme", "slug": "taken"}`,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "failed anonymous",
+			body:     `{"name": "Acme", "slug": "acme"}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "failed missing slug",
+			userID:   "1",
+			body:     `{"name": "Acme"}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupOrganization(organizationUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("POST", "/organization", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("User-ID", tt.userID)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestOrganizationController_Members(t *testing.T) {
+	organizationUsecase := new(mocks.OrganizationUsecase)
+	organizationUsecase.On("Members", mock.Anything, uint(1), 2, 10).Return([]models.Membership{
+		{OrganizationID: 1, UserID: 11, Role: models.RoleMember},
+	}, int64(11), nil)
+
+	g := setupOrganization(organizationUsecase)
+
+	w := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/organization/1/members?page=2&limit=10", nil)
+	g.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total_page":2`)
+	organizationUsecase.AssertExpectations(t)
+}
+
+func TestOrganizationController_AddMember(t *testing.T) {
+	organizationUsecase := new(mocks.OrganizationUsecase)
+	organizationUsecase.On("AddMember", mock.Anything, uint(1), uint(20), models.RoleMember).
+		Return(models.Membership{OrganizationID: 1, UserID: 20, Role: models.RoleMember}, nil)
+	organizationUsecase.On("AddMember", mock.Anything, uint(1), uint(20), models.RoleOwner).
+		Return(models.Membership{}, domain.ErrInsufficientRole)
+	organizationUsecase.On("AddMember", mock.Anything, uint(1), uint(20), "superuser").
+		Return(models.Membership{}, domain.ErrInvalidRole)
+	organizationUsecase.On("AddMember", mock.Anything, uint(1), uint(21), models.RoleMember).
+		Return(models.Membership{}, domain.ErrAlreadyMember)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			body:     `{"user_id": 20, "role": "member"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed insufficient role",
+			body:     `{"user_id": 20, "role": "owner"}`,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "failed invalid role",
+			body:     `{"user_id": 20, "role": "superuser"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "failed already member",
+			body:     `{"user_id": 21, "role": "member"}`,
+			wantCode: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupOrganization(organizationUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("POST", "/organization/1/members", bytes.NewReader([]byte(tt.body)))
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestOrganizationController_RemoveMember(t *testing.T) {
+	organizationUsecase := new(mocks.OrganizationUsecase)
+	organizationUsecase.On("RemoveMember", mock.Anything, uint(1), uint(20)).Return(nil)
+	organizationUsecase.On("RemoveMember", mock.Anything, uint(1), uint(10)).Return(domain.ErrLastOwner)
+	organizationUsecase.On("RemoveMember", mock.Anything, uint(1), uint(30)).Return(domain.ErrNotMember)
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			url:      "/organization/1/members/20",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed last owner",
+			url:      "/organization/1/members/10",
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "failed not a member",
+			url:      "/organization/1/members/30",
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupOrganization(organizationUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("DELETE", tt.url, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
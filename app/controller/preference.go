@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	domain "prototype/domain/user/preferences"
+	"prototype/lib/log"
+	"prototype/lib/validation"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PreferenceController struct {
+	preferenceUsecase domain.IPreferenceUsecase
+	log               log.ILogs
+}
+
+type PreferenceRequest struct {
+	Value   interface{} `json:"value" binding:"required"`
+	Version uint        `json:"version"`
+}
+
+func NewPreferenceController(preferenceUsecase domain.IPreferenceUsecase, log log.ILogs) *PreferenceController {
+	return &PreferenceController{
+		preferenceUsecase,
+		log,
+	}
+}
+
+func (handler *PreferenceController) Fetch(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	var keys []string
+	if query := c.Query("keys"); query != "" {
+		keys = strings.Split(query, ",")
+	}
+
+	preferences, err := handler.preferenceUsecase.Fetch(ctx, uint(userIdP), keys)
+
+	if err != nil {
+
+		statusCode = preferenceErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.preferenceUsecase.Fetch Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, preferences, nil)
+}
+
+func (handler *PreferenceController) Get(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	preference, err := handler.preferenceUsecase.Get(ctx, uint(userIdP), c.Param("key"))
+
+	if err != nil {
+
+		statusCode = preferenceErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.preferenceUsecase.Get Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, preference, nil)
+}
+
+func (handler *PreferenceController) Put(c *gin.Context) {
+	var (
+		statusCode int
+		request    PreferenceRequest
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	preference, err := handler.preferenceUsecase.Put(ctx, uint(userIdP), c.Param("key"), request.Value, request.Version)
+
+	if err != nil {
+
+		statusCode = preferenceErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.preferenceUsecase.Put Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, preference, nil)
+}
+
+// Delete reset the key to its default, the optional version query make the
+// delete conditional
+func (handler *PreferenceController) Delete(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	version, err := strconv.ParseUint(c.DefaultQuery("version", "0"), 10, 64)
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.ParseUint(c.Query('version')) Error", err)
+
+		return
+	}
+
+	preference, err := handler.preferenceUsecase.Delete(ctx, uint(userIdP), c.Param("key"), uint(version))
+
+	if err != nil {
+
+		statusCode = preferenceErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.preferenceUsecase.Delete Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, preference, nil)
+}
+
+// preferenceErrorStatusCode mapping usecase error to http status code
+func preferenceErrorStatusCode(err error) int {
+	var validationErr validation.Error
+
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrUnknownPreference):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrVersionConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
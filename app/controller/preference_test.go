@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	domain "prototype/domain/user/preferences"
+	"prototype/domain/user/preferences/mocks"
+	"prototype/domain/user/preferences/models"
+	"prototype/lib/log"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupPreference(preferenceUsecase domain.IPreferenceUsecase) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	handler := &PreferenceController{
+		preferenceUsecase: preferenceUsecase,
+		log:               log.NewLog(),
+	}
+
+	g.GET("/user/:user_id/preferences", handler.Fetch)
+	g.GET("/user/:user_id/preferences/:key", handler.Get)
+	g.PUT("/user/:user_id/preferences/:key", handler.Put)
+	g.DELETE("/user/:user_id/preferences/:key", handler.Delete)
+
+	return g
+}
+
+func TestPreferenceController_Fetch(t *testing.T) {
+	preferenceUsecase := new(mocks.PreferenceUsecase)
+	preferenceUsecase.On("Fetch", mock.Anything, uint(1), []string{"theme", "language"}).Return([]models.UserPreference{
+		{Key: "theme", Value: "dark", Version: 1},
+		{Key: "language", Value: "en", IsDefault: true},
+	}, nil)
+	preferenceUsecase.On("Fetch", mock.Anything, uint(1), []string(nil)).Return([]models.UserPreference{}, nil)
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{
+			name:     "success selected keys",
+			url:      "/user/1/preferences?keys=theme,language",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "success all keys",
+			url:      "/user/1/preferences",
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupPreference(preferenceUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+	preferenceUsecase.AssertExpectations(t)
+}
+
+func TestPreferenceController_Put(t *testing.T) {
+	preferenceUsecase := new(mocks.PreferenceUsecase)
+	preferenceUsecase.On("Put", mock.Anything, uint(1), "notification_email", false, uint(0)).
+		Return(models.UserPreference{Key: "notification_email", Value: false, Version: 1}, nil)
+	preferenceUsecase.On("Put", mock.Anything, uint(1), "theme", "dark", uint(1)).
+		Return(models.UserPreference{}, domain.ErrVersionConflict)
+	preferenceUsecase.On("Put", mock.Anything, uint(1), "font", "serif", uint(0)).
+		Return(models.UserPreference{}, domain.ErrUnknownPreference)
+
+	tests := []struct {
+		name     string
+		url      string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "success false value",
+			url:      "/user/1/preferences/notification_email",
+			body:     `{"value": false, "version": 0}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed stale version",
+			url:      "/user/1/preferences/theme",
+			body:     `{"value": "dark", "version": 1}`,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "failed unknown key",
+			url:      "/user/1/preferences/font",
+			body:     `{"value": "serif"}`,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "failed missing value",
+			url:      "/user/1/preferences/theme",
+			body:     `{"version": 1}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupPreference(preferenceUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("PUT", tt.url, bytes.NewReader([]byte(tt.body)))
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestPreferenceController_Delete(t *testing.T) {
+	preferenceUsecase := new(mocks.PreferenceUsecase)
+	preferenceUsecase.On("Delete", mock.Anything, uint(1), "theme", uint(2)).
+		Return(models.UserPreference{Key: "theme", Value: "light", IsDefault: true}, nil)
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			url:      "/user/1/preferences/theme?version=2",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed invalid version",
+			url:      "/user/1/preferences/theme?version=latest",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupPreference(preferenceUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("DELETE", tt.url, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
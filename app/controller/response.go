@@ -1,9 +1,11 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
+	"prototype/lib/validation"
 )
 
 const (
@@ -11,16 +13,30 @@ const (
 	CODE_PREFIX = "PCFG"
 
 	CODE_SUCCESS             = "PCFG-200"
+	CODE_REDIRECT            = "PCFG-308"
 	CODE_BAD_REQUEST         = "PCFG-400"
 	CODE_INTERNAL_SERVER     = "PCFG-500"
 	CODE_UNAUTHORIZED        = "401"
 	CODE_UNAUTHORIZED_ACCESS = "PCFG-403"
+	CODE_NOT_FOUND           = "PCFG-404"
+	CODE_CONFLICT            = "PCFG-409"
+	CODE_TOO_LARGE           = "PCFG-413"
+	CODE_UNSUPPORTED_MEDIA   = "PCFG-415"
+	CODE_UNPROCESSABLE       = "PCFG-422"
+	CODE_UNAVAILABLE         = "PCFG-503"
 
 	CODE_SUCCESS_MSG             = "Success"
+	CODE_REDIRECT_MSG            = "Permanent Redirect"
 	CODE_BAD_REQUEST_MSG         = "Bad Request"
 	CODE_INTERNAL_SERVER_MSG     = "Internal Server Error"
 	CODE_UNAUTHORIZED_MSG        = "Unauthorized"
 	CODE_UNAUTHORIZED_ACCESS_MSG = "Unauthorized Access"
+	CODE_NOT_FOUND_MSG           = "Not Found"
+	CODE_CONFLICT_MSG            = "Conflict"
+	CODE_TOO_LARGE_MSG           = "Request Entity Too Large"
+	CODE_UNSUPPORTED_MEDIA_MSG   = "Unsupported Media Type"
+	CODE_UNPROCESSABLE_MSG       = "Unprocessable Entity"
+	CODE_UNAVAILABLE_MSG         = "Service Unavailable"
 )
 
 type (
@@ -30,7 +46,8 @@ type (
 		Meta            Meta   `json:"debug_param"`
 		TraceID         string `json:"trace_id"`
 		Pagination
-		Data interface{} `json:"data,omitempty"`
+		Data   interface{}             `json:"data,omitempty"`
+		Errors []validation.FieldError `json:"errors,omitempty"`
 	}
 
 	Pagination struct {
@@ -67,6 +84,12 @@ func (response *Response) Set(statusCode int, data interface{}, err error) {
 	if err != nil {
 		response.setDebugParam(err)
 	}
+
+	// field level error of a failed validation
+	var validationErr validation.Error
+	if errors.As(err, &validationErr) {
+		response.Errors = validationErr.Fields
+	}
 }
 
 func (response *Response) SetPagination(page, limit int, totalCount int64) {
@@ -95,6 +118,30 @@ func (response *Response) mappingCode(statusCode int) {
 	case http.StatusUnauthorized:
 		response.ResponseCode = CODE_UNAUTHORIZED
 		response.ResponseMessage = CODE_UNAUTHORIZED_MSG
+	case http.StatusForbidden:
+		response.ResponseCode = CODE_UNAUTHORIZED_ACCESS
+		response.ResponseMessage = CODE_UNAUTHORIZED_ACCESS_MSG
+	case http.StatusNotFound:
+		response.ResponseCode = CODE_NOT_FOUND
+		response.ResponseMessage = CODE_NOT_FOUND_MSG
+	case http.StatusConflict:
+		response.ResponseCode = CODE_CONFLICT
+		response.ResponseMessage = CODE_CONFLICT_MSG
+	case http.StatusRequestEntityTooLarge:
+		response.ResponseCode = CODE_TOO_LARGE
+		response.ResponseMessage = CODE_TOO_LARGE_MSG
+	case http.StatusUnsupportedMediaType:
+		response.ResponseCode = CODE_UNSUPPORTED_MEDIA
+		response.ResponseMessage = CODE_UNSUPPORTED_MEDIA_MSG
+	case http.StatusUnprocessableEntity:
+		response.ResponseCode = CODE_UNPROCESSABLE
+		response.ResponseMessage = CODE_UNPROCESSABLE_MSG
+	case http.StatusServiceUnavailable:
+		response.ResponseCode = CODE_UNAVAILABLE
+		response.ResponseMessage = CODE_UNAVAILABLE_MSG
+	case http.StatusPermanentRedirect:
+		response.ResponseCode = CODE_REDIRECT
+		response.ResponseMessage = CODE_REDIRECT_MSG
 	case http.StatusOK:
 		response.ResponseCode = CODE_SUCCESS
 		response.ResponseMessage = CODE_SUCCESS_MSG
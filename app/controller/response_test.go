@@ -2,6 +2,7 @@ package controller
 
 import (
 	"errors"
+	"prototype/lib/validation"
 	"reflect"
 	"testing"
 )
@@ -78,6 +79,26 @@ func TestResponse_Set(t *testing.T) {
 				ResponseMessage: CODE_INTERNAL_SERVER_MSG,
 			},
 		},
+		{
+			name:   "error if there is a validation error 422",
+			fields: fields{},
+			args: args{
+				statusCode: 422,
+				err: validation.Error{Fields: []validation.FieldError{
+					{Field: "attributes.phone", Message: "unknown attribute"},
+				}},
+			},
+			result: &Response{
+				ResponseCode:    CODE_UNPROCESSABLE,
+				ResponseMessage: CODE_UNPROCESSABLE_MSG,
+				Meta: Meta{
+					DebugParam: "validation failed: attributes.phone: unknown attribute",
+				},
+				Errors: []validation.FieldError{
+					{Field: "attributes.phone", Message: "unknown attribute"},
+				},
+			},
+		},
 		{
 			name:   "error if there is an error 500",
 			fields: fields{},
@@ -277,6 +298,83 @@ func TestResponse_mappingCode(t *testing.T) {
 				ResponseMessage: CODE_UNAUTHORIZED_MSG,
 			},
 		},
+		{
+			name:   "success if set 403",
+			fields: fields{},
+			args: args{
+				statusCode: 403,
+			},
+			result: &Response{
+				ResponseCode:    CODE_UNAUTHORIZED_ACCESS,
+				ResponseMessage: CODE_UNAUTHORIZED_ACCESS_MSG,
+			},
+		},
+		{
+			name:   "success if set 404",
+			fields: fields{},
+			args: args{
+				statusCode: 404,
+			},
+			result: &Response{
+				ResponseCode:    CODE_NOT_FOUND,
+				ResponseMessage: CODE_NOT_FOUND_MSG,
+			},
+		},
+		{
+			name:   "success if set 409",
+			fields: fields{},
+			args: args{
+				statusCode: 409,
+			},
+			result: &Response{
+				ResponseCode:    CODE_CONFLICT,
+				ResponseMessage: CODE_CONFLICT_MSG,
+			},
+		},
+		{
+			name:   "success if set 413",
+			fields: fields{},
+			args: args{
+				statusCode: 413,
+			},
+			result: &Response{
+				ResponseCode:    CODE_TOO_LARGE,
+				ResponseMessage: CODE_TOO_LARGE_MSG,
+			},
+		},
+		{
+			name:   "success if set 415",
+			fields: fields{},
+			args: args{
+				statusCode: 415,
+			},
+			result: &Response{
+				ResponseCode:    CODE_UNSUPPORTED_MEDIA,
+				ResponseMessage: CODE_UNSUPPORTED_MEDIA_MSG,
+			},
+		},
+		{
+			name:   "success if set 422",
+			fields: fields{},
+			args: args{
+				statusCode: 422,
+			},
+			result: &Response{
+				ResponseCode:    CODE_UNPROCESSABLE,
+				ResponseMessage: CODE_UNPROCESSABLE_MSG,
+			},
+		},
+		{
+			name:   "success if set 503",
+			fields: fields{},
+			args: args{
+				statusCode: 503,
+			},
+			result: &Response{
+				ResponseCode:    CODE_UNAVAILABLE,
+				ResponseMessage: CODE_UNAVAILABLE_MSG,
+			},
+		},
 		{
 			name:   "success if set default",
 			fields: fields{},
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/stream"
+	"prototype/lib/tenant"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// event sent by the stream besides the user event
+const (
+	// streamReset tell the client it missed event and must reload
+	streamReset = "reset"
+)
+
+// StreamConfig of a stream, a heartbeat comment is sent every Heartbeat so
+// proxy keep an idle stream open and Retry is how long a client wait before
+// it reconnect
+type StreamConfig struct {
+	Heartbeat time.Duration
+	Retry     time.Duration
+}
+
+type StreamController struct {
+	userHub *stream.Hub
+	config  StreamConfig
+	log     log.ILogs
+}
+
+func NewStreamController(userHub *stream.Hub, config StreamConfig, log log.ILogs) *StreamController {
+	return &StreamController{
+		userHub,
+		config,
+		log,
+	}
+}
+
+// User stream the user event of the tenant as server-sent event, the event
+// name is the event type and its id the event id. It can be filtered by a
+// comma separated type, user_id and action. A client reconnecting with
+// Last-Event-ID, or the last_event_id query for client which can not set
+// header, get the event it missed first or a reset event when they are no
+// longer buffered. A client which does not keep up is disconnected and
+// resume the same way
+func (handler *StreamController) User(c *gin.Context) {
+	var (
+		res Response
+
+		ctx = c.Request.Context()
+	)
+
+	filter, err := userStreamFilter(c)
+	if err != nil {
+
+		res.Set(http.StatusBadRequest, nil, err)
+		handler.log.Error(ctx, "userStreamFilter Error", err)
+		c.JSON(http.StatusBadRequest, res)
+
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	client, replay, resumed := handler.userHub.Subscribe(filter, lastEventID)
+	defer handler.userHub.Unsubscribe(client)
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry:%d\n\n", handler.config.Retry.Milliseconds())
+
+	if !resumed {
+		sse.Encode(c.Writer, sse.Event{Event: streamReset, Data: gin.H{"last_event_id": lastEventID}})
+	}
+
+	for _, event := range replay {
+		sse.Encode(c.Writer, sse.Event{Id: event.ID, Event: event.Name, Data: string(event.Data)})
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(handler.config.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-client.Events:
+			if !ok {
+				if client.Lagged() {
+					handler.log.Warning(ctx, "handler.User", "client did not keep up and was disconnected")
+				}
+				return
+			}
+
+			sse.Encode(c.Writer, sse.Event{Id: event.ID, Event: event.Name, Data: string(event.Data)})
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ":heartbeat\n\n")
+		}
+
+		c.Writer.Flush()
+	}
+}
+
+// userStreamFilter build the filter of the query, only event of the tenant of
+// the request are ever sent
+func userStreamFilter(c *gin.Context) (stream.Filter, error) {
+	tenantID, ok := tenant.FromContext(c.Request.Context())
+	if !ok {
+		return nil, tenant.ErrMissing
+	}
+
+	types := map[string]bool{}
+	for _, eventType := range splitQuery(c.Query("type")) {
+		switch eventType {
+		case userModels.EventUserCreated, userModels.EventUserUpdated, userModels.EventUserDeleted:
+			types[eventType] = true
+		default:
+			return nil, fmt.Errorf("unknown event type %s", eventType)
+		}
+	}
+
+	userIDs := map[uint]bool{}
+	for _, value := range splitQuery(c.Query("user_id")) {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("user_id must be a comma separated list of id")
+		}
+		userIDs[uint(id)] = true
+	}
+
+	actions := map[string]bool{}
+	for _, action := range splitQuery(c.Query("action")) {
+		actions[action] = true
+	}
+
+	return func(event stream.Event) bool {
+		userEvent, ok := event.Payload.(userModels.UserEvent)
+		if !ok || userEvent.TenantID != tenantID {
+			return false
+		}
+
+		return (len(types) == 0 || types[userEvent.Type]) &&
+			(len(userIDs) == 0 || userIDs[userEvent.UserID]) &&
+			(len(actions) == 0 || actions[userEvent.Action])
+	}, nil
+}
+
+func splitQuery(value string) (result []string) {
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+
+	return
+}
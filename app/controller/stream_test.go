@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/stream"
+	"prototype/lib/tenant"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupStream(hub *stream.Hub) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	handler := &StreamController{
+		userHub: hub,
+		config:  StreamConfig{Heartbeat: time.Hour, Retry: 3 * time.Second},
+		log:     log.NewLog(),
+	}
+
+	g.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), 1))
+	})
+	g.GET("/user/stream", handler.User)
+
+	return g
+}
+
+func publishUserEvent(hub *stream.Hub, id string, event models.UserEvent) {
+	data, _ := json.Marshal(event)
+	hub.Publish(stream.Event{ID: id, Name: event.Type, Data: data, Payload: event})
+}
+
+func TestStreamController_User(t *testing.T) {
+	hub := stream.NewHub(stream.Config{Replay: 10, ClientBuffer: 10})
+	publishUserEvent(hub, "1", models.UserEvent{Type: models.EventUserCreated, TenantID: 1, UserID: 1})
+	publishUserEvent(hub, "2", models.UserEvent{Type: models.EventUserCreated, TenantID: 1, UserID: 2})
+	publishUserEvent(hub, "3", models.UserEvent{Type: models.EventUserCreated, TenantID: 2, UserID: 3})
+	publishUserEvent(hub, "4", models.UserEvent{Type: models.EventUserUpdated, TenantID: 1, UserID: 1})
+
+	tests := []struct {
+		name        string
+		query       string
+		lastEventID string
+		wantCode    int
+		want        []string
+		wantNot     []string
+	}{
+		{
+			name:        "resume with the event of the tenant",
+			lastEventID: "1",
+			wantCode:    http.StatusOK,
+			want:        []string{"retry:3000", "id:2\nevent:user.created", "id:4\nevent:user.updated"},
+			wantNot:     []string{"id:3", "event:reset"},
+		},
+		{
+			name:        "resume filtered",
+			query:       "?type=user.updated&user_id=1",
+			lastEventID: "1",
+			wantCode:    http.StatusOK,
+			want:        []string{"id:4"},
+			wantNot:     []string{"id:2", "id:3"},
+		},
+		{
+			name:        "reset once the event is no longer buffered",
+			lastEventID: "other",
+			wantCode:    http.StatusOK,
+			want:        []string{"event:reset", `"last_event_id":"other"`},
+			wantNot:     []string{"id:"},
+		},
+		{
+			name:     "failed unknown type",
+			query:    "?type=user.renamed",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "failed invalid user id",
+			query:    "?user_id=one",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupStream(hub)
+
+			w := httptest.NewRecorder()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			req, _ := http.NewRequestWithContext(ctx, "GET", "/user/stream"+tt.query, nil)
+			req.Header.Set("Last-Event-ID", tt.lastEventID)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			body := w.Body.String()
+			for _, want := range tt.want {
+				assert.True(t, strings.Contains(body, want), fmt.Sprintf("body %q does not contain %q", body, want))
+			}
+			for _, wantNot := range tt.wantNot {
+				assert.False(t, strings.Contains(body, wantNot), fmt.Sprintf("body %q contain %q", body, wantNot))
+			}
+		})
+	}
+}
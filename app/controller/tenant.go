@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	domain "prototype/domain/tenant"
+	model "prototype/domain/tenant/models"
+	"prototype/lib/log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TenantController struct {
+	tenantUsecase domain.ITenantUsecase
+	log           log.ILogs
+}
+
+type TenantUpdateRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Status string `json:"status"`
+}
+
+func NewTenantController(tenantUsecase domain.ITenantUsecase, log log.ILogs) *TenantController {
+	return &TenantController{
+		tenantUsecase,
+		log,
+	}
+}
+
+func (handler *TenantController) Fetch(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	page, limit := pagination(c)
+
+	tenants, total, err := handler.tenantUsecase.Fetch(ctx, page, limit)
+
+	if err != nil {
+
+		statusCode = tenantErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.tenantUsecase.Fetch Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, tenants, nil)
+	res.SetPagination(page, limit, total)
+}
+
+// Create provision a new tenant
+func (handler *TenantController) Create(c *gin.Context) {
+	var (
+		statusCode int
+		request    model.Tenant
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	tenant, err := handler.tenantUsecase.Create(ctx, request)
+
+	if err != nil {
+
+		statusCode = tenantErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.tenantUsecase.Create Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, tenant, nil)
+}
+
+func (handler *TenantController) GetByID(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	tenantIdP, err := strconv.Atoi(c.Param("tenant_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('tenant_id')) Error", err)
+
+		return
+	}
+
+	tenant, err := handler.tenantUsecase.GetByID(ctx, uint(tenantIdP))
+
+	if err != nil {
+
+		statusCode = tenantErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.tenantUsecase.GetByID Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, tenant, nil)
+}
+
+func (handler *TenantController) Update(c *gin.Context) {
+	var (
+		statusCode int
+		request    TenantUpdateRequest
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	tenantIdP, err := strconv.Atoi(c.Param("tenant_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('tenant_id')) Error", err)
+
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	tenant, err := handler.tenantUsecase.Update(ctx, model.Tenant{
+		ID:     uint(tenantIdP),
+		Name:   request.Name,
+		Status: request.Status,
+	})
+
+	if err != nil {
+
+		statusCode = tenantErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.tenantUsecase.Update Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, tenant, nil)
+}
+
+// tenantErrorStatusCode mapping usecase error to http status code
+func tenantErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidSlug), errors.Is(err, domain.ErrInvalidStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrTenantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrSlugTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
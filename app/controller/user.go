@@ -1,27 +1,74 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	domain "prototype/domain/user"
 	model "prototype/domain/user/models"
 	"prototype/lib/log"
+	"prototype/lib/masking"
+	"prototype/lib/validation"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type UserController struct {
 	userUsecase domain.IUserUsecase
+	masking     masking.Policy
 	log         log.ILogs
 }
 
-func NewUserController(userUsecase domain.IUserUsecase, log log.ILogs) *UserController {
+// UserRequest is what a client can set on a new user, the account state and
+// avatar are only ever set by the service
+type UserRequest struct {
+	Email      string           `json:"email"`
+	Username   string           `json:"username"`
+	FirstName  string           `json:"firstname"`
+	LastName   string           `json:"lastname"`
+	Status     string           `json:"status"`
+	Attributes model.Attributes `json:"attributes"`
+}
+
+func (request UserRequest) User() model.User {
+	return model.User{
+		Email:      request.Email,
+		Username:   request.Username,
+		FirstName:  request.FirstName,
+		LastName:   request.LastName,
+		Status:     request.Status,
+		Attributes: request.Attributes,
+	}
+}
+
+type TransitionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func NewUserController(userUsecase domain.IUserUsecase, masking masking.Policy, log log.ILogs) *UserController {
 	return &UserController{
 		userUsecase,
+		masking,
 		log,
 	}
 }
 
+// mask return user as the caller may see it, a masked away field is left out
+// while the others are kept even when empty
+func (handler *UserController) mask(ctx context.Context, user model.User) interface{} {
+	return handler.masking.Mask(masking.Role(ctx, user.ID), user)
+}
+
+// maskedMerge is a MergeResult whose user went through mask
+type maskedMerge struct {
+	model.MergeResult
+	User interface{} `json:"user"`
+}
+
 func (handler *UserController) Fetch(c *gin.Context) {
 	var (
 		statusCode int
@@ -38,17 +85,31 @@ func (handler *UserController) Fetch(c *gin.Context) {
 		c.JSON(statusCode, res)
 	}()
 
-	user, err := handler.userUsecase.Fetch(ctx)
+	var filter model.UserFilter
+	for _, status := range c.QueryArray("status") {
+		filter.Status = append(filter.Status, strings.Split(status, ",")...)
+	}
+
+	if attributes := c.QueryMap("attributes"); len(attributes) > 0 {
+		filter.Attributes = attributes
+	}
+
+	user, err := handler.userUsecase.Fetch(ctx, filter)
 
 	if err != nil {
-		statusCode = http.StatusInternalServerError
+		statusCode = errorStatusCode(err)
 		res.Set(statusCode, nil, err)
 		handler.log.Error(ctx, "usecase.userRepo.Fetch Error Error", err)
 		return
 	}
 
+	masked := make([]interface{}, len(user))
+	for i := range user {
+		masked[i] = handler.mask(ctx, user[i])
+	}
+
 	statusCode = http.StatusOK
-	res.Set(statusCode, user, nil)
+	res.Set(statusCode, masked, nil)
 }
 
 func (handler *UserController) GetByID(c *gin.Context) {
@@ -76,6 +137,15 @@ func (handler *UserController) GetByID(c *gin.Context) {
 
 	user, err := handler.userUsecase.GetByID(ctx, user_id)
 
+	// a merged user redirect to the user it was merged into
+	var merged domain.MergedError
+	if errors.As(err, &merged) {
+		statusCode = http.StatusPermanentRedirect
+		c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, c.Param("user_id"))+strconv.Itoa(int(merged.TargetID)))
+		res.Set(statusCode, merged, err)
+		return
+	}
+
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		res.Set(statusCode, nil, err)
@@ -84,13 +154,13 @@ func (handler *UserController) GetByID(c *gin.Context) {
 	}
 
 	statusCode = http.StatusOK
-	res.Set(http.StatusInternalServerError, user, nil)
+	res.Set(http.StatusInternalServerError, handler.mask(ctx, user), nil)
 }
 
 func (handler *UserController) Create(c *gin.Context) {
 	var (
 		statusCode int
-		request    model.User
+		request    UserRequest
 		res        Response
 
 		ctx = c.Request.Context()
@@ -108,18 +178,18 @@ func (handler *UserController) Create(c *gin.Context) {
 		return
 	}
 
-	user, err := handler.userUsecase.Create(ctx, request)
+	user, err := handler.userUsecase.Create(ctx, request.User())
 
 	if err != nil {
 
-		statusCode = http.StatusInternalServerError
-		res.Set(http.StatusInternalServerError, nil, err)
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
 		handler.log.Error(ctx, "handler.userUsecase.Create Error", err)
 		return
 	}
 
 	statusCode = http.StatusOK
-	res.Set(http.StatusOK, user, nil)
+	res.Set(http.StatusOK, handler.mask(ctx, user), nil)
 }
 
 func (handler *UserController) Update(c *gin.Context) {
@@ -162,15 +232,15 @@ func (handler *UserController) Update(c *gin.Context) {
 
 	if err != nil {
 
-		statusCode = http.StatusInternalServerError
-		res.Set(http.StatusInternalServerError, nil, err)
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
 		handler.log.Error(ctx, "handler.userUsecase.Update Error", err)
 
 		return
 	}
 
 	statusCode = http.StatusOK
-	res.Set(http.StatusOK, user, nil)
+	res.Set(http.StatusOK, handler.mask(ctx, user), nil)
 }
 
 func (handler *UserController) Delete(c *gin.Context) {
@@ -211,3 +281,415 @@ func (handler *UserController) Delete(c *gin.Context) {
 	statusCode = http.StatusOK
 	res.Set(http.StatusOK, nil, nil)
 }
+
+// Transition return handler that move the user to the given status
+func (handler *UserController) Transition(status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			statusCode int
+			request    TransitionRequest
+			res        Response
+
+			ctx = c.Request.Context()
+		)
+
+		defer func() {
+			c.JSON(statusCode, res)
+		}()
+
+		userIdP, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+
+			statusCode = http.StatusBadRequest
+			res.Set(statusCode, nil, err)
+			handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+			return
+		}
+
+		user_id := uint(userIdP)
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+
+			statusCode = http.StatusBadRequest
+			res.Set(statusCode, nil, err)
+			handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+			return
+		}
+
+		user, err := handler.userUsecase.ChangeStatus(ctx, user_id, status, request.Reason)
+
+		if err != nil {
+
+			statusCode = errorStatusCode(err)
+			res.Set(statusCode, nil, err)
+			handler.log.Error(ctx, "handler.userUsecase.ChangeStatus Error", err)
+
+			return
+		}
+
+		statusCode = http.StatusOK
+		res.Set(http.StatusOK, handler.mask(ctx, user), nil)
+	}
+}
+
+// errorStatusCode mapping usecase error to http status code
+func errorStatusCode(err error) int {
+	var validationErr validation.Error
+
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrInvalidStatus), errors.Is(err, domain.ErrAttributeFilter),
+		errors.Is(err, domain.ErrMergeSelf), errors.Is(err, domain.ErrMergeField):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrUserInactive):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, domain.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrAvatarTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrAvatarType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrAvatarInvalid):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (handler *UserController) PatchAttributes(c *gin.Context) {
+	var (
+		statusCode int
+		request    model.Attributes
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	user_id := uint(userIdP)
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	user, err := handler.userUsecase.PatchAttributes(ctx, user_id, request)
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.PatchAttributes Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, handler.mask(ctx, user), nil)
+}
+
+func (handler *UserController) UploadAvatar(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	user_id := uint(userIdP)
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+
+		// the body was cut by the size limit of the route
+		var tooLarge *http.MaxBytesError
+
+		statusCode = http.StatusBadRequest
+		if errors.As(err, &tooLarge) {
+			statusCode = http.StatusRequestEntityTooLarge
+		}
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.FormFile('avatar') Error", err)
+
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "fileHeader.Open() Error", err)
+
+		return
+	}
+	defer file.Close()
+
+	user, err := handler.userUsecase.UploadAvatar(ctx, user_id, file)
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.UploadAvatar Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, handler.mask(ctx, user), nil)
+}
+
+func (handler *UserController) History(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	user_id := uint(userIdP)
+	page, limit := pagination(c)
+
+	history, total, err := handler.userUsecase.History(ctx, user_id, page, limit)
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.History Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, history, nil)
+	res.SetPagination(page, limit, total)
+}
+
+func (handler *UserController) VerifyHistory(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	user_id := uint(userIdP)
+
+	verification, err := handler.userUsecase.VerifyHistory(ctx, user_id)
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.VerifyHistory Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, verification, nil)
+}
+
+// Merge fold the source user of the request into the user of the path, with
+// dry_run the result is only previewed
+func (handler *UserController) Merge(c *gin.Context) {
+	var (
+		statusCode int
+		request    model.MergeRequest
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	result, err := handler.userUsecase.Merge(ctx, uint(userIdP), request)
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.Merge Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, maskedMerge{result, handler.mask(ctx, result.User)}, nil)
+}
+
+// Export answer the data export of the user as a zip archive download
+func (handler *UserController) Export(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+		c.JSON(statusCode, res)
+
+		return
+	}
+
+	archive, err := handler.userUsecase.Export(ctx, uint(userIdP))
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.Export Error", err)
+		c.JSON(statusCode, res)
+
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d.zip"`, userIdP))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// Erase remove the personal data of the user from every data owner
+func (handler *UserController) Erase(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	userIdP, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('user_id')) Error", err)
+
+		return
+	}
+
+	erasure, err := handler.userUsecase.Erase(ctx, uint(userIdP))
+
+	if err != nil {
+
+		statusCode = errorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.userUsecase.Erase Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, erasure, nil)
+}
+
+// pagination read page and limit query with sane default and upper bound
+func pagination(c *gin.Context) (page, limit int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return
+}
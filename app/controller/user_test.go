@@ -3,16 +3,23 @@ package controller
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	domain "prototype/domain/user"
 	"prototype/domain/user/mocks"
 	"prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/masking"
+	"prototype/lib/validation"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 func setup(userUsecase domain.IUserUsecase) *gin.Engine {
@@ -21,6 +28,7 @@ func setup(userUsecase domain.IUserUsecase) *gin.Engine {
 
 	handler := &UserController{
 		userUsecase: userUsecase,
+		log:         log.NewLog(),
 	}
 
 	g.GET("/user", handler.Fetch)
@@ -28,6 +36,15 @@ func setup(userUsecase domain.IUserUsecase) *gin.Engine {
 	g.POST("/user", handler.Create)
 	g.PUT("/user/:user_id", handler.Update)
 	g.DELETE("/user/:user_id", handler.Delete)
+	g.POST("/user/:user_id/suspend", handler.Transition(models.StatusSuspended))
+	g.PATCH("/user/:user_id/attributes", handler.PatchAttributes)
+	g.PUT("/user/:user_id/avatar", handler.UploadAvatar)
+	g.GET("/user/:user_id/history", handler.History)
+	g.POST("/user/:user_id/merge", handler.Merge)
+	g.GET("/user/:user_id/export", handler.Export)
+	g.POST("/user/:user_id/erase", handler.Erase)
+	g.GET("/archive/report", handler.RetentionReport)
+	g.POST("/archive/:user_id/restore", handler.Restore)
 
 	return g
 }
@@ -44,7 +61,7 @@ func TestUserController_Fetch(t *testing.T) {
 	}
 
 	userUsecaseSuccess := new(mocks.UserUsecase)
-	userUsecaseSuccess.On("Fetch", mock.Anything).Return(user, nil)
+	userUsecaseSuccess.On("Fetch", mock.Anything, models.UserFilter{}).Return(user, nil)
 
 	type fields struct {
 		userUsecase domain.IUserUsecase
@@ -97,11 +114,15 @@ func TestUserController_Create(t *testing.T) {
 		LastName:  "test",
 	}
 
+	// the id, account state and avatar of the request are never bound
+	request := user
+	request.ID = 0
+
 	userUsecaseSuccess := new(mocks.UserUsecase)
-	userUsecaseSuccess.On("Create", ctx, user).Return(user, nil)
+	userUsecaseSuccess.On("Create", ctx, request).Return(user, nil)
 
 	type fields struct {
-		userUsecase domain.IUserMysqlRepository
+		userUsecase domain.IUserUsecase
 	}
 	type args struct {
 		c *gin.Context
@@ -137,7 +158,9 @@ func TestUserController_Create(t *testing.T) {
 					"email" : "test@gmail.com",
 					"username" : "test",
 					"firstname": "test",
-					"lastname": "test"
+					"lastname": "test",
+					"avatar": {"64": "/storage/avatars/1/2/abc-64.jpg"},
+					"status_reason": "imported"
 				}`)
 
 				bodyReader := bytes.NewReader(jsonBody)
@@ -165,7 +188,7 @@ func TestUserController_GetByID(t *testing.T) {
 	userUsecaseSuccess.On("GetByID", mock.Anything, uint(1)).Return(user, nil)
 
 	type fields struct {
-		userUsecase domain.IUserMysqlRepository
+		userUsecase domain.IUserUsecase
 	}
 	type args struct {
 		c *gin.Context
@@ -217,7 +240,7 @@ func TestUserController_Update(t *testing.T) {
 	userUsecaseSuccess.On("Update", mock.Anything, user).Return(user, nil)
 
 	type fields struct {
-		userUsecase domain.IUserMysqlRepository
+		userUsecase domain.IUserUsecase
 	}
 	type args struct {
 		c *gin.Context
@@ -272,7 +295,7 @@ func TestUserController_Delete(t *testing.T) {
 	userUsecaseSuccess.On("Delete", mock.Anything, uint(1)).Return(nil)
 
 	type fields struct {
-		userUsecase domain.IUserMysqlRepository
+		userUsecase domain.IUserUsecase
 	}
 	type args struct {
 		c *gin.Context
@@ -310,3 +333,547 @@ func TestUserController_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestUserController_Transition(t *testing.T) {
+	user := models.User{
+		ID:           1,
+		Email:        "test@gmail.com",
+		Username:     "test",
+		FirstName:    "test",
+		LastName:     "test",
+		Status:       models.StatusSuspended,
+		StatusReason: "fraud investigation",
+	}
+
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("ChangeStatus", mock.Anything, uint(1), models.StatusSuspended, "fraud investigation").Return(user, nil)
+	userUsecase.On("ChangeStatus", mock.Anything, uint(2), models.StatusSuspended, "fraud investigation").
+		Return(models.User{}, fmt.Errorf("%w: deactivated to suspended", domain.ErrInvalidTransition))
+
+	tests := []struct {
+		name     string
+		url      string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			url:      "/user/1/suspend",
+			body:     `{"reason": "fraud investigation"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed invalid transition",
+			url:      "/user/2/suspend",
+			body:     `{"reason": "fraud investigation"}`,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "failed missing reason",
+			url:      "/user/1/suspend",
+			body:     `{}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup router
+			g := setup(userUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("POST", tt.url, bytes.NewReader([]byte(tt.body)))
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestUserController_FetchByStatus(t *testing.T) {
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("Fetch", mock.Anything, models.UserFilter{
+		Status: []string{models.StatusSuspended, models.StatusLocked},
+	}).Return([]models.User{}, nil)
+	userUsecase.On("Fetch", mock.Anything, models.UserFilter{
+		Status: []string{"banned"},
+	}).Return(nil, fmt.Errorf("%w: banned", domain.ErrInvalidStatus))
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			url:      "/user?status=suspended,locked",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed unknown status",
+			url:      "/user?status=banned",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup router
+			g := setup(userUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+	userUsecase.AssertExpectations(t)
+}
+
+func TestUserController_History(t *testing.T) {
+	history := []models.UserAudit{
+		{
+			ID:     2,
+			UserID: 1,
+			Action: models.AuditActionUpdate,
+			Changes: models.AuditChanges{
+				{Field: "email", Before: "******", After: "******"},
+			},
+		},
+	}
+
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("History", mock.Anything, uint(1), 2, 1).Return(history, int64(2), nil)
+	userUsecase.On("History", mock.Anything, uint(1), 1, 20).Return(history, int64(1), nil)
+
+	tests := []struct {
+		name      string
+		url       string
+		wantCode  int
+		wantPage  int
+		wantTotal float64
+	}{
+		{
+			name:      "success with pagination",
+			url:       "/user/1/history?page=2&limit=1",
+			wantCode:  http.StatusOK,
+			wantPage:  2,
+			wantTotal: 2,
+		},
+		{
+			name:      "success default pagination",
+			url:       "/user/1/history?limit=1000",
+			wantCode:  http.StatusOK,
+			wantPage:  1,
+			wantTotal: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup router
+			g := setup(userUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			g.ServeHTTP(w, req)
+
+			var res Response
+			json.Unmarshal(w.Body.Bytes(), &res)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantPage, res.PageNum)
+			assert.Equal(t, tt.wantTotal, res.TotalPage)
+		})
+	}
+	userUsecase.AssertExpectations(t)
+}
+
+func TestUserController_PatchAttributes(t *testing.T) {
+	user := models.User{
+		ID:         1,
+		Email:      "test@gmail.com",
+		Attributes: models.Attributes{"locale": "id-ID"},
+	}
+
+	fieldErrors := []validation.FieldError{
+		{Field: "attributes.shoe_size", Message: "unknown attribute"},
+	}
+
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("PatchAttributes", mock.Anything, uint(1), models.Attributes{"locale": "id-ID"}).Return(user, nil)
+	userUsecase.On("PatchAttributes", mock.Anything, uint(1), models.Attributes{"shoe_size": float64(42)}).
+		Return(models.User{}, validation.Error{Fields: fieldErrors})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantErrors []validation.FieldError
+	}{
+		{
+			name:     "success",
+			body:     `{"locale": "id-ID"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "failed unknown attribute",
+			body:       `{"shoe_size": 42}`,
+			wantCode:   http.StatusUnprocessableEntity,
+			wantErrors: fieldErrors,
+		},
+		{
+			name:     "failed not an object",
+			body:     `["locale"]`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup router
+			g := setup(userUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("PATCH", "/user/1/attributes", bytes.NewReader([]byte(tt.body)))
+			g.ServeHTTP(w, req)
+
+			var res Response
+			json.Unmarshal(w.Body.Bytes(), &res)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantErrors, res.Errors)
+		})
+	}
+}
+
+func TestUserController_UploadAvatar(t *testing.T) {
+	user := models.User{
+		ID:     1,
+		Email:  "test@gmail.com",
+		Avatar: models.Avatar{"64": "/storage/avatars/1/abc-64.jpg"},
+	}
+
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("UploadAvatar", mock.Anything, uint(1), mock.Anything).Return(user, nil)
+	userUsecase.On("UploadAvatar", mock.Anything, uint(2), mock.Anything).Return(models.User{}, domain.ErrAvatarType)
+
+	tests := []struct {
+		name     string
+		url      string
+		field    string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			url:      "/user/1/avatar",
+			field:    "avatar",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed unsupported type",
+			url:      "/user/2/avatar",
+			field:    "avatar",
+			wantCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:     "failed missing file",
+			url:      "/user/1/avatar",
+			field:    "picture",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup router
+			g := setup(userUsecase)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, _ := writer.CreateFormFile(tt.field, "avatar.jpg")
+			part.Write([]byte("image"))
+			writer.Close()
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("PUT", tt.url, body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestUserController_Merge(t *testing.T) {
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("Merge", mock.Anything, uint(1), models.MergeRequest{SourceID: 2, Fields: map[string]string{"email": "source"}, DryRun: true}).
+		Return(models.MergeResult{User: models.User{ID: 1}, SourceID: 2, DryRun: true}, nil)
+	userUsecase.On("Merge", mock.Anything, uint(1), models.MergeRequest{SourceID: 1}).
+		Return(models.MergeResult{}, domain.ErrMergeSelf)
+	userUsecase.On("Merge", mock.Anything, uint(1), models.MergeRequest{SourceID: 9}).
+		Return(models.MergeResult{}, fmt.Errorf("wrap: %w", gorm.ErrRecordNotFound))
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "success dry run",
+			body:     `{"source_id": 2, "fields": {"email": "source"}, "dry_run": true}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed merge into itself",
+			body:     `{"source_id": 1}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "failed source not found",
+			body:     `{"source_id": 9}`,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "failed missing source",
+			body:     `{}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setup(userUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("POST", "/user/1/merge", bytes.NewReader([]byte(tt.body)))
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestUserController_GetByIDMerged(t *testing.T) {
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("GetByID", mock.Anything, uint(2)).Return(models.User{}, domain.MergedError{SourceID: 2, TargetID: 1})
+
+	g := setup(userUsecase)
+
+	w := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/user/2", nil)
+	g.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "/user/1", w.Header().Get("Location"))
+}
+
+func TestUserController_Export(t *testing.T) {
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("Export", mock.Anything, uint(1)).Return([]byte("PK"), nil)
+	userUsecase.On("Export", mock.Anything, uint(2)).Return(nil, gorm.ErrRecordNotFound)
+
+	tests := []struct {
+		name            string
+		path            string
+		wantCode        int
+		wantContentType string
+	}{
+		{
+			name:            "success",
+			path:            "/user/1/export",
+			wantCode:        http.StatusOK,
+			wantContentType: "application/zip",
+		},
+		{
+			name:            "failed not found",
+			path:            "/user/2/export",
+			wantCode:        http.StatusNotFound,
+			wantContentType: "application/json; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setup(userUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestUserController_Erase(t *testing.T) {
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("Erase", mock.Anything, uint(1)).Return(models.Erasure{UserID: 1, Owners: []string{"preferences"}}, nil)
+
+	g := setup(userUsecase)
+
+	w := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/user/1/erase", nil)
+	g.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"owners":["preferences"]`)
+}
+
+func TestUserController_Masking(t *testing.T) {
+	user := models.User{
+		ID:         1,
+		Email:      "jane@example.com",
+		Username:   "jane",
+		FirstName:  "Jane",
+		LastName:   "Doe",
+		Attributes: models.Attributes{"department": "sales"},
+	}
+
+	policy := masking.Policy{
+		"admin": {},
+		"support": {
+			"email":      masking.RulePartial,
+			"firstname":  masking.RulePartial,
+			"attributes": masking.RuleOmit,
+		},
+		masking.RolePublic: {
+			"email":      masking.RuleOmit,
+			"firstname":  masking.RuleOmit,
+			"lastname":   masking.RuleOmit,
+			"attributes": masking.RuleOmit,
+		},
+	}
+
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("GetByID", mock.Anything, uint(1)).Return(user, nil)
+
+	tests := []struct {
+		name   string
+		policy masking.Policy
+		userID uint
+		role   string
+		want   map[string]interface{}
+	}{
+		{
+			name:   "admin see everything",
+			policy: policy,
+			userID: 2,
+			role:   "admin",
+			want:   map[string]interface{}{"email": "jane@example.com", "firstname": "Jane", "lastname": "Doe", "attributes": map[string]interface{}{"department": "sales"}},
+		},
+		{
+			name:   "self see everything",
+			policy: policy,
+			userID: 1,
+			role:   "user",
+			want:   map[string]interface{}{"email": "jane@example.com", "firstname": "Jane", "lastname": "Doe", "attributes": map[string]interface{}{"department": "sales"}},
+		},
+		{
+			name:   "support see partial",
+			policy: policy,
+			userID: 2,
+			role:   "support",
+			want:   map[string]interface{}{"email": "j***@example.com", "firstname": "J***", "lastname": "Doe", "attributes": nil},
+		},
+		{
+			name:   "public field are omitted",
+			policy: policy,
+			want:   map[string]interface{}{"email": nil, "firstname": nil, "lastname": nil, "attributes": nil, "username": "jane"},
+		},
+		{
+			name:   "role not in policy is public",
+			policy: policy,
+			userID: 2,
+			role:   "user",
+			want:   map[string]interface{}{"email": nil, "firstname": nil, "username": "jane"},
+		},
+		{
+			name:   "no policy mask nothing",
+			userID: 2,
+			role:   "user",
+			want:   map[string]interface{}{"email": "jane@example.com", "lastname": "Doe"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			g := gin.New()
+
+			g.Use(func(c *gin.Context) {
+				ctx := c.Request.Context()
+				if tt.userID != 0 {
+					ctx = context.WithValue(ctx, "user-id", tt.userID)
+					ctx = context.WithValue(ctx, "user-role", tt.role)
+				}
+				c.Request = c.Request.WithContext(ctx)
+			})
+
+			handler := &UserController{userUsecase: userUsecase, masking: tt.policy, log: log.NewLog()}
+			g.GET("/user/:user_id", handler.GetByID)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("GET", "/user/1", nil)
+			g.ServeHTTP(w, req)
+
+			var res struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+			for field, want := range tt.want {
+				assert.Equal(t, want, res.Data[field], field)
+			}
+		})
+	}
+}
+
+func TestUserController_Restore(t *testing.T) {
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("Restore", mock.Anything, uint(1)).Return(models.User{ID: 1}, nil)
+	userUsecase.On("Restore", mock.Anything, uint(2)).Return(models.User{}, domain.ErrEmailTaken)
+	userUsecase.On("Restore", mock.Anything, uint(3)).Return(models.User{}, gorm.ErrRecordNotFound)
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+	}{
+		{name: "success", path: "/archive/1/restore", wantCode: http.StatusOK},
+		{name: "email taken meanwhile", path: "/archive/2/restore", wantCode: http.StatusConflict},
+		{name: "not archived", path: "/archive/3/restore", wantCode: http.StatusNotFound},
+		{name: "invalid id", path: "/archive/x/restore", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setup(userUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("POST", tt.path, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestUserController_RetentionReport(t *testing.T) {
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("Retention", mock.Anything, true).Return(models.RetentionReport{DryRun: true, Archived: []uint{3}, Purged: []uint{}}, nil)
+
+	g := setup(userUsecase)
+
+	w := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/archive/report", nil)
+	g.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	userUsecase.AssertExpectations(t)
+}
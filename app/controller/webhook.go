@@ -0,0 +1,363 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	domain "prototype/domain/webhook"
+	model "prototype/domain/webhook/models"
+	"prototype/lib/log"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	webhookUsecase domain.IWebhookUsecase
+	log            log.ILogs
+}
+
+func NewWebhookController(webhookUsecase domain.IWebhookUsecase, log log.ILogs) *WebhookController {
+	return &WebhookController{
+		webhookUsecase,
+		log,
+	}
+}
+
+func (handler *WebhookController) Fetch(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	page, limit := pagination(c)
+
+	webhooks, total, err := handler.webhookUsecase.Fetch(ctx, page, limit)
+
+	if err != nil {
+
+		statusCode = webhookErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.webhookUsecase.Fetch Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, webhooks, nil)
+	res.SetPagination(page, limit, total)
+}
+
+// Create return the webhook with its secret, it is not shown again
+func (handler *WebhookController) Create(c *gin.Context) {
+	var (
+		statusCode int
+		request    model.Webhook
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	webhook, err := handler.webhookUsecase.Create(ctx, request)
+
+	if err != nil {
+
+		statusCode = webhookErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.webhookUsecase.Create Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, webhook, nil)
+}
+
+func (handler *WebhookController) GetByID(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	webhookIdP, err := strconv.Atoi(c.Param("webhook_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('webhook_id')) Error", err)
+
+		return
+	}
+
+	webhook, err := handler.webhookUsecase.GetByID(ctx, uint(webhookIdP))
+
+	if err != nil {
+
+		statusCode = webhookErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.webhookUsecase.GetByID Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, webhook, nil)
+}
+
+// Update change the url, event and description of a webhook, setting status
+// to active enable a webhook disabled after too many failure
+func (handler *WebhookController) Update(c *gin.Context) {
+	var (
+		statusCode int
+		request    model.Webhook
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	webhookIdP, err := strconv.Atoi(c.Param("webhook_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('webhook_id')) Error", err)
+
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "c.ShouldBindJSON Error", err)
+
+		return
+	}
+
+	request.ID = uint(webhookIdP)
+
+	webhook, err := handler.webhookUsecase.Update(ctx, request)
+
+	if err != nil {
+
+		statusCode = webhookErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.webhookUsecase.Update Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, webhook, nil)
+}
+
+func (handler *WebhookController) Delete(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	webhookIdP, err := strconv.Atoi(c.Param("webhook_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('webhook_id')) Error", err)
+
+		return
+	}
+
+	if err := handler.webhookUsecase.Delete(ctx, uint(webhookIdP)); err != nil {
+
+		statusCode = webhookErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.webhookUsecase.Delete Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, nil, nil)
+}
+
+// RotateSecret return the webhook with its new secret
+func (handler *WebhookController) RotateSecret(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	webhookIdP, err := strconv.Atoi(c.Param("webhook_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('webhook_id')) Error", err)
+
+		return
+	}
+
+	webhook, err := handler.webhookUsecase.RotateSecret(ctx, uint(webhookIdP))
+
+	if err != nil {
+
+		statusCode = webhookErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.webhookUsecase.RotateSecret Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, webhook, nil)
+}
+
+// Deliveries return the delivery log of a webhook, newest first. It can be
+// filtered by event_id and a comma separated status
+func (handler *WebhookController) Deliveries(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	webhookIdP, err := strconv.Atoi(c.Param("webhook_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('webhook_id')) Error", err)
+
+		return
+	}
+
+	page, limit := pagination(c)
+
+	filter := model.DeliveryFilter{WebhookID: uint(webhookIdP), EventID: c.Query("event_id")}
+	if status := c.Query("status"); status != "" {
+		filter.Status = strings.Split(status, ",")
+	}
+
+	deliveries, total, err := handler.webhookUsecase.FetchDeliveries(ctx, filter, page, limit)
+
+	if err != nil {
+
+		statusCode = webhookErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.webhookUsecase.FetchDeliveries Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, deliveries, nil)
+	res.SetPagination(page, limit, total)
+}
+
+// Replay queue a finished delivery again, the new delivery is returned
+func (handler *WebhookController) Replay(c *gin.Context) {
+	var (
+		statusCode int
+		res        Response
+
+		ctx = c.Request.Context()
+	)
+
+	defer func() {
+		c.JSON(statusCode, res)
+	}()
+
+	webhookIdP, err := strconv.Atoi(c.Param("webhook_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('webhook_id')) Error", err)
+
+		return
+	}
+
+	deliveryIdP, err := strconv.Atoi(c.Param("delivery_id"))
+	if err != nil {
+
+		statusCode = http.StatusBadRequest
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "strconv.Atoi(c.Param('delivery_id')) Error", err)
+
+		return
+	}
+
+	delivery, err := handler.webhookUsecase.Replay(ctx, uint(webhookIdP), uint(deliveryIdP))
+
+	if err != nil {
+
+		statusCode = webhookErrorStatusCode(err)
+		res.Set(statusCode, nil, err)
+		handler.log.Error(ctx, "handler.webhookUsecase.Replay Error", err)
+
+		return
+	}
+
+	statusCode = http.StatusOK
+	res.Set(http.StatusOK, delivery, nil)
+}
+
+// webhookErrorStatusCode mapping usecase error to http status code
+func webhookErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidURL), errors.Is(err, domain.ErrInvalidEvent), errors.Is(err, domain.ErrInvalidStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrWebhookNotFound), errors.Is(err, domain.ErrDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrDeliveryPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	domain "prototype/domain/webhook"
+	"prototype/domain/webhook/mocks"
+	"prototype/domain/webhook/models"
+	"prototype/lib/log"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupWebhook(webhookUsecase domain.IWebhookUsecase) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	handler := &WebhookController{
+		webhookUsecase: webhookUsecase,
+		log:            log.NewLog(),
+	}
+
+	g.POST("/webhook", handler.Create)
+	g.GET("/webhook/:webhook_id/deliveries", handler.Deliveries)
+	g.POST("/webhook/:webhook_id/deliveries/:delivery_id/replay", handler.Replay)
+
+	return g
+}
+
+func TestWebhookController_Create(t *testing.T) {
+	webhookUsecase := new(mocks.WebhookUsecase)
+	webhookUsecase.On("Create", mock.Anything, models.Webhook{URL: "https://partner.example/hook", Events: models.Events{"user.created"}}).
+		Return(models.Webhook{ID: 1, URL: "https://partner.example/hook", Secret: "whsec_new", Status: models.StatusActive}, nil)
+	webhookUsecase.On("Create", mock.Anything, models.Webhook{URL: "https://partner.example/hook", Events: models.Events{"user.renamed"}}).
+		Return(models.Webhook{}, domain.ErrInvalidEvent)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			body:     `{"url": "https://partner.example/hook", "events": ["user.created"]}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "failed unknown event",
+			body:     `{"url": "https://partner.example/hook", "events": ["user.renamed"]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "failed without event",
+			body:     `{"url": "https://partner.example/hook", "events": []}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "failed invalid url",
+			body:     `{"url": "partner", "events": ["user.created"]}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupWebhook(webhookUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("POST", "/webhook", bytes.NewReader([]byte(tt.body)))
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestWebhookController_Deliveries(t *testing.T) {
+	webhookUsecase := new(mocks.WebhookUsecase)
+	webhookUsecase.On("FetchDeliveries", mock.Anything, models.DeliveryFilter{WebhookID: 1, Status: []string{models.DeliveryFailed}}, 1, 20).
+		Return([]models.Delivery{{ID: 3, WebhookID: 1, Status: models.DeliveryFailed}}, int64(1), nil)
+	webhookUsecase.On("FetchDeliveries", mock.Anything, models.DeliveryFilter{WebhookID: 2}, 1, 20).
+		Return([]models.Delivery{}, int64(0), domain.ErrWebhookNotFound)
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{name: "success", url: "/webhook/1/deliveries?status=failed", wantCode: http.StatusOK},
+		{name: "failed unknown webhook", url: "/webhook/2/deliveries", wantCode: http.StatusNotFound},
+		{name: "failed invalid id", url: "/webhook/abc/deliveries", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupWebhook(webhookUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestWebhookController_Replay(t *testing.T) {
+	webhookUsecase := new(mocks.WebhookUsecase)
+	webhookUsecase.On("Replay", mock.Anything, uint(1), uint(3)).Return(models.Delivery{ID: 4, ReplayOf: 3, Status: models.DeliveryPending}, nil)
+	webhookUsecase.On("Replay", mock.Anything, uint(1), uint(5)).Return(models.Delivery{}, domain.ErrDeliveryPending)
+	webhookUsecase.On("Replay", mock.Anything, uint(1), uint(6)).Return(models.Delivery{}, domain.ErrDeliveryNotFound)
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{name: "success", url: "/webhook/1/deliveries/3/replay", wantCode: http.StatusOK},
+		{name: "failed still pending", url: "/webhook/1/deliveries/5/replay", wantCode: http.StatusConflict},
+		{name: "failed not found", url: "/webhook/1/deliveries/6/replay", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupWebhook(webhookUsecase)
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("POST", tt.url, nil)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"prototype/app/controller"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admin only let through user with the admin role of the system tenant, the
+// tenant operator are the only one allowed to act across tenants
+func Admin(systemTenantID uint, log log.ILogs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		ctx := c.Request.Context()
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+
+		if _, ok := ctx.Value("user-id").(uint); !ok {
+			err := errors.New("authentication required")
+			res.Set(http.StatusUnauthorized, nil, err)
+			log.Warning(ctx, "middleware.Admin Rejected", err.Error())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
+			return
+		}
+
+		tenantID, _ := tenant.FromContext(ctx)
+		if role, _ := ctx.Value("user-role").(string); role != userModels.RoleAdmin || tenantID != systemTenantID {
+			err := errors.New("admin role of the system tenant required")
+			res.Set(http.StatusForbidden, nil, err)
+			log.Warning(ctx, "middleware.Admin Rejected", err.Error())
+			c.AbortWithStatusJSON(http.StatusForbidden, res)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// TenantAdmin only let through user with the admin role of the request tenant
+func TenantAdmin(log log.ILogs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		ctx := c.Request.Context()
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+
+		if _, ok := ctx.Value("user-id").(uint); !ok {
+			err := errors.New("authentication required")
+			res.Set(http.StatusUnauthorized, nil, err)
+			log.Warning(ctx, "middleware.TenantAdmin Rejected", err.Error())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
+			return
+		}
+
+		if role, _ := ctx.Value("user-role").(string); role != userModels.RoleAdmin {
+			err := errors.New("admin role required")
+			res.Set(http.StatusForbidden, nil, err)
+			log.Warning(ctx, "middleware.TenantAdmin Rejected", err.Error())
+			c.AbortWithStatusJSON(http.StatusForbidden, res)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SelfOrAdmin only let through the user of the user_id path parameter or a
+// user with the admin role of the request tenant
+func SelfOrAdmin(log log.ILogs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		ctx := c.Request.Context()
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+
+		userID, ok := ctx.Value("user-id").(uint)
+		if !ok {
+			err := errors.New("authentication required")
+			res.Set(http.StatusUnauthorized, nil, err)
+			log.Warning(ctx, "middleware.SelfOrAdmin Rejected", err.Error())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
+			return
+		}
+
+		role, _ := ctx.Value("user-role").(string)
+		if role != userModels.RoleAdmin && c.Param("user_id") != strconv.FormatUint(uint64(userID), 10) {
+			err := errors.New("only the user itself or an admin is allowed")
+			res.Set(http.StatusForbidden, nil, err)
+			log.Warning(ctx, "middleware.SelfOrAdmin Rejected", err.Error())
+			c.AbortWithStatusJSON(http.StatusForbidden, res)
+			return
+		}
+
+		c.Next()
+	}
+}
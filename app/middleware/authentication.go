@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"prototype/app/controller"
+	domain "prototype/domain/user"
+	loginDomain "prototype/domain/user/logins"
+	loginModels "prototype/domain/user/logins/models"
+	"prototype/lib/log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authentication resolve the caller from the User-ID header set by the gateway,
+// request without the header is passed through as anonymous. Every outcome is
+// reported to loginUsecase which buffer it, no request wait on a write
+func Authentication(userUsecase domain.IUserUsecase, loginUsecase loginDomain.ILoginUsecase, log log.ILogs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		ctx := c.Request.Context()
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+
+		userIDHeader := c.GetHeader("User-ID")
+		if userIDHeader == "" {
+			c.Next()
+			return
+		}
+
+		userID, err := strconv.Atoi(userIDHeader)
+		if err != nil {
+			res.Set(http.StatusUnauthorized, nil, err)
+			log.Error(ctx, "strconv.Atoi(c.GetHeader('User-ID')) Error", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
+			return
+		}
+
+		login := loginModels.Login{
+			UserID:    uint(userID),
+			Method:    loginModels.MethodGateway,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
+
+		user, err := userUsecase.Authenticate(ctx, uint(userID))
+		if err != nil {
+			login.Reason = err.Error()
+			loginUsecase.Failed(ctx, login)
+
+			statusCode := http.StatusUnauthorized
+			if errors.Is(err, domain.ErrUserInactive) {
+				statusCode = http.StatusForbidden
+			}
+
+			res.Set(statusCode, nil, err)
+			log.Warning(ctx, "userUsecase.Authenticate Error", err.Error())
+			c.AbortWithStatusJSON(statusCode, res)
+			return
+		}
+
+		loginUsecase.Seen(ctx, user, login)
+
+		ctx = context.WithValue(ctx, "user-id", user.ID)
+		ctx = context.WithValue(ctx, "user-role", user.Role)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"prototype/app/controller"
+	"prototype/lib/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit refuse a request body larger than limit bytes with 413, a body of
+// unknown length is cut at limit so the handler fail to read past it
+func BodyLimit(limit int64, log log.ILogs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			var res controller.Response
+
+			res.SetTraceID(c.GetHeader("Trace-ID"))
+
+			err := fmt.Errorf("request body is larger than %d bytes", limit)
+			res.Set(http.StatusRequestEntityTooLarge, nil, err)
+			log.Warning(c.Request.Context(), "middleware.BodyLimit Rejected", err.Error())
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, res)
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"prototype/app/controller"
+	"prototype/domain/user/mocks"
+	"prototype/domain/user/models"
+	"prototype/lib/log"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBodyLimit(t *testing.T) {
+	userUsecase := new(mocks.UserUsecase)
+	userUsecase.On("UploadAvatar", mock.Anything, uint(1), mock.Anything).Return(models.User{ID: 1}, nil)
+
+	tests := []struct {
+		name          string
+		size          int
+		unknownLength bool
+		wantCode      int
+		wantLogged    string
+	}{
+		{name: "success", size: 512, wantCode: http.StatusOK},
+		{name: "failed declared too large", size: 4096, wantCode: http.StatusRequestEntityTooLarge},
+		{name: "failed streamed too large", size: 4096, unknownLength: true, wantCode: http.StatusRequestEntityTooLarge, wantLogged: "[multipart body]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			g := gin.New()
+
+			logs := &httpLog{ILogs: log.NewLog()}
+			g.Use(Logging(logs))
+
+			handler := controller.NewUserController(userUsecase, nil, logs)
+			g.PUT("/user/:user_id/avatar", BodyLimit(2048, logs), handler.UploadAvatar)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, _ := writer.CreateFormFile("avatar", "avatar.jpg")
+			part.Write(make([]byte, tt.size))
+			writer.Close()
+
+			req, _ := http.NewRequest("PUT", "/user/1/avatar", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			if tt.unknownLength {
+				req.Body, req.ContentLength = io.NopCloser(body), -1
+			}
+
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantLogged != "" {
+				assert.Equal(t, tt.wantLogged, logs.req)
+			}
+		})
+	}
+}
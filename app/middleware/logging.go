@@ -4,23 +4,60 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"prototype/lib/log"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+// part of an exchange Redact keep out of the log
+const (
+	RedactRequest = 1 << iota
+	RedactResponse
+)
+
+const redactKey = "log-redact"
+
+// Redact keep the request or response body of a route out of the log, for
+// route whose body carry a secret
+func Redact(parts int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(redactKey, parts)
+		c.Next()
+	}
+}
+
+// maxLoggedBody is how much of a request body is kept for the log, the rest
+// reach the handler without being buffered
+const maxLoggedBody = 64 << 10
+
+// replayBody give back what was read for the log before the rest of the body
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
 type bodyLogWriter struct {
 	gin.ResponseWriter
 	body *bytes.Buffer
 }
 
 func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	if !w.streaming() {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
+// streaming tell whether the response is an event stream, it is never over
+// so it is not kept for the log
+func (w bodyLogWriter) streaming() bool {
+	return strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+}
+
 func Logging(log log.ILogs) gin.HandlerFunc {
 	logger := logrus.New()
 
@@ -35,17 +72,29 @@ func Logging(log log.ILogs) gin.HandlerFunc {
 		ctx = context.WithValue(ctx, "trace-id", traceID)
 		c.Request = c.Request.WithContext(ctx)
 
-		// Request body
+		// Request body, binary upload are not worth logging and are left
+		// unread for the route to limit
 		var bodyBytes []byte
 
-		if c.Request.Body != nil {
-			bodyBytes, _ = ioutil.ReadAll(c.Request.Body)
+		multipart := strings.HasPrefix(c.ContentType(), "multipart/")
+		if c.Request.Body != nil && !multipart {
+			bodyBytes, _ = ioutil.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBody+1))
+			c.Request.Body = replayBody{io.MultiReader(bytes.NewReader(bodyBytes), c.Request.Body), c.Request.Body}
 		}
-		c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
 
 		//Request routing
 		reqUri := c.Request.RequestURI
 
+		requestBody := string(bodyBytes)
+		switch {
+		case multipart && c.Request.ContentLength < 0:
+			requestBody = "[multipart body]"
+		case multipart:
+			requestBody = fmt.Sprintf("[multipart body of %d bytes]", c.Request.ContentLength)
+		case len(bodyBytes) > maxLoggedBody:
+			requestBody = string(bodyBytes[:maxLoggedBody]) + "[truncated]"
+		}
+
 		// response body
 		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
 		c.Writer = blw
@@ -53,14 +102,28 @@ func Logging(log log.ILogs) gin.HandlerFunc {
 		//Process request
 		c.Next()
 
+		responseBody := blw.body.String()
+		if blw.streaming() {
+			responseBody = "[event stream]"
+		}
+
+		// the route is only known once it ran
+		redact := c.GetInt(redactKey)
+		if redact&RedactRequest != 0 {
+			requestBody = fmt.Sprintf("[redacted body of %d bytes]", len(bodyBytes))
+		}
+		if redact&RedactResponse != 0 {
+			responseBody = fmt.Sprintf("[redacted body of %d bytes]", blw.body.Len())
+		}
+
 		log.Http(
 			ctx,
 			"Result",
 			reqUri,
 			c.Request.Method,
 			c.Request.Header,
-			fmt.Sprintf("%v", string(bodyBytes)),
-			fmt.Sprintf("%v", blw.body.String()),
+			requestBody,
+			responseBody,
 		)
 	}
 }
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"prototype/lib/log"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// httpLog keep what Logging log of the exchange
+type httpLog struct {
+	log.ILogs
+	req, res interface{}
+}
+
+func (logs *httpLog) Http(ctx context.Context, actName, url, method string, header, req, res interface{}) {
+	logs.req, logs.res = req, res
+}
+
+func TestLogging_Redact(t *testing.T) {
+	tests := []struct {
+		name    string
+		redact  int
+		wantReq string
+		wantRes string
+	}{
+		{name: "logged", wantReq: `{"token":"request-secret"}`, wantRes: `{"secret":"response-secret"}`},
+		{name: "request redacted", redact: RedactRequest, wantReq: "[redacted body of 26 bytes]", wantRes: `{"secret":"response-secret"}`},
+		{name: "response redacted", redact: RedactResponse, wantReq: `{"token":"request-secret"}`, wantRes: "[redacted body of 28 bytes]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			g := gin.New()
+
+			logs := &httpLog{ILogs: log.NewLog()}
+			g.Use(Logging(logs))
+
+			handlers := []gin.HandlerFunc{func(c *gin.Context) {
+				c.String(http.StatusOK, `{"secret":"response-secret"}`)
+			}}
+			if tt.redact != 0 {
+				handlers = append([]gin.HandlerFunc{Redact(tt.redact)}, handlers...)
+			}
+			g.POST("/secret", handlers...)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/secret", strings.NewReader(`{"token":"request-secret"}`))
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, `{"secret":"response-secret"}`, w.Body.String())
+			assert.Equal(t, tt.wantReq, logs.req)
+			assert.Equal(t, tt.wantRes, logs.res)
+		})
+	}
+}
+
+func TestLogging_LargeBody(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+
+	logs := &httpLog{ILogs: log.NewLog()}
+	g.Use(Logging(logs))
+
+	var received int
+	g.POST("/large", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		received = len(body)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/large", strings.NewReader(strings.Repeat("a", maxLoggedBody+100)))
+	g.ServeHTTP(w, req)
+
+	// the handler still get the whole body, only the log is cut
+	assert.Equal(t, maxLoggedBody+100, received)
+	assert.Equal(t, strings.Repeat("a", maxLoggedBody)+"[truncated]", logs.req)
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"prototype/app/controller"
+	domain "prototype/domain/organization"
+	"prototype/lib/log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationRole only let through caller whose membership in the
+// :organization_id organization grant at least role, the membership is put in
+// the request context under "organization-member"
+func OrganizationRole(organizationUsecase domain.IOrganizationUsecase, role string, log log.ILogs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		ctx := c.Request.Context()
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+
+		userID, ok := ctx.Value("user-id").(uint)
+		if !ok {
+			err := errors.New("authentication required")
+			res.Set(http.StatusUnauthorized, nil, err)
+			log.Warning(ctx, "middleware.OrganizationRole Rejected", err.Error())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
+			return
+		}
+
+		organizationID, err := strconv.Atoi(c.Param("organization_id"))
+		if err != nil {
+			res.Set(http.StatusBadRequest, nil, err)
+			log.Error(ctx, "strconv.Atoi(c.Param('organization_id')) Error", err)
+			c.AbortWithStatusJSON(http.StatusBadRequest, res)
+			return
+		}
+
+		membership, err := organizationUsecase.Authorize(ctx, uint(organizationID), userID, role)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			if errors.Is(err, domain.ErrNotMember) || errors.Is(err, domain.ErrInsufficientRole) {
+				statusCode = http.StatusForbidden
+			}
+
+			res.Set(statusCode, nil, err)
+			log.Warning(ctx, "organizationUsecase.Authorize Error", err.Error())
+			c.AbortWithStatusJSON(statusCode, res)
+			return
+		}
+
+		ctx = context.WithValue(ctx, "organization-member", membership)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
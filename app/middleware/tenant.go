@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"prototype/app/controller"
+	domain "prototype/domain/tenant"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tenant resolution strategy
+const (
+	TenantFromHeader    = "header"
+	TenantFromSubdomain = "subdomain"
+	TenantFromClaim     = "claim"
+)
+
+// TenantConfig list the strategies tried in order to find the tenant slug of
+// a request, Default is used when none of them match
+type TenantConfig struct {
+	Strategies []string
+	Header     string
+	Domain     string
+	Claim      string
+	Default    string
+}
+
+// Tenant resolve the tenant of the request and scope the request context to
+// it, it must run before Authentication since user lookup is tenant scoped
+func Tenant(tenantUsecase domain.ITenantUsecase, config TenantConfig, log log.ILogs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		ctx := c.Request.Context()
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+
+		slug := config.slug(c)
+		if slug == "" {
+			res.Set(http.StatusBadRequest, nil, domain.ErrTenantRequired)
+			log.Warning(ctx, "middleware.Tenant Rejected", domain.ErrTenantRequired.Error())
+			c.AbortWithStatusJSON(http.StatusBadRequest, res)
+			return
+		}
+
+		resolved, err := tenantUsecase.Resolve(ctx, slug)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, domain.ErrTenantNotFound):
+				statusCode = http.StatusNotFound
+			case errors.Is(err, domain.ErrTenantDisabled):
+				statusCode = http.StatusForbidden
+			}
+
+			res.Set(statusCode, nil, err)
+			log.Warning(ctx, "tenantUsecase.Resolve Error", err.Error())
+			c.AbortWithStatusJSON(statusCode, res)
+			return
+		}
+
+		ctx = tenant.WithID(ctx, resolved.ID)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+func (config TenantConfig) slug(c *gin.Context) string {
+	for _, strategy := range config.Strategies {
+		var slug string
+
+		switch strategy {
+		case TenantFromHeader:
+			slug = c.GetHeader(config.Header)
+		case TenantFromSubdomain:
+			slug = subdomain(c.Request.Host, config.Domain)
+		case TenantFromClaim:
+			slug = claim(c.GetHeader("Authorization"), config.Claim)
+		}
+
+		if slug = strings.TrimSpace(slug); slug != "" {
+			return strings.ToLower(slug)
+		}
+	}
+
+	return config.Default
+}
+
+// subdomain return the label right before domain, api.acme.example.com give
+// acme for the domain example.com
+func subdomain(host, domain string) string {
+	if domain == "" {
+		return ""
+	}
+
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	suffix := "." + strings.TrimPrefix(domain, ".")
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+
+	labels := strings.Split(strings.TrimSuffix(host, suffix), ".")
+	return labels[len(labels)-1]
+}
+
+// claim read a string claim of the bearer token, the signature is verified by
+// the gateway the same way the User-ID header is trusted
+func claim(authorization, name string) string {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == authorization || name == "" {
+		return ""
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	value, _ := claims[name].(string)
+	return value
+}
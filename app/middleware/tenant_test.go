@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	domain "prototype/domain/tenant"
+	"prototype/domain/tenant/mocks"
+	"prototype/domain/tenant/models"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTenant(t *testing.T) {
+	tenantUsecase := new(mocks.TenantUsecase)
+	tenantUsecase.On("Resolve", mock.Anything, "default").Return(models.Tenant{ID: 1, Slug: "default"}, nil)
+	tenantUsecase.On("Resolve", mock.Anything, "acme").Return(models.Tenant{ID: 2, Slug: "acme"}, nil)
+	tenantUsecase.On("Resolve", mock.Anything, "globex").Return(models.Tenant{ID: 3, Slug: "globex"}, nil)
+	tenantUsecase.On("Resolve", mock.Anything, "legacy").Return(models.Tenant{}, domain.ErrTenantDisabled)
+	tenantUsecase.On("Resolve", mock.Anything, "unknown").Return(models.Tenant{}, domain.ErrTenantNotFound)
+
+	token := "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(`{"tenant":"globex"}`)) + ".sig"
+
+	tests := []struct {
+		name         string
+		config       TenantConfig
+		host         string
+		header       map[string]string
+		wantCode     int
+		wantTenantID string
+	}{
+		{
+			name:         "success header",
+			config:       TenantConfig{Strategies: []string{TenantFromHeader}, Header: "Tenant-ID"},
+			header:       map[string]string{"Tenant-ID": "ACME"},
+			wantCode:     http.StatusOK,
+			wantTenantID: "2",
+		},
+		{
+			name:         "success subdomain",
+			config:       TenantConfig{Strategies: []string{TenantFromSubdomain}, Domain: "example.com"},
+			host:         "acme.example.com:8080",
+			wantCode:     http.StatusOK,
+			wantTenantID: "2",
+		},
+		{
+			name:         "success claim",
+			config:       TenantConfig{Strategies: []string{TenantFromClaim}, Claim: "tenant"},
+			header:       map[string]string{"Authorization": "Bearer " + token},
+			wantCode:     http.StatusOK,
+			wantTenantID: "3",
+		},
+		{
+			name:         "success first matching strategy win",
+			config:       TenantConfig{Strategies: []string{TenantFromHeader, TenantFromClaim}, Header: "Tenant-ID", Claim: "tenant"},
+			header:       map[string]string{"Authorization": "Bearer " + token},
+			wantCode:     http.StatusOK,
+			wantTenantID: "3",
+		},
+		{
+			name:         "success fall back to default",
+			config:       TenantConfig{Strategies: []string{TenantFromSubdomain}, Domain: "example.com", Default: "default"},
+			host:         "example.com",
+			wantCode:     http.StatusOK,
+			wantTenantID: "1",
+		},
+		{
+			name:     "failed no tenant and no default",
+			config:   TenantConfig{Strategies: []string{TenantFromHeader}, Header: "Tenant-ID"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "failed unknown tenant",
+			config:   TenantConfig{Strategies: []string{TenantFromHeader}, Header: "Tenant-ID"},
+			header:   map[string]string{"Tenant-ID": "unknown"},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "failed disabled tenant",
+			config:   TenantConfig{Strategies: []string{TenantFromHeader}, Header: "Tenant-ID"},
+			header:   map[string]string{"Tenant-ID": "legacy"},
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			g := gin.New()
+			g.Use(Tenant(tenantUsecase, tt.config, log.NewLog()))
+			g.GET("/", func(c *gin.Context) {
+				id, _ := tenant.FromContext(c.Request.Context())
+				c.String(http.StatusOK, strconv.Itoa(int(id)))
+			})
+
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("GET", "/", nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantTenantID != "" {
+				assert.Equal(t, tt.wantTenantID, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Command keyring manage the local keyring of Encryption.Keyring
+//
+//	keyring generate -file keyring.json   create a keyring with a single key
+//	keyring rotate -file keyring.json     add a key and make it current
+//	keyring encrypt -file keyring.json value
+//	                                      print value encrypted for env.json
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"prototype/lib/crypt"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	path := flags.String("file", "keyring.json", "keyring file")
+	flags.Parse(os.Args[2:])
+
+	var err error
+	switch os.Args[1] {
+	case "generate":
+		err = generate(*path)
+	case "rotate":
+		err = rotate(*path)
+	case "encrypt":
+		if flags.NArg() != 1 {
+			usage()
+		}
+		err = encrypt(*path, flags.Arg(0))
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: keyring generate|rotate|encrypt -file keyring.json [value]")
+	os.Exit(2)
+}
+
+func generate(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exist, use rotate", path)
+	}
+
+	file, err := crypt.GenerateKeyringFile()
+	if err != nil {
+		return err
+	}
+
+	return write(path, file)
+}
+
+// rotate keep every previous key, remove one only once the re-encryption job
+// moved every row and encrypted config value off it. Archived user keep their
+// key until they are restored or purged
+func rotate(path string) error {
+	file, err := read(path)
+	if err != nil {
+		return err
+	}
+
+	if err := file.Rotate(); err != nil {
+		return err
+	}
+
+	fmt.Println("current key", file.Current, "keys", file.KeyIDs())
+	return write(path, file)
+}
+
+func encrypt(path, value string) error {
+	keyring, _, err := crypt.LoadLocalKeyring(path)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := keyring.Encrypt(context.Background(), keyring.Current(), []byte(value))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("enc:%s:%s\n", keyring.Current(), base64.StdEncoding.EncodeToString(ciphertext))
+	return nil
+}
+
+func read(path string) (file crypt.KeyringFile, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(content, &file)
+	return
+}
+
+func write(path string, file crypt.KeyringFile) error {
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0600)
+}
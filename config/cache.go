@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	userDomain "prototype/domain/user"
+	userRepoMemory "prototype/domain/user/repositories/memory"
+	userRepoRedis "prototype/domain/user/repositories/redis"
+	"prototype/lib/cache"
+	"prototype/lib/crypt"
+	"prototype/lib/env"
+	"prototype/lib/log"
+	"prototype/lib/redis"
+	"time"
+)
+
+// UserCache wrap repo with the Redis cache when User.Cache.Enabled is set,
+// repo is returned as is when the cache is disabled or Redis unusable
+func UserCache(repo userDomain.IUserMysqlRepository, client redis.IRedis, cipher *crypt.FieldCipher, logging log.ILogs) userDomain.IUserMysqlRepository {
+	if !env.Bool("User.Cache.Enabled", false) {
+		return repo
+	}
+
+	if client == nil {
+		logging.Warning(context.Background(), "UserCache", "User.Cache.Enabled is set but Redis is not available, user are read from database")
+		return repo
+	}
+
+	return userRepoRedis.NewCachedUserRepo(repo, client, cipher, userRepoRedis.CacheConfig{
+		TTL:      env.Duration("User.Cache.TTL", 5*time.Minute),
+		Jitter:   env.Duration("User.Cache.Jitter", 30*time.Second),
+		LockTTL:  env.Duration("User.Cache.LockTTL", 2*time.Second),
+		Cooldown: env.Duration("User.Cache.Cooldown", 10*time.Second),
+	}, logging)
+}
+
+// UserMemoryCache wrap repo with the in process cache when
+// User.Memory.Enabled is set, the cache is returned for its statistics and
+// is nil when disabled. Invalidation reach the other replica through Redis
+func UserMemoryCache(repo userDomain.IUserMysqlRepository, client redis.IRedis, logging log.ILogs) (userDomain.IUserMysqlRepository, *cache.LRU) {
+	if !env.Bool("User.Memory.Enabled", false) {
+		return repo, nil
+	}
+
+	if client == nil {
+		logging.Warning(context.Background(), "UserMemoryCache", "Redis is not available, invalidation stay local to this replica")
+	}
+
+	lru := cache.New(cache.Config{
+		MaxEntries: env.Int("User.Memory.MaxEntries", 10000),
+		MaxBytes:   int64(env.Int("User.Memory.MaxBytes", 32<<20)),
+		TTL:        env.Duration("User.Memory.TTL", 30*time.Second),
+	})
+
+	invalidator := cache.NewInvalidator(lru, client, env.String("User.Memory.Channel", "user:invalidate"), logging)
+	go invalidator.Listen(context.Background())
+
+	return userRepoMemory.NewMemoryUserRepo(repo, lru, invalidator, logging), lru
+}
@@ -1,7 +1,12 @@
 package config
 
+import "prototype/lib/consumer"
+
 type Config struct {
-	Router *Router
+	Router        *Router
+	Consumers     *consumer.Registry
+	UserStream    *UserStream
+	Notifications *Notifications
 }
 
 func NewConfig() *Config {
@@ -10,6 +15,9 @@ func NewConfig() *Config {
 	route := NewRouter(injection)
 
 	return &Config{
-		Router: route,
+		Router:        route,
+		Consumers:     injection.Consumers,
+		UserStream:    injection.UserStream,
+		Notifications: injection.Notifications,
 	}
 }
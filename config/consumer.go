@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	appConsumer "prototype/app/consumer"
+	userDomain "prototype/domain/user"
+	userModels "prototype/domain/user/models"
+	webhookDomain "prototype/domain/webhook"
+	"prototype/lib/consumer"
+	"prototype/lib/env"
+	"prototype/lib/log"
+	"prototype/lib/pubsub"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NewConsumers register the consumer of every domain, a consumer whose
+// Consumer.<Name>.Subscription is empty is left out
+func NewConsumers(db *gorm.DB, broker pubsub.IPubSub, userUsecase userDomain.IUserUsecase, webhookUsecase webhookDomain.IWebhookUsecase, logging log.ILogs) (*consumer.Registry, error) {
+	store := consumer.NewStore(db)
+	registry := consumer.NewRegistry(broker, store, logging)
+
+	userConsumer := appConsumer.NewUserConsumer(userUsecase, logging)
+	webhookConsumer := appConsumer.NewWebhookConsumer(webhookUsecase, logging)
+
+	for _, declared := range []consumer.Consumer{
+		consumerConfig("Offboarding", appConsumer.OffboardedEvent{}, userConsumer.Offboarded),
+		consumerConfig("Webhook", userModels.UserEvent{}, webhookConsumer.UserEvent),
+	} {
+		if declared.Subscription == "" {
+			continue
+		}
+
+		if err := registry.Register(declared); err != nil {
+			return nil, err
+		}
+	}
+
+	StartConsumerPurge(store, env.Duration("Consumer.Keep", 168*time.Hour), logging)
+
+	return registry, nil
+}
+
+// consumerConfig read the subscription, dead letter topic and retry policy
+// of a consumer from the Consumer.<name> block
+func consumerConfig(name string, payload interface{}, handler consumer.Handler) consumer.Consumer {
+	key := "Consumer." + name + "."
+
+	return consumer.Consumer{
+		Name:         name,
+		Subscription: env.String(key+"Subscription", ""),
+		Payload:      payload,
+		Handler:      handler,
+		Retry: consumer.RetryPolicy{
+			MaxAttempts: env.Int(key+"MaxAttempts", 5),
+			MinBackoff:  env.Duration(key+"MinBackoff", time.Second),
+			MaxBackoff:  env.Duration(key+"MaxBackoff", time.Minute),
+		},
+		DeadLetter: env.String(key+"DeadLetter", ""),
+		Timeout:    env.Duration(key+"Timeout", 30*time.Second),
+	}
+}
+
+// StartConsumerPurge forget the message handled for longer than keep every
+// hour
+func StartConsumerPurge(store consumer.IStore, keep time.Duration, logging log.ILogs) {
+	if keep <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := store.Purge(context.Background(), time.Now().Add(-keep)); err != nil {
+				logging.Error(context.Background(), "store.Purge Error", err)
+			}
+		}
+	}()
+}
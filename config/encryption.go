@@ -0,0 +1,100 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	tenantDomain "prototype/domain/tenant"
+	userDomain "prototype/domain/user"
+	"prototype/lib/crypt"
+	"prototype/lib/env"
+	"prototype/lib/log"
+	"strings"
+	"time"
+)
+
+// secretPrefix mark a config value encrypted with the keyring, the value is
+// enc:<key id>:<base64 ciphertext> as written by cmd/keyring encrypt
+const secretPrefix = "enc:"
+
+// NewKeyring load the keyring file of Encryption.Keyring together with the
+// blind index key, personal data is stored in plaintext when it is not set
+func NewKeyring() (crypt.IKeyring, []byte, error) {
+	path := env.String("Encryption.Keyring", "")
+	if path == "" {
+		return nil, nil, nil
+	}
+
+	return crypt.LoadLocalKeyring(path)
+}
+
+// NewFieldCipher return nil without keyring which disable field encryption
+func NewFieldCipher(keyring crypt.IKeyring, indexKey []byte) *crypt.FieldCipher {
+	if keyring == nil {
+		return nil
+	}
+
+	return crypt.NewFieldCipher(keyring, indexKey)
+}
+
+// Secret decrypt a config value written by cmd/keyring encrypt, any other
+// value is returned as is
+func Secret(keyring crypt.IKeyring, value string) (string, error) {
+	if !strings.HasPrefix(value, secretPrefix) {
+		return value, nil
+	}
+
+	if keyring == nil {
+		return "", fmt.Errorf("encrypted config value without Encryption.Keyring")
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, secretPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("encrypted config value must be %s<key id>:<ciphertext>", secretPrefix)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := keyring.Decrypt(context.Background(), parts[0], ciphertext)
+	return string(plaintext), err
+}
+
+// StartReencryption seal again the user of every tenant on startup and then
+// on every interval, it pick up plaintext row and row under a rotated key
+func StartReencryption(tenantUsecase tenantDomain.ITenantUsecase, userUsecase userDomain.IUserUsecase, interval time.Duration, logging log.ILogs) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			reencrypt(tenantUsecase, userUsecase, logging)
+			<-ticker.C
+		}
+	}()
+}
+
+func reencrypt(tenantUsecase tenantDomain.ITenantUsecase, userUsecase userDomain.IUserUsecase, logging log.ILogs) {
+	forEachTenant(tenantUsecase, logging, func(ctx context.Context, id uint) {
+		progress, err := userUsecase.Reencrypt(ctx)
+		if err != nil {
+			logging.Error(ctx, fmt.Sprintf("userUsecase.Reencrypt tenant %d Error", id), err)
+			return
+		}
+
+		if progress.Sealed > 0 {
+			logging.Info(ctx, "userUsecase.Reencrypt", fmt.Sprintf("%d user of tenant %d sealed again", progress.Sealed, id))
+		}
+
+		// a skipped user stay under its key until an operator repair it
+		if progress.Skipped > 0 {
+			logging.Warning(ctx, "userUsecase.Reencrypt Skipped", fmt.Sprintf("%d user of tenant %d could not be opened, see the log for their id", progress.Skipped, id))
+		}
+	})
+}
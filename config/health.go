@@ -0,0 +1,98 @@
+package config
+
+import (
+	"net/http"
+	"prototype/app/controller"
+	"prototype/lib/cache"
+	"prototype/lib/redis"
+	"prototype/lib/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleDatabaseHealth report the primary database and every open dedicated
+// tenant database, any of them down turn the response into a 503
+func HandleDatabaseHealth(router *tenant.Router) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		health := router.Health(c.Request.Context())
+
+		statusCode := http.StatusOK
+		if !health.Primary.Up {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		for _, tenantHealth := range health.Tenants {
+			if !tenantHealth.Up {
+				statusCode = http.StatusServiceUnavailable
+			}
+		}
+
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+		res.Set(statusCode, health, nil)
+		c.JSON(statusCode, res)
+	}
+}
+
+// HandleRedisHealth ping every redis node, without client redis is reported
+// down as well
+func HandleRedisHealth(client redis.IRedis) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		health := redis.Health{Nodes: []redis.NodeHealth{}}
+		if client != nil {
+			health = client.Health(c.Request.Context())
+		}
+
+		statusCode := http.StatusOK
+		if !health.Up {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+		res.Set(statusCode, health, nil)
+		c.JSON(statusCode, res)
+	}
+}
+
+// HandleCacheStats report the hit and miss statistics of the in process
+// cache, a disabled cache report empty statistics
+func HandleCacheStats(lru *cache.LRU) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		var stats cache.Stats
+		if lru != nil {
+			stats = lru.Stats()
+		}
+
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+		res.Set(http.StatusOK, stats, nil)
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// HandleStreamStats report the client and event of the user event stream
+func HandleStreamStats(userStream *UserStream) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+		res.Set(http.StatusOK, userStream.Stats(), nil)
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// HandleNotificationStats report the user and socket connected to this
+// instance
+func HandleNotificationStats(notifications *Notifications) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var res controller.Response
+
+		res.SetTraceID(c.GetHeader("Trace-ID"))
+		res.Set(http.StatusOK, notifications.Stats(), nil)
+		c.JSON(http.StatusOK, res)
+	}
+}
@@ -1,35 +1,315 @@
 package config
 
 import (
+	"context"
 	"prototype/app/controller"
+	"prototype/lib/cache"
+	"prototype/lib/consumer"
+	"prototype/lib/env"
 	"prototype/lib/log"
+	"prototype/lib/notify"
+	"prototype/lib/outbox"
+	"prototype/lib/pubsub"
+	"prototype/lib/redis"
+	"prototype/lib/tenant"
+	"prototype/lib/validation"
+	"prototype/lib/webhook"
+	"time"
 
+	userDomain "prototype/domain/user"
+	userModels "prototype/domain/user/models"
 	userUsecase "prototype/domain/user/usecases"
 
 	userRepoMysql "prototype/domain/user/repositories/mysql"
+
+	preferenceUsecase "prototype/domain/user/preferences/usecases"
+
+	loginDomain "prototype/domain/user/logins"
+	loginUsecase "prototype/domain/user/logins/usecases"
+
+	loginRepoMysql "prototype/domain/user/logins/repositories/mysql"
+
+	preferenceRepoMysql "prototype/domain/user/preferences/repositories/mysql"
+
+	organizationDomain "prototype/domain/organization"
+	organizationUsecase "prototype/domain/organization/usecases"
+
+	organizationRepoMysql "prototype/domain/organization/repositories/mysql"
+
+	tenantDomain "prototype/domain/tenant"
+	tenantModels "prototype/domain/tenant/models"
+	tenantUsecase "prototype/domain/tenant/usecases"
+
+	tenantRepoMysql "prototype/domain/tenant/repositories/mysql"
+
+	invitationUsecase "prototype/domain/invitation/usecases"
+
+	invitationRepoMysql "prototype/domain/invitation/repositories/mysql"
+
+	webhookUsecase "prototype/domain/webhook/usecases"
+
+	webhookRepoMysql "prototype/domain/webhook/repositories/mysql"
 )
 
 type Injection struct {
 	Logging log.ILogs
 
-	UserController *controller.UserController
+	UserUsecase         userDomain.IUserUsecase
+	LoginUsecase        loginDomain.ILoginUsecase
+	OrganizationUsecase organizationDomain.IOrganizationUsecase
+	TenantUsecase       tenantDomain.ITenantUsecase
+
+	// SystemTenantID is the tenant whose admin operate the whole deployment
+	SystemTenantID uint
+	TenantRouter   *tenant.Router
+
+	// Redis is nil when RedisUniv can not be used
+	Redis redis.IRedis
+
+	PubSub pubsub.IPubSub
+
+	// Consumers is run by main until shutdown
+	Consumers *consumer.Registry
+
+	// UserCache is nil when User.Memory is disabled
+	UserCache *cache.LRU
+
+	// UserStream is run by main until shutdown
+	UserStream *UserStream
+
+	// Notifications is run by main until shutdown
+	Notifications *Notifications
+
+	UserController         *controller.UserController
+	LoginController        *controller.LoginController
+	PreferenceController   *controller.PreferenceController
+	OrganizationController *controller.OrganizationController
+	TenantController       *controller.TenantController
+	InvitationController   *controller.InvitationController
+	WebhookController      *controller.WebhookController
+	StreamController       *controller.StreamController
+	NotificationController *controller.NotificationController
 }
 
 func NewInjection() Injection {
 	logging := log.NewLog()
 
-	db, err := NewMysql()
+	keyring, indexKey, err := NewKeyring()
 	if err != nil {
+		logging.Fatal(context.Background(), "NewKeyring Error", err)
 	}
 
-	_userRepoMysql := userRepoMysql.NewMysqlUserRepo(db, logging)
+	if keyring == nil {
+		logging.Warning(context.Background(), "NewKeyring", "Encryption.Keyring is not set, personal data is stored in plaintext")
+	}
 
-	_userUsecase := userUsecase.NewUserUsecase(_userRepoMysql, logging)
+	db, tenantRouter, err := NewMysql(keyring)
+	if err != nil {
+		logging.Fatal(context.Background(), "NewMysql Error", err)
+	}
 
-	UserController := controller.NewUserController(_userUsecase, logging)
+	if err := Migrate(db); err != nil {
+		logging.Fatal(context.Background(), "Migrate Error", err)
+	}
+
+	redisClient, err := NewRedis(keyring, logging)
+	if err != nil {
+		logging.Warning(context.Background(), "NewRedis Error", err.Error())
+	} else {
+		// redis is not required to serve, a node down is only reported
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if health := redisClient.Health(ctx); !health.Up {
+			logging.Warning(ctx, "redisClient.Health", health)
+		}
+		cancel()
+	}
+
+	broker, err := NewPubSub()
+	if err != nil {
+		logging.Fatal(context.Background(), "NewPubSub Error", err)
+	}
+
+	_tenantRepoMysql := tenantRepoMysql.NewMysqlTenantRepo(db, logging)
+
+	_tenantUsecase := tenantUsecase.NewTenantUsecase(_tenantRepoMysql, logging)
+
+	systemTenant, err := _tenantRepoMysql.GetBySlug(context.Background(), tenantModels.DefaultSlug)
+	if err != nil {
+		logging.Fatal(context.Background(), "_tenantRepoMysql.GetBySlug Error", err)
+	}
+
+	TenantController := controller.NewTenantController(_tenantUsecase, logging)
+
+	fieldCipher := NewFieldCipher(keyring, indexKey)
+	// lookup go through the in process cache, then Redis, then database
+	_userRepoMysql, userCache := UserMemoryCache(
+		UserCache(userRepoMysql.NewMysqlUserRepo(db, fieldCipher, logging), redisClient, fieldCipher, logging),
+		redisClient, logging)
+
+	attributesSchema, err := validation.NewSchema(env.String("User.Attributes.Schema", ""))
+	if err != nil {
+		logging.Fatal(context.Background(), "validation.NewSchema Error", err)
+	}
+
+	blobStorage, err := NewStorage()
+	if err != nil {
+		logging.Fatal(context.Background(), "NewStorage Error", err)
+	}
+
+	maskingPolicy, err := MaskingPolicy()
+	if err != nil {
+		logging.Fatal(context.Background(), "MaskingPolicy Error", err)
+	}
+
+	// every domain holding personal data register itself for export and erasure
+	dataRegistry := userDomain.NewDataRegistry()
+
+	// usecases notify the signed in user through the notification socket
+	notifications := NewNotifications(broker, logging)
+
+	NotificationController := controller.NewNotificationController(notifications.Hub, NotificationOrigins(), notify.SocketConfig{
+		PingInterval: env.Duration("Notification.PingInterval", 30*time.Second),
+		PongWait:     env.Duration("Notification.PongWait", 60*time.Second),
+		WriteWait:    env.Duration("Notification.WriteWait", 10*time.Second),
+		MaxMessage:   int64(env.Int("Notification.MaxMessage", 4096)),
+	}, logging)
+
+	_userUsecase := userUsecase.NewUserUsecase(_userRepoMysql, userUsecase.AttributesConfig{
+		Schema:  attributesSchema,
+		Indexed: AttributesIndexed(),
+	}, userUsecase.AvatarConfig{
+		Storage:      blobStorage,
+		MaxSize:      AvatarMaxSize(),
+		MaxDimension: env.Int("User.Avatar.MaxDimension", 4096),
+		Sizes:        AvatarSizes(),
+	}, dataRegistry, maskingPolicy, userUsecase.RetentionConfig{
+		ArchiveAfter: env.Duration("Retention.ArchiveAfter", 0),
+		PurgeAfter:   env.Duration("Retention.PurgeAfter", 0),
+		Batch:        env.Int("Retention.Batch", 100),
+	}, notifications, logging)
+
+	UserController := controller.NewUserController(_userUsecase, maskingPolicy, logging)
+
+	StartReencryption(_tenantUsecase, _userUsecase, env.Duration("Encryption.ReencryptInterval", time.Hour), logging)
+
+	StartRetention(_tenantUsecase, _userUsecase, env.Duration("Retention.Interval", 24*time.Hour), env.Bool("Retention.DryRun", true), logging)
+
+	relay := outbox.NewRelay(db, broker, outbox.RelayConfig{
+		Batch:       env.Int("Outbox.Batch", 100),
+		MinBackoff:  env.Duration("Outbox.MinBackoff", time.Second),
+		MaxBackoff:  env.Duration("Outbox.MaxBackoff", 5*time.Minute),
+		MaxAttempts: env.Int("Outbox.MaxAttempts", 10),
+	}, logging)
+	StartOutboxRelay(_tenantUsecase, relay, env.Duration("Outbox.Interval", time.Second), env.Duration("Outbox.Keep", 168*time.Hour), logging)
+
+	_webhookRepoMysql := webhookRepoMysql.NewMysqlWebhookRepo(db, fieldCipher, logging)
+
+	// the delivery of a batch are attempted one after the other, the lease
+	// cover the whole batch
+	webhookTimeout := env.Duration("Webhook.Timeout", 10*time.Second)
+	webhookBatch := env.Int("Webhook.Batch", 20)
+
+	webhookAllow, err := webhook.ParseNetworks(WebhookAllow()...)
+	if err != nil {
+		logging.Fatal(context.Background(), "webhook.ParseNetworks Error", err)
+	}
+
+	webhookClient := webhook.NewClient(webhook.ClientConfig{Timeout: webhookTimeout, Allow: webhookAllow})
+
+	_webhookUsecase := webhookUsecase.NewWebhookUsecase(_webhookRepoMysql, webhookClient, webhookUsecase.WebhookConfig{
+		Events:       []string{userModels.EventUserCreated, userModels.EventUserUpdated, userModels.EventUserDeleted},
+		Batch:        webhookBatch,
+		MaxAttempts:  env.Int("Webhook.MaxAttempts", 8),
+		MinBackoff:   env.Duration("Webhook.MinBackoff", 30*time.Second),
+		MaxBackoff:   env.Duration("Webhook.MaxBackoff", 6*time.Hour),
+		DisableAfter: env.Int("Webhook.DisableAfter", 20),
+		Lease:        time.Duration(webhookBatch+1) * webhookTimeout,
+	}, logging)
+
+	WebhookController := controller.NewWebhookController(_webhookUsecase, logging)
+
+	StartWebhookDelivery(_tenantUsecase, _webhookUsecase, env.Duration("Webhook.Interval", 5*time.Second), env.Duration("Webhook.Keep", 720*time.Hour), logging)
+
+	consumers, err := NewConsumers(db, broker, _userUsecase, _webhookUsecase, logging)
+	if err != nil {
+		logging.Fatal(context.Background(), "NewConsumers Error", err)
+	}
+
+	userStream := NewUserStream(broker, logging)
+
+	StreamController := controller.NewStreamController(userStream.Hub, controller.StreamConfig{
+		Heartbeat: env.Duration("Stream.Heartbeat", 15*time.Second),
+		Retry:     env.Duration("Stream.Retry", 3*time.Second),
+	}, logging)
+
+	_loginRepoMysql := loginRepoMysql.NewMysqlLoginRepo(db, logging)
+
+	_loginUsecase := loginUsecase.NewLoginUsecase(_loginRepoMysql, loginUsecase.LoginConfig{
+		FlushInterval: env.Duration("Login.FlushInterval", 10*time.Second),
+		SessionGap:    env.Duration("Login.SessionGap", 30*time.Minute),
+		Retention:     env.Duration("Login.Retention", 90*24*time.Hour),
+	}, logging)
+
+	LoginController := controller.NewLoginController(_loginUsecase, logging)
+
+	dataRegistry.Register(_loginUsecase)
+
+	preferenceDefinitions, err := PreferenceDefinitions()
+	if err != nil {
+		logging.Fatal(context.Background(), "PreferenceDefinitions Error", err)
+	}
+
+	_preferenceRepoMysql := preferenceRepoMysql.NewMysqlPreferenceRepo(db, logging)
+
+	_preferenceUsecase := preferenceUsecase.NewPreferenceUsecase(_preferenceRepoMysql, preferenceDefinitions, logging)
+
+	PreferenceController := controller.NewPreferenceController(_preferenceUsecase, logging)
+
+	dataRegistry.Register(preferenceRepoMysql.NewMysqlPreferenceDataOwner(db, logging))
+
+	_organizationRepoMysql := organizationRepoMysql.NewMysqlOrganizationRepo(db, logging)
+
+	_organizationUsecase := organizationUsecase.NewOrganizationUsecase(_organizationRepoMysql, _userRepoMysql, notifications, logging)
+
+	OrganizationController := controller.NewOrganizationController(_organizationUsecase, logging)
+
+	dataRegistry.Register(organizationRepoMysql.NewMysqlOrganizationDataOwner(db, logging))
+
+	_invitationRepoMysql := invitationRepoMysql.NewMysqlInvitationRepo(db, logging)
+
+	_invitationUsecase := invitationUsecase.NewInvitationUsecase(_invitationRepoMysql, _userRepoMysql, _organizationRepoMysql, NewMailer(logging), invitationUsecase.InvitationConfig{
+		TTL:       env.Duration("Invitation.TTL", 7*24*time.Hour),
+		AcceptURL: env.String("Invitation.AcceptURL", ""),
+	}, logging)
+
+	InvitationController := controller.NewInvitationController(_invitationUsecase, maskingPolicy, logging)
+
+	dataRegistry.Register(invitationRepoMysql.NewMysqlInvitationDataOwner(db, logging))
 
 	return Injection{
-		UserController: UserController,
+		UserUsecase:         _userUsecase,
+		LoginUsecase:        _loginUsecase,
+		OrganizationUsecase: _organizationUsecase,
+		TenantUsecase:       _tenantUsecase,
+
+		SystemTenantID: systemTenant.ID,
+		TenantRouter:   tenantRouter,
+		Redis:          redisClient,
+		UserCache:      userCache,
+		PubSub:         broker,
+		Consumers:      consumers,
+		UserStream:     userStream,
+		Notifications:  notifications,
+
+		UserController:         UserController,
+		LoginController:        LoginController,
+		PreferenceController:   PreferenceController,
+		OrganizationController: OrganizationController,
+		TenantController:       TenantController,
+		InvitationController:   InvitationController,
+		WebhookController:      WebhookController,
+		StreamController:       StreamController,
+		NotificationController: NotificationController,
 
 		Logging: logging,
 	}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"prototype/lib/env"
+	"prototype/lib/log"
+	"prototype/lib/mail"
+)
+
+func NewMailer(logging log.ILogs) mail.IMailer {
+	switch env.String("Mail.Driver", "log") {
+	case "smtp":
+		return mail.NewSMTP(mail.ConfigSMTP{
+			Host:     env.String("Mail.SMTP.Host", ""),
+			Port:     env.String("Mail.SMTP.Port", "587"),
+			Username: env.String("Mail.SMTP.Username", ""),
+			Password: env.String("Mail.SMTP.Password", ""),
+			From:     env.String("Mail.SMTP.From", ""),
+		})
+	default:
+		return mail.NewLog(logging)
+	}
+}
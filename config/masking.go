@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"prototype/lib/env"
+	"prototype/lib/masking"
+)
+
+// MaskingPolicy read the rule of every field per caller role from config,
+// a role map json field name to full, partial or omit
+func MaskingPolicy() (masking.Policy, error) {
+	policy := masking.Policy{}
+
+	declared, _ := env.Interface("Masking", nil).(map[string]interface{})
+	for role, value := range declared {
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("masking %s: invalid declaration", role)
+		}
+
+		policy[role] = map[string]string{}
+		for field, rule := range entry {
+			policy[role][field] = fmt.Sprint(rule)
+		}
+	}
+
+	return policy, policy.Validate()
+}
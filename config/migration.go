@@ -0,0 +1,458 @@
+package config
+
+import (
+	"fmt"
+	invitationModels "prototype/domain/invitation/models"
+	organizationModels "prototype/domain/organization/models"
+	tenantModels "prototype/domain/tenant/models"
+	loginModels "prototype/domain/user/logins/models"
+	userModels "prototype/domain/user/models"
+	preferenceModels "prototype/domain/user/preferences/models"
+	webhookModels "prototype/domain/webhook/models"
+	"prototype/lib/consumer"
+	"prototype/lib/env"
+	"prototype/lib/outbox"
+	"strings"
+	"time"
+
+	userRepoMysql "prototype/domain/user/repositories/mysql"
+
+	"gorm.io/gorm"
+)
+
+// migrations run in order on the primary database on startup and on every
+// dedicated tenant database when it is first opened, every step must be safe
+// to run again
+var migrations = []func(db *gorm.DB) error{
+	migrateUser,
+	migrateUserStatus,
+	migrateUserAudit,
+	migrateUserAttributes,
+	migrateUserAvatar,
+	migrateUserPreference,
+	migrateOrganization,
+	migrateUserRole,
+	migrateTenant,
+	migrateUserEmailVerified,
+	migrateInvitation,
+	migrateUserMerge,
+	migrateUserLogin,
+	migrateUserEncryption,
+	migrateUserArchive,
+	migrateOutbox,
+	migrateWebhook,
+	migrateWebhookResponseBody,
+	migrateUserArchiveKey,
+	migrateOutboxStatus,
+	migrateUserCreatedAt,
+}
+
+// Migrate the primary database, it hold the tenant registry next to the data
+// of every tenant without a dedicated database
+func Migrate(db *gorm.DB) error {
+	if err := migrateTenantRegistry(db); err != nil {
+		return err
+	}
+
+	if err := migrateConsumerMessage(db); err != nil {
+		return err
+	}
+
+	return MigrateTenant(db)
+}
+
+// MigrateTenant bring the schema of a database holding tenant data up to date
+func MigrateTenant(db *gorm.DB) error {
+	for _, migrate := range migrations {
+		if err := migrate(db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrateUser(db *gorm.DB) error {
+	if db.Migrator().HasTable(&userModels.User{}) {
+		return nil
+	}
+
+	return db.Migrator().CreateTable(&userModels.User{})
+}
+
+func migrateUserStatus(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	for _, field := range []string{"Status", "StatusReason", "StatusChangedAt"} {
+		if migrator.HasColumn(&userModels.User{}, field) {
+			continue
+		}
+
+		if err := migrator.AddColumn(&userModels.User{}, field); err != nil {
+			return err
+		}
+	}
+
+	if !migrator.HasIndex(&userModels.User{}, "Status") {
+		return migrator.CreateIndex(&userModels.User{}, "Status")
+	}
+
+	return nil
+}
+
+func migrateUserAudit(db *gorm.DB) error {
+	if db.Migrator().HasTable(&userModels.UserAudit{}) {
+		return nil
+	}
+
+	return db.Migrator().CreateTable(&userModels.UserAudit{})
+}
+
+func migrateUserAttributes(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	if !migrator.HasColumn(&userModels.User{}, "Attributes") {
+		if err := migrator.AddColumn(&userModels.User{}, "Attributes"); err != nil {
+			return err
+		}
+	}
+
+	// functional index for every attribute declared filterable
+	for _, key := range AttributesIndexed() {
+		name := "idx_user_attr_" + key
+		if migrator.HasIndex(&userModels.User{}, name) {
+			continue
+		}
+
+		expression, err := userRepoMysql.AttributeExpression(key)
+		if err != nil {
+			return err
+		}
+
+		if err := db.Exec(fmt.Sprintf("CREATE INDEX %s ON `user` (%s)", name, expression)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AttributesIndexed return the filterable attribute keys from config
+func AttributesIndexed() (keys []string) {
+	for _, key := range strings.Split(env.String("User.Attributes.Indexed", ""), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return
+}
+
+func migrateUserAvatar(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&userModels.User{}, "Avatar") {
+		return nil
+	}
+
+	return db.Migrator().AddColumn(&userModels.User{}, "Avatar")
+}
+
+func migrateUserPreference(db *gorm.DB) error {
+	if db.Migrator().HasTable(&preferenceModels.Preference{}) {
+		return nil
+	}
+
+	return db.Migrator().CreateTable(&preferenceModels.Preference{})
+}
+
+func migrateOrganization(db *gorm.DB) error {
+	for _, model := range []interface{}{&organizationModels.Organization{}, &organizationModels.Membership{}} {
+		if db.Migrator().HasTable(model) {
+			continue
+		}
+
+		if err := db.Migrator().CreateTable(model); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrateUserRole(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&userModels.User{}, "Role") {
+		return nil
+	}
+
+	return db.Migrator().AddColumn(&userModels.User{}, "Role")
+}
+
+// migrateTenantRegistry create the tenant registry and its default tenant
+func migrateTenantRegistry(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	if !migrator.HasTable(&tenantModels.Tenant{}) {
+		if err := migrator.CreateTable(&tenantModels.Tenant{}); err != nil {
+			return err
+		}
+	}
+
+	if !migrator.HasColumn(&tenantModels.Tenant{}, "Database") {
+		if err := migrator.AddColumn(&tenantModels.Tenant{}, "Database"); err != nil {
+			return err
+		}
+	}
+
+	defaultTenant := tenantModels.Tenant{
+		Slug:   tenantModels.DefaultSlug,
+		Name:   "Default",
+		Status: tenantModels.StatusActive,
+	}
+
+	return db.Where("slug = ?", defaultTenant.Slug).FirstOrCreate(&defaultTenant).Error
+}
+
+// migrateTenant add the tenant column and move every record created before
+// multi-tenancy in the default tenant, a dedicated database has no registry
+// and nothing to move
+func migrateTenant(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	var defaultTenant tenantModels.Tenant
+	if migrator.HasTable(&tenantModels.Tenant{}) {
+		if err := db.Where("slug = ?", tenantModels.DefaultSlug).First(&defaultTenant).Error; err != nil {
+			return err
+		}
+	}
+
+	scoped := []struct {
+		model interface{}
+		table string
+		index string
+	}{
+		{&userModels.User{}, "user", "idx_user_tenant_email"},
+		{&userModels.UserAudit{}, "user_audit", "TenantID"},
+		{&preferenceModels.Preference{}, "user_preference", "TenantID"},
+		{&organizationModels.Organization{}, "organization", "idx_organization_tenant_slug"},
+		{&organizationModels.Membership{}, "organization_member", "TenantID"},
+	}
+
+	for _, step := range scoped {
+		if !migrator.HasColumn(step.model, "TenantID") {
+			if err := migrator.AddColumn(step.model, "TenantID"); err != nil {
+				return err
+			}
+		}
+
+		if defaultTenant.ID != 0 {
+			backfill := fmt.Sprintf("UPDATE `%s` SET tenant_id = ? WHERE tenant_id = 0", step.table)
+			if err := db.Exec(backfill, defaultTenant.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if migrator.HasIndex(step.model, step.index) {
+			continue
+		}
+
+		// email become part of a unique index and need a bounded length
+		if step.table == "user" {
+			if err := migrator.AlterColumn(step.model, "Email"); err != nil {
+				return err
+			}
+		}
+
+		// organization slug used to be unique across tenants
+		if step.table == "organization" && migrator.HasIndex(step.model, "idx_organization_slug") {
+			if err := migrator.DropIndex(step.model, "idx_organization_slug"); err != nil {
+				return err
+			}
+		}
+
+		if err := migrator.CreateIndex(step.model, step.index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrateUserEmailVerified(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&userModels.User{}, "EmailVerifiedAt") {
+		return nil
+	}
+
+	return db.Migrator().AddColumn(&userModels.User{}, "EmailVerifiedAt")
+}
+
+func migrateInvitation(db *gorm.DB) error {
+	if db.Migrator().HasTable(&invitationModels.Invitation{}) {
+		return nil
+	}
+
+	return db.Migrator().CreateTable(&invitationModels.Invitation{})
+}
+
+func migrateUserMerge(db *gorm.DB) error {
+	if db.Migrator().HasTable(&userModels.UserMerge{}) {
+		return nil
+	}
+
+	return db.Migrator().CreateTable(&userModels.UserMerge{})
+}
+
+func migrateUserLogin(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	for _, column := range []string{"LastLoginAt", "LastSeenAt"} {
+		if migrator.HasColumn(&userModels.User{}, column) {
+			continue
+		}
+
+		if err := migrator.AddColumn(&userModels.User{}, column); err != nil {
+			return err
+		}
+	}
+
+	if migrator.HasTable(&loginModels.Login{}) {
+		return nil
+	}
+
+	return migrator.CreateTable(&loginModels.Login{})
+}
+
+// migrateUserEncryption add the column of field encryption, existing row stay
+// in plaintext without blind index until the re-encryption job reach them
+func migrateUserEncryption(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	if !migrator.HasColumn(&userModels.User{}, "EmailIndex") {
+		// ciphertext is longer than the plaintext it replace
+		if err := migrator.AlterColumn(&userModels.User{}, "Email"); err != nil {
+			return err
+		}
+
+		for _, field := range []string{"EmailIndex", "KeyID", "DataKey"} {
+			if err := migrator.AddColumn(&userModels.User{}, field); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, index := range []string{"idx_user_tenant_email_index", "KeyID"} {
+		if migrator.HasIndex(&userModels.User{}, index) {
+			continue
+		}
+
+		if err := migrator.CreateIndex(&userModels.User{}, index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateUserCreatedAt add the creation time a user never seen is inactive
+// from, an existing user is taken as created with its first audit entry or
+// else now so retention does not archive it at once
+func migrateUserCreatedAt(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&userModels.User{}, "CreatedAt") {
+		return nil
+	}
+
+	if err := db.Migrator().AddColumn(&userModels.User{}, "CreatedAt"); err != nil {
+		return err
+	}
+
+	backfill := "UPDATE `user` SET created_at = COALESCE((SELECT MIN(user_audit.created_at) FROM user_audit WHERE user_audit.user_id = `user`.id), ?) WHERE created_at IS NULL"
+	return db.Exec(backfill, time.Now()).Error
+}
+
+func migrateUserArchive(db *gorm.DB) error {
+	if db.Migrator().HasTable(&userModels.UserArchive{}) {
+		return nil
+	}
+
+	return db.Migrator().CreateTable(&userModels.UserArchive{})
+}
+
+// migrateUserArchiveKey repeat the key id of the archived record in a column,
+// record archived earlier have none and are sealed again by the next reencrypt
+func migrateUserArchiveKey(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	if !migrator.HasColumn(&userModels.UserArchive{}, "KeyID") {
+		if err := migrator.AddColumn(&userModels.UserArchive{}, "KeyID"); err != nil {
+			return err
+		}
+	}
+
+	if migrator.HasIndex(&userModels.UserArchive{}, "KeyID") {
+		return nil
+	}
+
+	return migrator.CreateIndex(&userModels.UserArchive{}, "KeyID")
+}
+
+func migrateOutbox(db *gorm.DB) error {
+	if db.Migrator().HasTable(&outbox.Event{}) {
+		return nil
+	}
+
+	return db.Migrator().CreateTable(&outbox.Event{})
+}
+
+// migrateOutboxStatus add the status the relay select on, event delivered
+// earlier are marked so and the rest stay pending
+func migrateOutboxStatus(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	if !migrator.HasColumn(&outbox.Event{}, "Status") {
+		if err := migrator.AddColumn(&outbox.Event{}, "Status"); err != nil {
+			return err
+		}
+
+		if err := db.Exec("UPDATE `outbox` SET status = ? WHERE delivered_at IS NOT NULL", outbox.StatusDelivered).Error; err != nil {
+			return err
+		}
+	}
+
+	if migrator.HasIndex(&outbox.Event{}, "idx_outbox_due") {
+		return nil
+	}
+
+	return migrator.CreateIndex(&outbox.Event{}, "idx_outbox_due")
+}
+
+func migrateWebhook(db *gorm.DB) error {
+	for _, model := range []interface{}{&webhookModels.Webhook{}, &webhookModels.Delivery{}} {
+		if db.Migrator().HasTable(model) {
+			continue
+		}
+
+		if err := db.Migrator().CreateTable(model); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateWebhookResponseBody drop the response kept by earlier delivery, the
+// receiver is chosen by the tenant and what it answer is not stored
+func migrateWebhookResponseBody(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&webhookModels.Delivery{}, "response_body") {
+		return nil
+	}
+
+	return db.Migrator().DropColumn(&webhookModels.Delivery{}, "response_body")
+}
+
+// migrateConsumerMessage run on the primary database only, consumer are not
+// tenant scoped
+func migrateConsumerMessage(db *gorm.DB) error {
+	if db.Migrator().HasTable(&consumer.Processed{}) {
+		return nil
+	}
+
+	return db.Migrator().CreateTable(&consumer.Processed{})
+}
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"os"
+	tenantModels "prototype/domain/tenant/models"
+	"prototype/lib/crypt"
 	"prototype/lib/env"
+	"prototype/lib/tenant"
 	"time"
 
 	"gorm.io/driver/mysql"
@@ -34,12 +39,51 @@ func init() {
 	}
 }
 
-func NewMysql() (*gorm.DB, error) {
+// NewMysql open the primary database and return a connection routing every
+// statement to the database of the tenant in its context, see tenant.Router.
+// Database.Pass may be encrypted with keyring, see Secret
+func NewMysql(keyring crypt.IKeyring) (*gorm.DB, *tenant.Router, error) {
+	password, err := Secret(keyring, cfg.Password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Database.Pass: %w", err)
+	}
+
 	// init connection mysql
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+		cfg.User, password, cfg.Host, cfg.Port, cfg.Database)
+
+	primary, err := sql.Open("mysql", dsn)
+	if err != nil {
+		log.Fatalf("ERROR: %s", err.Error())
+	}
+
+	var db *gorm.DB
+
+	router := tenant.NewRouter(primary, tenant.RouterConfig{
+		DSN: func(ctx context.Context, id uint) (string, error) {
+			return tenantDSN(ctx, db, keyring, id)
+		},
+		Open:        openTenantMysql,
+		IdleTimeout: env.Duration("Tenant.IdleTimeout", 10*time.Minute),
+	})
+
+	db, err = gorm.Open(mysql.New(mysql.Config{Conn: router}), gormConfig())
+	if err != nil {
+		log.Fatalf("ERROR: %s", err.Error())
+	}
+	log.Printf("INFO: Connected to DB")
+
+	// scope every tenant owned model to the tenant of the query context
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		return nil, nil, err
+	}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	return db, router, nil
+
+}
+
+func gormConfig() *gorm.Config {
+	return &gorm.Config{
 		Logger: logger.New(
 			log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
 			logger.Config{
@@ -49,12 +93,59 @@ func NewMysql() (*gorm.DB, error) {
 				Colorful:                  true,        // Disable color
 			},
 		),
-	})
+	}
+}
+
+// tenantDSN return the dedicated database of a tenant, Tenant.Databases in
+// config take precedence over the registry. Both may be encrypted with
+// keyring as the DSN carry the password, see Secret
+func tenantDSN(ctx context.Context, db *gorm.DB, keyring crypt.IKeyring, id uint) (string, error) {
+	var registry tenantModels.Tenant
+	if err := db.WithContext(tenant.WithoutID(ctx)).Select("slug", "database").Where("id = ?", id).First(&registry).Error; err != nil {
+		return "", err
+	}
+
+	if databases, ok := env.Interface("Tenant.Databases", nil).(map[string]interface{}); ok {
+		if dsn, ok := databases[registry.Slug].(string); ok && dsn != "" {
+			dsn, err := Secret(keyring, dsn)
+			if err != nil {
+				return "", fmt.Errorf("Tenant.Databases.%s: %w", registry.Slug, err)
+			}
+
+			return dsn, nil
+		}
+	}
+
+	dsn, err := Secret(keyring, registry.Database)
 	if err != nil {
-		log.Fatalf("ERROR: %s", err.Error())
+		return "", fmt.Errorf("database of tenant %s: %w", registry.Slug, err)
 	}
-	log.Printf("INFO: Connected to DB")
 
-	return db, nil
+	return dsn, nil
+}
 
+// openTenantMysql connect to the dedicated database of a tenant and migrate it
+// before it serve any statement
+func openTenantMysql(ctx context.Context, id uint, dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(mysql.Open(dsn), gormConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB.SetMaxOpenConns(env.Int("Tenant.MaxOpenConns", 10))
+	sqlDB.SetMaxIdleConns(env.Int("Tenant.MaxIdleConns", 2))
+
+	if err := MigrateTenant(db); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("migrate tenant %d: %w", id, err)
+	}
+
+	log.Printf("INFO: Connected to tenant %d DB", id)
+
+	return db, nil
 }
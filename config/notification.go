@@ -0,0 +1,66 @@
+package config
+
+import (
+	"context"
+	"prototype/lib/env"
+	"prototype/lib/log"
+	"prototype/lib/notify"
+	"prototype/lib/pubsub"
+	"strings"
+)
+
+// Notifications hold the notification socket of this instance. A notification
+// is published on Notification.Topic and every instance deliver it to its own
+// socket from Notification.Subscription, which must therefore be a
+// subscription of its own per instance
+type Notifications struct {
+	*notify.Hub
+	*notify.Notifier
+
+	subscriber   pubsub.ISubscriber
+	subscription string
+	log          log.ILogs
+}
+
+// NewNotifications without Notification.Topic only deliver notification to
+// the socket of this instance, which is enough for a single replica
+func NewNotifications(broker pubsub.IPubSub, logging log.ILogs) *Notifications {
+	hub := notify.NewHub(notify.Config{
+		MaxConnections: env.Int("Notification.MaxConnections", 5),
+		SendBuffer:     env.Int("Notification.SendBuffer", 32),
+	})
+
+	topic := env.String("Notification.Topic", "")
+
+	return &Notifications{
+		Hub:          hub,
+		Notifier:     notify.NewNotifier(hub, broker, topic),
+		subscriber:   broker,
+		subscription: env.String("Notification.Subscription", ""),
+		log:          logging,
+	}
+}
+
+// Run deliver the notification of every instance until ctx is done and then
+// close every socket, the HTTP server does not track them on shutdown
+func (notifications *Notifications) Run(ctx context.Context) {
+	if notifications.subscription == "" {
+		<-ctx.Done()
+		notifications.Close()
+		return
+	}
+
+	notifications.Feed(ctx, notifications.subscriber, notifications.subscription, notifications.log)
+}
+
+// NotificationOrigins read the comma separated origin allowed to open a
+// notification socket
+func NotificationOrigins() (origins []string) {
+	for _, origin := range strings.Split(env.String("Notification.Origins", ""), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	tenantDomain "prototype/domain/tenant"
+	"prototype/lib/log"
+	"prototype/lib/outbox"
+	"time"
+)
+
+// StartOutboxRelay publish the outbox of every tenant on every interval and
+// delete the event delivered for longer than keep
+func StartOutboxRelay(tenantUsecase tenantDomain.ITenantUsecase, relay *outbox.Relay, interval, keep time.Duration, logging log.ILogs) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			forEachTenant(tenantUsecase, logging, func(ctx context.Context, id uint) {
+				if _, err := relay.Flush(ctx); err != nil {
+					logging.Error(ctx, fmt.Sprintf("relay.Flush tenant %d Error", id), err)
+					return
+				}
+
+				if keep > 0 {
+					if _, err := relay.Purge(ctx, time.Now().Add(-keep)); err != nil {
+						logging.Error(ctx, fmt.Sprintf("relay.Purge tenant %d Error", id), err)
+					}
+				}
+			})
+		}
+	}()
+}
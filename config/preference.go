@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	preferenceModels "prototype/domain/user/preferences/models"
+	"prototype/lib/env"
+	"strconv"
+	"strings"
+)
+
+// PreferenceDefinitions read the declared preference keys from config, every
+// entry has a Type (string, bool or number), a Default and an optional Enum
+func PreferenceDefinitions() (map[string]preferenceModels.Definition, error) {
+	definitions := map[string]preferenceModels.Definition{}
+
+	declared, _ := env.Interface("Preferences", nil).(map[string]interface{})
+	for key, value := range declared {
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("preference %s: invalid declaration", key)
+		}
+
+		definition := preferenceModels.Definition{
+			Key:  key,
+			Type: fmt.Sprint(entry["type"]),
+		}
+
+		if enum, ok := entry["enum"].(string); ok && enum != "" {
+			definition.Enum = strings.Split(enum, ",")
+		}
+
+		defaultValue := fmt.Sprint(entry["default"])
+
+		var err error
+		switch definition.Type {
+		case preferenceModels.TypeString:
+			definition.Default = defaultValue
+		case preferenceModels.TypeBool:
+			definition.Default, err = strconv.ParseBool(defaultValue)
+		case preferenceModels.TypeNumber:
+			definition.Default, err = strconv.ParseFloat(defaultValue, 64)
+		default:
+			err = fmt.Errorf("unsupported type %q", definition.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("preference %s: %w", key, err)
+		}
+
+		definitions[key] = definition
+	}
+
+	return definitions, nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"prototype/lib/env"
+	"prototype/lib/pubsub"
+)
+
+// NewPubSub build the broker of the PubSub block, PubSub.Driver is google or
+// memory for a single replica run
+func NewPubSub() (pubsub.IPubSub, error) {
+	config := pubsub.Config{
+		ProjectID:      env.String("PubSub.ProjectID", ""),
+		Credential:     env.String("PubSub.Credential", ""),
+		Topics:         pubsubNames("PubSub.Topic"),
+		Subscriptions:  pubsubNames("PubSub.SubID"),
+		Bindings:       pubsubNames("PubSub.Binding"),
+		MaxOutstanding: env.Int("PubSub.MaxOutstanding", 10),
+	}
+
+	switch driver := env.String("PubSub.Driver", "memory"); driver {
+	case "google":
+		return pubsub.NewGoogle(context.Background(), config)
+	case "memory":
+		return pubsub.NewMemory(config), nil
+	default:
+		return nil, fmt.Errorf("unknown PubSub.Driver %s", driver)
+	}
+}
+
+// pubsubNames read a name to id block of PubSub
+func pubsubNames(key string) map[string]string {
+	names := map[string]string{}
+	if declared, ok := env.Interface(key, nil).(map[string]interface{}); ok {
+		for name, id := range declared {
+			names[name] = fmt.Sprint(id)
+		}
+	}
+
+	return names
+}
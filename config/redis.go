@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"prototype/lib/crypt"
+	"prototype/lib/env"
+	"prototype/lib/log"
+	"prototype/lib/redis"
+	"strings"
+	"time"
+)
+
+// NewRedis build the client of the RedisUniv block, both password may be
+// encrypted with keyring, see Secret. Command are logged per class following
+// the Logging.Redis flags
+func NewRedis(keyring crypt.IKeyring, logging log.ILogs) (redis.IRedis, error) {
+	password, err := Secret(keyring, env.String("RedisUniv.Password", ""))
+	if err != nil {
+		return nil, fmt.Errorf("RedisUniv.Password: %w", err)
+	}
+
+	sentinelPassword, err := Secret(keyring, env.String("RedisUniv.SentinelPassword", ""))
+	if err != nil {
+		return nil, fmt.Errorf("RedisUniv.SentinelPassword: %w", err)
+	}
+
+	client, err := redis.New(redis.Config{
+		Hosts:    redisHosts(),
+		DB:       env.Int("RedisUniv.Db", 0),
+		ReadOnly: env.Bool("RedisUniv.ReadOnly", false),
+
+		Username:         env.String("RedisUniv.Username", ""),
+		Password:         password,
+		SentinelUsername: env.String("RedisUniv.SentinelUsername", ""),
+		SentinelPassword: sentinelPassword,
+
+		MaxRetries:   env.Int("RedisUniv.MaxRetries", 0),
+		DialTimeout:  env.Duration("RedisUniv.DialTimeout", 5*time.Second),
+		ReadTimeout:  env.Duration("RedisUniv.ReadTimeout", 0),
+		WriteTimeout: env.Duration("RedisUniv.WriteTimeout", 0),
+
+		PoolSize:           env.Int("RedisUniv.PoolSize", 0),
+		MinIdleConns:       env.Int("RedisUniv.MinIdleConns", 0),
+		MaxConnAge:         env.Duration("RedisUniv.MaxConnAge", 0),
+		PoolTimeout:        env.Duration("RedisUniv.PoolTimeout", 0),
+		IdleTimeout:        env.Duration("RedisUniv.IdleTimeout", 0),
+		IdleCheckFrequency: env.Duration("RedisUniv.IdleCheckFrequency", 0),
+
+		MaxRedirects:   env.Int("RedisUniv.MaxRedirects", 0),
+		RouteByLatency: env.Bool("RedisUniv.RouteByLatency", false),
+		RouteRandomly:  env.Bool("RedisUniv.RouteRandomly", false),
+		MasterName:     env.String("RedisUniv.MasterName", ""),
+
+		TLSCAPath: env.String("RedisUniv.TlsCAPath", ""),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client.AddHook(redis.NewLogHook(logging, redis.LogConfig{
+		Save:     env.Bool("Logging.Redis.Save", false),
+		Get:      env.Bool("Logging.Redis.Get", false),
+		Delete:   env.Bool("Logging.Redis.Delete", false),
+		MaxValue: env.Int("Logging.Redis.MaxValue", 256),
+	}))
+
+	return client, nil
+}
+
+// redisHosts accept RedisUniv.Host as a list or a comma separated string
+func redisHosts() (hosts []string) {
+	var values []string
+	switch declared := env.Interface("RedisUniv.Host", nil).(type) {
+	case []interface{}:
+		for _, host := range declared {
+			values = append(values, fmt.Sprint(host))
+		}
+	case string:
+		values = strings.Split(declared, ",")
+	}
+
+	for _, host := range values {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	tenantDomain "prototype/domain/tenant"
+	userDomain "prototype/domain/user"
+	"prototype/lib/log"
+	"time"
+)
+
+// StartRetention apply the retention policy to every tenant on every
+// interval, with dryRun the job only log what it would archive and purge
+func StartRetention(tenantUsecase tenantDomain.ITenantUsecase, userUsecase userDomain.IUserUsecase, interval time.Duration, dryRun bool, logging log.ILogs) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			forEachTenant(tenantUsecase, logging, func(ctx context.Context, id uint) {
+				report, err := userUsecase.Retention(ctx, dryRun)
+				if err != nil {
+					logging.Error(ctx, fmt.Sprintf("userUsecase.Retention tenant %d Error", id), err)
+					return
+				}
+
+				if dryRun {
+					logging.Info(ctx, fmt.Sprintf("userUsecase.Retention tenant %d dry run", id), report)
+				}
+			})
+		}
+	}()
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"prototype/app/middleware"
+	organizationModels "prototype/domain/organization/models"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/env"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,13 +20,109 @@ func NewRouter(inject Injection) *Router {
 
 	route.GET("/version", HandleVersion)
 
+	// blob of the local storage driver are served by the service itself
+	if env.String("Storage.Driver", "local") == "local" {
+		route.Static(env.String("Storage.Local.BaseURL", "/storage"), env.String("Storage.Local.Root", "storage"))
+	}
+
 	v1 := route.Group("v1")
+	v1.Use(middleware.Tenant(inject.TenantUsecase, TenantResolver(), inject.Logging))
+	v1.Use(middleware.Authentication(inject.UserUsecase, inject.LoginUsecase, inject.Logging))
 	{
 		v1.GET("/user", inject.UserController.Fetch)
 		v1.GET("/user/:user_id", inject.UserController.GetByID)
 		v1.POST("/user", inject.UserController.Create)
 		v1.PUT("/user/:user_id", inject.UserController.Update)
 		v1.DELETE("/user/:user_id", inject.UserController.Delete)
+
+		v1.POST("/user/:user_id/activate", middleware.TenantAdmin(inject.Logging), inject.UserController.Transition(userModels.StatusActive))
+		v1.POST("/user/:user_id/suspend", middleware.TenantAdmin(inject.Logging), inject.UserController.Transition(userModels.StatusSuspended))
+		v1.POST("/user/:user_id/lock", middleware.TenantAdmin(inject.Logging), inject.UserController.Transition(userModels.StatusLocked))
+		v1.POST("/user/:user_id/deactivate", middleware.TenantAdmin(inject.Logging), inject.UserController.Transition(userModels.StatusDeactivated))
+
+		v1.PATCH("/user/:user_id/attributes", middleware.SelfOrAdmin(inject.Logging), inject.UserController.PatchAttributes)
+		v1.PUT("/user/:user_id/avatar", middleware.SelfOrAdmin(inject.Logging), middleware.BodyLimit(AvatarMaxBody(), inject.Logging), inject.UserController.UploadAvatar)
+
+		v1.GET("/user/:user_id/history", middleware.SelfOrAdmin(inject.Logging), inject.UserController.History)
+		v1.GET("/user/:user_id/history/verify", middleware.TenantAdmin(inject.Logging), inject.UserController.VerifyHistory)
+
+		v1.GET("/user/:user_id/logins", middleware.SelfOrAdmin(inject.Logging), inject.LoginController.Fetch)
+
+		v1.GET("/user/:user_id/preferences", middleware.SelfOrAdmin(inject.Logging), inject.PreferenceController.Fetch)
+		v1.GET("/user/:user_id/preferences/:key", middleware.SelfOrAdmin(inject.Logging), inject.PreferenceController.Get)
+		v1.PUT("/user/:user_id/preferences/:key", middleware.SelfOrAdmin(inject.Logging), inject.PreferenceController.Put)
+		v1.DELETE("/user/:user_id/preferences/:key", middleware.SelfOrAdmin(inject.Logging), inject.PreferenceController.Delete)
+
+		v1.GET("/user/:user_id/organizations", middleware.SelfOrAdmin(inject.Logging), inject.OrganizationController.UserOrganizations)
+	}
+
+	v1.GET("/user/stream", middleware.TenantAdmin(inject.Logging), inject.StreamController.User)
+	v1.GET("/notification/socket", inject.NotificationController.Connect)
+	v1.POST("/user/:user_id/merge", middleware.TenantAdmin(inject.Logging), inject.UserController.Merge)
+	// the export is every personal data of the user, it must not end up in the log
+	v1.GET("/user/:user_id/export", middleware.SelfOrAdmin(inject.Logging), middleware.Redact(middleware.RedactResponse), inject.UserController.Export)
+	v1.POST("/user/:user_id/erase", middleware.TenantAdmin(inject.Logging), inject.UserController.Erase)
+
+	archive := v1.Group("/archive", middleware.TenantAdmin(inject.Logging))
+	{
+		archive.GET("", inject.UserController.FetchArchive)
+		archive.GET("/report", inject.UserController.RetentionReport)
+		archive.POST("/:user_id/restore", inject.UserController.Restore)
+	}
+
+	organizationRole := func(role string) gin.HandlerFunc {
+		return middleware.OrganizationRole(inject.OrganizationUsecase, role, inject.Logging)
+	}
+
+	organization := v1.Group("/organization")
+	{
+		organization.POST("", inject.OrganizationController.Create)
+		organization.GET("/:organization_id", organizationRole(organizationModels.RoleMember), inject.OrganizationController.GetByID)
+		organization.PUT("/:organization_id", organizationRole(organizationModels.RoleAdmin), inject.OrganizationController.Update)
+		organization.DELETE("/:organization_id", organizationRole(organizationModels.RoleOwner), inject.OrganizationController.Delete)
+
+		organization.GET("/:organization_id/members", organizationRole(organizationModels.RoleMember), inject.OrganizationController.Members)
+		organization.POST("/:organization_id/members", organizationRole(organizationModels.RoleAdmin), inject.OrganizationController.AddMember)
+		organization.PUT("/:organization_id/members/:user_id", organizationRole(organizationModels.RoleAdmin), inject.OrganizationController.ChangeMemberRole)
+		organization.DELETE("/:organization_id/members/:user_id", organizationRole(organizationModels.RoleAdmin), inject.OrganizationController.RemoveMember)
+	}
+
+	// accepting is anonymous, the token is the credential and is not logged
+	v1.POST("/invitation/accept", middleware.Redact(middleware.RedactRequest), inject.InvitationController.Accept)
+
+	invitation := v1.Group("/invitation", middleware.TenantAdmin(inject.Logging))
+	{
+		invitation.GET("", inject.InvitationController.Fetch)
+		invitation.POST("", inject.InvitationController.Create)
+		invitation.POST("/:invitation_id/resend", inject.InvitationController.Resend)
+		invitation.DELETE("/:invitation_id", inject.InvitationController.Revoke)
+	}
+
+	webhook := v1.Group("/webhook", middleware.TenantAdmin(inject.Logging))
+	{
+		webhook.GET("", inject.WebhookController.Fetch)
+		// the signing secret is only ever returned by create and rotate
+		webhook.POST("", middleware.Redact(middleware.RedactResponse), inject.WebhookController.Create)
+		webhook.GET("/:webhook_id", inject.WebhookController.GetByID)
+		webhook.PUT("/:webhook_id", inject.WebhookController.Update)
+		webhook.DELETE("/:webhook_id", inject.WebhookController.Delete)
+		webhook.POST("/:webhook_id/rotate", middleware.Redact(middleware.RedactResponse), inject.WebhookController.RotateSecret)
+		webhook.GET("/:webhook_id/deliveries", inject.WebhookController.Deliveries)
+		webhook.POST("/:webhook_id/deliveries/:delivery_id/replay", inject.WebhookController.Replay)
+	}
+
+	admin := v1.Group("/admin", middleware.Admin(inject.SystemTenantID, inject.Logging))
+	{
+		admin.GET("/tenant", inject.TenantController.Fetch)
+		admin.POST("/tenant", inject.TenantController.Create)
+		admin.GET("/tenant/:tenant_id", inject.TenantController.GetByID)
+		admin.PUT("/tenant/:tenant_id", inject.TenantController.Update)
+
+		admin.GET("/health/database", HandleDatabaseHealth(inject.TenantRouter))
+		admin.GET("/health/redis", HandleRedisHealth(inject.Redis))
+		admin.GET("/metrics/cache", HandleCacheStats(inject.UserCache))
+		admin.GET("/metrics/stream", HandleStreamStats(inject.UserStream))
+		admin.GET("/metrics/notification", HandleNotificationStats(inject.Notifications))
 	}
 
 	return &Router{route}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"prototype/app/controller"
+	tenantMocks "prototype/domain/tenant/mocks"
+	tenantModels "prototype/domain/tenant/models"
+	loginMocks "prototype/domain/user/logins/mocks"
+	userMocks "prototype/domain/user/mocks"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupInjection serve tenant acme where user 1 is an admin and user 2 and 3
+// are not, the controller are left nil so a request passing the guards of
+// its route is answered 500 by the recovery
+func setupInjection() Injection {
+	gin.SetMode(gin.ReleaseMode)
+
+	tenantUsecase := new(tenantMocks.TenantUsecase)
+	tenantUsecase.On("Resolve", mock.Anything, "acme").Return(tenantModels.Tenant{ID: 1}, nil)
+
+	userUsecase := new(userMocks.UserUsecase)
+	userUsecase.On("Authenticate", mock.Anything, uint(1)).Return(userModels.User{ID: 1, Role: userModels.RoleAdmin}, nil)
+	userUsecase.On("Authenticate", mock.Anything, uint(2)).Return(userModels.User{ID: 2, Role: userModels.RoleUser}, nil)
+	userUsecase.On("Authenticate", mock.Anything, uint(3)).Return(userModels.User{ID: 3, Role: userModels.RoleUser}, nil)
+
+	loginUsecase := new(loginMocks.LoginUsecase)
+	loginUsecase.On("Seen", mock.Anything, mock.Anything, mock.Anything)
+
+	return Injection{
+		Logging:       log.NewLog(),
+		TenantUsecase: tenantUsecase,
+		UserUsecase:   userUsecase,
+		LoginUsecase:  loginUsecase,
+	}
+}
+
+// serve send request as userID, anonymously when it is empty
+func serve(router *Router, method, path, userID string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Tenant-ID", "acme")
+	if userID != "" {
+		req.Header.Set("User-ID", userID)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestRouter_Guards(t *testing.T) {
+	router := NewRouter(setupInjection())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID string
+		want   int
+	}{
+		{name: "history anonymous", method: http.MethodGet, path: "/v1/user/2/history", want: http.StatusUnauthorized},
+		{name: "history of another user", method: http.MethodGet, path: "/v1/user/2/history", userID: "3", want: http.StatusForbidden},
+		{name: "history verify of another user", method: http.MethodGet, path: "/v1/user/2/history/verify", userID: "3", want: http.StatusForbidden},
+		{name: "history verify of itself", method: http.MethodGet, path: "/v1/user/2/history/verify", userID: "2", want: http.StatusForbidden},
+		{name: "organizations anonymous", method: http.MethodGet, path: "/v1/user/2/organizations", want: http.StatusUnauthorized},
+		{name: "organizations of another user", method: http.MethodGet, path: "/v1/user/2/organizations", userID: "3", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(router, tt.method, tt.path, tt.userID); got != tt.want {
+				t.Errorf("%s %s as %q = %d, want %d", tt.method, tt.path, tt.userID, got, tt.want)
+			}
+		})
+	}
+}
+
+// httpLog keep the response body Logging log
+type httpLog struct {
+	log.ILogs
+	res interface{}
+}
+
+func (logs *httpLog) Http(ctx context.Context, actName, url, method string, header, req, res interface{}) {
+	logs.res = res
+}
+
+func TestRouter_ExportIsNotLogged(t *testing.T) {
+	inject := setupInjection()
+
+	logs := &httpLog{ILogs: inject.Logging}
+	inject.Logging = logs
+
+	userUsecase := inject.UserUsecase.(*userMocks.UserUsecase)
+	userUsecase.On("Export", mock.Anything, uint(2)).Return([]byte("personal data"), nil)
+	inject.UserController = controller.NewUserController(userUsecase, nil, logs)
+
+	if got := serve(NewRouter(inject), http.MethodGet, "/v1/user/2/export", "2"); got != http.StatusOK {
+		t.Fatalf("GET /v1/user/2/export = %d, want %d", got, http.StatusOK)
+	}
+
+	if logs.res != "[redacted body of 13 bytes]" {
+		t.Errorf("logged response = %v, want it redacted", logs.res)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"prototype/lib/env"
+	"prototype/lib/storage"
+	"strconv"
+	"strings"
+)
+
+func NewStorage() (storage.IStorage, error) {
+	switch env.String("Storage.Driver", "local") {
+	case "s3":
+		return storage.NewS3(storage.ConfigS3{
+			Endpoint:  env.String("Storage.S3.Endpoint", ""),
+			AccessKey: env.String("Storage.S3.AccessKey", ""),
+			SecretKey: env.String("Storage.S3.SecretKey", ""),
+			Bucket:    env.String("Storage.S3.Bucket", ""),
+			Region:    env.String("Storage.S3.Region", ""),
+			UseSSL:    env.Bool("Storage.S3.UseSSL", false),
+			PublicURL: env.String("Storage.S3.PublicURL", ""),
+		})
+	default:
+		return storage.NewLocal(
+			env.String("Storage.Local.Root", "storage"),
+			env.String("Storage.Local.BaseURL", "/storage"),
+		)
+	}
+}
+
+// avatarOverhead is room for the multipart framing around an avatar
+const avatarOverhead = 64 << 10
+
+// AvatarMaxSize return the largest avatar accepted from config
+func AvatarMaxSize() int64 {
+	return int64(env.Int("User.Avatar.MaxSize", 5<<20))
+}
+
+// AvatarMaxBody return the largest avatar upload request accepted
+func AvatarMaxBody() int64 {
+	return AvatarMaxSize() + avatarOverhead
+}
+
+// AvatarSizes return the avatar variant sizes from config
+func AvatarSizes() (sizes []int) {
+	for _, value := range strings.Split(env.String("User.Avatar.Sizes", "64,256,512"), ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || size <= 0 {
+			continue
+		}
+
+		sizes = append(sizes, size)
+	}
+
+	return
+}
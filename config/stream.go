@@ -0,0 +1,64 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/env"
+	"prototype/lib/log"
+	"prototype/lib/outbox"
+	"prototype/lib/pubsub"
+	"prototype/lib/stream"
+)
+
+// UserStream is the hub of the user event stream, it is fed from the user
+// topic through Stream.Subscription. Every replica need a subscription of its
+// own, a Last-Event-ID is then found on any replica since each buffer the same
+// event
+type UserStream struct {
+	*stream.Hub
+
+	subscriber   pubsub.ISubscriber
+	subscription string
+	log          log.ILogs
+}
+
+func NewUserStream(subscriber pubsub.ISubscriber, logging log.ILogs) *UserStream {
+	return &UserStream{
+		Hub: stream.NewHub(stream.Config{
+			Replay:       env.Int("Stream.Replay", 1000),
+			ClientBuffer: env.Int("Stream.ClientBuffer", 64),
+		}),
+		subscriber:   subscriber,
+		subscription: env.String("Stream.Subscription", ""),
+		log:          logging,
+	}
+}
+
+// Run feed the hub until ctx is done and then end every open stream so they
+// do not hold back the shutdown, without subscription the stream stay empty
+func (userStream *UserStream) Run(ctx context.Context) {
+	if userStream.subscription == "" {
+		<-ctx.Done()
+		userStream.Close()
+		return
+	}
+
+	userStream.Feed(ctx, userStream.subscriber, userStream.subscription, decodeUserEvent, userStream.log)
+}
+
+// decodeUserEvent turn a message of the user topic into a stream event whose
+// id is the outbox event id
+func decodeUserEvent(message pubsub.Message) (stream.Event, error) {
+	var event userModels.UserEvent
+	if err := json.Unmarshal(message.Data, &event); err != nil {
+		return stream.Event{}, err
+	}
+
+	id := message.Attributes[outbox.AttributeEventID]
+	if id == "" {
+		id = message.ID
+	}
+
+	return stream.Event{ID: id, Name: event.Type, Data: message.Data, Payload: event}, nil
+}
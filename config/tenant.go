@@ -0,0 +1,53 @@
+package config
+
+import (
+	"context"
+	"prototype/app/middleware"
+	tenantDomain "prototype/domain/tenant"
+	"prototype/lib/env"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"strings"
+)
+
+// TenantResolver return how the tenant of a request is resolved, leaving
+// Tenant.Default empty make the tenant mandatory on every request
+func TenantResolver() middleware.TenantConfig {
+	var strategies []string
+	for _, strategy := range strings.Split(env.String("Tenant.Resolve", "header"), ",") {
+		if strategy = strings.TrimSpace(strategy); strategy != "" {
+			strategies = append(strategies, strategy)
+		}
+	}
+
+	return middleware.TenantConfig{
+		Strategies: strategies,
+		Header:     env.String("Tenant.Header", "Tenant-ID"),
+		Domain:     env.String("Tenant.Domain", ""),
+		Claim:      env.String("Tenant.Claim", "tenant"),
+		Default:    env.String("Tenant.Default", ""),
+	}
+}
+
+// forEachTenant run job with a context scoped to every tenant in turn, a
+// failing tenant is left to job to report and never hold back the others
+func forEachTenant(tenantUsecase tenantDomain.ITenantUsecase, logging log.ILogs, job func(ctx context.Context, id uint)) {
+	const limit = 100
+	ctx := tenant.WithoutID(context.Background())
+
+	for page := 1; ; page++ {
+		tenants, _, err := tenantUsecase.Fetch(ctx, page, limit)
+		if err != nil {
+			logging.Error(ctx, "tenantUsecase.Fetch Error", err)
+			return
+		}
+
+		for _, registry := range tenants {
+			job(tenant.WithID(context.Background(), registry.ID), registry.ID)
+		}
+
+		if len(tenants) < limit {
+			return
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	tenantDomain "prototype/domain/tenant"
+	webhookDomain "prototype/domain/webhook"
+	"prototype/lib/env"
+	"prototype/lib/log"
+	"strings"
+	"time"
+)
+
+// StartWebhookDelivery attempt the due webhook delivery of every tenant on
+// every interval and delete the finished delivery older than keep
+func StartWebhookDelivery(tenantUsecase tenantDomain.ITenantUsecase, webhookUsecase webhookDomain.IWebhookUsecase, interval, keep time.Duration, logging log.ILogs) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			forEachTenant(tenantUsecase, logging, func(ctx context.Context, id uint) {
+				if _, err := webhookUsecase.Deliver(ctx); err != nil {
+					logging.Error(ctx, fmt.Sprintf("webhookUsecase.Deliver tenant %d Error", id), err)
+					return
+				}
+
+				if keep > 0 {
+					if _, err := webhookUsecase.PurgeDeliveries(ctx, time.Now().Add(-keep)); err != nil {
+						logging.Error(ctx, fmt.Sprintf("webhookUsecase.PurgeDeliveries tenant %d Error", id), err)
+					}
+				}
+			})
+		}
+	}()
+}
+
+// WebhookAllow read the comma separated internal network webhook receiver
+// may be in, every other internal address is refused
+func WebhookAllow() (networks []string) {
+	for _, network := range strings.Split(env.String("Webhook.Allow", ""), ",") {
+		if network = strings.TrimSpace(network); network != "" {
+			networks = append(networks, network)
+		}
+	}
+
+	return
+}
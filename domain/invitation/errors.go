@@ -0,0 +1,12 @@
+package domain
+
+import "errors"
+
+var (
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrInvitationExpired    = errors.New("invitation has expired")
+	ErrInvitationNotPending = errors.New("invitation is no longer pending")
+	ErrAlreadyInvited       = errors.New("email already has a pending invitation")
+	ErrInvalidRole          = errors.New("invalid invitation role")
+	ErrInvalidStatus        = errors.New("invalid invitation status")
+)
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"prototype/domain/invitation/models"
+	userModels "prototype/domain/user/models"
+)
+
+// interface for repository
+type IInvitationMysqlRepository interface {
+	Fetch(ctx context.Context, filter models.InvitationFilter, page, limit int) ([]models.Invitation, int64, error)
+	Create(ctx context.Context, invitation models.Invitation) (models.Invitation, error)
+	Update(ctx context.Context, invitation models.Invitation) (models.Invitation, error)
+	GetByID(ctx context.Context, id uint) (models.Invitation, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (models.Invitation, error)
+
+	// ChangeStatus move the invitation from status from to status to, it fail
+	// with ErrInvitationNotPending when another request changed it first
+	ChangeStatus(ctx context.Context, id uint, from, to string) error
+}
+
+// interface for usecase
+type IInvitationUsecase interface {
+	Fetch(ctx context.Context, filter models.InvitationFilter, page, limit int) ([]models.Invitation, int64, error)
+	Create(ctx context.Context, invitation models.Invitation) (models.Invitation, error)
+	Resend(ctx context.Context, id uint) (models.Invitation, error)
+	Revoke(ctx context.Context, id uint) (models.Invitation, error)
+	Accept(ctx context.Context, request models.AcceptRequest) (userModels.User, error)
+}
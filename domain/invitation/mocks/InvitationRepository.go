@@ -0,0 +1,126 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/invitation/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type InvitationRepository struct {
+	mock.Mock
+}
+
+func (m *InvitationRepository) Fetch(ctx context.Context, filter models.InvitationFilter, page int, limit int) ([]models.Invitation, int64, error) {
+	ret := m.Called(ctx, filter, page, limit)
+
+	var (
+		r0 []models.Invitation
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Invitation)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *InvitationRepository) Create(ctx context.Context, invitation models.Invitation) (models.Invitation, error) {
+	ret := m.Called(ctx, invitation)
+
+	var (
+		r0 models.Invitation
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Invitation)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *InvitationRepository) Update(ctx context.Context, invitation models.Invitation) (models.Invitation, error) {
+	ret := m.Called(ctx, invitation)
+
+	var (
+		r0 models.Invitation
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Invitation)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *InvitationRepository) GetByID(ctx context.Context, id uint) (models.Invitation, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Invitation
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Invitation)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *InvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (models.Invitation, error) {
+	ret := m.Called(ctx, tokenHash)
+
+	var (
+		r0 models.Invitation
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Invitation)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *InvitationRepository) ChangeStatus(ctx context.Context, id uint, from string, to string) error {
+	ret := m.Called(ctx, id, from, to)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
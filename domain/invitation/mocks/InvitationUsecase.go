@@ -0,0 +1,113 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/invitation/models"
+	userModels "prototype/domain/user/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type InvitationUsecase struct {
+	mock.Mock
+}
+
+func (m *InvitationUsecase) Fetch(ctx context.Context, filter models.InvitationFilter, page int, limit int) ([]models.Invitation, int64, error) {
+	ret := m.Called(ctx, filter, page, limit)
+
+	var (
+		r0 []models.Invitation
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Invitation)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *InvitationUsecase) Create(ctx context.Context, invitation models.Invitation) (models.Invitation, error) {
+	ret := m.Called(ctx, invitation)
+
+	var (
+		r0 models.Invitation
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Invitation)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *InvitationUsecase) Resend(ctx context.Context, id uint) (models.Invitation, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Invitation
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Invitation)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *InvitationUsecase) Revoke(ctx context.Context, id uint) (models.Invitation, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Invitation
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Invitation)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *InvitationUsecase) Accept(ctx context.Context, request models.AcceptRequest) (userModels.User, error) {
+	ret := m.Called(ctx, request)
+
+	var (
+		r0 userModels.User
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(userModels.User)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
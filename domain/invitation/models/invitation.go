@@ -0,0 +1,72 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// invitation status, a pending invitation past ExpiresAt can no longer be
+// accepted but can still be resent
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusRevoked  = "revoked"
+)
+
+// Invitation let someone create their own account, the token itself is only
+// ever sent by email and only its hash is stored
+type Invitation struct {
+	ID               uint       `json:"id"`
+	TenantID         uint       `gorm:"not null;index" json:"-"`
+	Email            string     `gorm:"size:191;not null;index" json:"email" binding:"required,email"`
+	Role             string     `gorm:"size:20;not null" json:"role"`
+	OrganizationID   uint       `json:"organization_id,omitempty"`
+	OrganizationRole string     `gorm:"size:20" json:"organization_role,omitempty"`
+	TokenHash        string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Status           string     `gorm:"size:20;not null;index" json:"status"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expires_at"`
+	InvitedBy        uint       `json:"invited_by"`
+	SentAt           *time.Time `json:"sent_at,omitempty"`
+	AcceptedAt       *time.Time `json:"accepted_at,omitempty"`
+	UserID           uint       `json:"user_id,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func (Invitation) TableName() string {
+	return "invitation"
+}
+
+// InvitationFilter
+type InvitationFilter struct {
+	Email  string
+	Status []string
+}
+
+// AcceptRequest is what the invitee fill in to create their account
+type AcceptRequest struct {
+	Token     string `json:"token" binding:"required"`
+	Username  string `json:"username" binding:"required"`
+	FirstName string `json:"firstname"`
+	LastName  string `json:"lastname"`
+}
+
+func (invitation Invitation) IsExpired(now time.Time) bool {
+	return !now.Before(invitation.ExpiresAt)
+}
+
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusAccepted, StatusRevoked:
+		return true
+	}
+
+	return false
+}
+
+// HashToken return the stored form of an invitation token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,111 @@
+package repository_mysql
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/invitation"
+	"prototype/domain/invitation/models"
+	"prototype/lib/log"
+
+	"gorm.io/gorm"
+)
+
+type invitationMysqlRepository struct {
+	DB  *gorm.DB
+	log log.ILogs
+}
+
+func NewMysqlInvitationRepo(DB *gorm.DB, log log.ILogs) domain.IInvitationMysqlRepository {
+	return invitationMysqlRepository{DB, log}
+}
+
+func (repo invitationMysqlRepository) Fetch(ctx context.Context, filter models.InvitationFilter, page, limit int) (result []models.Invitation, total int64, err error) {
+	query := repo.DB.WithContext(ctx).Model(&models.Invitation{})
+
+	if filter.Email != "" {
+		query = query.Where("email = ?", filter.Email)
+	}
+
+	if len(filter.Status) > 0 {
+		query = query.Where("status IN ?", filter.Status)
+	}
+
+	if err = query.Count(&total).Error; err != nil {
+		repo.log.Error(ctx, "query.Count(&total)", err)
+		return
+	}
+
+	if err = query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Order('id DESC').Find(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo invitationMysqlRepository) Create(ctx context.Context, invitation models.Invitation) (result models.Invitation, err error) {
+	if err = repo.DB.WithContext(ctx).Create(&invitation).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Create(&invitation)", err)
+		return
+	}
+
+	result = invitation
+	return
+}
+
+func (repo invitationMysqlRepository) Update(ctx context.Context, invitation models.Invitation) (result models.Invitation, err error) {
+	if err = repo.DB.WithContext(ctx).Save(&invitation).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Save(&invitation)", err)
+		return
+	}
+
+	result = invitation
+	return
+}
+
+func (repo invitationMysqlRepository) GetByID(ctx context.Context, id uint) (result models.Invitation, err error) {
+	err = repo.DB.WithContext(ctx).Where("id = ?", id).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domain.ErrInvitationNotFound
+		return
+	}
+
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('id = ?', id).First(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo invitationMysqlRepository) GetByTokenHash(ctx context.Context, tokenHash string) (result models.Invitation, err error) {
+	err = repo.DB.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domain.ErrInvitationNotFound
+		return
+	}
+
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('token_hash = ?', tokenHash).First(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo invitationMysqlRepository) ChangeStatus(ctx context.Context, id uint, from, to string) (err error) {
+	update := repo.DB.WithContext(ctx).Model(&models.Invitation{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if err = update.Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Model(&models.Invitation{}).Update('status')", err)
+		return
+	}
+
+	if update.RowsAffected == 0 {
+		err = domain.ErrInvitationNotPending
+		return
+	}
+
+	return
+}
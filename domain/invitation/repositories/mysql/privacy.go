@@ -0,0 +1,49 @@
+package repository_mysql
+
+import (
+	"context"
+	"prototype/domain/invitation/models"
+	userDomain "prototype/domain/user"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+
+	"gorm.io/gorm"
+)
+
+type invitationDataOwner struct {
+	DB  *gorm.DB
+	log log.ILogs
+}
+
+// NewMysqlInvitationDataOwner expose the invitation sent to a user, matched
+// by the user it created or by its email
+func NewMysqlInvitationDataOwner(DB *gorm.DB, log log.ILogs) userDomain.IDataOwner {
+	return invitationDataOwner{DB, log}
+}
+
+func (owner invitationDataOwner) Name() string {
+	return "invitations"
+}
+
+func (owner invitationDataOwner) Export(ctx context.Context, user userModels.User) (interface{}, error) {
+	result := []models.Invitation{}
+	if err := owner.query(ctx, user).Order("id ASC").Find(&result).Error; err != nil {
+		owner.log.Error(ctx, "owner.query(ctx, user).Find(&result)", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (owner invitationDataOwner) Erase(ctx context.Context, user userModels.User) error {
+	if err := owner.query(ctx, user).Delete(&models.Invitation{}).Error; err != nil {
+		owner.log.Error(ctx, "owner.query(ctx, user).Delete(&models.Invitation{})", err)
+		return err
+	}
+
+	return nil
+}
+
+func (owner invitationDataOwner) query(ctx context.Context, user userModels.User) *gorm.DB {
+	return owner.DB.WithContext(ctx).Where("user_id = ? OR email = ?", user.ID, user.Email)
+}
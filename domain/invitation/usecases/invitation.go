@@ -0,0 +1,341 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	domain "prototype/domain/invitation"
+	"prototype/domain/invitation/models"
+	organizationDomain "prototype/domain/organization"
+	organizationModels "prototype/domain/organization/models"
+	userDomain "prototype/domain/user"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/mail"
+	"time"
+)
+
+type invitationUsecase struct {
+	invitationRepo   domain.IInvitationMysqlRepository
+	userRepo         userDomain.IUserMysqlRepository
+	organizationRepo organizationDomain.IOrganizationMysqlRepository
+	mailer           mail.IMailer
+	config           InvitationConfig
+	log              log.ILogs
+}
+
+// InvitationConfig control how long an invitation stay valid and where the
+// invitee is sent to accept it, the token is appended as the token query
+type InvitationConfig struct {
+	TTL       time.Duration
+	AcceptURL string
+}
+
+func NewInvitationUsecase(invitationRepo domain.IInvitationMysqlRepository, userRepo userDomain.IUserMysqlRepository, organizationRepo organizationDomain.IOrganizationMysqlRepository, mailer mail.IMailer, config InvitationConfig, log log.ILogs) domain.IInvitationUsecase {
+	return &invitationUsecase{invitationRepo, userRepo, organizationRepo, mailer, config, log}
+}
+
+func (usecase invitationUsecase) Fetch(ctx context.Context, filter models.InvitationFilter, page, limit int) (result []models.Invitation, total int64, err error) {
+	for _, status := range filter.Status {
+		if !models.IsValidStatus(status) {
+			err = fmt.Errorf("%w: %s", domain.ErrInvalidStatus, status)
+			return
+		}
+	}
+
+	result, total, err = usecase.invitationRepo.Fetch(ctx, filter, page, limit)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.Fetch Error", err)
+		return
+	}
+
+	return
+}
+
+// Create store the invitation and email its token, a failed delivery is not
+// fatal and leave SentAt empty so the invitation can be resent
+func (usecase invitationUsecase) Create(ctx context.Context, invitation models.Invitation) (result models.Invitation, err error) {
+	if invitation.Role == "" {
+		invitation.Role = userModels.RoleUser
+	}
+
+	if !userModels.IsValidRole(invitation.Role) {
+		err = fmt.Errorf("%w: %s", domain.ErrInvalidRole, invitation.Role)
+		return
+	}
+
+	if invitation.OrganizationID == 0 {
+		invitation.OrganizationRole = ""
+	} else {
+		if invitation.OrganizationRole == "" {
+			invitation.OrganizationRole = organizationModels.RoleMember
+		}
+
+		if !organizationModels.IsValidRole(invitation.OrganizationRole) {
+			err = fmt.Errorf("%w: %s", domain.ErrInvalidRole, invitation.OrganizationRole)
+			return
+		}
+
+		if _, err = usecase.organizationRepo.GetByID(ctx, invitation.OrganizationID); err != nil {
+			usecase.log.Error(ctx, "usecase.organizationRepo.GetByID Error", err)
+			return
+		}
+	}
+
+	users, err := usecase.userRepo.Fetch(ctx, userModels.UserFilter{Email: invitation.Email})
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.Fetch Error", err)
+		return
+	}
+
+	if len(users) > 0 {
+		err = fmt.Errorf("%w: %s", userDomain.ErrEmailTaken, invitation.Email)
+		return
+	}
+
+	_, pending, err := usecase.invitationRepo.Fetch(ctx, models.InvitationFilter{
+		Email:  invitation.Email,
+		Status: []string{models.StatusPending},
+	}, 1, 1)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.Fetch Error", err)
+		return
+	}
+
+	if pending > 0 {
+		err = fmt.Errorf("%w: %s", domain.ErrAlreadyInvited, invitation.Email)
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return
+	}
+
+	invitedBy, _ := ctx.Value("user-id").(uint)
+
+	invitation.ID = 0
+	invitation.TokenHash = models.HashToken(token)
+	invitation.Status = models.StatusPending
+	invitation.ExpiresAt = time.Now().Add(usecase.config.TTL)
+	invitation.InvitedBy = invitedBy
+	invitation.SentAt = nil
+	invitation.AcceptedAt = nil
+	invitation.UserID = 0
+
+	result, err = usecase.invitationRepo.Create(ctx, invitation)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.Create Error", err)
+		return
+	}
+
+	if err := usecase.send(ctx, &result, token); err != nil {
+		usecase.log.Error(ctx, "usecase.send Error", err)
+		return result, nil
+	}
+
+	result, err = usecase.invitationRepo.Update(ctx, result)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.Update Error", err)
+		return
+	}
+
+	return
+}
+
+// Resend issue a new token and expiry, the previous token stop working
+func (usecase invitationUsecase) Resend(ctx context.Context, id uint) (result models.Invitation, err error) {
+	invitation, err := usecase.invitationRepo.GetByID(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.GetByID Error", err)
+		return
+	}
+
+	if invitation.Status != models.StatusPending {
+		err = fmt.Errorf("%w: %s", domain.ErrInvitationNotPending, invitation.Status)
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return
+	}
+
+	invitation.TokenHash = models.HashToken(token)
+	invitation.ExpiresAt = time.Now().Add(usecase.config.TTL)
+	invitation.SentAt = nil
+
+	if err = usecase.send(ctx, &invitation, token); err != nil {
+		usecase.log.Error(ctx, "usecase.send Error", err)
+		return
+	}
+
+	result, err = usecase.invitationRepo.Update(ctx, invitation)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.Update Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase invitationUsecase) Revoke(ctx context.Context, id uint) (result models.Invitation, err error) {
+	result, err = usecase.invitationRepo.GetByID(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.GetByID Error", err)
+		return
+	}
+
+	if err = usecase.invitationRepo.ChangeStatus(ctx, id, models.StatusPending, models.StatusRevoked); err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.ChangeStatus Error", err)
+		return
+	}
+
+	result.Status = models.StatusRevoked
+	return
+}
+
+// Accept create the invited user with the invited role and a verified email,
+// the invitation is claimed first so a token can only ever create one user.
+// The user is only recorded on the invitation once every step succeeded, an
+// accepted invitation without user is therefore resumed by accepting it again
+func (usecase invitationUsecase) Accept(ctx context.Context, request models.AcceptRequest) (result userModels.User, err error) {
+	invitation, err := usecase.invitationRepo.GetByTokenHash(ctx, models.HashToken(request.Token))
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.GetByTokenHash Error", err)
+		return
+	}
+
+	now := time.Now()
+
+	switch {
+	case invitation.Status == models.StatusPending:
+		if invitation.IsExpired(now) {
+			err = domain.ErrInvitationExpired
+			return
+		}
+
+		if err = usecase.invitationRepo.ChangeStatus(ctx, invitation.ID, models.StatusPending, models.StatusAccepted); err != nil {
+			usecase.log.Error(ctx, "usecase.invitationRepo.ChangeStatus Error", err)
+			return
+		}
+
+		if result, err = usecase.createInvitee(ctx, invitation, request, now); err != nil {
+			// release the claim so the invitee can try again
+			if err := usecase.invitationRepo.ChangeStatus(ctx, invitation.ID, models.StatusAccepted, models.StatusPending); err != nil {
+				usecase.log.Error(ctx, "usecase.invitationRepo.ChangeStatus Error", err)
+			}
+
+			return
+		}
+	case invitation.Status == models.StatusAccepted && invitation.UserID == 0:
+		if result, err = usecase.resumeInvitee(ctx, invitation, request, now); err != nil {
+			return
+		}
+	default:
+		err = fmt.Errorf("%w: %s", domain.ErrInvitationNotPending, invitation.Status)
+		return
+	}
+
+	// a membership added by an earlier attempt is kept as it is
+	if invitation.OrganizationID != 0 {
+		_, err = usecase.organizationRepo.AddMember(ctx, organizationModels.Membership{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         result.ID,
+			Role:           invitation.OrganizationRole,
+		})
+		if errors.Is(err, organizationDomain.ErrAlreadyMember) {
+			err = nil
+		}
+		if err != nil {
+			usecase.log.Error(ctx, "usecase.organizationRepo.AddMember Error", err)
+			return
+		}
+	}
+
+	invitation.Status = models.StatusAccepted
+	invitation.AcceptedAt = &now
+	invitation.UserID = result.ID
+
+	if _, err = usecase.invitationRepo.Update(ctx, invitation); err != nil {
+		usecase.log.Error(ctx, "usecase.invitationRepo.Update Error", err)
+		return
+	}
+
+	return
+}
+
+// createInvitee create the user of invitation with a verified email
+func (usecase invitationUsecase) createInvitee(ctx context.Context, invitation models.Invitation, request models.AcceptRequest, now time.Time) (result userModels.User, err error) {
+	result, err = usecase.userRepo.Create(ctx, userModels.User{
+		Email:           invitation.Email,
+		EmailVerifiedAt: &now,
+		Username:        request.Username,
+		FirstName:       request.FirstName,
+		LastName:        request.LastName,
+		Role:            invitation.Role,
+		Status:          userModels.StatusActive,
+	})
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.Create Error", err)
+		return
+	}
+
+	return
+}
+
+// resumeInvitee find the user created by an earlier attempt to accept
+// invitation, the user is created when that attempt stopped before it
+func (usecase invitationUsecase) resumeInvitee(ctx context.Context, invitation models.Invitation, request models.AcceptRequest, now time.Time) (result userModels.User, err error) {
+	users, err := usecase.userRepo.Fetch(ctx, userModels.UserFilter{Email: invitation.Email})
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.Fetch Error", err)
+		return
+	}
+
+	if len(users) > 0 {
+		result = users[0]
+		return
+	}
+
+	return usecase.createInvitee(ctx, invitation, request, now)
+}
+
+// send email the token and set SentAt on success
+func (usecase invitationUsecase) send(ctx context.Context, invitation *models.Invitation, token string) (err error) {
+	link, err := url.Parse(usecase.config.AcceptURL)
+	if err != nil {
+		return
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	err = usecase.mailer.Send(ctx, mail.Message{
+		To:      []string{invitation.Email},
+		Subject: "You have been invited",
+		Body: fmt.Sprintf("You have been invited to join.\n\nAccept the invitation before %s:\n%s\n",
+			invitation.ExpiresAt.Format(time.RFC1123), link.String()),
+	})
+	if err != nil {
+		return
+	}
+
+	sentAt := time.Now()
+	invitation.SentAt = &sentAt
+	return
+}
+
+// newToken return a random url safe token
+func newToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
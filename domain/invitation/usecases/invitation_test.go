@@ -0,0 +1,245 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/invitation"
+	"prototype/domain/invitation/mocks"
+	"prototype/domain/invitation/models"
+	organizationDomain "prototype/domain/organization"
+	organizationMocks "prototype/domain/organization/mocks"
+	organizationModels "prototype/domain/organization/models"
+	userDomain "prototype/domain/user"
+	userMocks "prototype/domain/user/mocks"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// recordMailer keep every sent message and fail when err is set
+type recordMailer struct {
+	sent []mail.Message
+	err  error
+}
+
+func (mailer *recordMailer) Send(ctx context.Context, message mail.Message) error {
+	if mailer.err != nil {
+		return mailer.err
+	}
+
+	mailer.sent = append(mailer.sent, message)
+	return nil
+}
+
+func Test_invitationUsecase_Create(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "user-id", uint(1))
+
+	pending := models.InvitationFilter{Email: "pending@mail.com", Status: []string{models.StatusPending}}
+	fresh := models.InvitationFilter{Email: "new@mail.com", Status: []string{models.StatusPending}}
+
+	userRepo := new(userMocks.UserRepository)
+	userRepo.On("Fetch", ctx, userModels.UserFilter{Email: "taken@mail.com"}).Return([]userModels.User{{ID: 2}}, nil)
+	userRepo.On("Fetch", ctx, mock.Anything).Return([]userModels.User{}, nil)
+
+	organizationRepo := new(organizationMocks.OrganizationRepository)
+	organizationRepo.On("GetByID", ctx, uint(5)).Return(organizationModels.Organization{ID: 5}, nil)
+
+	invitationRepo := new(mocks.InvitationRepository)
+	invitationRepo.On("Fetch", ctx, pending, 1, 1).Return([]models.Invitation{{ID: 3}}, int64(1), nil)
+	invitationRepo.On("Fetch", ctx, fresh, 1, 1).Return([]models.Invitation{}, int64(0), nil)
+	invitationRepo.On("Create", ctx, mock.MatchedBy(func(invitation models.Invitation) bool {
+		return invitation.Status == models.StatusPending && invitation.InvitedBy == 1 && len(invitation.TokenHash) == 64
+	})).Return(models.Invitation{ID: 10, Email: "new@mail.com", Status: models.StatusPending}, nil)
+	invitationRepo.On("Update", ctx, mock.Anything).Return(models.Invitation{ID: 10, SentAt: &time.Time{}}, nil)
+
+	tests := []struct {
+		name       string
+		invitation models.Invitation
+		mailErr    error
+		wantSent   bool
+		wantErr    error
+	}{
+		{
+			name:       "success",
+			invitation: models.Invitation{Email: "new@mail.com", OrganizationID: 5},
+			wantSent:   true,
+		},
+		{
+			name:       "success mail failed",
+			invitation: models.Invitation{Email: "new@mail.com"},
+			mailErr:    errors.New("smtp down"),
+		},
+		{
+			name:       "failed invalid role",
+			invitation: models.Invitation{Email: "new@mail.com", Role: "root"},
+			wantErr:    domain.ErrInvalidRole,
+		},
+		{
+			name:       "failed invalid organization role",
+			invitation: models.Invitation{Email: "new@mail.com", OrganizationID: 5, OrganizationRole: "root"},
+			wantErr:    domain.ErrInvalidRole,
+		},
+		{
+			name:       "failed email taken",
+			invitation: models.Invitation{Email: "taken@mail.com"},
+			wantErr:    userDomain.ErrEmailTaken,
+		},
+		{
+			name:       "failed already invited",
+			invitation: models.Invitation{Email: "pending@mail.com"},
+			wantErr:    domain.ErrAlreadyInvited,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := &recordMailer{err: tt.mailErr}
+			usecase := invitationUsecase{
+				invitationRepo:   invitationRepo,
+				userRepo:         userRepo,
+				organizationRepo: organizationRepo,
+				mailer:           mailer,
+				config:           InvitationConfig{TTL: time.Hour, AcceptURL: "https://app.local/invitation"},
+				log:              log.NewLog(),
+			}
+			gotResult, err := usecase.Create(ctx, tt.invitation)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("invitationUsecase.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if gotResult.ID != 10 {
+				t.Errorf("invitationUsecase.Create() ID = %v, want 10", gotResult.ID)
+			}
+			if gotSent := gotResult.SentAt != nil; gotSent != tt.wantSent {
+				t.Errorf("invitationUsecase.Create() sent = %v, want %v", gotSent, tt.wantSent)
+			}
+			if tt.wantSent && !strings.Contains(mailer.sent[0].Body, "https://app.local/invitation?token=") {
+				t.Errorf("invitationUsecase.Create() mail body = %v, want accept link", mailer.sent[0].Body)
+			}
+		})
+	}
+}
+
+func Test_invitationUsecase_Accept(t *testing.T) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	invitationRepo := new(mocks.InvitationRepository)
+	invitationRepo.On("GetByTokenHash", ctx, models.HashToken("valid")).
+		Return(models.Invitation{ID: 1, Email: "new@mail.com", Role: userModels.RoleAdmin, OrganizationID: 5, OrganizationRole: organizationModels.RoleAdmin, Status: models.StatusPending, ExpiresAt: expiresAt}, nil)
+	invitationRepo.On("GetByTokenHash", ctx, models.HashToken("expired")).
+		Return(models.Invitation{ID: 2, Status: models.StatusPending, ExpiresAt: time.Now().Add(-time.Hour)}, nil)
+	invitationRepo.On("GetByTokenHash", ctx, models.HashToken("revoked")).
+		Return(models.Invitation{ID: 3, Status: models.StatusRevoked, ExpiresAt: expiresAt}, nil)
+	invitationRepo.On("GetByTokenHash", ctx, models.HashToken("claimed")).
+		Return(models.Invitation{ID: 4, Status: models.StatusPending, ExpiresAt: expiresAt}, nil)
+	invitationRepo.On("GetByTokenHash", ctx, models.HashToken("taken")).
+		Return(models.Invitation{ID: 6, Email: "taken@mail.com", Role: userModels.RoleUser, Status: models.StatusPending, ExpiresAt: expiresAt}, nil)
+	invitationRepo.On("GetByTokenHash", ctx, models.HashToken("resumed")).
+		Return(models.Invitation{ID: 8, Email: "resumed@mail.com", OrganizationID: 5, OrganizationRole: organizationModels.RoleMember, Status: models.StatusAccepted, ExpiresAt: expiresAt}, nil)
+	invitationRepo.On("GetByTokenHash", ctx, models.HashToken("completed")).
+		Return(models.Invitation{ID: 9, Email: "completed@mail.com", UserID: 10, Status: models.StatusAccepted, ExpiresAt: expiresAt}, nil)
+	invitationRepo.On("GetByTokenHash", ctx, models.HashToken("unknown")).
+		Return(models.Invitation{}, domain.ErrInvitationNotFound)
+	invitationRepo.On("ChangeStatus", ctx, uint(4), models.StatusPending, models.StatusAccepted).Return(domain.ErrInvitationNotPending)
+	invitationRepo.On("ChangeStatus", ctx, mock.Anything, models.StatusPending, models.StatusAccepted).Return(nil)
+	invitationRepo.On("ChangeStatus", ctx, uint(6), models.StatusAccepted, models.StatusPending).Return(nil)
+	invitationRepo.On("Update", ctx, mock.MatchedBy(func(invitation models.Invitation) bool {
+		return invitation.ID == 1 && invitation.UserID == 7 && invitation.AcceptedAt != nil
+	})).Return(models.Invitation{}, nil)
+	invitationRepo.On("Update", ctx, mock.MatchedBy(func(invitation models.Invitation) bool {
+		return invitation.ID == 8 && invitation.UserID == 11 && invitation.AcceptedAt != nil
+	})).Return(models.Invitation{}, nil)
+
+	userRepo := new(userMocks.UserRepository)
+	userRepo.On("Create", ctx, mock.MatchedBy(func(user userModels.User) bool {
+		return user.Email == "new@mail.com" && user.Role == userModels.RoleAdmin && user.EmailVerifiedAt != nil
+	})).Return(userModels.User{ID: 7, Email: "new@mail.com", Role: userModels.RoleAdmin}, nil)
+	userRepo.On("Create", ctx, mock.MatchedBy(func(user userModels.User) bool {
+		return user.Email == "taken@mail.com"
+	})).Return(userModels.User{}, userDomain.ErrEmailTaken)
+	userRepo.On("Fetch", ctx, userModels.UserFilter{Email: "resumed@mail.com"}).
+		Return([]userModels.User{{ID: 11, Email: "resumed@mail.com"}}, nil)
+
+	organizationRepo := new(organizationMocks.OrganizationRepository)
+	organizationRepo.On("AddMember", ctx, organizationModels.Membership{OrganizationID: 5, UserID: 7, Role: organizationModels.RoleAdmin}).
+		Return(organizationModels.Membership{OrganizationID: 5, UserID: 7, Role: organizationModels.RoleAdmin}, nil)
+	organizationRepo.On("AddMember", ctx, organizationModels.Membership{OrganizationID: 5, UserID: 11, Role: organizationModels.RoleMember}).
+		Return(organizationModels.Membership{}, organizationDomain.ErrAlreadyMember)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantUserID uint
+		wantErr    error
+	}{
+		{
+			name:       "success",
+			token:      "valid",
+			wantUserID: 7,
+		},
+		{
+			name:       "success resume an interrupted accept",
+			token:      "resumed",
+			wantUserID: 11,
+		},
+		{
+			name:    "failed already accepted",
+			token:   "completed",
+			wantErr: domain.ErrInvitationNotPending,
+		},
+		{
+			name:    "failed expired",
+			token:   "expired",
+			wantErr: domain.ErrInvitationExpired,
+		},
+		{
+			name:    "failed revoked",
+			token:   "revoked",
+			wantErr: domain.ErrInvitationNotPending,
+		},
+		{
+			name:    "failed claimed concurrently",
+			token:   "claimed",
+			wantErr: domain.ErrInvitationNotPending,
+		},
+		{
+			name:    "failed email taken",
+			token:   "taken",
+			wantErr: userDomain.ErrEmailTaken,
+		},
+		{
+			name:    "failed not found",
+			token:   "unknown",
+			wantErr: domain.ErrInvitationNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := invitationUsecase{
+				invitationRepo:   invitationRepo,
+				userRepo:         userRepo,
+				organizationRepo: organizationRepo,
+				mailer:           &recordMailer{},
+				log:              log.NewLog(),
+			}
+			gotResult, err := usecase.Accept(ctx, models.AcceptRequest{Token: tt.token, Username: "new"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("invitationUsecase.Accept() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotResult.ID != tt.wantUserID {
+				t.Errorf("invitationUsecase.Accept() ID = %v, want %v", gotResult.ID, tt.wantUserID)
+			}
+		})
+	}
+
+	invitationRepo.AssertCalled(t, "ChangeStatus", ctx, uint(6), models.StatusAccepted, models.StatusPending)
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "errors"
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrSlugTaken            = errors.New("organization slug already taken")
+	ErrInvalidRole          = errors.New("invalid membership role")
+	ErrAlreadyMember        = errors.New("user is already a member")
+	ErrNotMember            = errors.New("user is not a member")
+	ErrInsufficientRole     = errors.New("membership role does not allow this action")
+	ErrLastOwner            = errors.New("organization must keep at least one owner")
+)
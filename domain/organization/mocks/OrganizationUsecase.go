@@ -0,0 +1,202 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/organization/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type OrganizationUsecase struct {
+	mock.Mock
+}
+
+func (m *OrganizationUsecase) Create(ctx context.Context, organization models.Organization, ownerID uint) (models.Organization, error) {
+	ret := m.Called(ctx, organization, ownerID)
+
+	var (
+		r0 models.Organization
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Organization)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *OrganizationUsecase) Update(ctx context.Context, organization models.Organization) (models.Organization, error) {
+	ret := m.Called(ctx, organization)
+
+	var (
+		r0 models.Organization
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Organization)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *OrganizationUsecase) GetByID(ctx context.Context, id uint) (models.Organization, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Organization
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Organization)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *OrganizationUsecase) Delete(ctx context.Context, id uint) error {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *OrganizationUsecase) AddMember(ctx context.Context, organizationID uint, userID uint, role string) (models.Membership, error) {
+	ret := m.Called(ctx, organizationID, userID, role)
+
+	var (
+		r0 models.Membership
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Membership)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *OrganizationUsecase) ChangeMemberRole(ctx context.Context, organizationID uint, userID uint, role string) (models.Membership, error) {
+	ret := m.Called(ctx, organizationID, userID, role)
+
+	var (
+		r0 models.Membership
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Membership)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *OrganizationUsecase) RemoveMember(ctx context.Context, organizationID uint, userID uint) error {
+	ret := m.Called(ctx, organizationID, userID)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *OrganizationUsecase) Members(ctx context.Context, organizationID uint, page int, limit int) ([]models.Membership, int64, error) {
+	ret := m.Called(ctx, organizationID, page, limit)
+
+	var (
+		r0 []models.Membership
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Membership)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *OrganizationUsecase) UserOrganizations(ctx context.Context, userID uint, page int, limit int) ([]models.UserOrganization, int64, error) {
+	ret := m.Called(ctx, userID, page, limit)
+
+	var (
+		r0 []models.UserOrganization
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.UserOrganization)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *OrganizationUsecase) Authorize(ctx context.Context, organizationID uint, userID uint, role string) (models.Membership, error) {
+	ret := m.Called(ctx, organizationID, userID, role)
+
+	var (
+		r0 models.Membership
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Membership)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
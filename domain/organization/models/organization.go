@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// membership role, ordered from the least to the most privileged
+const (
+	RoleMember = "member"
+	RoleAdmin  = "admin"
+	RoleOwner  = "owner"
+)
+
+// notification topic sent to the member whose membership changed
+const (
+	TopicMemberAdded   = "organization.member_added"
+	TopicRoleChanged   = "organization.role_changed"
+	TopicMemberRemoved = "organization.member_removed"
+)
+
+var roleRank = map[string]int{
+	RoleMember: 1,
+	RoleAdmin:  2,
+	RoleOwner:  3,
+}
+
+type Organization struct {
+	ID        uint      `json:"id"`
+	TenantID  uint      `gorm:"not null;uniqueIndex:idx_organization_tenant_slug" json:"-"`
+	Name      string    `gorm:"size:100;not null" json:"name" binding:"required"`
+	Slug      string    `gorm:"size:100;not null;uniqueIndex:idx_organization_tenant_slug" json:"slug" binding:"required"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Organization) TableName() string {
+	return "organization"
+}
+
+// Membership link a user to an organization with a single role
+type Membership struct {
+	TenantID       uint      `gorm:"not null;index" json:"-"`
+	OrganizationID uint      `gorm:"primaryKey;autoIncrement:false" json:"organization_id"`
+	UserID         uint      `gorm:"primaryKey;autoIncrement:false;index" json:"user_id"`
+	Role           string    `gorm:"size:20;not null" json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (Membership) TableName() string {
+	return "organization_member"
+}
+
+// UserOrganization is an organization seen from one of its member
+type UserOrganization struct {
+	Organization `gorm:"embedded"`
+	Role         string `json:"role"`
+}
+
+func IsValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// HasRole report whether the membership grant at least the required role
+func (membership Membership) HasRole(required string) bool {
+	return roleRank[membership.Role] >= roleRank[required]
+}
+
+// MembershipChange is the notification sent to the member, a removed member
+// has no role left
+type MembershipChange struct {
+	OrganizationID uint   `json:"organization_id"`
+	Role           string `json:"role,omitempty"`
+	PreviousRole   string `json:"previous_role,omitempty"`
+}
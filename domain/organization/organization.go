@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"prototype/domain/organization/models"
+)
+
+// interface for repository
+type IOrganizationMysqlRepository interface {
+	Create(ctx context.Context, organization models.Organization, ownerID uint) (models.Organization, error)
+	Update(ctx context.Context, organization models.Organization) (models.Organization, error)
+	GetByID(ctx context.Context, id uint) (models.Organization, error)
+	Delete(ctx context.Context, id uint) error
+
+	GetMember(ctx context.Context, organizationID, userID uint) (models.Membership, error)
+	AddMember(ctx context.Context, membership models.Membership) (models.Membership, error)
+	UpdateMember(ctx context.Context, membership models.Membership) (models.Membership, error)
+	RemoveMember(ctx context.Context, organizationID, userID uint) error
+	FetchMembers(ctx context.Context, organizationID uint, page, limit int) ([]models.Membership, int64, error)
+	FetchByUser(ctx context.Context, userID uint, page, limit int) ([]models.UserOrganization, int64, error)
+}
+
+// interface for usecase
+type IOrganizationUsecase interface {
+	Create(ctx context.Context, organization models.Organization, ownerID uint) (models.Organization, error)
+	Update(ctx context.Context, organization models.Organization) (models.Organization, error)
+	GetByID(ctx context.Context, id uint) (models.Organization, error)
+	Delete(ctx context.Context, id uint) error
+
+	AddMember(ctx context.Context, organizationID, userID uint, role string) (models.Membership, error)
+	ChangeMemberRole(ctx context.Context, organizationID, userID uint, role string) (models.Membership, error)
+	RemoveMember(ctx context.Context, organizationID, userID uint) error
+	Members(ctx context.Context, organizationID uint, page, limit int) ([]models.Membership, int64, error)
+	UserOrganizations(ctx context.Context, userID uint, page, limit int) ([]models.UserOrganization, int64, error)
+
+	Authorize(ctx context.Context, organizationID, userID uint, role string) (models.Membership, error)
+}
@@ -0,0 +1,250 @@
+package repository_mysql
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/organization"
+	"prototype/domain/organization/models"
+	"prototype/lib/log"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type organizationMysqlRepository struct {
+	DB  *gorm.DB
+	log log.ILogs
+}
+
+func NewMysqlOrganizationRepo(DB *gorm.DB, log log.ILogs) domain.IOrganizationMysqlRepository {
+	return organizationMysqlRepository{DB, log}
+}
+
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// Create insert the organization and its first owner in one transaction
+func (repo organizationMysqlRepository) Create(ctx context.Context, organization models.Organization, ownerID uint) (result models.Organization, err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&organization).Error; err != nil {
+			if isDuplicate(err) {
+				return domain.ErrSlugTaken
+			}
+
+			repo.log.Error(ctx, "tx.Create(&organization)", err)
+			return err
+		}
+
+		owner := models.Membership{
+			OrganizationID: organization.ID,
+			UserID:         ownerID,
+			Role:           models.RoleOwner,
+		}
+		if err := tx.Create(&owner).Error; err != nil {
+			repo.log.Error(ctx, "tx.Create(&owner)", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	result = organization
+	return
+}
+
+func (repo organizationMysqlRepository) Update(ctx context.Context, organization models.Organization) (result models.Organization, err error) {
+	if err = repo.DB.WithContext(ctx).Save(&organization).Error; err != nil {
+		if isDuplicate(err) {
+			err = domain.ErrSlugTaken
+			return
+		}
+
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Save(&organization)", err)
+		return
+	}
+
+	result = organization
+	return
+}
+
+func (repo organizationMysqlRepository) GetByID(ctx context.Context, id uint) (result models.Organization, err error) {
+	err = repo.DB.WithContext(ctx).Where("id = ?", id).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domain.ErrOrganizationNotFound
+		return
+	}
+
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('id = ?', id).First(&result)", err)
+		return
+	}
+
+	return
+}
+
+// Delete remove the organization together with all of its membership
+func (repo organizationMysqlRepository) Delete(ctx context.Context, id uint) (err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", id).Delete(&models.Membership{}).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('organization_id = ?', id).Delete(&models.Membership{})", err)
+			return err
+		}
+
+		if err := tx.Where("id = ?", id).Delete(&models.Organization{}).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('id = ?', id).Delete(&models.Organization{})", err)
+			return err
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func (repo organizationMysqlRepository) GetMember(ctx context.Context, organizationID, userID uint) (result models.Membership, err error) {
+	err = repo.DB.WithContext(ctx).Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domain.ErrNotMember
+		return
+	}
+
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('organization_id = ? AND user_id = ?').First(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo organizationMysqlRepository) AddMember(ctx context.Context, membership models.Membership) (result models.Membership, err error) {
+	if err = repo.DB.WithContext(ctx).Create(&membership).Error; err != nil {
+		if isDuplicate(err) {
+			err = domain.ErrAlreadyMember
+			return
+		}
+
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Create(&membership)", err)
+		return
+	}
+
+	result = membership
+	return
+}
+
+// UpdateMember change the role of the member, an owner losing its role is
+// checked against the other owner in the same transaction
+func (repo organizationMysqlRepository) UpdateMember(ctx context.Context, membership models.Membership) (result models.Membership, err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if membership.Role != models.RoleOwner {
+			if err := repo.keepOwner(ctx, tx, membership.OrganizationID, membership.UserID); err != nil {
+				return err
+			}
+		}
+
+		update := tx.Model(&models.Membership{}).
+			Where("organization_id = ? AND user_id = ?", membership.OrganizationID, membership.UserID).
+			Update("role", membership.Role)
+		if err := update.Error; err != nil {
+			repo.log.Error(ctx, "tx.Model(&models.Membership{}).Update('role')", err)
+			return err
+		}
+
+		if update.RowsAffected == 0 {
+			return domain.ErrNotMember
+		}
+
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	return repo.GetMember(ctx, membership.OrganizationID, membership.UserID)
+}
+
+// RemoveMember delete the membership, an owner is removed only when another
+// owner is left
+func (repo organizationMysqlRepository) RemoveMember(ctx context.Context, organizationID, userID uint) (err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repo.keepOwner(ctx, tx, organizationID, userID); err != nil {
+			return err
+		}
+
+		deleted := tx.Where("organization_id = ? AND user_id = ?", organizationID, userID).Delete(&models.Membership{})
+		if err := deleted.Error; err != nil {
+			repo.log.Error(ctx, "tx.Delete(&models.Membership{})", err)
+			return err
+		}
+
+		if deleted.RowsAffected == 0 {
+			return domain.ErrNotMember
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// keepOwner lock the owner of the organization until tx end and reject the
+// change when userID is the last of them, two owner leaving at once are
+// serialized on the lock and the second one see it is the last
+func (repo organizationMysqlRepository) keepOwner(ctx context.Context, tx *gorm.DB, organizationID, userID uint) error {
+	var owners []models.Membership
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND role = ?", organizationID, models.RoleOwner).
+		Find(&owners).Error
+	if err != nil {
+		repo.log.Error(ctx, "tx.Where('organization_id = ? AND role = ?').Find(&owners)", err)
+		return err
+	}
+
+	if len(owners) == 1 && owners[0].UserID == userID {
+		return domain.ErrLastOwner
+	}
+
+	return nil
+}
+
+func (repo organizationMysqlRepository) FetchMembers(ctx context.Context, organizationID uint, page, limit int) (result []models.Membership, total int64, err error) {
+	query := repo.DB.WithContext(ctx).Model(&models.Membership{}).Where("organization_id = ?", organizationID)
+
+	if err = query.Count(&total).Error; err != nil {
+		repo.log.Error(ctx, "query.Count(&total)", err)
+		return
+	}
+
+	if err = query.Order("user_id ASC").Offset((page - 1) * limit).Limit(limit).Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Order('user_id ASC').Find(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo organizationMysqlRepository) FetchByUser(ctx context.Context, userID uint, page, limit int) (result []models.UserOrganization, total int64, err error) {
+	query := repo.DB.WithContext(ctx).Model(&models.Organization{}).
+		Joins("JOIN organization_member ON organization_member.organization_id = organization.id").
+		Where("organization_member.user_id = ?", userID)
+
+	if err = query.Count(&total).Error; err != nil {
+		repo.log.Error(ctx, "query.Count(&total)", err)
+		return
+	}
+
+	err = query.Select("organization.*, organization_member.role").
+		Order("organization.id ASC").Offset((page - 1) * limit).Limit(limit).
+		Scan(&result).Error
+	if err != nil {
+		repo.log.Error(ctx, "query.Select('organization.*, organization_member.role').Scan(&result)", err)
+		return
+	}
+
+	return
+}
@@ -0,0 +1,66 @@
+package repository_mysql
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/organization"
+	"prototype/domain/organization/models"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupOrganizationDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&models.Organization{}, &models.Membership{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func Test_organizationMysqlRepository_keepOwner(t *testing.T) {
+	db := setupOrganizationDB(t)
+	repo := NewMysqlOrganizationRepo(db, log.NewLog())
+	ctx := tenant.WithID(context.Background(), 1)
+
+	organization, err := repo.Create(ctx, models.Organization{Name: "Acme", Slug: "acme"}, 10)
+	if err != nil {
+		t.Fatalf("repo.Create() error = %v", err)
+	}
+
+	if _, err := repo.AddMember(ctx, models.Membership{OrganizationID: organization.ID, UserID: 20, Role: models.RoleOwner}); err != nil {
+		t.Fatalf("repo.AddMember() error = %v", err)
+	}
+
+	if _, err := repo.UpdateMember(ctx, models.Membership{OrganizationID: organization.ID, UserID: 20, Role: models.RoleAdmin}); err != nil {
+		t.Fatalf("repo.UpdateMember() demote one of two owner error = %v", err)
+	}
+
+	if _, err := repo.UpdateMember(ctx, models.Membership{OrganizationID: organization.ID, UserID: 10, Role: models.RoleAdmin}); !errors.Is(err, domain.ErrLastOwner) {
+		t.Errorf("repo.UpdateMember() demote last owner error = %v, want %v", err, domain.ErrLastOwner)
+	}
+
+	if err := repo.RemoveMember(ctx, organization.ID, 10); !errors.Is(err, domain.ErrLastOwner) {
+		t.Errorf("repo.RemoveMember() last owner error = %v, want %v", err, domain.ErrLastOwner)
+	}
+
+	if err := repo.RemoveMember(ctx, organization.ID, 20); err != nil {
+		t.Errorf("repo.RemoveMember() admin error = %v", err)
+	}
+
+	if member, err := repo.GetMember(ctx, organization.ID, 10); err != nil || member.Role != models.RoleOwner {
+		t.Errorf("repo.GetMember() = %+v, %v, want the owner kept", member, err)
+	}
+}
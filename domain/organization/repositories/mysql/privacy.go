@@ -0,0 +1,74 @@
+package repository_mysql
+
+import (
+	"context"
+	"prototype/domain/organization/models"
+	userDomain "prototype/domain/user"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+
+	"gorm.io/gorm"
+)
+
+type organizationDataOwner struct {
+	DB  *gorm.DB
+	log log.ILogs
+}
+
+// NewMysqlOrganizationDataOwner expose the membership of a user to export and
+// erasure, the organization themselves belong to the tenant and are kept
+func NewMysqlOrganizationDataOwner(DB *gorm.DB, log log.ILogs) userDomain.IDataOwner {
+	return organizationDataOwner{DB, log}
+}
+
+func (owner organizationDataOwner) Name() string {
+	return "organizations"
+}
+
+func (owner organizationDataOwner) Export(ctx context.Context, user userModels.User) (interface{}, error) {
+	result := []models.UserOrganization{}
+	err := owner.DB.WithContext(ctx).Model(&models.Organization{}).
+		Joins("JOIN organization_member ON organization_member.organization_id = organization.id").
+		Where("organization_member.user_id = ?", user.ID).
+		Select("organization.*, organization_member.role").
+		Order("organization.id ASC").
+		Scan(&result).Error
+	if err != nil {
+		owner.log.Error(ctx, "owner.DB.WithContext(ctx).Select('organization.*, organization_member.role').Scan(&result)", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Erase remove every membership, an organization left without owner is
+// logged so a tenant admin can appoint a new one
+func (owner organizationDataOwner) Erase(ctx context.Context, user userModels.User) error {
+	var owned []uint
+	err := owner.DB.WithContext(ctx).Model(&models.Membership{}).
+		Where("user_id = ? AND role = ?", user.ID, models.RoleOwner).
+		Pluck("organization_id", &owned).Error
+	if err != nil {
+		owner.log.Error(ctx, "owner.DB.WithContext(ctx).Model(&models.Membership{}).Pluck('organization_id')", err)
+		return err
+	}
+
+	if err := owner.DB.WithContext(ctx).Where("user_id = ?", user.ID).Delete(&models.Membership{}).Error; err != nil {
+		owner.log.Error(ctx, "owner.DB.WithContext(ctx).Where('user_id = ?', user.ID).Delete(&models.Membership{})", err)
+		return err
+	}
+
+	for _, organizationID := range owned {
+		var owners int64
+		err := owner.DB.WithContext(ctx).Model(&models.Membership{}).
+			Where("organization_id = ? AND role = ?", organizationID, models.RoleOwner).
+			Count(&owners).Error
+		if err == nil && owners == 0 {
+			owner.log.Warning(ctx, "Organization Without Owner", map[string]interface{}{
+				"organization_id": organizationID,
+			})
+		}
+	}
+
+	return nil
+}
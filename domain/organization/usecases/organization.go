@@ -0,0 +1,248 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	domain "prototype/domain/organization"
+	"prototype/domain/organization/models"
+	userDomain "prototype/domain/user"
+	"prototype/lib/log"
+	"prototype/lib/notify"
+)
+
+type organizationUsecase struct {
+	organizationRepo domain.IOrganizationMysqlRepository
+	userRepo         userDomain.IUserMysqlRepository
+	notifier         notify.INotifier
+	log              log.ILogs
+}
+
+func NewOrganizationUsecase(organizationRepo domain.IOrganizationMysqlRepository, userRepo userDomain.IUserMysqlRepository, notifier notify.INotifier, log log.ILogs) domain.IOrganizationUsecase {
+	return &organizationUsecase{organizationRepo, userRepo, notifier, log}
+}
+
+// Create the organization with ownerID as its first owner
+func (usecase organizationUsecase) Create(ctx context.Context, organization models.Organization, ownerID uint) (result models.Organization, err error) {
+	result, err = usecase.organizationRepo.Create(ctx, organization, ownerID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.Create Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase organizationUsecase) Update(ctx context.Context, organization models.Organization) (result models.Organization, err error) {
+	organizationData, err := usecase.organizationRepo.GetByID(ctx, organization.ID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.GetByID Error", err)
+		return
+	}
+
+	organizationData.Name = organization.Name
+	organizationData.Slug = organization.Slug
+
+	result, err = usecase.organizationRepo.Update(ctx, organizationData)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.Update Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase organizationUsecase) GetByID(ctx context.Context, id uint) (result models.Organization, err error) {
+	result, err = usecase.organizationRepo.GetByID(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.GetByID Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase organizationUsecase) Delete(ctx context.Context, id uint) (err error) {
+	if _, err = usecase.organizationRepo.GetByID(ctx, id); err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.GetByID Error", err)
+		return
+	}
+
+	if err = usecase.organizationRepo.Delete(ctx, id); err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.Delete Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase organizationUsecase) AddMember(ctx context.Context, organizationID, userID uint, role string) (result models.Membership, err error) {
+	if !models.IsValidRole(role) {
+		err = fmt.Errorf("%w: %s", domain.ErrInvalidRole, role)
+		return
+	}
+
+	if err = usecase.authorizeActor(ctx, organizationID, role); err != nil {
+		return
+	}
+
+	if _, err = usecase.userRepo.GetByID(ctx, userID); err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
+	result, err = usecase.organizationRepo.AddMember(ctx, models.Membership{
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Role:           role,
+	})
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.AddMember Error", err)
+		return
+	}
+
+	usecase.notify(ctx, userID, models.TopicMemberAdded, models.MembershipChange{
+		OrganizationID: organizationID,
+		Role:           role,
+	})
+
+	return
+}
+
+func (usecase organizationUsecase) ChangeMemberRole(ctx context.Context, organizationID, userID uint, role string) (result models.Membership, err error) {
+	if !models.IsValidRole(role) {
+		err = fmt.Errorf("%w: %s", domain.ErrInvalidRole, role)
+		return
+	}
+
+	membership, err := usecase.organizationRepo.GetMember(ctx, organizationID, userID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.GetMember Error", err)
+		return
+	}
+
+	if err = usecase.authorizeActor(ctx, organizationID, membership.Role, role); err != nil {
+		return
+	}
+
+	previousRole := membership.Role
+	membership.Role = role
+
+	// the repository keep the organization from losing its last owner
+	result, err = usecase.organizationRepo.UpdateMember(ctx, membership)
+	if err != nil {
+		if !errors.Is(err, domain.ErrLastOwner) {
+			usecase.log.Error(ctx, "usecase.organizationRepo.UpdateMember Error", err)
+		}
+		return
+	}
+
+	if previousRole != role {
+		usecase.notify(ctx, userID, models.TopicRoleChanged, models.MembershipChange{
+			OrganizationID: organizationID,
+			Role:           role,
+			PreviousRole:   previousRole,
+		})
+	}
+
+	return
+}
+
+func (usecase organizationUsecase) RemoveMember(ctx context.Context, organizationID, userID uint) (err error) {
+	membership, err := usecase.organizationRepo.GetMember(ctx, organizationID, userID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.GetMember Error", err)
+		return
+	}
+
+	if err = usecase.authorizeActor(ctx, organizationID, membership.Role); err != nil {
+		return
+	}
+
+	if err = usecase.organizationRepo.RemoveMember(ctx, organizationID, userID); err != nil {
+		if !errors.Is(err, domain.ErrLastOwner) {
+			usecase.log.Error(ctx, "usecase.organizationRepo.RemoveMember Error", err)
+		}
+		return
+	}
+
+	usecase.notify(ctx, userID, models.TopicMemberRemoved, models.MembershipChange{
+		OrganizationID: organizationID,
+		PreviousRole:   membership.Role,
+	})
+
+	return
+}
+
+func (usecase organizationUsecase) Members(ctx context.Context, organizationID uint, page, limit int) (result []models.Membership, total int64, err error) {
+	result, total, err = usecase.organizationRepo.FetchMembers(ctx, organizationID, page, limit)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.FetchMembers Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase organizationUsecase) UserOrganizations(ctx context.Context, userID uint, page, limit int) (result []models.UserOrganization, total int64, err error) {
+	result, total, err = usecase.organizationRepo.FetchByUser(ctx, userID, page, limit)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.organizationRepo.FetchByUser Error", err)
+		return
+	}
+
+	return
+}
+
+// Authorize return the membership of the user when it grant at least role,
+// this is the single entry point for organization scoped access decision
+func (usecase organizationUsecase) Authorize(ctx context.Context, organizationID, userID uint, role string) (result models.Membership, err error) {
+	result, err = usecase.organizationRepo.GetMember(ctx, organizationID, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotMember) {
+			usecase.log.Error(ctx, "usecase.organizationRepo.GetMember Error", err)
+		}
+		return
+	}
+
+	if !result.HasRole(role) {
+		err = fmt.Errorf("%w: %s required", domain.ErrInsufficientRole, role)
+		result = models.Membership{}
+		return
+	}
+
+	return
+}
+
+// authorizeActor make sure only an owner can grant or touch the owner role,
+// call without an authenticated actor in ctx are internal and trusted
+func (usecase organizationUsecase) authorizeActor(ctx context.Context, organizationID uint, roles ...string) error {
+	actorID, ok := ctx.Value("user-id").(uint)
+	if !ok {
+		return nil
+	}
+
+	for _, role := range roles {
+		if role != models.RoleOwner {
+			continue
+		}
+
+		if _, err := usecase.Authorize(ctx, organizationID, actorID, models.RoleOwner); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notify tell the member about a change of its membership, the change is
+// made whether or not the notification could be sent
+func (usecase organizationUsecase) notify(ctx context.Context, userID uint, topic string, change models.MembershipChange) {
+	if usecase.notifier == nil {
+		return
+	}
+
+	if err := usecase.notifier.Notify(ctx, userID, notify.Payload{Topic: topic, Data: change}); err != nil {
+		usecase.log.Error(ctx, "usecase.notifier.Notify Error", err)
+	}
+}
@@ -0,0 +1,293 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/organization"
+	"prototype/domain/organization/mocks"
+	"prototype/domain/organization/models"
+	userMocks "prototype/domain/user/mocks"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/notify"
+	"prototype/lib/tenant"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func Test_organizationUsecase_Authorize(t *testing.T) {
+	ctx := context.Background()
+
+	organizationRepo := new(mocks.OrganizationRepository)
+	organizationRepo.On("GetMember", ctx, uint(1), uint(10)).Return(models.Membership{OrganizationID: 1, UserID: 10, Role: models.RoleAdmin}, nil)
+	organizationRepo.On("GetMember", ctx, uint(1), uint(11)).Return(models.Membership{}, domain.ErrNotMember)
+
+	tests := []struct {
+		name       string
+		userID     uint
+		role       string
+		wantResult models.Membership
+		wantErr    error
+	}{
+		{
+			name:       "success role above required",
+			userID:     10,
+			role:       models.RoleMember,
+			wantResult: models.Membership{OrganizationID: 1, UserID: 10, Role: models.RoleAdmin},
+		},
+		{
+			name:       "success exact role",
+			userID:     10,
+			role:       models.RoleAdmin,
+			wantResult: models.Membership{OrganizationID: 1, UserID: 10, Role: models.RoleAdmin},
+		},
+		{
+			name:    "failed role below required",
+			userID:  10,
+			role:    models.RoleOwner,
+			wantErr: domain.ErrInsufficientRole,
+		},
+		{
+			name:    "failed not a member",
+			userID:  11,
+			role:    models.RoleMember,
+			wantErr: domain.ErrNotMember,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := organizationUsecase{
+				organizationRepo: organizationRepo,
+				log:              log.NewLog(),
+			}
+			gotResult, err := usecase.Authorize(ctx, 1, tt.userID, tt.role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("organizationUsecase.Authorize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("organizationUsecase.Authorize() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_organizationUsecase_AddMember(t *testing.T) {
+	adminCtx := context.WithValue(context.Background(), "user-id", uint(10))
+	ownerCtx := context.WithValue(context.Background(), "user-id", uint(12))
+
+	organizationRepo := new(mocks.OrganizationRepository)
+	organizationRepo.On("GetMember", adminCtx, uint(1), uint(10)).Return(models.Membership{OrganizationID: 1, UserID: 10, Role: models.RoleAdmin}, nil)
+	organizationRepo.On("GetMember", ownerCtx, uint(1), uint(12)).Return(models.Membership{OrganizationID: 1, UserID: 12, Role: models.RoleOwner}, nil)
+	organizationRepo.On("AddMember", adminCtx, models.Membership{OrganizationID: 1, UserID: 20, Role: models.RoleMember}).
+		Return(models.Membership{OrganizationID: 1, UserID: 20, Role: models.RoleMember}, nil)
+	organizationRepo.On("AddMember", ownerCtx, models.Membership{OrganizationID: 1, UserID: 20, Role: models.RoleOwner}).
+		Return(models.Membership{}, domain.ErrAlreadyMember)
+
+	userRepo := new(userMocks.UserRepository)
+	userRepo.On("GetByID", adminCtx, uint(20)).Return(userModels.User{ID: 20}, nil)
+	userRepo.On("GetByID", ownerCtx, uint(20)).Return(userModels.User{ID: 20}, nil)
+	userRepo.On("GetByID", adminCtx, uint(21)).Return(userModels.User{}, gorm.ErrRecordNotFound)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		userID     uint
+		role       string
+		wantResult models.Membership
+		wantErr    error
+	}{
+		{
+			name:       "success",
+			ctx:        adminCtx,
+			userID:     20,
+			role:       models.RoleMember,
+			wantResult: models.Membership{OrganizationID: 1, UserID: 20, Role: models.RoleMember},
+		},
+		{
+			name:    "failed invalid role",
+			ctx:     adminCtx,
+			userID:  20,
+			role:    "superuser",
+			wantErr: domain.ErrInvalidRole,
+		},
+		{
+			name:    "failed admin grant owner",
+			ctx:     adminCtx,
+			userID:  20,
+			role:    models.RoleOwner,
+			wantErr: domain.ErrInsufficientRole,
+		},
+		{
+			name:    "failed unknown user",
+			ctx:     adminCtx,
+			userID:  21,
+			role:    models.RoleMember,
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name:    "failed already member",
+			ctx:     ownerCtx,
+			userID:  20,
+			role:    models.RoleOwner,
+			wantErr: domain.ErrAlreadyMember,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := organizationUsecase{
+				organizationRepo: organizationRepo,
+				userRepo:         userRepo,
+				log:              log.NewLog(),
+			}
+			gotResult, err := usecase.AddMember(tt.ctx, 1, tt.userID, tt.role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("organizationUsecase.AddMember() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("organizationUsecase.AddMember() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_organizationUsecase_ChangeMemberRole(t *testing.T) {
+	ctx := context.Background()
+
+	organizationRepo := new(mocks.OrganizationRepository)
+	organizationRepo.On("GetMember", ctx, uint(1), uint(10)).Return(models.Membership{OrganizationID: 1, UserID: 10, Role: models.RoleOwner}, nil)
+	organizationRepo.On("GetMember", ctx, uint(2), uint(10)).Return(models.Membership{OrganizationID: 2, UserID: 10, Role: models.RoleOwner}, nil)
+	organizationRepo.On("UpdateMember", ctx, models.Membership{OrganizationID: 1, UserID: 10, Role: models.RoleAdmin}).
+		Return(models.Membership{}, domain.ErrLastOwner)
+	organizationRepo.On("UpdateMember", ctx, models.Membership{OrganizationID: 2, UserID: 10, Role: models.RoleAdmin}).
+		Return(models.Membership{OrganizationID: 2, UserID: 10, Role: models.RoleAdmin}, nil)
+
+	tests := []struct {
+		name           string
+		organizationID uint
+		role           string
+		wantResult     models.Membership
+		wantErr        error
+	}{
+		{
+			name:           "success demote one of many owner",
+			organizationID: 2,
+			role:           models.RoleAdmin,
+			wantResult:     models.Membership{OrganizationID: 2, UserID: 10, Role: models.RoleAdmin},
+		},
+		{
+			name:           "failed demote last owner",
+			organizationID: 1,
+			role:           models.RoleAdmin,
+			wantErr:        domain.ErrLastOwner,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := organizationUsecase{
+				organizationRepo: organizationRepo,
+				log:              log.NewLog(),
+			}
+			gotResult, err := usecase.ChangeMemberRole(ctx, tt.organizationID, 10, tt.role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("organizationUsecase.ChangeMemberRole() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("organizationUsecase.ChangeMemberRole() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_organizationUsecase_RemoveMember(t *testing.T) {
+	ctx := context.Background()
+
+	organizationRepo := new(mocks.OrganizationRepository)
+	organizationRepo.On("GetMember", ctx, uint(1), uint(10)).Return(models.Membership{OrganizationID: 1, UserID: 10, Role: models.RoleOwner}, nil)
+	organizationRepo.On("GetMember", ctx, uint(1), uint(20)).Return(models.Membership{OrganizationID: 1, UserID: 20, Role: models.RoleMember}, nil)
+	organizationRepo.On("GetMember", ctx, uint(1), uint(21)).Return(models.Membership{}, domain.ErrNotMember)
+	organizationRepo.On("RemoveMember", ctx, uint(1), uint(20)).Return(nil)
+	organizationRepo.On("RemoveMember", ctx, uint(1), uint(10)).Return(domain.ErrLastOwner)
+
+	tests := []struct {
+		name    string
+		userID  uint
+		wantErr error
+	}{
+		{
+			name:   "success",
+			userID: 20,
+		},
+		{
+			name:    "failed last owner",
+			userID:  10,
+			wantErr: domain.ErrLastOwner,
+		},
+		{
+			name:    "failed not a member",
+			userID:  21,
+			wantErr: domain.ErrNotMember,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := organizationUsecase{
+				organizationRepo: organizationRepo,
+				log:              log.NewLog(),
+			}
+			if err := usecase.RemoveMember(ctx, 1, tt.userID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("organizationUsecase.RemoveMember() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_organizationUsecase_notify(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), 1)
+
+	organizationRepo := new(mocks.OrganizationRepository)
+	organizationRepo.On("GetMember", ctx, uint(1), uint(20)).Return(models.Membership{OrganizationID: 1, UserID: 20, Role: models.RoleMember}, nil)
+	organizationRepo.On("UpdateMember", ctx, models.Membership{OrganizationID: 1, UserID: 20, Role: models.RoleAdmin}).
+		Return(models.Membership{OrganizationID: 1, UserID: 20, Role: models.RoleAdmin}, nil)
+	organizationRepo.On("RemoveMember", ctx, uint(1), uint(20)).Return(nil)
+
+	hub := notify.NewHub(notify.Config{MaxConnections: 1, SendBuffer: 10})
+	conn, _ := hub.Register(1, 20)
+	conn.Subscribe(notify.TopicAll)
+
+	usecase := organizationUsecase{
+		organizationRepo: organizationRepo,
+		notifier:         notify.NewNotifier(hub, nil, ""),
+		log:              log.NewLog(),
+	}
+
+	if _, err := usecase.ChangeMemberRole(ctx, 1, 20, models.RoleAdmin); err != nil {
+		t.Fatalf("organizationUsecase.ChangeMemberRole() error = %v", err)
+	}
+
+	if err := usecase.RemoveMember(ctx, 1, 20); err != nil {
+		t.Fatalf("organizationUsecase.RemoveMember() error = %v", err)
+	}
+
+	tests := []struct {
+		topic    string
+		wantData string
+	}{
+		{topic: models.TopicRoleChanged, wantData: `{"organization_id":1,"role":"admin","previous_role":"member"}`},
+		{topic: models.TopicMemberRemoved, wantData: `{"organization_id":1,"previous_role":"member"}`},
+	}
+	for _, tt := range tests {
+		select {
+		case notification := <-conn.Send:
+			if notification.Topic != tt.topic || string(notification.Data) != tt.wantData {
+				t.Errorf("member got %v %s, want %v %s", notification.Topic, notification.Data, tt.topic, tt.wantData)
+			}
+		default:
+			t.Errorf("member got no %v notification", tt.topic)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "errors"
+
+var (
+	ErrTenantNotFound = errors.New("tenant not found")
+	ErrTenantDisabled = errors.New("tenant is disabled")
+	ErrTenantRequired = errors.New("tenant could not be resolved")
+	ErrInvalidSlug    = errors.New("invalid tenant slug")
+	ErrInvalidStatus  = errors.New("invalid tenant status")
+	ErrSlugTaken      = errors.New("tenant slug already taken")
+)
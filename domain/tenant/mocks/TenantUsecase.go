@@ -0,0 +1,112 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/tenant/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type TenantUsecase struct {
+	mock.Mock
+}
+
+func (m *TenantUsecase) Fetch(ctx context.Context, page int, limit int) ([]models.Tenant, int64, error) {
+	ret := m.Called(ctx, page, limit)
+
+	var (
+		r0 []models.Tenant
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Tenant)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *TenantUsecase) Create(ctx context.Context, tenant models.Tenant) (models.Tenant, error) {
+	ret := m.Called(ctx, tenant)
+
+	var (
+		r0 models.Tenant
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Tenant)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *TenantUsecase) Update(ctx context.Context, tenant models.Tenant) (models.Tenant, error) {
+	ret := m.Called(ctx, tenant)
+
+	var (
+		r0 models.Tenant
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Tenant)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *TenantUsecase) GetByID(ctx context.Context, id uint) (models.Tenant, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Tenant
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Tenant)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *TenantUsecase) Resolve(ctx context.Context, slug string) (models.Tenant, error) {
+	ret := m.Called(ctx, slug)
+
+	var (
+		r0 models.Tenant
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Tenant)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
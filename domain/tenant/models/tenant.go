@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// tenant status
+const (
+	StatusActive   = "active"
+	StatusDisabled = "disabled"
+)
+
+// DefaultSlug is the tenant every record created before multi-tenancy
+// belong to
+const DefaultSlug = "default"
+
+// Tenant is an entry of the tenant registry, Database is the DSN of the
+// dedicated database of the tenant and is empty when it share the primary one
+type Tenant struct {
+	ID        uint      `json:"id"`
+	Slug      string    `gorm:"size:63;not null;uniqueIndex" json:"slug" binding:"required"`
+	Name      string    `gorm:"size:100;not null" json:"name" binding:"required"`
+	Status    string    `gorm:"size:20;not null;default:active" json:"status"`
+	Database  string    `gorm:"size:500" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Tenant) TableName() string {
+	return "tenant"
+}
+
+func IsValidStatus(status string) bool {
+	return status == StatusActive || status == StatusDisabled
+}
@@ -0,0 +1,100 @@
+package repository_mysql
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/tenant"
+	"prototype/domain/tenant/models"
+	"prototype/lib/log"
+	tenantContext "prototype/lib/tenant"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type tenantMysqlRepository struct {
+	DB  *gorm.DB
+	log log.ILogs
+}
+
+// NewMysqlTenantRepo return the tenant registry, it always live in the primary
+// database whatever the tenant of the calling context
+func NewMysqlTenantRepo(DB *gorm.DB, log log.ILogs) domain.ITenantMysqlRepository {
+	return tenantMysqlRepository{DB, log}
+}
+
+func (repo tenantMysqlRepository) db(ctx context.Context) *gorm.DB {
+	return repo.DB.WithContext(tenantContext.WithoutID(ctx))
+}
+
+func (repo tenantMysqlRepository) Fetch(ctx context.Context, page, limit int) (result []models.Tenant, total int64, err error) {
+	query := repo.db(ctx).Model(&models.Tenant{})
+
+	if err = query.Count(&total).Error; err != nil {
+		repo.log.Error(ctx, "query.Count(&total)", err)
+		return
+	}
+
+	if err = query.Order("id ASC").Offset((page - 1) * limit).Limit(limit).Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Order('id ASC').Find(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo tenantMysqlRepository) Create(ctx context.Context, tenant models.Tenant) (result models.Tenant, err error) {
+	if err = repo.db(ctx).Create(&tenant).Error; err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			err = domain.ErrSlugTaken
+			return
+		}
+
+		repo.log.Error(ctx, "repo.db(ctx).Create(&tenant)", err)
+		return
+	}
+
+	result = tenant
+	return
+}
+
+func (repo tenantMysqlRepository) Update(ctx context.Context, tenant models.Tenant) (result models.Tenant, err error) {
+	if err = repo.db(ctx).Save(&tenant).Error; err != nil {
+		repo.log.Error(ctx, "repo.db(ctx).Save(&tenant)", err)
+		return
+	}
+
+	result = tenant
+	return
+}
+
+func (repo tenantMysqlRepository) GetByID(ctx context.Context, id uint) (result models.Tenant, err error) {
+	err = repo.db(ctx).Where("id = ?", id).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domain.ErrTenantNotFound
+		return
+	}
+
+	if err != nil {
+		repo.log.Error(ctx, "repo.db(ctx).Where('id = ?', id).First(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo tenantMysqlRepository) GetBySlug(ctx context.Context, slug string) (result models.Tenant, err error) {
+	err = repo.db(ctx).Where("slug = ?", slug).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domain.ErrTenantNotFound
+		return
+	}
+
+	if err != nil {
+		repo.log.Error(ctx, "repo.db(ctx).Where('slug = ?', slug).First(&result)", err)
+		return
+	}
+
+	return
+}
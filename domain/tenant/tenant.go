@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"prototype/domain/tenant/models"
+)
+
+// interface for repository
+type ITenantMysqlRepository interface {
+	Fetch(ctx context.Context, page, limit int) ([]models.Tenant, int64, error)
+	Create(ctx context.Context, tenant models.Tenant) (models.Tenant, error)
+	Update(ctx context.Context, tenant models.Tenant) (models.Tenant, error)
+	GetByID(ctx context.Context, id uint) (models.Tenant, error)
+	GetBySlug(ctx context.Context, slug string) (models.Tenant, error)
+}
+
+// interface for usecase
+type ITenantUsecase interface {
+	Fetch(ctx context.Context, page, limit int) ([]models.Tenant, int64, error)
+	Create(ctx context.Context, tenant models.Tenant) (models.Tenant, error)
+	Update(ctx context.Context, tenant models.Tenant) (models.Tenant, error)
+	GetByID(ctx context.Context, id uint) (models.Tenant, error)
+
+	// Resolve return the active tenant identified by slug
+	Resolve(ctx context.Context, slug string) (models.Tenant, error)
+}
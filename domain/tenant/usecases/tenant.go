@@ -0,0 +1,108 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	domain "prototype/domain/tenant"
+	"prototype/domain/tenant/models"
+	"prototype/lib/log"
+	"regexp"
+)
+
+type tenantUsecase struct {
+	tenantRepo domain.ITenantMysqlRepository
+	log        log.ILogs
+}
+
+// slugPattern keep the slug usable as a subdomain label
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+func NewTenantUsecase(tenantRepo domain.ITenantMysqlRepository, log log.ILogs) domain.ITenantUsecase {
+	return &tenantUsecase{tenantRepo, log}
+}
+
+func (usecase tenantUsecase) Fetch(ctx context.Context, page, limit int) (result []models.Tenant, total int64, err error) {
+	result, total, err = usecase.tenantRepo.Fetch(ctx, page, limit)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.tenantRepo.Fetch Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase tenantUsecase) Create(ctx context.Context, tenant models.Tenant) (result models.Tenant, err error) {
+	if !slugPattern.MatchString(tenant.Slug) {
+		err = fmt.Errorf("%w: %s", domain.ErrInvalidSlug, tenant.Slug)
+		return
+	}
+
+	tenant.Status = models.StatusActive
+
+	result, err = usecase.tenantRepo.Create(ctx, tenant)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.tenantRepo.Create Error", err)
+		return
+	}
+
+	usecase.log.Info(ctx, "Tenant Provisioned", map[string]interface{}{
+		"tenant_id": result.ID,
+		"slug":      result.Slug,
+	})
+
+	return
+}
+
+// Update change the name and status of a tenant, the slug is immutable since
+// it is part of how request are routed to the tenant
+func (usecase tenantUsecase) Update(ctx context.Context, tenant models.Tenant) (result models.Tenant, err error) {
+	if tenant.Status != "" && !models.IsValidStatus(tenant.Status) {
+		err = fmt.Errorf("%w: %s", domain.ErrInvalidStatus, tenant.Status)
+		return
+	}
+
+	tenantData, err := usecase.tenantRepo.GetByID(ctx, tenant.ID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.tenantRepo.GetByID Error", err)
+		return
+	}
+
+	tenantData.Name = tenant.Name
+	if tenant.Status != "" {
+		tenantData.Status = tenant.Status
+	}
+
+	result, err = usecase.tenantRepo.Update(ctx, tenantData)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.tenantRepo.Update Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase tenantUsecase) GetByID(ctx context.Context, id uint) (result models.Tenant, err error) {
+	result, err = usecase.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.tenantRepo.GetByID Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase tenantUsecase) Resolve(ctx context.Context, slug string) (result models.Tenant, err error) {
+	result, err = usecase.tenantRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.tenantRepo.GetBySlug Error", err)
+		return
+	}
+
+	if result.Status != models.StatusActive {
+		err = fmt.Errorf("%w: %s", domain.ErrTenantDisabled, slug)
+		result = models.Tenant{}
+		return
+	}
+
+	return
+}
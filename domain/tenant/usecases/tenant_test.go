@@ -0,0 +1,109 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/tenant"
+	"prototype/domain/tenant/mocks"
+	"prototype/domain/tenant/models"
+	"prototype/lib/log"
+	"reflect"
+	"testing"
+)
+
+func Test_tenantUsecase_Create(t *testing.T) {
+	ctx := context.Background()
+
+	tenantRepo := new(mocks.TenantRepository)
+	tenantRepo.On("Create", ctx, models.Tenant{Slug: "acme", Name: "Acme", Status: models.StatusActive}).
+		Return(models.Tenant{ID: 2, Slug: "acme", Name: "Acme", Status: models.StatusActive}, nil)
+	tenantRepo.On("Create", ctx, models.Tenant{Slug: "default", Name: "Again", Status: models.StatusActive}).
+		Return(models.Tenant{}, domain.ErrSlugTaken)
+
+	tests := []struct {
+		name       string
+		tenant     models.Tenant
+		wantResult models.Tenant
+		wantErr    error
+	}{
+		{
+			name:       "success",
+			tenant:     models.Tenant{Slug: "acme", Name: "Acme", Status: models.StatusDisabled},
+			wantResult: models.Tenant{ID: 2, Slug: "acme", Name: "Acme", Status: models.StatusActive},
+		},
+		{
+			name:    "failed slug taken",
+			tenant:  models.Tenant{Slug: "default", Name: "Again"},
+			wantErr: domain.ErrSlugTaken,
+		},
+		{
+			name:    "failed slug not a subdomain label",
+			tenant:  models.Tenant{Slug: "Acme Corp", Name: "Acme"},
+			wantErr: domain.ErrInvalidSlug,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := tenantUsecase{
+				tenantRepo: tenantRepo,
+				log:        log.NewLog(),
+			}
+			gotResult, err := usecase.Create(ctx, tt.tenant)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("tenantUsecase.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("tenantUsecase.Create() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_tenantUsecase_Resolve(t *testing.T) {
+	ctx := context.Background()
+
+	tenantRepo := new(mocks.TenantRepository)
+	tenantRepo.On("GetBySlug", ctx, "acme").Return(models.Tenant{ID: 2, Slug: "acme", Status: models.StatusActive}, nil)
+	tenantRepo.On("GetBySlug", ctx, "legacy").Return(models.Tenant{ID: 3, Slug: "legacy", Status: models.StatusDisabled}, nil)
+	tenantRepo.On("GetBySlug", ctx, "unknown").Return(models.Tenant{}, domain.ErrTenantNotFound)
+
+	tests := []struct {
+		name       string
+		slug       string
+		wantResult models.Tenant
+		wantErr    error
+	}{
+		{
+			name:       "success",
+			slug:       "acme",
+			wantResult: models.Tenant{ID: 2, Slug: "acme", Status: models.StatusActive},
+		},
+		{
+			name:    "failed disabled",
+			slug:    "legacy",
+			wantErr: domain.ErrTenantDisabled,
+		},
+		{
+			name:    "failed unknown",
+			slug:    "unknown",
+			wantErr: domain.ErrTenantNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := tenantUsecase{
+				tenantRepo: tenantRepo,
+				log:        log.NewLog(),
+			}
+			gotResult, err := usecase.Resolve(ctx, tt.slug)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("tenantUsecase.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("tenantUsecase.Resolve() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("invalid user status")
+	ErrInvalidTransition = errors.New("invalid user status transition")
+	ErrUserInactive      = errors.New("user is not active")
+	ErrAttributeFilter   = errors.New("attribute is not filterable")
+	ErrAvatarTooLarge    = errors.New("avatar is too large")
+	ErrAvatarType        = errors.New("avatar type is not supported")
+	ErrAvatarInvalid     = errors.New("avatar is not a valid image")
+	ErrEmailTaken        = errors.New("email already taken in tenant")
+	ErrMergeSelf         = errors.New("user cannot be merged into itself")
+	ErrMergeField        = errors.New("invalid merge field resolution")
+	ErrUserMerged        = errors.New("user has been merged")
+	ErrUserNotInactive   = errors.New("user is no longer inactive")
+)
+
+// MergedError is returned when a merged user is requested, TargetID is the
+// user it was merged into
+type MergedError struct {
+	SourceID uint
+	TargetID uint
+}
+
+func (err MergedError) Error() string {
+	return fmt.Sprintf("%s: %d into %d", ErrUserMerged, err.SourceID, err.TargetID)
+}
+
+func (err MergedError) Unwrap() error {
+	return ErrUserMerged
+}
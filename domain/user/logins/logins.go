@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	userDomain "prototype/domain/user"
+	"prototype/domain/user/logins/models"
+	userModels "prototype/domain/user/models"
+	"time"
+)
+
+// interface for repository
+type ILoginMysqlRepository interface {
+	Create(ctx context.Context, logins []models.Login) error
+	Fetch(ctx context.Context, userID uint, page, limit int) ([]models.Login, int64, error)
+
+	// TouchLastSeen and TouchLastLogin only ever move the timestamp forward
+	TouchLastSeen(ctx context.Context, seen map[uint]time.Time) error
+	TouchLastLogin(ctx context.Context, logins map[uint]time.Time) error
+
+	Purge(ctx context.Context, before time.Time) (int64, error)
+
+	FetchAll(ctx context.Context, userID uint) ([]models.Login, error)
+	DeleteByUser(ctx context.Context, userID uint) error
+}
+
+// interface for usecase
+type ILoginUsecase interface {
+	// Seen mark the user as seen, it record a login when the user was idle
+	// for longer than the session gap
+	Seen(ctx context.Context, user userModels.User, login models.Login)
+	Failed(ctx context.Context, login models.Login)
+	Fetch(ctx context.Context, userID uint, page, limit int) ([]models.Login, int64, error)
+
+	// Flush write the buffered event, Close flush one last time and stop the
+	// background flush
+	Flush(ctx context.Context) error
+	Close() error
+
+	// the login history is personal data, the usecase is the owner since it
+	// also hold the event not written yet
+	userDomain.IDataOwner
+}
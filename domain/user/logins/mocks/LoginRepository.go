@@ -0,0 +1,131 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/user/logins/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type LoginRepository struct {
+	mock.Mock
+}
+
+func (m *LoginRepository) Create(ctx context.Context, logins []models.Login) error {
+	ret := m.Called(ctx, logins)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *LoginRepository) Fetch(ctx context.Context, userID uint, page int, limit int) ([]models.Login, int64, error) {
+	ret := m.Called(ctx, userID, page, limit)
+
+	var (
+		r0 []models.Login
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Login)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *LoginRepository) TouchLastSeen(ctx context.Context, seen map[uint]time.Time) error {
+	ret := m.Called(ctx, seen)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *LoginRepository) TouchLastLogin(ctx context.Context, logins map[uint]time.Time) error {
+	ret := m.Called(ctx, logins)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *LoginRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ret := m.Called(ctx, before)
+
+	var (
+		r0 int64
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *LoginRepository) FetchAll(ctx context.Context, userID uint) ([]models.Login, error) {
+	ret := m.Called(ctx, userID)
+
+	var (
+		r0 []models.Login
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Login)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *LoginRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	ret := m.Called(ctx, userID)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
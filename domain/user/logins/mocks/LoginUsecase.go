@@ -0,0 +1,120 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/user/logins/models"
+	userModels "prototype/domain/user/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type LoginUsecase struct {
+	mock.Mock
+}
+
+func (m *LoginUsecase) Seen(ctx context.Context, user userModels.User, login models.Login) {
+	m.Called(ctx, user, login)
+}
+
+func (m *LoginUsecase) Failed(ctx context.Context, login models.Login) {
+	m.Called(ctx, login)
+}
+
+func (m *LoginUsecase) Fetch(ctx context.Context, userID uint, page int, limit int) ([]models.Login, int64, error) {
+	ret := m.Called(ctx, userID, page, limit)
+
+	var (
+		r0 []models.Login
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Login)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *LoginUsecase) Flush(ctx context.Context) error {
+	ret := m.Called(ctx)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *LoginUsecase) Close() error {
+	ret := m.Called()
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *LoginUsecase) Name() string {
+	ret := m.Called()
+
+	var (
+		r0 string
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+func (m *LoginUsecase) Export(ctx context.Context, user userModels.User) (interface{}, error) {
+	ret := m.Called(ctx, user)
+
+	var (
+		r0 interface{}
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(interface{})
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *LoginUsecase) Erase(ctx context.Context, user userModels.User) error {
+	ret := m.Called(ctx, user)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// authentication method
+const (
+	// MethodGateway is a caller identified by the User-ID header of the gateway
+	MethodGateway = "gateway"
+)
+
+// Login is one authentication event of a user, failed attempt included
+type Login struct {
+	ID        uint      `json:"id"`
+	TenantID  uint      `gorm:"not null;index" json:"-"`
+	UserID    uint      `gorm:"not null;index:idx_user_login_user_created" json:"user_id"`
+	Method    string    `gorm:"size:20;not null" json:"method"`
+	IP        string    `gorm:"column:ip;size:45" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	Success   bool      `gorm:"not null" json:"success"`
+	Reason    string    `gorm:"size:255" json:"reason,omitempty"`
+	CreatedAt time.Time `gorm:"not null;index:idx_user_login_user_created;index" json:"created_at"`
+}
+
+func (Login) TableName() string {
+	return "user_login"
+}
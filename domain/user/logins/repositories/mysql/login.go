@@ -0,0 +1,110 @@
+package repository_mysql
+
+import (
+	"context"
+	domain "prototype/domain/user/logins"
+	"prototype/domain/user/logins/models"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type loginMysqlRepository struct {
+	DB  *gorm.DB
+	log log.ILogs
+}
+
+func NewMysqlLoginRepo(DB *gorm.DB, log log.ILogs) domain.ILoginMysqlRepository {
+	return loginMysqlRepository{DB, log}
+}
+
+func (repo loginMysqlRepository) Create(ctx context.Context, logins []models.Login) (err error) {
+	if err = repo.DB.WithContext(ctx).CreateInBatches(&logins, 100).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).CreateInBatches(&logins)", err)
+		return
+	}
+
+	return
+}
+
+func (repo loginMysqlRepository) Fetch(ctx context.Context, userID uint, page, limit int) (result []models.Login, total int64, err error) {
+	query := repo.DB.WithContext(ctx).Model(&models.Login{}).Where("user_id = ?", userID)
+
+	if err = query.Count(&total).Error; err != nil {
+		repo.log.Error(ctx, "query.Count(&total)", err)
+		return
+	}
+
+	if err = query.Order("created_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Order('created_at DESC').Find(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo loginMysqlRepository) TouchLastSeen(ctx context.Context, seen map[uint]time.Time) error {
+	return repo.touch(ctx, "last_seen_at", seen)
+}
+
+func (repo loginMysqlRepository) TouchLastLogin(ctx context.Context, logins map[uint]time.Time) error {
+	return repo.touch(ctx, "last_login_at", logins)
+}
+
+// touch move column forward for every user in one transaction, UpdateColumn
+// keep the write out of the user audit
+func (repo loginMysqlRepository) touch(ctx context.Context, column string, at map[uint]time.Time) (err error) {
+	if len(at) == 0 {
+		return
+	}
+
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for userID, timestamp := range at {
+			err := tx.Model(&userModels.User{}).
+				Where("id = ? AND ("+column+" IS NULL OR "+column+" < ?)", userID, timestamp).
+				UpdateColumn(column, timestamp).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		repo.log.Error(ctx, "tx.Model(&userModels.User{}).UpdateColumn("+column+")", err)
+		return
+	}
+
+	return
+}
+
+func (repo loginMysqlRepository) Purge(ctx context.Context, before time.Time) (purged int64, err error) {
+	deleted := repo.DB.WithContext(ctx).Where("created_at < ?", before).Delete(&models.Login{})
+	if err = deleted.Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('created_at < ?', before).Delete(&models.Login{})", err)
+		return
+	}
+
+	purged = deleted.RowsAffected
+	return
+}
+
+func (repo loginMysqlRepository) FetchAll(ctx context.Context, userID uint) (result []models.Login, err error) {
+	if err = repo.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC, id ASC").Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('user_id = ?', userID).Find(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo loginMysqlRepository) DeleteByUser(ctx context.Context, userID uint) (err error) {
+	if err = repo.DB.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.Login{}).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('user_id = ?', userID).Delete(&models.Login{})", err)
+		return
+	}
+
+	return
+}
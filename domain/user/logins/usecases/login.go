@@ -0,0 +1,261 @@
+package usecases
+
+import (
+	"context"
+	domain "prototype/domain/user/logins"
+	"prototype/domain/user/logins/models"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"sync"
+	"time"
+)
+
+type loginUsecase struct {
+	loginRepo domain.ILoginMysqlRepository
+	config    LoginConfig
+	log       log.ILogs
+
+	mu       sync.Mutex
+	seen     map[uint]map[uint]time.Time
+	pending  map[uint]*batch
+	purgedAt map[uint]time.Time
+
+	done chan struct{}
+	once sync.Once
+}
+
+// LoginConfig control how login event are buffered and kept. A request count
+// as a new login when the user was not seen for SessionGap, buffered event
+// are written every FlushInterval and login older than Retention are purged
+type LoginConfig struct {
+	FlushInterval time.Duration
+	SessionGap    time.Duration
+	Retention     time.Duration
+}
+
+// batch is what a single tenant buffered since the last flush
+type batch struct {
+	logins    []models.Login
+	lastSeen  map[uint]time.Time
+	lastLogin map[uint]time.Time
+}
+
+// purgeInterval bound how often a tenant login history is purged
+const purgeInterval = time.Hour
+
+func NewLoginUsecase(loginRepo domain.ILoginMysqlRepository, config LoginConfig, log log.ILogs) domain.ILoginUsecase {
+	usecase := &loginUsecase{
+		loginRepo: loginRepo,
+		config:    config,
+		log:       log,
+		seen:      map[uint]map[uint]time.Time{},
+		pending:   map[uint]*batch{},
+		purgedAt:  map[uint]time.Time{},
+		done:      make(chan struct{}),
+	}
+
+	if config.FlushInterval > 0 {
+		go usecase.flusher()
+	}
+
+	return usecase
+}
+
+func (usecase *loginUsecase) Seen(ctx context.Context, user userModels.User, login models.Login) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+
+	usecase.mu.Lock()
+	defer usecase.mu.Unlock()
+
+	login = fit(login)
+
+	last := usecase.seen[tenantID][user.ID]
+	if user.LastSeenAt != nil && user.LastSeenAt.After(last) {
+		last = *user.LastSeenAt
+	}
+
+	if usecase.seen[tenantID] == nil {
+		usecase.seen[tenantID] = map[uint]time.Time{}
+	}
+	usecase.seen[tenantID][user.ID] = now
+
+	pending := usecase.batch(tenantID)
+	pending.lastSeen[user.ID] = now
+
+	if !last.IsZero() && now.Sub(last) < usecase.config.SessionGap {
+		return
+	}
+
+	login.UserID = user.ID
+	login.Success = true
+	login.CreatedAt = now
+
+	pending.logins = append(pending.logins, login)
+	pending.lastLogin[user.ID] = now
+}
+
+func (usecase *loginUsecase) Failed(ctx context.Context, login models.Login) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	login = fit(login)
+	login.Success = false
+	login.CreatedAt = time.Now()
+
+	usecase.mu.Lock()
+	defer usecase.mu.Unlock()
+
+	pending := usecase.batch(tenantID)
+	pending.logins = append(pending.logins, login)
+}
+
+func (usecase *loginUsecase) Fetch(ctx context.Context, userID uint, page, limit int) (result []models.Login, total int64, err error) {
+	result, total, err = usecase.loginRepo.Fetch(ctx, userID, page, limit)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.loginRepo.Fetch Error", err)
+		return
+	}
+
+	return
+}
+
+// Flush write every tenant batch, a tenant whose write failed is dropped so a
+// broken database can not grow the buffer without bound
+func (usecase *loginUsecase) Flush(ctx context.Context) (err error) {
+	usecase.mu.Lock()
+	pending := usecase.pending
+	usecase.pending = map[uint]*batch{}
+
+	// forget user idle for longer than a session, they start a new one anyway
+	cutoff := time.Now().Add(-usecase.config.SessionGap)
+	for tenantID, users := range usecase.seen {
+		for userID, at := range users {
+			if at.Before(cutoff) {
+				delete(users, userID)
+			}
+		}
+
+		if len(users) == 0 {
+			delete(usecase.seen, tenantID)
+		}
+	}
+	usecase.mu.Unlock()
+
+	for tenantID, tenantBatch := range pending {
+		tenantCtx := tenant.WithID(ctx, tenantID)
+
+		if flushErr := usecase.flush(tenantCtx, tenantBatch); flushErr != nil {
+			usecase.log.Error(tenantCtx, "usecase.flush Error", flushErr)
+			if err == nil {
+				err = flushErr
+			}
+
+			continue
+		}
+
+		usecase.purge(tenantCtx, tenantID)
+	}
+
+	return
+}
+
+func (usecase *loginUsecase) flush(ctx context.Context, pending *batch) error {
+	if len(pending.logins) > 0 {
+		if err := usecase.loginRepo.Create(ctx, pending.logins); err != nil {
+			return err
+		}
+	}
+
+	if err := usecase.loginRepo.TouchLastLogin(ctx, pending.lastLogin); err != nil {
+		return err
+	}
+
+	return usecase.loginRepo.TouchLastSeen(ctx, pending.lastSeen)
+}
+
+// purge remove login past the retention, at most once per purgeInterval and
+// only for tenant with recent activity since idle tenant gain no new row
+func (usecase *loginUsecase) purge(ctx context.Context, tenantID uint) {
+	if usecase.config.Retention <= 0 {
+		return
+	}
+
+	usecase.mu.Lock()
+	due := time.Since(usecase.purgedAt[tenantID]) >= purgeInterval
+	if due {
+		usecase.purgedAt[tenantID] = time.Now()
+	}
+	usecase.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	purged, err := usecase.loginRepo.Purge(ctx, time.Now().Add(-usecase.config.Retention))
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.loginRepo.Purge Error", err)
+		return
+	}
+
+	if purged > 0 {
+		usecase.log.Info(ctx, "User Login Purged", map[string]interface{}{
+			"tenant_id": tenantID,
+			"purged":    purged,
+		})
+	}
+}
+
+func (usecase *loginUsecase) Close() error {
+	usecase.once.Do(func() {
+		close(usecase.done)
+	})
+
+	return usecase.Flush(context.Background())
+}
+
+func (usecase *loginUsecase) flusher() {
+	ticker := time.NewTicker(usecase.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-usecase.done:
+			return
+		case <-ticker.C:
+			usecase.Flush(context.Background())
+		}
+	}
+}
+
+// fit cut the free text of the client to its column size, a single oversized
+// value would otherwise fail the whole batch
+func fit(login models.Login) models.Login {
+	if len(login.UserAgent) > 255 {
+		login.UserAgent = login.UserAgent[:255]
+	}
+
+	if len(login.Reason) > 255 {
+		login.Reason = login.Reason[:255]
+	}
+
+	return login
+}
+
+// batch return the buffer of the tenant, the caller hold mu
+func (usecase *loginUsecase) batch(tenantID uint) *batch {
+	pending, ok := usecase.pending[tenantID]
+	if !ok {
+		pending = &batch{lastSeen: map[uint]time.Time{}, lastLogin: map[uint]time.Time{}}
+		usecase.pending[tenantID] = pending
+	}
+
+	return pending
+}
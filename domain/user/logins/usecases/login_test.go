@@ -0,0 +1,107 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"prototype/domain/user/logins/mocks"
+	"prototype/domain/user/logins/models"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestLoginUsecase(loginRepo *mocks.LoginRepository, config LoginConfig) *loginUsecase {
+	return NewLoginUsecase(loginRepo, config, log.NewLog()).(*loginUsecase)
+}
+
+func Test_loginUsecase_Seen(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), 1)
+	recent := time.Now().Add(-time.Minute)
+	stale := time.Now().Add(-2 * time.Hour)
+
+	tests := []struct {
+		name       string
+		user       userModels.User
+		repeat     int
+		wantLogins int
+	}{
+		{
+			name:       "first request is a login",
+			user:       userModels.User{ID: 1},
+			repeat:     3,
+			wantLogins: 1,
+		},
+		{
+			name:       "seen recently is not a login",
+			user:       userModels.User{ID: 2, LastSeenAt: &recent},
+			repeat:     1,
+			wantLogins: 0,
+		},
+		{
+			name:       "seen before the session gap is a login",
+			user:       userModels.User{ID: 3, LastSeenAt: &stale},
+			repeat:     1,
+			wantLogins: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := newTestLoginUsecase(new(mocks.LoginRepository), LoginConfig{SessionGap: 30 * time.Minute})
+
+			for i := 0; i < tt.repeat; i++ {
+				usecase.Seen(ctx, tt.user, models.Login{Method: models.MethodGateway, IP: "10.0.0.1"})
+			}
+
+			pending := usecase.pending[1]
+			if len(pending.logins) != tt.wantLogins {
+				t.Errorf("loginUsecase.Seen() logins = %d, want %d", len(pending.logins), tt.wantLogins)
+			}
+			if _, ok := pending.lastSeen[tt.user.ID]; !ok {
+				t.Errorf("loginUsecase.Seen() last seen of %d not buffered", tt.user.ID)
+			}
+		})
+	}
+}
+
+func Test_loginUsecase_Flush(t *testing.T) {
+	tenantA := tenant.WithID(context.Background(), 1)
+	tenantB := tenant.WithID(context.Background(), 2)
+
+	inTenant := func(id uint) interface{} {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			tenantID, _ := tenant.FromContext(ctx)
+			return tenantID == id
+		})
+	}
+
+	loginRepo := new(mocks.LoginRepository)
+	loginRepo.On("Create", inTenant(1), mock.MatchedBy(func(logins []models.Login) bool {
+		return len(logins) == 2 && logins[0].Success && !logins[1].Success
+	})).Return(nil).Once()
+	loginRepo.On("TouchLastLogin", inTenant(1), mock.Anything).Return(nil).Once()
+	loginRepo.On("TouchLastSeen", inTenant(1), mock.Anything).Return(nil).Once()
+	loginRepo.On("Purge", inTenant(1), mock.Anything).Return(int64(3), nil).Once()
+	loginRepo.On("Create", inTenant(2), mock.Anything).Return(errors.New("database down")).Once()
+
+	usecase := newTestLoginUsecase(loginRepo, LoginConfig{SessionGap: time.Hour, Retention: 24 * time.Hour})
+
+	usecase.Seen(tenantA, userModels.User{ID: 1}, models.Login{Method: models.MethodGateway})
+	usecase.Seen(tenantA, userModels.User{ID: 1}, models.Login{Method: models.MethodGateway})
+	usecase.Failed(tenantA, models.Login{UserID: 9, Method: models.MethodGateway, Reason: "user is not active"})
+	usecase.Seen(tenantB, userModels.User{ID: 5}, models.Login{Method: models.MethodGateway})
+
+	if err := usecase.Flush(context.Background()); err == nil {
+		t.Error("loginUsecase.Flush() error = nil, want the error of tenant 2")
+	}
+
+	// the failed batch is dropped and the purge is not due again
+	if err := usecase.Close(); err != nil {
+		t.Errorf("loginUsecase.Close() error = %v", err)
+	}
+
+	loginRepo.AssertExpectations(t)
+}
@@ -0,0 +1,69 @@
+package usecases
+
+import (
+	"context"
+	"prototype/domain/user/logins/models"
+	userModels "prototype/domain/user/models"
+	"prototype/lib/tenant"
+)
+
+func (usecase *loginUsecase) Name() string {
+	return "logins"
+}
+
+// Export include the login still buffered so the archive is complete
+func (usecase *loginUsecase) Export(ctx context.Context, user userModels.User) (interface{}, error) {
+	result, err := usecase.loginRepo.FetchAll(ctx, user.ID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.loginRepo.FetchAll Error", err)
+		return nil, err
+	}
+
+	if result == nil {
+		result = []models.Login{}
+	}
+
+	tenantID, _ := tenant.FromContext(ctx)
+
+	usecase.mu.Lock()
+	defer usecase.mu.Unlock()
+
+	if pending, ok := usecase.pending[tenantID]; ok {
+		for _, login := range pending.logins {
+			if login.UserID == user.ID {
+				result = append(result, login)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Erase drop the buffered event of the user first so a later flush can not
+// write them back
+func (usecase *loginUsecase) Erase(ctx context.Context, user userModels.User) error {
+	tenantID, _ := tenant.FromContext(ctx)
+
+	usecase.mu.Lock()
+	if pending, ok := usecase.pending[tenantID]; ok {
+		logins := pending.logins[:0]
+		for _, login := range pending.logins {
+			if login.UserID != user.ID {
+				logins = append(logins, login)
+			}
+		}
+
+		pending.logins = logins
+		delete(pending.lastSeen, user.ID)
+		delete(pending.lastLogin, user.ID)
+	}
+	delete(usecase.seen[tenantID], user.ID)
+	usecase.mu.Unlock()
+
+	if err := usecase.loginRepo.DeleteByUser(ctx, user.ID); err != nil {
+		usecase.log.Error(ctx, "usecase.loginRepo.DeleteByUser Error", err)
+		return err
+	}
+
+	return nil
+}
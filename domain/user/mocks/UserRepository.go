@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 	"prototype/domain/user/models"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -11,8 +12,8 @@ type UserRepository struct {
 	mock.Mock
 }
 
-func (m *UserRepository) Fetch(ctx context.Context) ([]models.User, error) {
-	ret := m.Called(ctx)
+func (m *UserRepository) Fetch(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	ret := m.Called(ctx, filter)
 
 	var (
 		r0 []models.User
@@ -100,3 +101,226 @@ func (m *UserRepository) Delete(ctx context.Context, id uint) error {
 
 	return r0
 }
+
+func (m *UserRepository) FetchHistory(ctx context.Context, userID uint, page int, limit int) ([]models.UserAudit, int64, error) {
+	ret := m.Called(ctx, userID, page, limit)
+
+	var (
+		r0 []models.UserAudit
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.UserAudit)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *UserRepository) FetchHistoryChain(ctx context.Context, userID uint) ([]models.UserAudit, error) {
+	ret := m.Called(ctx, userID)
+
+	var (
+		r0 []models.UserAudit
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.UserAudit)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserRepository) Merge(ctx context.Context, sourceID uint, target models.User, dryRun bool) (models.MergeResult, error) {
+	ret := m.Called(ctx, sourceID, target, dryRun)
+
+	var (
+		r0 models.MergeResult
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.MergeResult)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserRepository) GetMerge(ctx context.Context, sourceID uint) (models.UserMerge, error) {
+	ret := m.Called(ctx, sourceID)
+
+	var (
+		r0 models.UserMerge
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.UserMerge)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserRepository) Erase(ctx context.Context, id uint) error {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *UserRepository) Reencrypt(ctx context.Context, progress models.Reencryption, limit int) (models.Reencryption, error) {
+	ret := m.Called(ctx, progress, limit)
+
+	var (
+		r0 models.Reencryption
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Reencryption)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserRepository) FetchInactive(ctx context.Context, before time.Time, limit int) ([]uint, error) {
+	ret := m.Called(ctx, before, limit)
+
+	var (
+		r0 []uint
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]uint)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserRepository) Archive(ctx context.Context, id uint, before time.Time) error {
+	ret := m.Called(ctx, id, before)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *UserRepository) FetchArchive(ctx context.Context, filter models.ArchiveFilter, page int, limit int) ([]models.UserArchive, int64, error) {
+	ret := m.Called(ctx, filter, page, limit)
+
+	var (
+		r0 []models.UserArchive
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.UserArchive)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *UserRepository) GetArchive(ctx context.Context, id uint) (models.UserArchive, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.UserArchive
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.UserArchive)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserRepository) Restore(ctx context.Context, id uint) (models.User, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.User
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserRepository) PurgeArchive(ctx context.Context, id uint) error {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"io"
 	"prototype/domain/user/models"
 
 	"github.com/stretchr/testify/mock"
@@ -11,8 +12,8 @@ type UserUsecase struct {
 	mock.Mock
 }
 
-func (m *UserUsecase) Fetch(ctx context.Context) ([]models.User, error) {
-	ret := m.Called(ctx)
+func (m *UserUsecase) Fetch(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	ret := m.Called(ctx, filter)
 
 	var (
 		r0 []models.User
@@ -100,3 +101,260 @@ func (m *UserUsecase) Delete(ctx context.Context, id uint) error {
 
 	return r0
 }
+
+func (m *UserUsecase) ChangeStatus(ctx context.Context, id uint, status, reason string) (models.User, error) {
+	ret := m.Called(ctx, id, status, reason)
+
+	var (
+		r0 models.User
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) Authenticate(ctx context.Context, id uint) (models.User, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.User
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) History(ctx context.Context, userID uint, page int, limit int) ([]models.UserAudit, int64, error) {
+	ret := m.Called(ctx, userID, page, limit)
+
+	var (
+		r0 []models.UserAudit
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.UserAudit)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *UserUsecase) VerifyHistory(ctx context.Context, userID uint) (models.AuditVerification, error) {
+	ret := m.Called(ctx, userID)
+
+	var (
+		r0 models.AuditVerification
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.AuditVerification)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) PatchAttributes(ctx context.Context, id uint, patch models.Attributes) (models.User, error) {
+	ret := m.Called(ctx, id, patch)
+
+	var (
+		r0 models.User
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) UploadAvatar(ctx context.Context, id uint, file io.Reader) (models.User, error) {
+	ret := m.Called(ctx, id, file)
+
+	var (
+		r0 models.User
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) Merge(ctx context.Context, targetID uint, request models.MergeRequest) (models.MergeResult, error) {
+	ret := m.Called(ctx, targetID, request)
+
+	var (
+		r0 models.MergeResult
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.MergeResult)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) Export(ctx context.Context, id uint) ([]byte, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 []byte
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]byte)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) Erase(ctx context.Context, id uint) (models.Erasure, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Erasure
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Erasure)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) Reencrypt(ctx context.Context) (models.Reencryption, error) {
+	ret := m.Called(ctx)
+
+	var (
+		r0 models.Reencryption
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Reencryption)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) Retention(ctx context.Context, dryRun bool) (models.RetentionReport, error) {
+	ret := m.Called(ctx, dryRun)
+
+	var (
+		r0 models.RetentionReport
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.RetentionReport)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *UserUsecase) FetchArchive(ctx context.Context, page int, limit int) ([]models.UserArchive, int64, error) {
+	ret := m.Called(ctx, page, limit)
+
+	var (
+		r0 []models.UserArchive
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.UserArchive)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *UserUsecase) Restore(ctx context.Context, id uint) (models.User, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.User
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// UserArchive is a user moved out of the user table by the retention policy,
+// Record keep the row as it was stored so encrypted field stay encrypted
+type UserArchive struct {
+	UserID     uint         `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	TenantID   uint         `gorm:"not null;index" json:"-"`
+	Record     ArchivedUser `gorm:"type:json" json:"-"`
+	LastSeenAt *time.Time   `json:"last_seen_at"`
+	ArchivedAt time.Time    `gorm:"not null;index" json:"archived_at"`
+
+	// KeyID repeat the key id of Record so a record under a key which is not
+	// current anymore can be found and sealed again
+	KeyID string `gorm:"column:key_id;size:64;index" json:"-"`
+
+	// User is Record decrypted by the repository
+	User User `gorm:"-" json:"user"`
+}
+
+func (UserArchive) TableName() string {
+	return "user_archive"
+}
+
+// ArchivedUser is the stored user including the column hidden from json
+type ArchivedUser struct {
+	User
+	EmailIndex *string `json:"email_index"`
+	KeyID      string  `json:"key_id"`
+	DataKey    string  `json:"data_key"`
+}
+
+// NewArchivedUser capture user as stored
+func NewArchivedUser(user User) ArchivedUser {
+	return ArchivedUser{User: user, EmailIndex: user.EmailIndex, KeyID: user.KeyID, DataKey: user.DataKey}
+}
+
+// Stored return the user row to write back on restore
+func (archived ArchivedUser) Stored() User {
+	user := archived.User
+	user.EmailIndex, user.KeyID, user.DataKey = archived.EmailIndex, archived.KeyID, archived.DataKey
+	return user
+}
+
+func (archived ArchivedUser) Value() (driver.Value, error) {
+	value, err := json.Marshal(archived)
+	return string(value), err
+}
+
+func (archived *ArchivedUser) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, archived)
+	case string:
+		return json.Unmarshal([]byte(data), archived)
+	}
+
+	return errors.New("unsupported type for ArchivedUser")
+}
+
+// ArchiveFilter select archived user, Before on ArchivedAt
+type ArchiveFilter struct {
+	Before *time.Time
+}
+
+// RetentionReport list the user a retention run archived and purged, a dry
+// run list the user it would have
+type RetentionReport struct {
+	DryRun        bool      `json:"dry_run"`
+	ArchiveBefore time.Time `json:"archive_before"`
+	PurgeBefore   time.Time `json:"purge_before"`
+	Archived      []uint    `json:"archived"`
+	Purged        []uint    `json:"purged"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Attributes is the free form profile document of a user, its shape is
+// governed by the json schema configured in User.Attributes.Schema
+type Attributes map[string]interface{}
+
+func (attributes Attributes) Value() (driver.Value, error) {
+	if attributes == nil {
+		return nil, nil
+	}
+
+	value, err := json.Marshal(attributes)
+	return string(value), err
+}
+
+func (attributes *Attributes) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, attributes)
+	case string:
+		return json.Unmarshal([]byte(data), attributes)
+	case nil:
+		*attributes = nil
+		return nil
+	}
+
+	return errors.New("unsupported type for Attributes")
+}
+
+// Merge apply patch as a json merge patch, null value remove the key
+func (attributes Attributes) Merge(patch Attributes) Attributes {
+	result := Attributes{}
+	for key, value := range attributes {
+		result[key] = value
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+
+		result[key] = value
+	}
+
+	return result
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// audit action
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionMerge   = "merge"
+	AuditActionErase   = "erase"
+	AuditActionArchive = "archive"
+	AuditActionRestore = "restore"
+
+	auditMask = "******"
+)
+
+// UserAudit is an append only record of a change on user, every entry is
+// chained to the previous entry of the same user through PrevHash
+type UserAudit struct {
+	ID        uint         `json:"id"`
+	TenantID  uint         `gorm:"not null;index" json:"-"`
+	UserID    uint         `gorm:"not null;index" json:"user_id"`
+	ActorID   uint         `json:"actor_id"`
+	TraceID   string       `gorm:"size:100" json:"trace_id"`
+	Action    string       `gorm:"size:20;not null" json:"action"`
+	Changes   AuditChanges `gorm:"type:json" json:"changes"`
+	PrevHash  string       `gorm:"size:64" json:"prev_hash"`
+	Hash      string       `gorm:"size:64;not null" json:"hash"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func (UserAudit) TableName() string {
+	return "user_audit"
+}
+
+// AuditChange
+type AuditChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges
+type AuditChanges []AuditChange
+
+// AuditVerification
+type AuditVerification struct {
+	UserID   uint `json:"user_id"`
+	Valid    bool `json:"valid"`
+	Checked  int  `json:"checked"`
+	BrokenAt uint `json:"broken_at,omitempty"`
+}
+
+func (changes AuditChanges) Value() (driver.Value, error) {
+	if changes == nil {
+		return "[]", nil
+	}
+
+	value, err := json.Marshal(changes)
+	return string(value), err
+}
+
+func (changes *AuditChanges) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, changes)
+	case string:
+		return json.Unmarshal([]byte(data), changes)
+	case nil:
+		*changes = nil
+		return nil
+	}
+
+	return errors.New("unsupported type for AuditChanges")
+}
+
+// ComputeHash hash the entry content together with the previous hash
+func (audit UserAudit) ComputeHash() string {
+	changes, _ := json.Marshal(audit.Changes)
+
+	payload := strings.Join([]string{
+		audit.PrevHash,
+		fmt.Sprint(audit.UserID),
+		fmt.Sprint(audit.ActorID),
+		audit.TraceID,
+		audit.Action,
+		string(changes),
+		audit.CreatedAt.UTC().Format(time.RFC3339),
+	}, "|")
+
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// DiffUser return the field level difference between two user, fields tagged
+// with audit:"mask" only record that they changed and audit:"skip" are ignored
+func DiffUser(before, after User) AuditChanges {
+	changes := AuditChanges{}
+
+	beforeValue := reflect.ValueOf(before)
+	afterValue := reflect.ValueOf(after)
+	userType := beforeValue.Type()
+
+	for i := 0; i < userType.NumField(); i++ {
+		field := userType.Field(i)
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || field.Tag.Get("audit") == "skip" {
+			continue
+		}
+
+		oldValue := beforeValue.Field(i).Interface()
+		newValue := afterValue.Field(i).Interface()
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		if field.Tag.Get("audit") == "mask" {
+			oldValue, newValue = maskAudit(beforeValue.Field(i)), maskAudit(afterValue.Field(i))
+		}
+
+		changes = append(changes, AuditChange{
+			Field:  name,
+			Before: oldValue,
+			After:  newValue,
+		})
+	}
+
+	return changes
+}
+
+func maskAudit(value reflect.Value) interface{} {
+	if value.IsZero() {
+		return nil
+	}
+
+	return auditMask
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Avatar map every generated variant name (e.g "256") to its public url
+type Avatar map[string]string
+
+func (avatar Avatar) Value() (driver.Value, error) {
+	if avatar == nil {
+		return nil, nil
+	}
+
+	value, err := json.Marshal(avatar)
+	return string(value), err
+}
+
+func (avatar *Avatar) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, avatar)
+	case string:
+		return json.Unmarshal([]byte(data), avatar)
+	case nil:
+		*avatar = nil
+		return nil
+	}
+
+	return errors.New("unsupported type for Avatar")
+}
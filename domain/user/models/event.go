@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// TopicUser is the topic user event are published to
+const TopicUser = "user"
+
+// user event type
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// UserEventVersion is the version of UserEvent, schema/user-event.v1.json
+// describe it. A breaking change of the payload is a new version
+const UserEventVersion = 1
+
+// UserEvent is the payload of a user event. Personal field are never part of
+// it, a consumer needing them read the user. Action is the audit action which
+// caused the event, a merge update the target and delete the source
+type UserEvent struct {
+	Version    int        `json:"version"`
+	Type       string     `json:"type"`
+	Action     string     `json:"action"`
+	TenantID   uint       `json:"tenant_id"`
+	UserID     uint       `json:"user_id"`
+	ActorID    uint       `json:"actor_id,omitempty"`
+	Changed    []string   `json:"changed,omitempty"`
+	User       *EventUser `json:"user,omitempty"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
+
+// EventUser is the state of the user after the event, deleted user have none
+type EventUser struct {
+	Username string `json:"username,omitempty"`
+	Role     string `json:"role"`
+	Status   string `json:"status"`
+}
+
+// NewUserEvent return the event of a change of user, changes only give the
+// name of the changed field
+func NewUserEvent(eventType, action string, user User, changes AuditChanges) UserEvent {
+	event := UserEvent{
+		Version:    UserEventVersion,
+		Type:       eventType,
+		Action:     action,
+		UserID:     user.ID,
+		OccurredAt: time.Now().UTC(),
+	}
+
+	for _, change := range changes {
+		event.Changed = append(event.Changed, change.Field)
+	}
+
+	if eventType != EventUserDeleted {
+		event.User = &EventUser{Username: user.Username, Role: user.Role, Status: user.Status}
+	}
+
+	return event
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+)
+
+// merge field resolution, the target value is kept unless source is chosen
+const (
+	MergeKeepTarget = "target"
+	MergeKeepSource = "source"
+)
+
+// MergeFields list the user fields a merge can take from the source
+var MergeFields = []string{"email", "username", "firstname", "lastname", "attributes", "avatar"}
+
+// UserMerge is the tombstone left by a merged user, it redirect the merged
+// SourceID to the surviving TargetID
+type UserMerge struct {
+	SourceID  uint      `gorm:"primaryKey;autoIncrement:false" json:"source_id"`
+	TenantID  uint      `gorm:"not null;index" json:"-"`
+	TargetID  uint      `gorm:"not null;index" json:"target_id"`
+	ActorID   uint      `json:"actor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (UserMerge) TableName() string {
+	return "user_merge"
+}
+
+// MergeRequest ask to merge SourceID into the target user, Fields choose per
+// field which of the two value survive
+type MergeRequest struct {
+	SourceID uint              `json:"source_id" binding:"required"`
+	Fields   map[string]string `json:"fields"`
+	DryRun   bool              `json:"dry_run"`
+}
+
+// MergeResult describe the surviving user and how many dependent record were
+// moved, a dry run report the same without keeping any change
+type MergeResult struct {
+	User     User             `json:"user"`
+	SourceID uint             `json:"source_id"`
+	Moved    map[string]int64 `json:"moved"`
+	DryRun   bool             `json:"dry_run"`
+}
+
+// Resolve return target with the fields chosen from source
+func (request MergeRequest) Resolve(target, source User) User {
+	for field, keep := range request.Fields {
+		if keep != MergeKeepSource {
+			continue
+		}
+
+		switch field {
+		case "email":
+			target.Email = source.Email
+			target.EmailVerifiedAt = source.EmailVerifiedAt
+		case "username":
+			target.Username = source.Username
+		case "firstname":
+			target.FirstName = source.FirstName
+		case "lastname":
+			target.LastName = source.LastName
+		case "attributes":
+			target.Attributes = source.Attributes
+		case "avatar":
+			target.Avatar = source.Avatar
+		}
+	}
+
+	return target
+}
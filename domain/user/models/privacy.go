@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Erasure report which data owner erased the personal data of a user
+type Erasure struct {
+	UserID   uint      `json:"user_id"`
+	Owners   []string  `json:"owners"`
+	ErasedAt time.Time `json:"erased_at"`
+}
@@ -1,14 +1,96 @@
 package models
 
+import "time"
+
+// user account status
+const (
+	StatusPending     = "pending"
+	StatusActive      = "active"
+	StatusSuspended   = "suspended"
+	StatusLocked      = "locked"
+	StatusDeactivated = "deactivated"
+)
+
+// user role
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
 // User
 type User struct {
-	ID        uint   `json:"id"`
-	Email     string `json:"email"`
-	Username  string `json:"username"`
-	FirstName string `gorm:"column:firstname" json:"firstname"`
-	LastName  string `gorm:"column:lastname" json:"lastname"`
+	ID              uint       `json:"id"`
+	TenantID        uint       `gorm:"not null;uniqueIndex:idx_user_tenant_email;uniqueIndex:idx_user_tenant_email_index" json:"-"`
+	Email           string     `gorm:"size:512;uniqueIndex:idx_user_tenant_email" json:"email" audit:"mask"`
+	EmailIndex      *string    `gorm:"column:email_index;size:64;uniqueIndex:idx_user_tenant_email_index" json:"-"`
+	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at" json:"email_verified_at,omitempty"`
+	Username        string     `json:"username"`
+	FirstName       string     `gorm:"column:firstname" json:"firstname" audit:"mask"`
+	LastName        string     `gorm:"column:lastname" json:"lastname" audit:"mask"`
+	Role            string     `gorm:"column:role;size:20;not null;default:user" json:"role"`
+	Status          string     `gorm:"column:status;size:20;not null;default:active;index" json:"status"`
+	StatusReason    string     `gorm:"column:status_reason" json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `gorm:"column:status_changed_at" json:"status_changed_at,omitempty"`
+	Attributes      Attributes `gorm:"column:attributes;type:json" json:"attributes,omitempty" audit:"mask"`
+	Avatar          Avatar     `gorm:"column:avatar;type:json" json:"avatar,omitempty"`
+	LastLoginAt     *time.Time `gorm:"column:last_login_at" json:"last_login_at,omitempty" audit:"skip"`
+	LastSeenAt      *time.Time `gorm:"column:last_seen_at" json:"last_seen_at,omitempty" audit:"skip"`
+	CreatedAt       time.Time  `gorm:"column:created_at" json:"created_at" audit:"skip"`
+
+	// KeyID and DataKey hold the wrapped key Email, FirstName and LastName are
+	// encrypted with, empty while the row is still plaintext
+	KeyID   string `gorm:"column:key_id;size:64;index" json:"-"`
+	DataKey string `gorm:"column:data_key;size:512" json:"-"`
 }
 
 func (User) TableName() string {
 	return "user"
 }
+
+// encrypted field of user, the name is bound to the ciphertext
+const (
+	FieldEmail     = "email"
+	FieldFirstName = "firstname"
+	FieldLastName  = "lastname"
+)
+
+// Reencryption is the progress of a re-encryption pass, a user which can not
+// be opened is skipped and left as it is for an operator. UserID and
+// ArchiveID are the last user and archived user looked at so a skipped row is
+// not picked up again by the next batch
+type Reencryption struct {
+	Sealed    int
+	Skipped   int
+	UserID    uint
+	ArchiveID uint
+}
+
+// StatusChange is the notification a user get when its session is revoked
+// because its account is no longer active
+type StatusChange struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// UserFilter
+type UserFilter struct {
+	Email      string
+	Status     []string
+	Attributes map[string]string
+}
+
+// IsValidRole report whether role is one of the known user role
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleSupport || role == RoleAdmin
+}
+
+// IsValidStatus report whether status is one of the known account status
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusActive, StatusSuspended, StatusLocked, StatusDeactivated:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,9 @@
+package domain
+
+import "errors"
+
+var (
+	ErrUnknownPreference  = errors.New("unknown preference key")
+	ErrPreferenceNotFound = errors.New("preference not found")
+	ErrVersionConflict    = errors.New("preference version conflict")
+)
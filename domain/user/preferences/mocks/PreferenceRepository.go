@@ -0,0 +1,83 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/user/preferences/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PreferenceRepository struct {
+	mock.Mock
+}
+
+func (m *PreferenceRepository) Fetch(ctx context.Context, userID uint, keys []string) ([]models.Preference, error) {
+	ret := m.Called(ctx, userID, keys)
+
+	var (
+		r0 []models.Preference
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Preference)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *PreferenceRepository) GetByKey(ctx context.Context, userID uint, key string) (models.Preference, error) {
+	ret := m.Called(ctx, userID, key)
+
+	var (
+		r0 models.Preference
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Preference)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *PreferenceRepository) Save(ctx context.Context, preference models.Preference, version uint) (models.Preference, error) {
+	ret := m.Called(ctx, preference, version)
+
+	var (
+		r0 models.Preference
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Preference)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *PreferenceRepository) Delete(ctx context.Context, userID uint, key string, version uint) error {
+	ret := m.Called(ctx, userID, key, version)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
@@ -0,0 +1,88 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/user/preferences/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PreferenceUsecase struct {
+	mock.Mock
+}
+
+func (m *PreferenceUsecase) Fetch(ctx context.Context, userID uint, keys []string) ([]models.UserPreference, error) {
+	ret := m.Called(ctx, userID, keys)
+
+	var (
+		r0 []models.UserPreference
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.UserPreference)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *PreferenceUsecase) Get(ctx context.Context, userID uint, key string) (models.UserPreference, error) {
+	ret := m.Called(ctx, userID, key)
+
+	var (
+		r0 models.UserPreference
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.UserPreference)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *PreferenceUsecase) Put(ctx context.Context, userID uint, key string, value interface{}, version uint) (models.UserPreference, error) {
+	ret := m.Called(ctx, userID, key, value, version)
+
+	var (
+		r0 models.UserPreference
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.UserPreference)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *PreferenceUsecase) Delete(ctx context.Context, userID uint, key string, version uint) (models.UserPreference, error) {
+	ret := m.Called(ctx, userID, key, version)
+
+	var (
+		r0 models.UserPreference
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.UserPreference)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
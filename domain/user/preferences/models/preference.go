@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// preference value type
+const (
+	TypeString = "string"
+	TypeBool   = "bool"
+	TypeNumber = "number"
+)
+
+// Preference is a stored value of a single key, Value hold the json
+// encoded value and Version is bumped on every write
+type Preference struct {
+	ID        uint      `json:"id"`
+	TenantID  uint      `gorm:"not null;index" json:"-"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_preference_key" json:"user_id"`
+	Key       string    `gorm:"column:key;size:100;not null;uniqueIndex:idx_user_preference_key" json:"key"`
+	Value     string    `gorm:"type:text;not null" json:"value"`
+	Version   uint      `gorm:"not null" json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Preference) TableName() string {
+	return "user_preference"
+}
+
+// Definition declare a preference key, its type and its default value
+type Definition struct {
+	Key     string
+	Type    string
+	Enum    []string
+	Default interface{}
+}
+
+// UserPreference is the effective value of a key for a user, Version is 0
+// while the user still use the default
+type UserPreference struct {
+	Key       string      `json:"key"`
+	Value     interface{} `json:"value"`
+	Version   uint        `json:"version"`
+	IsDefault bool        `json:"is_default"`
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"context"
+	"prototype/domain/user/preferences/models"
+)
+
+// interface for repository
+type IPreferenceMysqlRepository interface {
+	Fetch(ctx context.Context, userID uint, keys []string) ([]models.Preference, error)
+	GetByKey(ctx context.Context, userID uint, key string) (models.Preference, error)
+	Save(ctx context.Context, preference models.Preference, version uint) (models.Preference, error)
+	Delete(ctx context.Context, userID uint, key string, version uint) error
+}
+
+// interface for usecase
+type IPreferenceUsecase interface {
+	Fetch(ctx context.Context, userID uint, keys []string) ([]models.UserPreference, error)
+	Get(ctx context.Context, userID uint, key string) (models.UserPreference, error)
+	Put(ctx context.Context, userID uint, key string, value interface{}, version uint) (models.UserPreference, error)
+	Delete(ctx context.Context, userID uint, key string, version uint) (models.UserPreference, error)
+}
@@ -0,0 +1,116 @@
+package repository_mysql
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/user/preferences"
+	"prototype/domain/user/preferences/models"
+	"prototype/lib/log"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type preferenceMysqlRepository struct {
+	DB  *gorm.DB
+	log log.ILogs
+}
+
+func NewMysqlPreferenceRepo(DB *gorm.DB, log log.ILogs) domain.IPreferenceMysqlRepository {
+	return preferenceMysqlRepository{DB, log}
+}
+
+func (repo preferenceMysqlRepository) Fetch(ctx context.Context, userID uint, keys []string) (result []models.Preference, err error) {
+	query := repo.DB.WithContext(ctx).Where("user_id = ?", userID)
+
+	if len(keys) > 0 {
+		query = query.Where("`key` IN ?", keys)
+	}
+
+	if err = query.Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Find(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo preferenceMysqlRepository) GetByKey(ctx context.Context, userID uint, key string) (result models.Preference, err error) {
+	err = repo.DB.WithContext(ctx).Where("user_id = ? AND `key` = ?", userID, key).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domain.ErrPreferenceNotFound
+		return
+	}
+
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('user_id = ? AND `key` = ?', userID, key).First(&result)", err)
+		return
+	}
+
+	return
+}
+
+// Save write preference only if the stored version still equal version,
+// version 0 expect the key to not be stored yet
+func (repo preferenceMysqlRepository) Save(ctx context.Context, preference models.Preference, version uint) (result models.Preference, err error) {
+	db := repo.DB.WithContext(ctx)
+
+	if version == 0 {
+		preference.Version = 1
+		err = db.Create(&preference).Error
+
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			err = domain.ErrVersionConflict
+			return
+		}
+
+		if err != nil {
+			repo.log.Error(ctx, "db.Create(&preference)", err)
+			return
+		}
+
+		result = preference
+		return
+	}
+
+	update := db.Model(&models.Preference{}).
+		Where("user_id = ? AND `key` = ? AND version = ?", preference.UserID, preference.Key, version).
+		Updates(map[string]interface{}{
+			"value":   preference.Value,
+			"version": gorm.Expr("version + 1"),
+		})
+	if err = update.Error; err != nil {
+		repo.log.Error(ctx, "db.Model(&models.Preference{}).Updates", err)
+		return
+	}
+
+	if update.RowsAffected == 0 {
+		err = domain.ErrVersionConflict
+		return
+	}
+
+	return repo.GetByKey(ctx, preference.UserID, preference.Key)
+}
+
+// Delete remove the stored value, version 0 skip the version check
+func (repo preferenceMysqlRepository) Delete(ctx context.Context, userID uint, key string, version uint) (err error) {
+	query := repo.DB.WithContext(ctx).Where("user_id = ? AND `key` = ?", userID, key)
+
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	deleted := query.Delete(&models.Preference{})
+	if err = deleted.Error; err != nil {
+		repo.log.Error(ctx, "query.Delete(&models.Preference{})", err)
+		return
+	}
+
+	if deleted.RowsAffected == 0 && version != 0 {
+		err = domain.ErrVersionConflict
+		return
+	}
+
+	return
+}
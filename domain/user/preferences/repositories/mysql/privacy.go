@@ -0,0 +1,45 @@
+package repository_mysql
+
+import (
+	"context"
+	userDomain "prototype/domain/user"
+	userModels "prototype/domain/user/models"
+	"prototype/domain/user/preferences/models"
+	"prototype/lib/log"
+
+	"gorm.io/gorm"
+)
+
+type preferenceDataOwner struct {
+	DB  *gorm.DB
+	log log.ILogs
+}
+
+// NewMysqlPreferenceDataOwner expose the stored preference of a user to
+// export and erasure
+func NewMysqlPreferenceDataOwner(DB *gorm.DB, log log.ILogs) userDomain.IDataOwner {
+	return preferenceDataOwner{DB, log}
+}
+
+func (owner preferenceDataOwner) Name() string {
+	return "preferences"
+}
+
+func (owner preferenceDataOwner) Export(ctx context.Context, user userModels.User) (interface{}, error) {
+	result := []models.Preference{}
+	if err := owner.DB.WithContext(ctx).Where("user_id = ?", user.ID).Order("`key` ASC").Find(&result).Error; err != nil {
+		owner.log.Error(ctx, "owner.DB.WithContext(ctx).Where('user_id = ?', user.ID).Find(&result)", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (owner preferenceDataOwner) Erase(ctx context.Context, user userModels.User) error {
+	if err := owner.DB.WithContext(ctx).Where("user_id = ?", user.ID).Delete(&models.Preference{}).Error; err != nil {
+		owner.log.Error(ctx, "owner.DB.WithContext(ctx).Where('user_id = ?', user.ID).Delete(&models.Preference{})", err)
+		return err
+	}
+
+	return nil
+}
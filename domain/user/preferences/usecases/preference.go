@@ -0,0 +1,195 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	domain "prototype/domain/user/preferences"
+	"prototype/domain/user/preferences/models"
+	"prototype/lib/log"
+	"prototype/lib/validation"
+	"sort"
+)
+
+type preferenceUsecase struct {
+	preferenceRepo domain.IPreferenceMysqlRepository
+	definitions    map[string]models.Definition
+	log            log.ILogs
+}
+
+func NewPreferenceUsecase(preferenceRepo domain.IPreferenceMysqlRepository, definitions map[string]models.Definition, log log.ILogs) domain.IPreferenceUsecase {
+	return &preferenceUsecase{preferenceRepo, definitions, log}
+}
+
+// Fetch return every requested key with its stored or default value, all
+// declared keys are returned when keys is empty
+func (usecase preferenceUsecase) Fetch(ctx context.Context, userID uint, keys []string) (result []models.UserPreference, err error) {
+	if len(keys) == 0 {
+		for key := range usecase.definitions {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, ok := usecase.definitions[key]; !ok {
+			err = fmt.Errorf("%w: %s", domain.ErrUnknownPreference, key)
+			return
+		}
+	}
+
+	stored, err := usecase.preferenceRepo.Fetch(ctx, userID, keys)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.preferenceRepo.Fetch Error", err)
+		return
+	}
+
+	storedByKey := map[string]models.Preference{}
+	for _, preference := range stored {
+		storedByKey[preference.Key] = preference
+	}
+
+	result = make([]models.UserPreference, 0, len(keys))
+	for _, key := range keys {
+		preference, ok := storedByKey[key]
+		if !ok {
+			result = append(result, usecase.defaultOf(key))
+			continue
+		}
+
+		result = append(result, usecase.decode(preference))
+	}
+
+	return
+}
+
+func (usecase preferenceUsecase) Get(ctx context.Context, userID uint, key string) (result models.UserPreference, err error) {
+	if _, ok := usecase.definitions[key]; !ok {
+		err = fmt.Errorf("%w: %s", domain.ErrUnknownPreference, key)
+		return
+	}
+
+	preference, err := usecase.preferenceRepo.GetByKey(ctx, userID, key)
+	if errors.Is(err, domain.ErrPreferenceNotFound) {
+		return usecase.defaultOf(key), nil
+	}
+
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.preferenceRepo.GetByKey Error", err)
+		return
+	}
+
+	result = usecase.decode(preference)
+	return
+}
+
+// Put store value when version is the version the caller last saw
+func (usecase preferenceUsecase) Put(ctx context.Context, userID uint, key string, value interface{}, version uint) (result models.UserPreference, err error) {
+	definition, ok := usecase.definitions[key]
+	if !ok {
+		err = fmt.Errorf("%w: %s", domain.ErrUnknownPreference, key)
+		return
+	}
+
+	if err = validate(definition, value); err != nil {
+		return
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	preference, err := usecase.preferenceRepo.Save(ctx, models.Preference{
+		UserID: userID,
+		Key:    key,
+		Value:  string(encoded),
+	}, version)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.preferenceRepo.Save Error", err)
+		return
+	}
+
+	result = usecase.decode(preference)
+	return
+}
+
+// Delete reset the key to its default value
+func (usecase preferenceUsecase) Delete(ctx context.Context, userID uint, key string, version uint) (result models.UserPreference, err error) {
+	if _, ok := usecase.definitions[key]; !ok {
+		err = fmt.Errorf("%w: %s", domain.ErrUnknownPreference, key)
+		return
+	}
+
+	if err = usecase.preferenceRepo.Delete(ctx, userID, key, version); err != nil {
+		usecase.log.Error(ctx, "usecase.preferenceRepo.Delete Error", err)
+		return
+	}
+
+	result = usecase.defaultOf(key)
+	return
+}
+
+func (usecase preferenceUsecase) defaultOf(key string) models.UserPreference {
+	return models.UserPreference{
+		Key:       key,
+		Value:     usecase.definitions[key].Default,
+		IsDefault: true,
+	}
+}
+
+// decode fall back to the default when the stored value no longer match
+// its definition, e.g after the type was changed in config
+func (usecase preferenceUsecase) decode(preference models.Preference) models.UserPreference {
+	var value interface{}
+	if err := json.Unmarshal([]byte(preference.Value), &value); err != nil || validate(usecase.definitions[preference.Key], value) != nil {
+		return usecase.defaultOf(preference.Key)
+	}
+
+	return models.UserPreference{
+		Key:     preference.Key,
+		Value:   value,
+		Version: preference.Version,
+	}
+}
+
+func validate(definition models.Definition, value interface{}) error {
+	message := ""
+
+	switch definition.Type {
+	case models.TypeString:
+		text, ok := value.(string)
+		if !ok {
+			message = "expected string"
+			break
+		}
+
+		if len(definition.Enum) > 0 {
+			message = fmt.Sprintf("must be one of %v", definition.Enum)
+			for _, option := range definition.Enum {
+				if option == text {
+					message = ""
+				}
+			}
+		}
+	case models.TypeBool:
+		if _, ok := value.(bool); !ok {
+			message = "expected bool"
+		}
+	case models.TypeNumber:
+		if _, ok := value.(float64); !ok {
+			message = "expected number"
+		}
+	default:
+		message = "unsupported preference type " + definition.Type
+	}
+
+	if message == "" {
+		return nil
+	}
+
+	return validation.Error{Fields: []validation.FieldError{
+		{Field: definition.Key, Message: message},
+	}}
+}
@@ -0,0 +1,204 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/user/preferences"
+	"prototype/domain/user/preferences/mocks"
+	"prototype/domain/user/preferences/models"
+	"prototype/lib/log"
+	"prototype/lib/validation"
+	"reflect"
+	"testing"
+)
+
+var definitions = map[string]models.Definition{
+	"theme": {
+		Key:     "theme",
+		Type:    models.TypeString,
+		Enum:    []string{"light", "dark"},
+		Default: "light",
+	},
+	"notification_email": {
+		Key:     "notification_email",
+		Type:    models.TypeBool,
+		Default: true,
+	},
+}
+
+func Test_preferenceUsecase_Fetch(t *testing.T) {
+	ctx := context.Background()
+
+	preferenceRepo := new(mocks.PreferenceRepository)
+	preferenceRepo.On("Fetch", ctx, uint(1), []string{"notification_email", "theme"}).Return([]models.Preference{
+		{UserID: 1, Key: "theme", Value: `"dark"`, Version: 3},
+	}, nil)
+	preferenceRepo.On("Fetch", ctx, uint(2), []string{"notification_email", "theme"}).Return([]models.Preference{
+		{UserID: 2, Key: "theme", Value: `"purple"`, Version: 1},
+	}, nil)
+
+	tests := []struct {
+		name       string
+		userID     uint
+		keys       []string
+		wantResult []models.UserPreference
+		wantErr    error
+	}{
+		{
+			name:   "success merge stored and default",
+			userID: 1,
+			wantResult: []models.UserPreference{
+				{Key: "notification_email", Value: true, IsDefault: true},
+				{Key: "theme", Value: "dark", Version: 3},
+			},
+		},
+		{
+			name:   "success stored value no longer valid fall back to default",
+			userID: 2,
+			keys:   []string{"theme", "notification_email"},
+			wantResult: []models.UserPreference{
+				{Key: "notification_email", Value: true, IsDefault: true},
+				{Key: "theme", Value: "light", IsDefault: true},
+			},
+		},
+		{
+			name:    "failed unknown key",
+			userID:  1,
+			keys:    []string{"font"},
+			wantErr: domain.ErrUnknownPreference,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := preferenceUsecase{
+				preferenceRepo: preferenceRepo,
+				definitions:    definitions,
+				log:            log.NewLog(),
+			}
+			gotResult, err := usecase.Fetch(ctx, tt.userID, tt.keys)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("preferenceUsecase.Fetch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("preferenceUsecase.Fetch() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_preferenceUsecase_Put(t *testing.T) {
+	ctx := context.Background()
+
+	preferenceRepo := new(mocks.PreferenceRepository)
+	preferenceRepo.On("Save", ctx, models.Preference{UserID: 1, Key: "theme", Value: `"dark"`}, uint(2)).
+		Return(models.Preference{UserID: 1, Key: "theme", Value: `"dark"`, Version: 3}, nil)
+	preferenceRepo.On("Save", ctx, models.Preference{UserID: 1, Key: "notification_email", Value: `false`}, uint(1)).
+		Return(models.Preference{}, domain.ErrVersionConflict)
+
+	tests := []struct {
+		name       string
+		key        string
+		value      interface{}
+		version    uint
+		wantResult models.UserPreference
+		wantErr    error
+	}{
+		{
+			name:       "success",
+			key:        "theme",
+			value:      "dark",
+			version:    2,
+			wantResult: models.UserPreference{Key: "theme", Value: "dark", Version: 3},
+		},
+		{
+			name:    "failed stale version",
+			key:     "notification_email",
+			value:   false,
+			version: 1,
+			wantErr: domain.ErrVersionConflict,
+		},
+		{
+			name:    "failed unknown key",
+			key:     "font",
+			value:   "serif",
+			wantErr: domain.ErrUnknownPreference,
+		},
+		{
+			name:  "failed value not in enum",
+			key:   "theme",
+			value: "purple",
+			wantErr: validation.Error{Fields: []validation.FieldError{
+				{Field: "theme", Message: "must be one of [light dark]"},
+			}},
+		},
+		{
+			name:  "failed wrong type",
+			key:   "notification_email",
+			value: "yes",
+			wantErr: validation.Error{Fields: []validation.FieldError{
+				{Field: "notification_email", Message: "expected bool"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := preferenceUsecase{
+				preferenceRepo: preferenceRepo,
+				definitions:    definitions,
+				log:            log.NewLog(),
+			}
+			gotResult, err := usecase.Put(ctx, 1, tt.key, tt.value, tt.version)
+			if !errors.Is(err, tt.wantErr) && !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("preferenceUsecase.Put() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("preferenceUsecase.Put() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_preferenceUsecase_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	preferenceRepo := new(mocks.PreferenceRepository)
+	preferenceRepo.On("Delete", ctx, uint(1), "theme", uint(3)).Return(nil)
+	preferenceRepo.On("Delete", ctx, uint(1), "theme", uint(2)).Return(domain.ErrVersionConflict)
+
+	tests := []struct {
+		name       string
+		version    uint
+		wantResult models.UserPreference
+		wantErr    error
+	}{
+		{
+			name:       "success reset to default",
+			version:    3,
+			wantResult: models.UserPreference{Key: "theme", Value: "light", IsDefault: true},
+		},
+		{
+			name:    "failed stale version",
+			version: 2,
+			wantErr: domain.ErrVersionConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := preferenceUsecase{
+				preferenceRepo: preferenceRepo,
+				definitions:    definitions,
+				log:            log.NewLog(),
+			}
+			gotResult, err := usecase.Delete(ctx, 1, "theme", tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("preferenceUsecase.Delete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("preferenceUsecase.Delete() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"prototype/domain/user/models"
+	"sync"
+)
+
+// IDataOwner is a domain holding personal data of a user, every owner is
+// registered in the DataRegistry so export and erasure reach it
+type IDataOwner interface {
+	// Name is unique and name the file of the owner in the export archive
+	Name() string
+	Export(ctx context.Context, user models.User) (interface{}, error)
+	Erase(ctx context.Context, user models.User) error
+}
+
+// DataRegistry list the data owner of the deployment, the user profile and
+// its audit history are handled by the user usecase itself
+type DataRegistry struct {
+	mu     sync.RWMutex
+	owners []IDataOwner
+}
+
+func NewDataRegistry() *DataRegistry {
+	return &DataRegistry{}
+}
+
+// Register add owner, it panic when the name is already registered
+func (registry *DataRegistry) Register(owner IDataOwner) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for _, registered := range registry.owners {
+		if registered.Name() == owner.Name() {
+			panic(fmt.Sprintf("data owner %q registered twice", owner.Name()))
+		}
+	}
+
+	registry.owners = append(registry.owners, owner)
+}
+
+// Owners return the owner in registration order
+func (registry *DataRegistry) Owners() []IDataOwner {
+	if registry == nil {
+		return nil
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	return append([]IDataOwner(nil), registry.owners...)
+}
@@ -0,0 +1,163 @@
+package repository_memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	domain "prototype/domain/user"
+	"prototype/domain/user/models"
+	"prototype/lib/cache"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"time"
+)
+
+// userMemoryRepository cache GetByID of the wrapped repository in process,
+// every other method go to the wrapped repository and invalidate the user it
+// changed on every replica
+type userMemoryRepository struct {
+	domain.IUserMysqlRepository
+
+	cache       *cache.LRU
+	invalidator *cache.Invalidator
+	log         log.ILogs
+}
+
+// cachedUser keep the tenant of the user which is not serialized by its
+// json tag. User are cached serialized so a caller never share its map with
+// the cache
+type cachedUser struct {
+	models.User
+	TenantID uint `json:"tenant_id"`
+}
+
+// NewMemoryUserRepo wrap repo with the in process cache lru, invalidation
+// go through invalidator
+func NewMemoryUserRepo(repo domain.IUserMysqlRepository, lru *cache.LRU, invalidator *cache.Invalidator, log log.ILogs) domain.IUserMysqlRepository {
+	return userMemoryRepository{
+		IUserMysqlRepository: repo,
+		cache:                lru,
+		invalidator:          invalidator,
+		log:                  log,
+	}
+}
+
+// loadTimeout bound a load shared by concurrent caller
+const loadTimeout = 10 * time.Second
+
+// detached keep the value of a context, the tenant and trace id, without its
+// deadline and cancellation
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+func key(tenantID, id uint) string {
+	return fmt.Sprintf("user:%d:%d", tenantID, id)
+}
+
+func (repo userMemoryRepository) GetByID(ctx context.Context, id uint) (result models.User, err error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok || domain.IsUncached(ctx) {
+		return repo.IUserMysqlRepository.GetByID(ctx, id)
+	}
+
+	// the load is shared by every caller waiting for the key, so it must not
+	// fail because the first of them went away
+	raw, err := repo.cache.Do(key(tenantID, id), func() ([]byte, error) {
+		loadCtx, cancel := context.WithTimeout(detached{ctx}, loadTimeout)
+		defer cancel()
+
+		user, err := repo.IUserMysqlRepository.GetByID(loadCtx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(cachedUser{User: user, TenantID: user.TenantID})
+	})
+	if err != nil {
+		return
+	}
+
+	var cached cachedUser
+	if err = json.Unmarshal(raw, &cached); err != nil {
+		repo.log.Error(ctx, "json.Unmarshal Error", err)
+		return
+	}
+
+	result = cached.User
+	result.TenantID = cached.TenantID
+	return
+}
+
+func (repo userMemoryRepository) invalidate(ctx context.Context, ids ...uint) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, key(tenantID, id))
+	}
+
+	repo.invalidator.Invalidate(ctx, keys...)
+}
+
+func (repo userMemoryRepository) Update(ctx context.Context, user models.User) (result models.User, err error) {
+	result, err = repo.IUserMysqlRepository.Update(ctx, user)
+	if err != nil {
+		return
+	}
+
+	repo.invalidate(ctx, user.ID)
+	return
+}
+
+func (repo userMemoryRepository) Delete(ctx context.Context, id uint) (err error) {
+	if err = repo.IUserMysqlRepository.Delete(ctx, id); err != nil {
+		return
+	}
+
+	repo.invalidate(ctx, id)
+	return
+}
+
+func (repo userMemoryRepository) Merge(ctx context.Context, sourceID uint, target models.User, dryRun bool) (result models.MergeResult, err error) {
+	result, err = repo.IUserMysqlRepository.Merge(ctx, sourceID, target, dryRun)
+	if err != nil || dryRun {
+		return
+	}
+
+	repo.invalidate(ctx, sourceID, target.ID)
+	return
+}
+
+func (repo userMemoryRepository) Erase(ctx context.Context, id uint) (err error) {
+	if err = repo.IUserMysqlRepository.Erase(ctx, id); err != nil {
+		return
+	}
+
+	repo.invalidate(ctx, id)
+	return
+}
+
+func (repo userMemoryRepository) Archive(ctx context.Context, id uint, before time.Time) (err error) {
+	if err = repo.IUserMysqlRepository.Archive(ctx, id, before); err != nil {
+		return
+	}
+
+	repo.invalidate(ctx, id)
+	return
+}
@@ -0,0 +1,117 @@
+package repository_memory
+
+import (
+	"context"
+	domain "prototype/domain/user"
+	"prototype/domain/user/mocks"
+	"prototype/domain/user/models"
+	"prototype/lib/cache"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userMemoryRepository(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), 1)
+	user := models.User{ID: 1, TenantID: 1, Username: "before", Attributes: models.Attributes{"locale": "id"}}
+	updated := models.User{ID: 1, TenantID: 1, Username: "after"}
+
+	// a load run on a context of its own which keep the tenant
+	loaded := mock.MatchedBy(func(ctx context.Context) bool {
+		tenantID, _ := tenant.FromContext(ctx)
+		return tenantID == 1 && !domain.IsUncached(ctx)
+	})
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", loaded, uint(1)).Return(user, nil).Once()
+	userRepo.On("GetByID", loaded, uint(1)).Return(updated, nil).Once()
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(1)).Return(updated, nil)
+	userRepo.On("Update", ctx, mock.Anything).Return(updated, nil)
+
+	lru := cache.New(cache.Config{MaxEntries: 10})
+	repo := NewMemoryUserRepo(userRepo, lru, cache.NewInvalidator(lru, nil, "", log.NewLog()), log.NewLog())
+
+	got, err := repo.GetByID(ctx, 1)
+	if err != nil || got.Username != "before" || got.TenantID != 1 {
+		t.Fatalf("repo.GetByID() = %+v, %v", got, err)
+	}
+
+	// a caller changing its copy leave the cache untouched
+	got.Attributes["locale"] = "en"
+	if got, _ = repo.GetByID(ctx, 1); got.Attributes["locale"] != "id" {
+		t.Errorf("repo.GetByID() attributes = %v, want cached copy unchanged", got.Attributes)
+	}
+
+	userRepo.AssertNumberOfCalls(t, "GetByID", 1)
+
+	// a read made to update the user skip the cache
+	if got, _ = repo.GetByID(domain.Uncached(ctx), 1); got.Username != "after" {
+		t.Errorf("repo.GetByID() uncached = %v, want after", got.Username)
+	}
+
+	repo.Update(ctx, updated)
+	if got, _ = repo.GetByID(ctx, 1); got.Username != "after" {
+		t.Errorf("repo.GetByID() after update = %v, want after", got.Username)
+	}
+
+	if stats := lru.Stats(); stats.Hits != 1 || stats.Invalidations != 1 {
+		t.Errorf("lru.Stats() = %+v", stats)
+	}
+}
+
+// slowRepo answer GetByID once released, or fail like the database when the
+// context it got is done first
+type slowRepo struct {
+	domain.IUserMysqlRepository
+	started, release chan struct{}
+}
+
+func (repo slowRepo) GetByID(ctx context.Context, id uint) (models.User, error) {
+	close(repo.started)
+
+	select {
+	case <-repo.release:
+		return models.User{ID: id, TenantID: 1}, nil
+	case <-ctx.Done():
+		return models.User{}, ctx.Err()
+	}
+}
+
+func Test_userMemoryRepository_LeaderCanceled(t *testing.T) {
+	slow := slowRepo{started: make(chan struct{}), release: make(chan struct{})}
+
+	lru := cache.New(cache.Config{MaxEntries: 10})
+	repo := NewMemoryUserRepo(slow, lru, cache.NewInvalidator(lru, nil, "", log.NewLog()), log.NewLog())
+
+	leaderCtx, cancel := context.WithCancel(tenant.WithID(context.Background(), 1))
+	leader := make(chan error)
+	go func() {
+		_, err := repo.GetByID(leaderCtx, 1)
+		leader <- err
+	}()
+	<-slow.started
+
+	follower := make(chan error)
+	go func() {
+		user, err := repo.GetByID(tenant.WithID(context.Background(), 1), 1)
+		if err == nil && user.ID != 1 {
+			t.Errorf("repo.GetByID() follower = %+v", user)
+		}
+		follower <- err
+	}()
+
+	// give the follower time to join the load, then let the leader go away
+	// before the database answer
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	close(slow.release)
+
+	if err := <-follower; err != nil {
+		t.Errorf("repo.GetByID() follower error = %v, want the user", err)
+	}
+	<-leader
+}
@@ -0,0 +1,189 @@
+package repository_mysql
+
+import (
+	"context"
+	domain "prototype/domain/user"
+	"prototype/domain/user/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FetchInactive return the id of up to limit user last seen before before, a
+// user never seen count from its creation and admin are never considered
+// inactive
+func (repo userMysqlRepository) FetchInactive(ctx context.Context, before time.Time, limit int) (result []uint, err error) {
+	err = repo.DB.WithContext(ctx).Model(&models.User{}).
+		Where("COALESCE(last_seen_at, created_at) < ? AND role <> ?", before, models.RoleAdmin).
+		Order("id ASC").Limit(limit).Pluck("id", &result).Error
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Model(&models.User{}).Pluck('id')", err)
+		return
+	}
+
+	return
+}
+
+// inactive report whether user is one FetchInactive return for before
+func inactive(user models.User, before time.Time) bool {
+	seen := user.CreatedAt
+	if user.LastSeenAt != nil {
+		seen = *user.LastSeenAt
+	}
+
+	return seen.Before(before) && user.Role != models.RoleAdmin
+}
+
+// Archive move the user row as stored to the archive, its dependent record and
+// audit chain stay in place for the restore. The user is checked again under
+// the lock so one seen since it was fetched as inactive is left alone
+func (repo userMysqlRepository) Archive(ctx context.Context, id uint, before time.Time) (err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&user).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('id = ?', id).First(&user)", err)
+			return err
+		}
+
+		if !inactive(user, before) {
+			return domain.ErrUserNotInactive
+		}
+
+		archive := models.UserArchive{
+			UserID:     user.ID,
+			Record:     models.NewArchivedUser(user),
+			LastSeenAt: user.LastSeenAt,
+			ArchivedAt: time.Now(),
+			KeyID:      user.KeyID,
+		}
+
+		if err := tx.Create(&archive).Error; err != nil {
+			repo.log.Error(ctx, "tx.Create(&archive)", err)
+			return err
+		}
+
+		if err := tx.Where("id = ?", id).Delete(&models.User{}).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('id = ?', id).Delete(&models.User{})", err)
+			return err
+		}
+
+		if err := repo.audit(ctx, tx, id, models.AuditActionArchive, models.AuditChanges{}); err != nil {
+			return err
+		}
+
+		return repo.event(ctx, tx, models.EventUserDeleted, models.AuditActionArchive, user, nil)
+	})
+
+	return
+}
+
+func (repo userMysqlRepository) FetchArchive(ctx context.Context, filter models.ArchiveFilter, page, limit int) (result []models.UserArchive, total int64, err error) {
+	query := repo.DB.WithContext(ctx).Model(&models.UserArchive{})
+
+	if filter.Before != nil {
+		query = query.Where("archived_at < ?", *filter.Before)
+	}
+
+	if err = query.Count(&total).Error; err != nil {
+		repo.log.Error(ctx, "query.Count(&total)", err)
+		return
+	}
+
+	if err = query.Order("archived_at ASC").Offset((page - 1) * limit).Limit(limit).Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Order('archived_at ASC').Find(&result)", err)
+		return
+	}
+
+	for i := range result {
+		if result[i].User, err = repo.open(ctx, result[i].Record.Stored()); err != nil {
+			repo.log.Error(ctx, "repo.open(ctx, result[i].Record.Stored())", err)
+			return nil, 0, err
+		}
+	}
+
+	return
+}
+
+// GetArchive return the archived user id with its record decrypted
+func (repo userMysqlRepository) GetArchive(ctx context.Context, id uint) (result models.UserArchive, err error) {
+	if err = repo.DB.WithContext(ctx).Where("user_id = ?", id).First(&result).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('user_id = ?', id).First(&result)", err)
+		return
+	}
+
+	if result.User, err = repo.open(ctx, result.Record.Stored()); err != nil {
+		repo.log.Error(ctx, "repo.open(ctx, result.Record.Stored())", err)
+		return
+	}
+
+	return
+}
+
+// Restore move an archived user back with its original id, it fail with
+// ErrEmailTaken when its email was taken in the meantime
+func (repo userMysqlRepository) Restore(ctx context.Context, id uint) (result models.User, err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var archive models.UserArchive
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", id).First(&archive).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('user_id = ?', id).First(&archive)", err)
+			return err
+		}
+
+		user := archive.Record.Stored()
+		if err := tx.Create(&user).Error; err != nil {
+			if isDuplicate(err) {
+				return domain.ErrEmailTaken
+			}
+
+			repo.log.Error(ctx, "tx.Create(&user)", err)
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserArchive{}).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('user_id = ?', id).Delete(&models.UserArchive{})", err)
+			return err
+		}
+
+		if err := repo.audit(ctx, tx, id, models.AuditActionRestore, models.AuditChanges{}); err != nil {
+			return err
+		}
+
+		if err := repo.event(ctx, tx, models.EventUserCreated, models.AuditActionRestore, user, nil); err != nil {
+			return err
+		}
+
+		result = user
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	if result, err = repo.open(ctx, result); err != nil {
+		repo.log.Error(ctx, "repo.open(ctx, result)", err)
+		return
+	}
+
+	return
+}
+
+// PurgeArchive remove an archived user for good, like Erase its history is
+// replaced by a single erase entry
+func (repo userMysqlRepository) PurgeArchive(ctx context.Context, id uint) (err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		remove := tx.Where("user_id = ?", id).Delete(&models.UserArchive{})
+		if err := remove.Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('user_id = ?', id).Delete(&models.UserArchive{})", err)
+			return err
+		}
+
+		if remove.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return repo.eraseHistory(ctx, tx, id)
+	})
+
+	return
+}
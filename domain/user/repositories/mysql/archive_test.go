@@ -0,0 +1,136 @@
+package repository_mysql
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/user"
+	"prototype/domain/user/models"
+	"prototype/lib/crypt"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func Test_userMysqlRepository_Archive(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), 1)
+
+	file, err := crypt.GenerateKeyringFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := setupTenantDB(t)
+	repo := NewMysqlUserRepo(db, newTestCipher(t, file), log.NewLog())
+
+	longAgo := time.Now().AddDate(-1, 0, 0)
+	user, err := repo.Create(ctx, models.User{Email: "idle@mail.com", Username: "idle", FirstName: "Idle", Status: models.StatusActive, LastSeenAt: &longAgo})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recent := time.Now()
+	repo.Create(ctx, models.User{Email: "recent@mail.com", Username: "recent", LastSeenAt: &recent})
+	repo.Create(ctx, models.User{Email: "admin@mail.com", Username: "admin", Role: models.RoleAdmin, LastSeenAt: &longAgo})
+	repo.Create(ctx, models.User{Email: "never@mail.com", Username: "never"})
+
+	// never seen since it was created long ago
+	forgotten, _ := repo.Create(ctx, models.User{Email: "forgotten@mail.com", Username: "forgotten"})
+	db.WithContext(ctx).Model(&models.User{}).Where("id = ?", forgotten.ID).UpdateColumn("created_at", longAgo)
+
+	before := time.Now().AddDate(0, -6, 0)
+	inactive, err := repo.FetchInactive(ctx, before, 10)
+	if err != nil || len(inactive) != 2 || inactive[0] != user.ID || inactive[1] != forgotten.ID {
+		t.Fatalf("repo.FetchInactive() = %v, %v, want [%d %d]", inactive, err, user.ID, forgotten.ID)
+	}
+
+	// seen again between the fetch and the archive
+	now := time.Now()
+	db.WithContext(ctx).Model(&models.User{}).Where("id = ?", forgotten.ID).UpdateColumn("last_seen_at", now)
+	if err := repo.Archive(ctx, forgotten.ID, before); !errors.Is(err, domain.ErrUserNotInactive) {
+		t.Errorf("repo.Archive() of a user seen again error = %v, want %v", err, domain.ErrUserNotInactive)
+	}
+
+	if err := repo.Archive(ctx, user.ID, before); err != nil {
+		t.Fatalf("repo.Archive() error = %v", err)
+	}
+
+	if _, err := repo.GetByID(ctx, user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("repo.GetByID() error = %v, want user archived", err)
+	}
+
+	archives, total, err := repo.FetchArchive(ctx, models.ArchiveFilter{}, 1, 10)
+	if err != nil || total != 1 || archives[0].User.Email != "idle@mail.com" {
+		t.Fatalf("repo.FetchArchive() = %+v, %v", archives, err)
+	}
+
+	t.Run("reencrypt seal the archived record again", func(t *testing.T) {
+		file.Keys["rotated"] = file.Keys[file.Current]
+		file.Current = "rotated"
+		rotated := NewMysqlUserRepo(db, newTestCipher(t, file), log.NewLog())
+
+		// the four user left first, then the archived one
+		if progress, err := rotated.Reencrypt(ctx, models.Reencryption{}, 10); err != nil || progress.Sealed != 5 {
+			t.Fatalf("repo.Reencrypt() = %+v, %v, want 5 sealed", progress, err)
+		}
+
+		if progress, _ := rotated.Reencrypt(ctx, models.Reencryption{}, 10); progress.Sealed != 0 {
+			t.Errorf("repo.Reencrypt() again = %+v, want 0 sealed", progress)
+		}
+
+		archive, err := rotated.GetArchive(ctx, user.ID)
+		if err != nil || archive.KeyID != "rotated" || archive.Record.KeyID != "rotated" || archive.User.Email != "idle@mail.com" {
+			t.Errorf("repo.GetArchive() = %+v, %v", archive, err)
+		}
+
+		repo = rotated
+	})
+
+	t.Run("restore keep id and encrypted field", func(t *testing.T) {
+		restored, err := repo.Restore(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("repo.Restore() error = %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, user.ID)
+		if err != nil || got.Email != "idle@mail.com" || got.FirstName != "Idle" || restored.ID != user.ID {
+			t.Errorf("repo.GetByID() = %+v, %v", got, err)
+		}
+
+		users, _ := repo.Fetch(ctx, models.UserFilter{Email: "idle@mail.com"})
+		if len(users) != 1 {
+			t.Errorf("repo.Fetch() by email = %d user, want 1", len(users))
+		}
+
+		entries, _ := repo.FetchHistoryChain(ctx, user.ID)
+		if len(entries) != 3 || entries[1].Action != models.AuditActionArchive || entries[2].Action != models.AuditActionRestore {
+			t.Errorf("repo.FetchHistoryChain() = %v, want create, archive, restore", entries)
+		}
+	})
+
+	t.Run("purge leave a single erase entry", func(t *testing.T) {
+		if err := repo.Archive(ctx, user.ID, before); err != nil {
+			t.Fatal(err)
+		}
+
+		before := time.Now().Add(time.Minute)
+		archives, _, _ := repo.FetchArchive(ctx, models.ArchiveFilter{Before: &before}, 1, 10)
+		if len(archives) != 1 {
+			t.Fatalf("repo.FetchArchive() before = %d archive, want 1", len(archives))
+		}
+
+		if err := repo.PurgeArchive(ctx, user.ID); err != nil {
+			t.Fatalf("repo.PurgeArchive() error = %v", err)
+		}
+
+		if _, err := repo.Restore(ctx, user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("repo.Restore() error = %v, want archive gone", err)
+		}
+
+		entries, _ := repo.FetchHistoryChain(ctx, user.ID)
+		if len(entries) != 1 || entries[0].Action != models.AuditActionErase {
+			t.Errorf("repo.FetchHistoryChain() = %v, want a single erase entry", entries)
+		}
+	})
+}
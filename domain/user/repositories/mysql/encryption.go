@@ -0,0 +1,205 @@
+package repository_mysql
+
+import (
+	"context"
+	"fmt"
+	"prototype/domain/user/models"
+	"prototype/lib/crypt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// seal return the copy of user stored in database, the blind index of email
+// is always set and the personal field are encrypted with a new data key when
+// a cipher is configured
+func (repo userMysqlRepository) seal(ctx context.Context, user models.User) (sealed models.User, err error) {
+	sealed = user
+	sealed.EmailIndex, sealed.KeyID, sealed.DataKey = nil, "", ""
+
+	if user.Email != "" {
+		index := repo.cipher.Index(user.Email)
+		sealed.EmailIndex = &index
+	}
+
+	if repo.cipher == nil {
+		return
+	}
+
+	dataKey, keyID, wrapped, err := repo.cipher.NewDataKey(ctx)
+	if err != nil {
+		return
+	}
+
+	for field, value := range map[string]*string{
+		models.FieldEmail:     &sealed.Email,
+		models.FieldFirstName: &sealed.FirstName,
+		models.FieldLastName:  &sealed.LastName,
+	} {
+		if *value, err = dataKey.Seal(field, *value); err != nil {
+			return
+		}
+	}
+
+	sealed.KeyID, sealed.DataKey = keyID, wrapped
+	return
+}
+
+// unseal put the plaintext field of user back on its sealed copy once stored
+func unseal(sealed, user models.User) models.User {
+	sealed.Email, sealed.FirstName, sealed.LastName = user.Email, user.FirstName, user.LastName
+	return sealed
+}
+
+// open decrypt the personal field of a stored user, row without key id were
+// written before encryption and are returned as is
+func (repo userMysqlRepository) open(ctx context.Context, user models.User) (models.User, error) {
+	if user.KeyID == "" {
+		return user, nil
+	}
+
+	if repo.cipher == nil {
+		return user, fmt.Errorf("%w: %s, encryption is not configured", crypt.ErrUnknownKey, user.KeyID)
+	}
+
+	dataKey, err := repo.cipher.OpenDataKey(ctx, user.KeyID, user.DataKey)
+	if err != nil {
+		return user, err
+	}
+
+	for field, value := range map[string]*string{
+		models.FieldEmail:     &user.Email,
+		models.FieldFirstName: &user.FirstName,
+		models.FieldLastName:  &user.LastName,
+	} {
+		if *value, err = dataKey.Open(field, *value); err != nil {
+			return user, fmt.Errorf("user %d %s: %w", user.ID, field, err)
+		}
+	}
+
+	return user, nil
+}
+
+// Reencrypt seal again up to limit user still in plaintext, without blind
+// index or under a key which is not current anymore, then archived user once
+// no user is left. A row which can not be opened is skipped so it does not
+// hold back the rotation of the others. It is a storage change only so no
+// audit entry is written
+func (repo userMysqlRepository) Reencrypt(ctx context.Context, progress models.Reencryption, limit int) (result models.Reencryption, err error) {
+	result = progress
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id > ?", progress.UserID)
+		if current := repo.cipher.Current(); current != "" {
+			query = query.Where("((email_index IS NULL AND email <> '') OR key_id <> ? OR key_id IS NULL)", current)
+		} else {
+			query = query.Where("email_index IS NULL AND email <> ''")
+		}
+
+		var users []models.User
+		if err := query.Order("id ASC").Limit(limit).Find(&users).Error; err != nil {
+			repo.log.Error(ctx, "query.Find(&users)", err)
+			return err
+		}
+
+		for _, user := range users {
+			result.UserID = user.ID
+
+			plain, err := repo.open(ctx, user)
+			if err != nil {
+				repo.skip(ctx, "user_id", user.ID, user.KeyID, err)
+				result.Skipped++
+				continue
+			}
+
+			sealed, err := repo.seal(ctx, plain)
+			if err != nil {
+				repo.log.Error(ctx, "repo.seal(ctx, plain)", err)
+				return err
+			}
+
+			err = tx.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+				"email":       sealed.Email,
+				"email_index": sealed.EmailIndex,
+				"firstname":   sealed.FirstName,
+				"lastname":    sealed.LastName,
+				"key_id":      sealed.KeyID,
+				"data_key":    sealed.DataKey,
+			}).Error
+			if err != nil {
+				repo.log.Error(ctx, "tx.Model(&models.User{}).UpdateColumns", err)
+				return err
+			}
+
+			result.Sealed++
+		}
+
+		if len(users) >= limit {
+			return nil
+		}
+
+		return repo.reencryptArchive(ctx, tx, &result, limit-len(users))
+	})
+	if err != nil {
+		result = progress
+	}
+
+	return
+}
+
+// skip log a row Reencrypt could not open by its id and key, what it hold is
+// never logged
+func (repo userMysqlRepository) skip(ctx context.Context, column string, id uint, keyID string, err error) {
+	repo.log.Error(ctx, "repo.Reencrypt Skipped", map[string]interface{}{
+		column:   id,
+		"key_id": keyID,
+		"error":  err.Error(),
+	})
+}
+
+// reencryptArchive seal again up to limit archived user in plaintext or under
+// a key which is not current anymore, archived user are not looked up by email
+// so they are left alone while encryption is disabled
+func (repo userMysqlRepository) reencryptArchive(ctx context.Context, tx *gorm.DB, result *models.Reencryption, limit int) error {
+	current := repo.cipher.Current()
+	if current == "" {
+		return nil
+	}
+
+	var archives []models.UserArchive
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id > ? AND (key_id <> ? OR key_id IS NULL)", result.ArchiveID, current).
+		Order("user_id ASC").Limit(limit).Find(&archives).Error
+	if err != nil {
+		repo.log.Error(ctx, "tx.Find(&archives)", err)
+		return err
+	}
+
+	for _, archive := range archives {
+		result.ArchiveID = archive.UserID
+
+		plain, err := repo.open(ctx, archive.Record.Stored())
+		if err != nil {
+			repo.skip(ctx, "archive_user_id", archive.UserID, archive.Record.KeyID, err)
+			result.Skipped++
+			continue
+		}
+
+		sealed, err := repo.seal(ctx, plain)
+		if err != nil {
+			repo.log.Error(ctx, "repo.seal(ctx, plain)", err)
+			return err
+		}
+
+		err = tx.Model(&models.UserArchive{}).Where("user_id = ?", archive.UserID).UpdateColumns(map[string]interface{}{
+			"record": models.NewArchivedUser(sealed),
+			"key_id": sealed.KeyID,
+		}).Error
+		if err != nil {
+			repo.log.Error(ctx, "tx.Model(&models.UserArchive{}).UpdateColumns", err)
+			return err
+		}
+
+		result.Sealed++
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package repository_mysql
+
+import (
+	"context"
+	"fmt"
+	"prototype/domain/user/models"
+	"prototype/lib/outbox"
+	"prototype/lib/tenant"
+
+	"gorm.io/gorm"
+)
+
+// event write the event of a change of user to the outbox of the running
+// transaction, event of a user are published in order
+func (repo userMysqlRepository) event(ctx context.Context, tx *gorm.DB, eventType, action string, user models.User, changes models.AuditChanges) error {
+	event := models.NewUserEvent(eventType, action, user, changes)
+	event.TenantID, _ = tenant.FromContext(ctx)
+
+	if actorID, ok := ctx.Value("user-id").(uint); ok {
+		event.ActorID = actorID
+	}
+
+	orderingKey := fmt.Sprintf("%d:%d", event.TenantID, user.ID)
+	if err := outbox.Write(ctx, tx, models.TopicUser, eventType, orderingKey, event); err != nil {
+		repo.log.Error(ctx, "outbox.Write(ctx, tx, event)", err)
+		return err
+	}
+
+	return nil
+}
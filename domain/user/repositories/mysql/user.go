@@ -2,48 +2,266 @@ package repository_mysql
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	invitationModels "prototype/domain/invitation/models"
+	organizationModels "prototype/domain/organization/models"
 	domain "prototype/domain/user"
+	loginModels "prototype/domain/user/logins/models"
 	"prototype/domain/user/models"
+	preferenceModels "prototype/domain/user/preferences/models"
+	"prototype/lib/crypt"
 	"prototype/lib/log"
+	"reflect"
+	"regexp"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userMysqlRepository struct {
-	DB  *gorm.DB
-	log log.ILogs
+	DB     *gorm.DB
+	cipher *crypt.FieldCipher
+	log    log.ILogs
 }
 
-func NewMysqlUserRepo(DB *gorm.DB, log log.ILogs) domain.IUserMysqlRepository {
-	return userMysqlRepository{DB, log}
+var attributeKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// AttributeExpression return the sql expression of an attribute key, it is
+// shared by the functional index and the filter so mysql can use the index
+func AttributeExpression(key string) (string, error) {
+	if !attributeKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid attribute key %q", key)
+	}
+
+	return fmt.Sprintf("(CAST(attributes->>'$.%s' AS CHAR(191)) COLLATE utf8mb4_bin)", key), nil
+}
+
+// isDuplicate report whether err is a unique index violation, email is unique
+// per tenant
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// NewMysqlUserRepo store email, firstname and lastname encrypted with cipher,
+// a nil cipher store them in plaintext next to the blind index of email
+func NewMysqlUserRepo(DB *gorm.DB, cipher *crypt.FieldCipher, log log.ILogs) domain.IUserMysqlRepository {
+	return userMysqlRepository{DB, cipher, log}
 }
 
-func (repo userMysqlRepository) Fetch(ctx context.Context) (result []models.User, err error) {
-	if err = repo.DB.WithContext(ctx).Find(&result).Error; err != nil {
-		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Find(&result)", err)
+func (repo userMysqlRepository) Fetch(ctx context.Context, filter models.UserFilter) (result []models.User, err error) {
+	query := repo.DB.WithContext(ctx)
+
+	// row not reached yet by Reencrypt have no blind index
+	if filter.Email != "" {
+		query = query.Where("(email_index = ? OR (email_index IS NULL AND email = ?))", repo.cipher.Index(filter.Email), filter.Email)
+	}
+
+	if len(filter.Status) > 0 {
+		query = query.Where("status IN ?", filter.Status)
+	}
+
+	for key, value := range filter.Attributes {
+		expression, exprErr := AttributeExpression(key)
+		if exprErr != nil {
+			err = exprErr
+			repo.log.Error(ctx, "AttributeExpression(key)", err)
+			return
+		}
+
+		query = query.Where(expression+" = ?", value)
+	}
+
+	if err = query.Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Find(&result)", err)
 		return
 	}
 
+	for i := range result {
+		if result[i], err = repo.open(ctx, result[i]); err != nil {
+			repo.log.Error(ctx, "repo.open(ctx, result[i])", err)
+			return nil, err
+		}
+	}
+
 	return
 }
 
 func (repo userMysqlRepository) Create(ctx context.Context, user models.User) (result models.User, err error) {
-	if err = repo.DB.WithContext(ctx).Create(&user).Error; err != nil {
-		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Create(&user)", err)
+	sealed, err := repo.seal(ctx, user)
+	if err != nil {
+		repo.log.Error(ctx, "repo.seal(ctx, user)", err)
+		return
+	}
+
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sealed).Error; err != nil {
+			if isDuplicate(err) {
+				return domain.ErrEmailTaken
+			}
+
+			repo.log.Error(ctx, "tx.Create(&sealed)", err)
+			return err
+		}
+
+		user.ID = sealed.ID
+		changes := models.DiffUser(models.User{}, user)
+		if err := repo.audit(ctx, tx, user.ID, models.AuditActionCreate, changes); err != nil {
+			return err
+		}
+
+		return repo.event(ctx, tx, models.EventUserCreated, models.AuditActionCreate, unseal(sealed, user), changes)
+	})
+	if err != nil {
 		return
 	}
 
-	result = user
+	result = unseal(sealed, user)
 	return
 }
 
 func (repo userMysqlRepository) Update(ctx context.Context, user models.User) (result models.User, err error) {
-	if err = repo.DB.WithContext(ctx).Save(&user).Error; err != nil {
-		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Save(&user)", err)
+	sealed, err := repo.seal(ctx, user)
+	if err != nil {
+		repo.log.Error(ctx, "repo.seal(ctx, user)", err)
+		return
+	}
+
+	var before models.User
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) (err error) {
+		if before, err = repo.lock(ctx, tx, user.ID); err != nil {
+			return err
+		}
+
+		if err := repo.write(ctx, tx, before, user, sealed); err != nil {
+			return err
+		}
+
+		changes := models.DiffUser(before, user)
+		if len(changes) == 0 {
+			return nil
+		}
+
+		if err := repo.audit(ctx, tx, user.ID, models.AuditActionUpdate, changes); err != nil {
+			return err
+		}
+
+		return repo.event(ctx, tx, models.EventUserUpdated, models.AuditActionUpdate, user, changes)
+	})
+	if err != nil {
+		return
+	}
+
+	result = unseal(sealed, user)
+	result.LastLoginAt, result.LastSeenAt = before.LastLoginAt, before.LastSeenAt
+	return
+}
+
+// write update the column where user differ from the locked row before, so
+// a column the caller did not change is never written back from a possibly
+// stale copy
+func (repo userMysqlRepository) write(ctx context.Context, tx *gorm.DB, before, user, sealed models.User) error {
+	columns, err := changedColumns(ctx, tx, before, user, sealed)
+	if err != nil {
+		repo.log.Error(ctx, "changedColumns(ctx, tx, before, user, sealed)", err)
+		return err
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(columns).Error; err != nil {
+		if isDuplicate(err) {
+			return domain.ErrEmailTaken
+		}
+
+		repo.log.Error(ctx, "tx.Model(&models.User{}).Updates(columns)", err)
+		return err
+	}
+
+	return nil
+}
+
+// ignoredColumns are never written by write, the activity column belong to
+// the activity flush, the sealing column follow the personal column and the
+// creation time is set once
+var ignoredColumns = map[string]bool{
+	"id":            true,
+	"tenant_id":     true,
+	"created_at":    true,
+	"last_login_at": true,
+	"last_seen_at":  true,
+	"email_index":   true,
+	"key_id":        true,
+	"data_key":      true,
+}
+
+// personalColumns are sealed with one data key so they are written together
+var personalColumns = map[string]bool{
+	models.FieldEmail:     true,
+	models.FieldFirstName: true,
+	models.FieldLastName:  true,
+}
+
+// changedColumns map the column where user differ from before to their value,
+// the personal column are taken from sealed
+func changedColumns(ctx context.Context, tx *gorm.DB, before, user, sealed models.User) (columns map[string]interface{}, err error) {
+	statement := &gorm.Statement{DB: tx}
+	if err = statement.Parse(&models.User{}); err != nil {
+		return
+	}
+
+	columns = map[string]interface{}{}
+	beforeValue, userValue := reflect.ValueOf(before), reflect.ValueOf(user)
+
+	personal := false
+	for _, field := range statement.Schema.Fields {
+		if field.DBName == "" || ignoredColumns[field.DBName] {
+			continue
+		}
+
+		old, _ := field.ValueOf(ctx, beforeValue)
+		value, _ := field.ValueOf(ctx, userValue)
+		if reflect.DeepEqual(old, value) {
+			continue
+		}
+
+		if personalColumns[field.DBName] {
+			personal = true
+			continue
+		}
+
+		columns[field.DBName] = value
+	}
+
+	if personal {
+		columns["email"] = sealed.Email
+		columns["email_index"] = sealed.EmailIndex
+		columns["firstname"] = sealed.FirstName
+		columns["lastname"] = sealed.LastName
+		columns["key_id"] = sealed.KeyID
+		columns["data_key"] = sealed.DataKey
+	}
+
+	return
+}
+
+// lock read and decrypt the user for the rest of the transaction
+func (repo userMysqlRepository) lock(ctx context.Context, tx *gorm.DB, id uint) (user models.User, err error) {
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&user).Error; err != nil {
+		repo.log.Error(ctx, "tx.Where('id = ?', id).First(&user)", err)
+		return
+	}
+
+	if user, err = repo.open(ctx, user); err != nil {
+		repo.log.Error(ctx, "repo.open(ctx, user)", err)
 		return
 	}
 
-	result = user
 	return
 }
 
@@ -53,14 +271,349 @@ func (repo userMysqlRepository) GetByID(ctx context.Context, id uint) (result mo
 		return
 	}
 
+	if result, err = repo.open(ctx, result); err != nil {
+		repo.log.Error(ctx, "repo.open(ctx, result)", err)
+		return
+	}
+
 	return
 }
 
 func (repo userMysqlRepository) Delete(ctx context.Context, id uint) (err error) {
-	if err = repo.DB.WithContext(ctx).Where("id = ?", id).Delete(&models.User{}).Error; err != nil {
-		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('id = ?', id).Delete(&models.User{})", err)
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := repo.lock(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ?", id).Delete(&models.User{}).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('id = ?', id).Delete(&models.User{})", err)
+			return err
+		}
+
+		if err := repo.audit(ctx, tx, id, models.AuditActionDelete, models.DiffUser(before, models.User{})); err != nil {
+			return err
+		}
+
+		return repo.event(ctx, tx, models.EventUserDeleted, models.AuditActionDelete, before, nil)
+	})
+
+	return
+}
+
+// FetchHistory include the history of every user merged into userID
+func (repo userMysqlRepository) FetchHistory(ctx context.Context, userID uint, page, limit int) (result []models.UserAudit, total int64, err error) {
+	merged := repo.DB.WithContext(ctx).Model(&models.UserMerge{}).Select("source_id").Where("target_id = ?", userID)
+	query := repo.DB.WithContext(ctx).Model(&models.UserAudit{}).Where("user_id = ? OR user_id IN (?)", userID, merged)
+
+	if err = query.Count(&total).Error; err != nil {
+		repo.log.Error(ctx, "query.Count(&total)", err)
+		return
+	}
+
+	if err = query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Order('id DESC').Find(&result)", err)
 		return
 	}
 
 	return
 }
+
+func (repo userMysqlRepository) FetchHistoryChain(ctx context.Context, userID uint) (result []models.UserAudit, err error) {
+	if err = repo.DB.WithContext(ctx).Where("user_id = ?", userID).Order("id ASC").Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('user_id = ?', userID).Find(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo userMysqlRepository) Erase(ctx context.Context, id uint) (err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&models.User{}).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('id = ?', id).First(&models.User{})", err)
+			return err
+		}
+
+		if err := tx.Where("id = ?", id).Delete(&models.User{}).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('id = ?', id).Delete(&models.User{})", err)
+			return err
+		}
+
+		if err := repo.eraseHistory(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return repo.event(ctx, tx, models.EventUserDeleted, models.AuditActionErase, models.User{ID: id}, nil)
+	})
+
+	return
+}
+
+// eraseHistory replace the audit chain of id, and of every user merged into
+// it, by a single erase entry and drop the tombstone pointing to id
+func (repo userMysqlRepository) eraseHistory(ctx context.Context, tx *gorm.DB, id uint) error {
+	var merged []uint
+	if err := tx.Model(&models.UserMerge{}).Where("target_id = ?", id).Pluck("source_id", &merged).Error; err != nil {
+		repo.log.Error(ctx, "tx.Model(&models.UserMerge{}).Pluck('source_id')", err)
+		return err
+	}
+
+	// the history of merged user is part of the history of id
+	if err := tx.Where("user_id IN ?", append(merged, id)).Delete(&models.UserAudit{}).Error; err != nil {
+		repo.log.Error(ctx, "tx.Where('user_id IN ?').Delete(&models.UserAudit{})", err)
+		return err
+	}
+
+	if err := tx.Where("target_id = ?", id).Delete(&models.UserMerge{}).Error; err != nil {
+		repo.log.Error(ctx, "tx.Where('target_id = ?', id).Delete(&models.UserMerge{})", err)
+		return err
+	}
+
+	return repo.audit(ctx, tx, id, models.AuditActionErase, models.AuditChanges{})
+}
+
+// errDryRun roll back a dry run merge once everything was applied
+var errDryRun = errors.New("dry run")
+
+// Merge move every dependent record of source to target, save the resolved
+// target and replace source by a tombstone in one transaction. Audit entries
+// are hash chained per user so they are not rewritten, both chains get a merge
+// entry instead and FetchHistory of target read the history of source
+func (repo userMysqlRepository) Merge(ctx context.Context, sourceID uint, target models.User, dryRun bool) (result models.MergeResult, err error) {
+	result = models.MergeResult{SourceID: sourceID, Moved: map[string]int64{}, DryRun: dryRun}
+
+	sealed, err := repo.seal(ctx, target)
+	if err != nil {
+		repo.log.Error(ctx, "repo.seal(ctx, target)", err)
+		return
+	}
+
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := repo.lock(ctx, tx, target.ID)
+		if err != nil {
+			return err
+		}
+
+		source, err := repo.lock(ctx, tx, sourceID)
+		if err != nil {
+			return err
+		}
+
+		// source go first so target can take over its email
+		if err := tx.Where("id = ?", sourceID).Delete(&models.User{}).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('id = ?', sourceID).Delete(&models.User{})", err)
+			return err
+		}
+
+		if err := repo.write(ctx, tx, before, target, sealed); err != nil {
+			return err
+		}
+
+		for _, dependent := range []struct {
+			name string
+			move func(tx *gorm.DB, sourceID, targetID uint) (int64, error)
+		}{
+			{"memberships", repo.mergeMemberships},
+			{"preferences", repo.mergePreferences},
+			{"invitations", repo.mergeInvitations},
+			{"logins", repo.mergeLogins},
+			{"merges", repo.mergeTombstones},
+		} {
+			moved, err := dependent.move(tx, sourceID, target.ID)
+			if err != nil {
+				repo.log.Error(ctx, "repo.Merge "+dependent.name, err)
+				return err
+			}
+
+			result.Moved[dependent.name] = moved
+		}
+
+		var audits int64
+		if err := tx.Model(&models.UserAudit{}).Where("user_id = ?", sourceID).Count(&audits).Error; err != nil {
+			repo.log.Error(ctx, "tx.Model(&models.UserAudit{}).Count(&audits)", err)
+			return err
+		}
+		result.Moved["audit"] = audits
+
+		sourceChanges := append(models.DiffUser(source, models.User{}), models.AuditChange{Field: "merged_into", After: target.ID})
+		if err := repo.audit(ctx, tx, sourceID, models.AuditActionMerge, sourceChanges); err != nil {
+			return err
+		}
+
+		targetChanges := append(models.DiffUser(before, target), models.AuditChange{Field: "merged_from", After: sourceID})
+		if err := repo.audit(ctx, tx, target.ID, models.AuditActionMerge, targetChanges); err != nil {
+			return err
+		}
+
+		if err := repo.event(ctx, tx, models.EventUserDeleted, models.AuditActionMerge, source, nil); err != nil {
+			return err
+		}
+
+		if err := repo.event(ctx, tx, models.EventUserUpdated, models.AuditActionMerge, target, targetChanges); err != nil {
+			return err
+		}
+
+		tombstone := models.UserMerge{SourceID: sourceID, TargetID: target.ID}
+		if actorID, ok := ctx.Value("user-id").(uint); ok {
+			tombstone.ActorID = actorID
+		}
+
+		if err := tx.Create(&tombstone).Error; err != nil {
+			repo.log.Error(ctx, "tx.Create(&tombstone)", err)
+			return err
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	result.User = unseal(sealed, target)
+	return
+}
+
+// mergeMemberships move the membership of source, where both are member the
+// target keep the higher of the two role
+func (repo userMysqlRepository) mergeMemberships(tx *gorm.DB, sourceID, targetID uint) (moved int64, err error) {
+	var memberships []organizationModels.Membership
+	if err = tx.Where("user_id = ?", sourceID).Find(&memberships).Error; err != nil {
+		return
+	}
+
+	for _, membership := range memberships {
+		var existing organizationModels.Membership
+		err = tx.Where("organization_id = ? AND user_id = ?", membership.OrganizationID, targetID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Model(&organizationModels.Membership{}).
+				Where("organization_id = ? AND user_id = ?", membership.OrganizationID, sourceID).
+				Update("user_id", targetID).Error
+			if err != nil {
+				return
+			}
+
+			moved++
+			continue
+		}
+
+		if err != nil {
+			return
+		}
+
+		if membership.HasRole(existing.Role) && membership.Role != existing.Role {
+			err = tx.Model(&organizationModels.Membership{}).
+				Where("organization_id = ? AND user_id = ?", membership.OrganizationID, targetID).
+				Update("role", membership.Role).Error
+			if err != nil {
+				return
+			}
+		}
+
+		err = tx.Where("organization_id = ? AND user_id = ?", membership.OrganizationID, sourceID).
+			Delete(&organizationModels.Membership{}).Error
+		if err != nil {
+			return
+		}
+
+		moved++
+	}
+
+	return
+}
+
+// mergePreferences move the preference of source for the key target has not
+// set, the value of target win otherwise
+func (repo userMysqlRepository) mergePreferences(tx *gorm.DB, sourceID, targetID uint) (moved int64, err error) {
+	var keys []string
+	if err = tx.Model(&preferenceModels.Preference{}).Where("user_id = ?", targetID).Pluck("key", &keys).Error; err != nil {
+		return
+	}
+
+	query := tx.Model(&preferenceModels.Preference{}).Where("user_id = ?", sourceID)
+	if len(keys) > 0 {
+		query = query.Where("`key` NOT IN ?", keys)
+	}
+
+	update := query.Update("user_id", targetID)
+	if err = update.Error; err != nil {
+		return
+	}
+
+	moved = update.RowsAffected
+	err = tx.Where("user_id = ?", sourceID).Delete(&preferenceModels.Preference{}).Error
+	return
+}
+
+func (repo userMysqlRepository) mergeInvitations(tx *gorm.DB, sourceID, targetID uint) (moved int64, err error) {
+	update := tx.Model(&invitationModels.Invitation{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	return update.RowsAffected, update.Error
+}
+
+func (repo userMysqlRepository) mergeLogins(tx *gorm.DB, sourceID, targetID uint) (moved int64, err error) {
+	update := tx.Model(&loginModels.Login{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	return update.RowsAffected, update.Error
+}
+
+// mergeTombstones point user previously merged into source to target so a
+// tombstone never redirect to another tombstone
+func (repo userMysqlRepository) mergeTombstones(tx *gorm.DB, sourceID, targetID uint) (moved int64, err error) {
+	update := tx.Model(&models.UserMerge{}).Where("target_id = ?", sourceID).Update("target_id", targetID)
+	return update.RowsAffected, update.Error
+}
+
+func (repo userMysqlRepository) GetMerge(ctx context.Context, sourceID uint) (result models.UserMerge, err error) {
+	if err = repo.DB.WithContext(ctx).Where("source_id = ?", sourceID).First(&result).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('source_id = ?', sourceID).First(&result)", err)
+		return
+	}
+
+	return
+}
+
+// audit append an entry to the user audit chain inside the running transaction
+func (repo userMysqlRepository) audit(ctx context.Context, tx *gorm.DB, userID uint, action string, changes models.AuditChanges) error {
+	if action == models.AuditActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	var last models.UserAudit
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).Order("id DESC").First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		repo.log.Error(ctx, "tx.Where('user_id = ?', userID).First(&last)", err)
+		return err
+	}
+
+	entry := models.UserAudit{
+		UserID:    userID,
+		Action:    action,
+		Changes:   changes,
+		PrevHash:  last.Hash,
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+
+	if actorID, ok := ctx.Value("user-id").(uint); ok {
+		entry.ActorID = actorID
+	}
+
+	if traceID, ok := ctx.Value("trace-id").(string); ok {
+		entry.TraceID = traceID
+	}
+
+	entry.Hash = entry.ComputeHash()
+
+	if err := tx.Create(&entry).Error; err != nil {
+		repo.log.Error(ctx, "tx.Create(&entry)", err)
+		return err
+	}
+
+	return nil
+}
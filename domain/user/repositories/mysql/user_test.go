@@ -0,0 +1,431 @@
+package repository_mysql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	invitationModels "prototype/domain/invitation/models"
+	organizationModels "prototype/domain/organization/models"
+	loginModels "prototype/domain/user/logins/models"
+	"prototype/domain/user/models"
+	preferenceModels "prototype/domain/user/preferences/models"
+	"prototype/lib/crypt"
+	"prototype/lib/log"
+	"prototype/lib/outbox"
+	"prototype/lib/tenant"
+	"prototype/lib/validation"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTenantDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&models.User{}, &models.UserAudit{}, &models.UserMerge{}, &models.UserArchive{},
+		&organizationModels.Membership{}, &preferenceModels.Preference{}, &invitationModels.Invitation{}, &loginModels.Login{}, &outbox.Event{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func Test_userMysqlRepository_TenantIsolation(t *testing.T) {
+	repo := NewMysqlUserRepo(setupTenantDB(t), nil, log.NewLog())
+
+	tenantA := tenant.WithID(context.Background(), 1)
+	tenantB := tenant.WithID(context.Background(), 2)
+
+	userA, err := repo.Create(tenantA, models.User{Email: "same@mail.com", Username: "a", Status: models.StatusActive})
+	if err != nil {
+		t.Fatalf("repo.Create() tenant A error = %v", err)
+	}
+
+	// uniqueness is per tenant, the same email is free in another tenant
+	userB, err := repo.Create(tenantB, models.User{Email: "same@mail.com", Username: "b", Status: models.StatusActive})
+	if err != nil {
+		t.Fatalf("repo.Create() tenant B error = %v", err)
+	}
+
+	t.Run("fetch only return own tenant", func(t *testing.T) {
+		users, err := repo.Fetch(tenantB, models.UserFilter{})
+		if err != nil {
+			t.Fatalf("repo.Fetch() error = %v", err)
+		}
+
+		if len(users) != 1 || users[0].ID != userB.ID {
+			t.Errorf("repo.Fetch() = %v, want only user %d", users, userB.ID)
+		}
+	})
+
+	t.Run("get by id of another tenant is not found", func(t *testing.T) {
+		if _, err := repo.GetByID(tenantB, userA.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("repo.GetByID() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+	})
+
+	t.Run("update of another tenant fail", func(t *testing.T) {
+		userA.Username = "hijacked"
+		if _, err := repo.Update(tenantB, userA); err == nil {
+			t.Error("repo.Update() error = nil, want an error")
+		}
+
+		stored, err := repo.GetByID(tenantA, userA.ID)
+		if err != nil || stored.Username != "a" {
+			t.Errorf("repo.GetByID() = %v, %v, want unchanged username", stored.Username, err)
+		}
+	})
+
+	t.Run("delete of another tenant fail", func(t *testing.T) {
+		if err := repo.Delete(tenantB, userA.ID); err == nil {
+			t.Error("repo.Delete() error = nil, want an error")
+		}
+
+		if _, err := repo.GetByID(tenantA, userA.ID); err != nil {
+			t.Errorf("repo.GetByID() error = %v, want user still there", err)
+		}
+	})
+
+	t.Run("history of another tenant is empty", func(t *testing.T) {
+		entries, total, err := repo.FetchHistory(tenantB, userA.ID, 1, 20)
+		if err != nil || total != 0 || len(entries) != 0 {
+			t.Errorf("repo.FetchHistory() = %v, %d, %v, want nothing", entries, total, err)
+		}
+	})
+
+	t.Run("query without tenant is rejected", func(t *testing.T) {
+		if _, err := repo.Fetch(context.Background(), models.UserFilter{}); !errors.Is(err, tenant.ErrMissing) {
+			t.Errorf("repo.Fetch() error = %v, want %v", err, tenant.ErrMissing)
+		}
+	})
+}
+
+func Test_userMysqlRepository_Merge(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewMysqlUserRepo(db, nil, log.NewLog())
+
+	ctx := tenant.WithID(context.Background(), 1)
+
+	target, _ := repo.Create(ctx, models.User{Email: "target@mail.com", Username: "target", Status: models.StatusActive})
+	source, _ := repo.Create(ctx, models.User{Email: "source@mail.com", Username: "source", Status: models.StatusActive})
+
+	db.WithContext(ctx).Create(&[]organizationModels.Membership{
+		{OrganizationID: 1, UserID: target.ID, Role: organizationModels.RoleMember},
+		{OrganizationID: 1, UserID: source.ID, Role: organizationModels.RoleAdmin},
+		{OrganizationID: 2, UserID: source.ID, Role: organizationModels.RoleMember},
+	})
+	db.WithContext(ctx).Create(&[]preferenceModels.Preference{
+		{UserID: target.ID, Key: "theme", Value: `"dark"`, Version: 1},
+		{UserID: source.ID, Key: "theme", Value: `"light"`, Version: 1},
+		{UserID: source.ID, Key: "language", Value: `"id"`, Version: 1},
+	})
+
+	survivor := target
+	survivor.Email = source.Email
+
+	t.Run("dry run keep everything", func(t *testing.T) {
+		result, err := repo.Merge(ctx, source.ID, survivor, true)
+		if err != nil {
+			t.Fatalf("repo.Merge() error = %v", err)
+		}
+
+		if result.Moved["memberships"] != 2 || result.Moved["preferences"] != 1 {
+			t.Errorf("repo.Merge() moved = %v, want 2 memberships and 1 preference", result.Moved)
+		}
+
+		if _, err := repo.GetByID(ctx, source.ID); err != nil {
+			t.Errorf("repo.GetByID() error = %v, want source kept", err)
+		}
+	})
+
+	t.Run("merge move dependent record", func(t *testing.T) {
+		if _, err := repo.Merge(ctx, source.ID, survivor, false); err != nil {
+			t.Fatalf("repo.Merge() error = %v", err)
+		}
+
+		if _, err := repo.GetByID(ctx, source.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("repo.GetByID() error = %v, want source gone", err)
+		}
+
+		stored, _ := repo.GetByID(ctx, target.ID)
+		if stored.Email != "source@mail.com" {
+			t.Errorf("repo.GetByID() email = %v, want email of source", stored.Email)
+		}
+
+		var memberships []organizationModels.Membership
+		db.WithContext(ctx).Where("user_id = ?", target.ID).Order("organization_id").Find(&memberships)
+		if len(memberships) != 2 || memberships[0].Role != organizationModels.RoleAdmin {
+			t.Errorf("memberships = %v, want both organization with the higher role kept", memberships)
+		}
+
+		var preferences []preferenceModels.Preference
+		db.WithContext(ctx).Where("user_id = ?", target.ID).Order("`key`").Find(&preferences)
+		if len(preferences) != 2 || preferences[1].Value != `"dark"` {
+			t.Errorf("preferences = %v, want language moved and theme of target kept", preferences)
+		}
+
+		merge, err := repo.GetMerge(ctx, source.ID)
+		if err != nil || merge.TargetID != target.ID {
+			t.Errorf("repo.GetMerge() = %v, %v, want tombstone to %d", merge, err, target.ID)
+		}
+
+		_, total, _ := repo.FetchHistory(ctx, target.ID, 1, 20)
+		if total != 4 {
+			t.Errorf("repo.FetchHistory() total = %d, want history of both user", total)
+		}
+	})
+}
+
+func Test_userMysqlRepository_Erase(t *testing.T) {
+	repo := NewMysqlUserRepo(setupTenantDB(t), nil, log.NewLog())
+
+	ctx := tenant.WithID(context.Background(), 1)
+
+	user, _ := repo.Create(ctx, models.User{Email: "erase@mail.com", Username: "erase", Status: models.StatusActive})
+	user.Username = "renamed"
+	repo.Update(ctx, user)
+
+	if err := repo.Erase(ctx, user.ID); err != nil {
+		t.Fatalf("repo.Erase() error = %v", err)
+	}
+
+	if _, err := repo.GetByID(ctx, user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("repo.GetByID() error = %v, want user gone", err)
+	}
+
+	entries, err := repo.FetchHistoryChain(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("repo.FetchHistoryChain() error = %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Action != models.AuditActionErase || len(entries[0].Changes) != 0 {
+		t.Errorf("repo.FetchHistoryChain() = %v, want a single erase entry without change", entries)
+	}
+}
+
+func newTestCipher(t *testing.T, file crypt.KeyringFile) *crypt.FieldCipher {
+	keyring, indexKey, err := crypt.NewLocalKeyring(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return crypt.NewFieldCipher(keyring, indexKey)
+}
+
+func Test_userMysqlRepository_Encryption(t *testing.T) {
+	db := setupTenantDB(t)
+	ctx := tenant.WithID(context.Background(), 1)
+
+	file, err := crypt.GenerateKeyringFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewMysqlUserRepo(db, newTestCipher(t, file), log.NewLog())
+
+	// written before encryption was enabled
+	legacy := models.User{Email: "legacy@mail.com", Username: "legacy", FirstName: "Old", Status: models.StatusActive}
+	if err := db.WithContext(ctx).Create(&legacy).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := repo.Create(ctx, models.User{Email: "user@mail.com", Username: "user", FirstName: "Jane", LastName: "Doe", Status: models.StatusActive})
+	if err != nil {
+		t.Fatalf("repo.Create() error = %v", err)
+	}
+
+	if user.Email != "user@mail.com" || user.FirstName != "Jane" {
+		t.Errorf("repo.Create() = %+v, want plaintext", user)
+	}
+
+	var stored models.User
+	db.WithContext(ctx).Where("id = ?", user.ID).First(&stored)
+	if stored.Email == user.Email || stored.FirstName == user.FirstName || stored.KeyID != file.Current || stored.EmailIndex == nil {
+		t.Errorf("stored user = %+v, want encrypted with blind index", stored)
+	}
+
+	t.Run("email lookup use the blind index", func(t *testing.T) {
+		for _, email := range []string{"USER@mail.com", "legacy@mail.com"} {
+			users, err := repo.Fetch(ctx, models.UserFilter{Email: email})
+			if err != nil || len(users) != 1 {
+				t.Fatalf("repo.Fetch(%s) = %v, %v", email, users, err)
+			}
+		}
+	})
+
+	t.Run("duplicate email is rejected", func(t *testing.T) {
+		if _, err := repo.Create(ctx, models.User{Email: "user@mail.com", Username: "again"}); err == nil {
+			t.Error("repo.Create() duplicate error = nil")
+		}
+	})
+
+	t.Run("reencrypt after rotation", func(t *testing.T) {
+		previous := file.Current
+		file.Keys["rotated"] = file.Keys[previous]
+		file.Current = "rotated"
+		rotated := NewMysqlUserRepo(db, newTestCipher(t, file), log.NewLog())
+
+		progress, err := rotated.Reencrypt(ctx, models.Reencryption{}, 10)
+		if err != nil || progress.Sealed != 2 {
+			t.Fatalf("repo.Reencrypt() = %+v, %v, want 2 sealed", progress, err)
+		}
+
+		if progress, _ := rotated.Reencrypt(ctx, models.Reencryption{}, 10); progress.Sealed != 0 {
+			t.Errorf("repo.Reencrypt() again = %+v, want 0 sealed", progress)
+		}
+
+		got, err := rotated.GetByID(ctx, legacy.ID)
+		if err != nil || got.Email != legacy.Email || got.FirstName != legacy.FirstName || got.KeyID != "rotated" {
+			t.Errorf("repo.GetByID() = %+v, %v", got, err)
+		}
+
+		// history is recorded in plaintext diff only and untouched by rotation
+		history, _, _ := rotated.FetchHistory(ctx, user.ID, 1, 10)
+		if len(history) != 1 {
+			t.Errorf("repo.FetchHistory() = %d entries, want 1", len(history))
+		}
+	})
+
+	t.Run("reencrypt skip a row which can not be opened", func(t *testing.T) {
+		broken, err := repo.Create(ctx, models.User{Email: "broken@mail.com", Username: "broken", FirstName: "Broken"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.WithContext(ctx).Model(&models.User{}).Where("id = ?", broken.ID).UpdateColumn("data_key", "corrupted")
+
+		file.Keys["third"] = file.Keys[file.Current]
+		file.Current = "third"
+		third := NewMysqlUserRepo(db, newTestCipher(t, file), log.NewLog())
+
+		progress, err := third.Reencrypt(ctx, models.Reencryption{}, 10)
+		if err != nil || progress.Sealed != 2 || progress.Skipped != 1 {
+			t.Fatalf("repo.Reencrypt() = %+v, %v, want 2 sealed and 1 skipped", progress, err)
+		}
+
+		// the next batch start after the skipped row instead of looking at it again
+		progress, err = third.Reencrypt(ctx, models.Reencryption{}, 1)
+		if err != nil || progress.Skipped != 1 || progress.UserID != broken.ID {
+			t.Fatalf("repo.Reencrypt() = %+v, %v, want the broken row skipped", progress, err)
+		}
+
+		if next, _ := third.Reencrypt(ctx, progress, 1); next != progress {
+			t.Errorf("repo.Reencrypt() after the skipped row = %+v, want nothing more looked at", next)
+		}
+
+		got, err := third.GetByID(ctx, user.ID)
+		if err != nil || got.Email != user.Email || got.KeyID != "third" {
+			t.Errorf("repo.GetByID() = %+v, %v", got, err)
+		}
+	})
+}
+
+func Test_userMysqlRepository_Update(t *testing.T) {
+	db := setupTenantDB(t)
+	ctx := tenant.WithID(context.Background(), 1)
+
+	file, err := crypt.GenerateKeyringFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewMysqlUserRepo(db, newTestCipher(t, file), log.NewLog())
+
+	stale, err := repo.Create(ctx, models.User{Email: "user@mail.com", Username: "user", FirstName: "Jane", LastName: "Doe", Status: models.StatusActive})
+	if err != nil {
+		t.Fatalf("repo.Create() error = %v", err)
+	}
+
+	// written by the activity flush after the copy was read
+	seenAt := time.Now().Truncate(time.Second)
+	if err := db.WithContext(ctx).Model(&models.User{}).Where("id = ?", stale.ID).Update("last_seen_at", seenAt).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("unchanged column are not written back", func(t *testing.T) {
+		update := stale
+		update.Username = "renamed"
+
+		got, err := repo.Update(ctx, update)
+		if err != nil {
+			t.Fatalf("repo.Update() error = %v", err)
+		}
+
+		if got.LastSeenAt == nil || !got.LastSeenAt.Equal(seenAt) {
+			t.Errorf("repo.Update() last seen = %v, want %v", got.LastSeenAt, seenAt)
+		}
+
+		stored, _ := repo.GetByID(ctx, stale.ID)
+		if stored.Username != "renamed" || stored.FirstName != "Jane" || stored.LastSeenAt == nil || !stored.LastSeenAt.Equal(seenAt) {
+			t.Errorf("stored user = %+v", stored)
+		}
+	})
+
+	t.Run("personal column are sealed again together", func(t *testing.T) {
+		update := stale
+		update.Username = "renamed"
+		update.FirstName = "John"
+
+		if _, err := repo.Update(ctx, update); err != nil {
+			t.Fatalf("repo.Update() error = %v", err)
+		}
+
+		stored, err := repo.GetByID(ctx, stale.ID)
+		if err != nil || stored.Email != "user@mail.com" || stored.FirstName != "John" || stored.LastName != "Doe" {
+			t.Errorf("repo.GetByID() = %+v, %v", stored, err)
+		}
+	})
+}
+
+func Test_userMysqlRepository_Events(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewMysqlUserRepo(db, nil, log.NewLog())
+	ctx := tenant.WithID(context.Background(), 1)
+
+	schema, err := validation.NewSchema("../../../../schema/user-event.v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, _ := repo.Create(ctx, models.User{Email: "event@mail.com", Username: "event", Status: models.StatusActive})
+	repo.Create(ctx, models.User{Email: "event@mail.com", Username: "duplicate"})
+
+	user.Username = "renamed"
+	repo.Update(ctx, user)
+	repo.Update(ctx, user)
+	repo.Delete(ctx, user.ID)
+
+	var events []outbox.Event
+	db.WithContext(ctx).Order("id ASC").Find(&events)
+
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := schema.Validate("", payload); err != nil {
+			t.Errorf("payload %s = %v", event.Payload, err)
+		}
+
+		if strings.Contains(event.Payload, "event@mail.com") {
+			t.Errorf("payload %s carry the email", event.Payload)
+		}
+	}
+
+	want := []string{models.EventUserCreated, models.EventUserUpdated, models.EventUserDeleted}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("outbox = %v, want %v without the rolled back create and the empty update", types, want)
+	}
+}
@@ -0,0 +1,306 @@
+package repository_redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	domain "prototype/domain/user"
+	"prototype/domain/user/models"
+	"prototype/lib/crypt"
+	"prototype/lib/log"
+	"prototype/lib/redis"
+	"prototype/lib/tenant"
+	"sync/atomic"
+	"time"
+)
+
+// fieldCache is the field name sealing a cached user
+const fieldCache = "cache"
+
+// CacheConfig of the user cache, an entry live TTL plus a random part of
+// Jitter so entry written together do not expire together. A miss is loaded
+// by the holder of a lock living at most LockTTL while other wait for it,
+// and Redis is skipped for Cooldown after it failed
+type CacheConfig struct {
+	TTL      time.Duration
+	Jitter   time.Duration
+	LockTTL  time.Duration
+	Cooldown time.Duration
+}
+
+// userCacheRepository cache GetByID of the wrapped repository, every other
+// method go to the wrapped repository and invalidate the user it changed.
+// Column written outside of this repository like last_seen_at may lag up
+// to TTL
+type userCacheRepository struct {
+	domain.IUserMysqlRepository
+
+	client redis.IRedis
+	cipher *crypt.FieldCipher
+	config CacheConfig
+	log    log.ILogs
+
+	// downUntil is the unix nano time Redis is skipped until
+	downUntil *int64
+}
+
+// cachedUser keep the tenant of the user which is not serialized by its
+// json tag, the whole value is sealed when a cipher is configured
+type cachedUser struct {
+	models.User
+	TenantID uint `json:"tenant_id"`
+}
+
+type envelope struct {
+	KeyID   string `json:"key_id,omitempty"`
+	DataKey string `json:"data_key,omitempty"`
+	Value   string `json:"value"`
+}
+
+// NewCachedUserRepo wrap repo with a read-through cache, cached user are
+// sealed with cipher so Redis never hold personal data in plaintext
+func NewCachedUserRepo(repo domain.IUserMysqlRepository, client redis.IRedis, cipher *crypt.FieldCipher, config CacheConfig, log log.ILogs) domain.IUserMysqlRepository {
+	return userCacheRepository{
+		IUserMysqlRepository: repo,
+		client:               client,
+		cipher:               cipher,
+		config:               config,
+		log:                  log,
+		downUntil:            new(int64),
+	}
+}
+
+func key(tenantID, id uint) string {
+	return fmt.Sprintf("user:%d:%d", tenantID, id)
+}
+
+func (repo userCacheRepository) available() bool {
+	return time.Now().UnixNano() >= atomic.LoadInt64(repo.downUntil)
+}
+
+// fail report a Redis error and skip Redis for the cooldown
+func (repo userCacheRepository) fail(ctx context.Context, actName string, err error) {
+	atomic.StoreInt64(repo.downUntil, time.Now().Add(repo.config.Cooldown).UnixNano())
+	repo.log.Warning(ctx, actName, err.Error())
+}
+
+func (repo userCacheRepository) GetByID(ctx context.Context, id uint) (result models.User, err error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok || !repo.available() || domain.IsUncached(ctx) {
+		return repo.IUserMysqlRepository.GetByID(ctx, id)
+	}
+
+	cacheKey := key(tenantID, id)
+
+	result, hit, err := repo.get(ctx, cacheKey)
+	if err != nil {
+		repo.fail(ctx, "repo.get Error", err)
+		return repo.IUserMysqlRepository.GetByID(ctx, id)
+	}
+
+	if hit {
+		return
+	}
+
+	// only the lock holder load the user, other wait for the value it write
+	locked, err := repo.client.SetNX(ctx, cacheKey+":lock", 1, repo.config.LockTTL).Result()
+	if err != nil {
+		repo.fail(ctx, "repo.client.SetNX Error", err)
+		return repo.IUserMysqlRepository.GetByID(ctx, id)
+	}
+
+	if !locked {
+		if result, hit = repo.wait(ctx, cacheKey); hit {
+			return
+		}
+
+		return repo.IUserMysqlRepository.GetByID(ctx, id)
+	}
+
+	defer repo.client.Del(ctx, cacheKey+":lock")
+
+	result, err = repo.IUserMysqlRepository.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+
+	if err := repo.set(ctx, cacheKey, result); err != nil {
+		repo.fail(ctx, "repo.set Error", err)
+	}
+
+	return
+}
+
+// wait poll the key until the lock holder wrote it or released the lock
+// without writing, like for a user not found
+func (repo userCacheRepository) wait(ctx context.Context, cacheKey string) (models.User, bool) {
+	deadline := time.Now().Add(repo.config.LockTTL)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return models.User{}, false
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		result, hit, err := repo.get(ctx, cacheKey)
+		if err != nil {
+			repo.fail(ctx, "repo.get Error", err)
+			return models.User{}, false
+		}
+
+		if hit {
+			return result, true
+		}
+
+		if locked, err := repo.client.Exists(ctx, cacheKey+":lock").Result(); err != nil || locked == 0 {
+			return models.User{}, false
+		}
+	}
+
+	return models.User{}, false
+}
+
+// get read a cached user, an entry which can not be opened anymore like
+// one sealed by a removed key is a miss
+func (repo userCacheRepository) get(ctx context.Context, cacheKey string) (result models.User, hit bool, err error) {
+	raw, err := repo.client.Get(ctx, cacheKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return result, false, nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	var entry envelope
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		repo.log.Warning(ctx, "json.Unmarshal Error", err.Error())
+		return result, false, nil
+	}
+
+	value := entry.Value
+	if entry.KeyID != "" {
+		if repo.cipher == nil {
+			return result, false, nil
+		}
+
+		dataKey, err := repo.cipher.OpenDataKey(ctx, entry.KeyID, entry.DataKey)
+		if err == nil {
+			value, err = dataKey.Open(fieldCache, entry.Value)
+		}
+
+		if err != nil {
+			repo.log.Warning(ctx, "repo.cipher.OpenDataKey Error", err.Error())
+			return result, false, nil
+		}
+	}
+
+	var cached cachedUser
+	if err := json.Unmarshal([]byte(value), &cached); err != nil {
+		repo.log.Warning(ctx, "json.Unmarshal Error", err.Error())
+		return result, false, nil
+	}
+
+	result = cached.User
+	result.TenantID = cached.TenantID
+	return result, true, nil
+}
+
+func (repo userCacheRepository) set(ctx context.Context, cacheKey string, user models.User) error {
+	value, err := json.Marshal(cachedUser{User: user, TenantID: user.TenantID})
+	if err != nil {
+		return err
+	}
+
+	entry := envelope{Value: string(value)}
+	if repo.cipher.Current() != "" {
+		dataKey, keyID, wrapped, err := repo.cipher.NewDataKey(ctx)
+		if err != nil {
+			return err
+		}
+
+		if entry.Value, err = dataKey.Seal(fieldCache, entry.Value); err != nil {
+			return err
+		}
+		entry.KeyID, entry.DataKey = keyID, wrapped
+	}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	ttl := repo.config.TTL
+	if repo.config.Jitter > 0 {
+		ttl += time.Duration(rand.Int63n(int64(repo.config.Jitter)))
+	}
+
+	return repo.client.Set(ctx, cacheKey, raw, ttl).Err()
+}
+
+// invalidate drop the cached user, it is attempted even while Redis is in
+// cooldown since a stale entry would outlive it
+func (repo userCacheRepository) invalidate(ctx context.Context, ids ...uint) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, key(tenantID, id))
+	}
+
+	if err := repo.client.Del(ctx, keys...).Err(); err != nil {
+		repo.fail(ctx, "repo.client.Del Error", err)
+	}
+}
+
+func (repo userCacheRepository) Update(ctx context.Context, user models.User) (result models.User, err error) {
+	result, err = repo.IUserMysqlRepository.Update(ctx, user)
+	if err != nil {
+		return
+	}
+
+	repo.invalidate(ctx, user.ID)
+	return
+}
+
+func (repo userCacheRepository) Delete(ctx context.Context, id uint) (err error) {
+	if err = repo.IUserMysqlRepository.Delete(ctx, id); err != nil {
+		return
+	}
+
+	repo.invalidate(ctx, id)
+	return
+}
+
+func (repo userCacheRepository) Merge(ctx context.Context, sourceID uint, target models.User, dryRun bool) (result models.MergeResult, err error) {
+	result, err = repo.IUserMysqlRepository.Merge(ctx, sourceID, target, dryRun)
+	if err != nil || dryRun {
+		return
+	}
+
+	repo.invalidate(ctx, sourceID, target.ID)
+	return
+}
+
+func (repo userCacheRepository) Erase(ctx context.Context, id uint) (err error) {
+	if err = repo.IUserMysqlRepository.Erase(ctx, id); err != nil {
+		return
+	}
+
+	repo.invalidate(ctx, id)
+	return
+}
+
+func (repo userCacheRepository) Archive(ctx context.Context, id uint, before time.Time) (err error) {
+	if err = repo.IUserMysqlRepository.Archive(ctx, id, before); err != nil {
+		return
+	}
+
+	repo.invalidate(ctx, id)
+	return
+}
@@ -0,0 +1,166 @@
+package repository_redis
+
+import (
+	"context"
+	"errors"
+	"prototype/domain/user/mocks"
+	"prototype/domain/user/models"
+	"prototype/lib/crypt"
+	"prototype/lib/log"
+	"prototype/lib/redis"
+	"prototype/lib/tenant"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+var testConfig = CacheConfig{TTL: time.Minute, Jitter: time.Second, LockTTL: time.Second, Cooldown: time.Minute}
+
+func setupRedis(t *testing.T) (*miniredis.Miniredis, redis.IRedis) {
+	server := miniredis.RunT(t)
+
+	client, err := redis.New(redis.Config{Hosts: []string{server.Addr()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, client
+}
+
+func newTestCipher(t *testing.T) *crypt.FieldCipher {
+	file, err := crypt.GenerateKeyringFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, indexKey, err := crypt.NewLocalKeyring(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return crypt.NewFieldCipher(keyring, indexKey)
+}
+
+func Test_userCacheRepository_GetByID(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), 1)
+	user := models.User{ID: 1, TenantID: 1, Email: "user@mail.com", Status: models.StatusActive}
+
+	t.Run("second read is served from cache", func(t *testing.T) {
+		server, client := setupRedis(t)
+
+		userRepo := new(mocks.UserRepository)
+		userRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+
+		repo := NewCachedUserRepo(userRepo, client, newTestCipher(t), testConfig, log.NewLog())
+
+		for i := 0; i < 2; i++ {
+			got, err := repo.GetByID(ctx, 1)
+			if err != nil || got.Email != user.Email || got.TenantID != 1 {
+				t.Fatalf("repo.GetByID() = %+v, %v", got, err)
+			}
+		}
+
+		userRepo.AssertNumberOfCalls(t, "GetByID", 1)
+
+		// the entry is sealed and expire with jitter
+		stored, _ := server.Get(key(1, 1))
+		if strings.Contains(stored, user.Email) {
+			t.Errorf("cached entry = %s, want sealed", stored)
+		}
+
+		if ttl := server.TTL(key(1, 1)); ttl < testConfig.TTL || ttl > testConfig.TTL+testConfig.Jitter {
+			t.Errorf("cached entry ttl = %v", ttl)
+		}
+	})
+
+	t.Run("tenant are cached apart", func(t *testing.T) {
+		_, client := setupRedis(t)
+		other := tenant.WithID(context.Background(), 2)
+
+		userRepo := new(mocks.UserRepository)
+		userRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		userRepo.On("GetByID", other, uint(1)).Return(models.User{}, errors.New("record not found")).Twice()
+
+		repo := NewCachedUserRepo(userRepo, client, nil, testConfig, log.NewLog())
+
+		repo.GetByID(ctx, 1)
+		for i := 0; i < 2; i++ {
+			if _, err := repo.GetByID(other, 1); err == nil {
+				t.Error("repo.GetByID() other tenant error = nil")
+			}
+		}
+	})
+
+	t.Run("concurrent miss load once", func(t *testing.T) {
+		_, client := setupRedis(t)
+
+		userRepo := new(mocks.UserRepository)
+		userRepo.On("GetByID", ctx, uint(1)).Return(user, nil).After(50 * time.Millisecond)
+
+		repo := NewCachedUserRepo(userRepo, client, nil, testConfig, log.NewLog())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got, err := repo.GetByID(ctx, 1); err != nil || got.ID != 1 {
+					t.Errorf("repo.GetByID() = %+v, %v", got, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		userRepo.AssertNumberOfCalls(t, "GetByID", 1)
+	})
+
+	t.Run("redis down read database", func(t *testing.T) {
+		server, client := setupRedis(t)
+		server.Close()
+
+		userRepo := new(mocks.UserRepository)
+		userRepo.On("GetByID", ctx, uint(1)).Return(user, nil)
+
+		repo := NewCachedUserRepo(userRepo, client, nil, testConfig, log.NewLog())
+
+		for i := 0; i < 2; i++ {
+			if got, err := repo.GetByID(ctx, 1); err != nil || got.ID != 1 {
+				t.Fatalf("repo.GetByID() = %+v, %v", got, err)
+			}
+		}
+
+		userRepo.AssertNumberOfCalls(t, "GetByID", 2)
+	})
+}
+
+func Test_userCacheRepository_Invalidate(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), 1)
+	user := models.User{ID: 1, TenantID: 1, Username: "before"}
+	updated := models.User{ID: 1, TenantID: 1, Username: "after"}
+
+	_, client := setupRedis(t)
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+	userRepo.On("GetByID", ctx, uint(1)).Return(updated, nil).Once()
+	userRepo.On("Update", ctx, mock.Anything).Return(updated, nil)
+	userRepo.On("Delete", ctx, uint(1)).Return(nil)
+
+	repo := NewCachedUserRepo(userRepo, client, nil, testConfig, log.NewLog())
+
+	repo.GetByID(ctx, 1)
+	repo.Update(ctx, updated)
+
+	if got, _ := repo.GetByID(ctx, 1); got.Username != "after" {
+		t.Errorf("repo.GetByID() after update = %v, want after", got.Username)
+	}
+
+	repo.Delete(ctx, 1)
+	if exists, _ := client.Exists(ctx, key(1, 1)).Result(); exists != 0 {
+		t.Error("cached entry still exists after delete")
+	}
+}
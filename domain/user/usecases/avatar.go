@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	domain "prototype/domain/user"
+	"prototype/domain/user/models"
+	"prototype/lib/imaging"
+	"prototype/lib/storage"
+	"prototype/lib/tenant"
+	"strconv"
+	"strings"
+)
+
+// AvatarConfig limit what can be uploaded as avatar and which square
+// variants are generated from it
+type AvatarConfig struct {
+	Storage      storage.IStorage
+	MaxSize      int64
+	MaxDimension int
+	Sizes        []int
+}
+
+func (usecase userUsecase) UploadAvatar(ctx context.Context, id uint, file io.Reader) (result models.User, err error) {
+	data, err := io.ReadAll(io.LimitReader(file, usecase.avatar.MaxSize+1))
+	if err != nil {
+		usecase.log.Error(ctx, "io.ReadAll(file) Error", err)
+		return
+	}
+
+	if int64(len(data)) > usecase.avatar.MaxSize {
+		err = fmt.Errorf("%w: limit is %d bytes", domain.ErrAvatarTooLarge, usecase.avatar.MaxSize)
+		return
+	}
+
+	img, format, err := imaging.Decode(data, usecase.avatar.MaxDimension)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupportedType):
+		err = domain.ErrAvatarType
+		return
+	case errors.Is(err, imaging.ErrTooLarge):
+		err = fmt.Errorf("%w: limit is %dpx", domain.ErrAvatarTooLarge, usecase.avatar.MaxDimension)
+		return
+	case err != nil:
+		err = domain.ErrAvatarInvalid
+		return
+	}
+
+	userData, err := usecase.userRepo.GetByID(domain.Uncached(ctx), id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
+	// every upload get a fresh version so cached url never serve a stale image
+	version := make([]byte, 8)
+	if _, err = rand.Read(version); err != nil {
+		return
+	}
+
+	avatar := models.Avatar{}
+	for _, size := range usecase.avatar.Sizes {
+		variant, contentType, ext, encodeErr := imaging.Encode(imaging.Thumbnail(img, size), format)
+		if encodeErr != nil {
+			err = encodeErr
+			usecase.log.Error(ctx, "imaging.Encode Error", err)
+			usecase.removeAvatar(ctx, id, avatar)
+			return
+		}
+
+		key := fmt.Sprintf("%s%s-%d.%s", avatarPrefix(ctx, id), hex.EncodeToString(version), size, ext)
+		if err = usecase.avatar.Storage.Put(ctx, key, bytes.NewReader(variant), int64(len(variant)), contentType); err != nil {
+			usecase.log.Error(ctx, "usecase.avatar.Storage.Put Error", err)
+			usecase.removeAvatar(ctx, id, avatar)
+			return
+		}
+
+		avatar[strconv.Itoa(size)] = usecase.avatar.Storage.URL(key)
+	}
+
+	previous := userData.Avatar
+	userData.Avatar = avatar
+
+	result, err = usecase.userRepo.Update(ctx, userData)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.Update Error", err)
+		usecase.removeAvatar(ctx, id, avatar)
+		return
+	}
+
+	usecase.removeAvatar(ctx, id, previous)
+
+	return
+}
+
+// removeAvatar delete the blob of every variant of the avatar of user id,
+// failure is only logged since the user record no longer point to them. Only
+// the blob under the prefix of the user are deleted whatever the url say
+func (usecase userUsecase) removeAvatar(ctx context.Context, id uint, avatar models.Avatar) {
+	if usecase.avatar.Storage == nil {
+		return
+	}
+
+	prefix := avatarPrefix(ctx, id)
+
+	for _, url := range avatar {
+		key, ok := usecase.avatar.Storage.Key(url)
+		if !ok || !strings.HasPrefix(key, prefix) || strings.Contains(key, "..") {
+			continue
+		}
+
+		if err := usecase.avatar.Storage.Delete(ctx, key); err != nil {
+			usecase.log.Warning(ctx, "usecase.avatar.Storage.Delete Error", map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// avatarPrefix is where the avatar of user id is stored, the tenant is part
+// of it since id of dedicated tenant database are not unique
+func avatarPrefix(ctx context.Context, id uint) string {
+	tenantID, _ := tenant.FromContext(ctx)
+	return fmt.Sprintf("avatars/%d/%d/", tenantID, id)
+}
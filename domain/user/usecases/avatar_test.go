@@ -0,0 +1,129 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	domain "prototype/domain/user"
+	"prototype/domain/user/mocks"
+	"prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/storage"
+	"prototype/lib/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func newJPEG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func Test_userUsecase_UploadAvatar(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), 7)
+	root := t.TempDir()
+
+	blobStorage, err := storage.NewLocal(root, "/storage")
+	if err != nil {
+		t.Fatalf("storage.NewLocal() error = %v", err)
+	}
+
+	// avatar of a previous upload that must be cleaned up
+	oldKey := "avatars/7/1/old-64.jpg"
+	blobStorage.Put(ctx, oldKey, bytes.NewReader([]byte("old")), 3, "image/jpeg")
+
+	// blob of another user the avatar point to, it is never deleted
+	otherKey := "avatars/7/2/other-64.jpg"
+	blobStorage.Put(ctx, otherKey, bytes.NewReader([]byte("other")), 5, "image/jpeg")
+
+	user := models.User{
+		ID:     1,
+		Email:  "test@gmail.com",
+		Avatar: models.Avatar{"64": blobStorage.URL(oldKey), "128": blobStorage.URL(otherKey)},
+	}
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(1)).Return(user, nil)
+	userRepo.On("Update", ctx, mock.MatchedBy(func(user models.User) bool {
+		return len(user.Avatar) == 2
+	})).Return(user, nil)
+
+	tests := []struct {
+		name    string
+		file    []byte
+		wantErr error
+	}{
+		{
+			name: "success",
+			file: newJPEG(t, 300, 200),
+		},
+		{
+			name:    "failed too large file",
+			file:    bytes.Repeat([]byte{0xff}, 64<<10),
+			wantErr: domain.ErrAvatarTooLarge,
+		},
+		{
+			name:    "failed too large dimension",
+			file:    newJPEG(t, 2000, 10),
+			wantErr: domain.ErrAvatarTooLarge,
+		},
+		{
+			name:    "failed not an image",
+			file:    []byte("\x89PNG\r\n\x1a\nnot really a png"),
+			wantErr: domain.ErrAvatarInvalid,
+		},
+		{
+			name:    "failed unsupported type",
+			file:    []byte("%PDF-1.4"),
+			wantErr: domain.ErrAvatarType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := userUsecase{
+				userRepo: userRepo,
+				avatar: AvatarConfig{
+					Storage:      blobStorage,
+					MaxSize:      32 << 10,
+					MaxDimension: 1024,
+					Sizes:        []int{64, 128},
+				},
+				log: log.NewLog(),
+			}
+			_, err := usecase.UploadAvatar(ctx, 1, bytes.NewReader(tt.file))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("userUsecase.UploadAvatar() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			variants, _ := filepath.Glob(filepath.Join(root, "avatars", "7", "1", "*.jpg"))
+			if len(variants) != 2 {
+				t.Errorf("userUsecase.UploadAvatar() stored %v, want 2 variants", variants)
+			}
+			if _, err := os.Stat(filepath.Join(root, oldKey)); !os.IsNotExist(err) {
+				t.Errorf("userUsecase.UploadAvatar() previous avatar was not removed")
+			}
+			if _, err := os.Stat(filepath.Join(root, otherKey)); err != nil {
+				t.Errorf("userUsecase.UploadAvatar() removed the avatar of another user")
+			}
+		})
+	}
+}
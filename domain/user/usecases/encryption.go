@@ -0,0 +1,27 @@
+package usecases
+
+import (
+	"context"
+	"prototype/domain/user/models"
+)
+
+// reencryptBatch is how many user are looked at per transaction
+const reencryptBatch = 100
+
+// Reencrypt run batches until no user is left to seal again, a short batch
+// mean the tenant is done
+func (usecase userUsecase) Reencrypt(ctx context.Context) (progress models.Reencryption, err error) {
+	for {
+		var next models.Reencryption
+		if next, err = usecase.userRepo.Reencrypt(ctx, progress, reencryptBatch); err != nil {
+			usecase.log.Error(ctx, "usecase.userRepo.Reencrypt Error", err)
+			return
+		}
+
+		looked := next.Sealed + next.Skipped - progress.Sealed - progress.Skipped
+		progress = next
+		if looked < reencryptBatch {
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	domain "prototype/domain/user"
+	"prototype/domain/user/models"
+)
+
+// Merge fold the source user of the request into targetID, the request choose
+// per field whether the source value replace the target value
+func (usecase userUsecase) Merge(ctx context.Context, targetID uint, request models.MergeRequest) (result models.MergeResult, err error) {
+	if request.SourceID == targetID {
+		err = fmt.Errorf("%w: %d", domain.ErrMergeSelf, targetID)
+		return
+	}
+
+	for field, keep := range request.Fields {
+		if !isMergeField(field) || (keep != models.MergeKeepSource && keep != models.MergeKeepTarget) {
+			err = fmt.Errorf("%w: %s=%s", domain.ErrMergeField, field, keep)
+			return
+		}
+	}
+
+	target, err := usecase.userRepo.GetByID(domain.Uncached(ctx), targetID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
+	source, err := usecase.userRepo.GetByID(domain.Uncached(ctx), request.SourceID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
+	result, err = usecase.userRepo.Merge(ctx, source.ID, request.Resolve(target, source), request.DryRun)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.Merge Error", err)
+		return
+	}
+
+	if request.DryRun {
+		return
+	}
+
+	// the avatar blob of source are orphaned unless target took them over, a
+	// taken over avatar stay under the prefix of source and is left in the
+	// storage once target is removed
+	if request.Fields["avatar"] != models.MergeKeepSource {
+		usecase.removeAvatar(ctx, source.ID, source.Avatar)
+	}
+
+	usecase.log.Info(ctx, "User Merged", map[string]interface{}{
+		"source_id": source.ID,
+		"target_id": targetID,
+		"moved":     result.Moved,
+	})
+
+	return
+}
+
+func isMergeField(field string) bool {
+	for _, mergeField := range models.MergeFields {
+		if mergeField == field {
+			return true
+		}
+	}
+
+	return false
+}
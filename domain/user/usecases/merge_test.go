@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/user"
+	"prototype/domain/user/mocks"
+	"prototype/domain/user/models"
+	"prototype/lib/log"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func Test_userUsecase_Merge(t *testing.T) {
+	ctx := context.Background()
+
+	target := models.User{ID: 1, Email: "target@mail.com", Username: "target", FirstName: "Target"}
+	source := models.User{ID: 2, Email: "source@mail.com", Username: "source", FirstName: "Source"}
+
+	resolved := target
+	resolved.Email = source.Email
+
+	moved := map[string]int64{"memberships": 1, "preferences": 2}
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(1)).Return(target, nil)
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(2)).Return(source, nil)
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(3)).Return(models.User{}, gorm.ErrRecordNotFound)
+	userRepo.On("Merge", ctx, uint(2), resolved, true).
+		Return(models.MergeResult{User: resolved, SourceID: 2, Moved: moved, DryRun: true}, nil)
+	userRepo.On("Merge", ctx, uint(2), target, false).
+		Return(models.MergeResult{User: target, SourceID: 2, Moved: moved}, nil)
+
+	tests := []struct {
+		name       string
+		targetID   uint
+		request    models.MergeRequest
+		wantResult models.MergeResult
+		wantErr    error
+	}{
+		{
+			name:       "success dry run with field from source",
+			targetID:   1,
+			request:    models.MergeRequest{SourceID: 2, Fields: map[string]string{"email": "source", "firstname": "target"}, DryRun: true},
+			wantResult: models.MergeResult{User: resolved, SourceID: 2, Moved: moved, DryRun: true},
+		},
+		{
+			name:       "success keep target",
+			targetID:   1,
+			request:    models.MergeRequest{SourceID: 2},
+			wantResult: models.MergeResult{User: target, SourceID: 2, Moved: moved},
+		},
+		{
+			name:     "failed merge into itself",
+			targetID: 1,
+			request:  models.MergeRequest{SourceID: 1},
+			wantErr:  domain.ErrMergeSelf,
+		},
+		{
+			name:     "failed unknown field",
+			targetID: 1,
+			request:  models.MergeRequest{SourceID: 2, Fields: map[string]string{"role": "source"}},
+			wantErr:  domain.ErrMergeField,
+		},
+		{
+			name:     "failed unknown choice",
+			targetID: 1,
+			request:  models.MergeRequest{SourceID: 2, Fields: map[string]string{"email": "both"}},
+			wantErr:  domain.ErrMergeField,
+		},
+		{
+			name:     "failed source not found",
+			targetID: 1,
+			request:  models.MergeRequest{SourceID: 3},
+			wantErr:  gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := userUsecase{
+				userRepo: userRepo,
+				log:      log.NewLog(),
+			}
+			gotResult, err := usecase.Merge(ctx, tt.targetID, tt.request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("userUsecase.Merge() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("userUsecase.Merge() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_userUsecase_GetByIDMerged(t *testing.T) {
+	ctx := context.Background()
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", ctx, uint(2)).Return(models.User{}, gorm.ErrRecordNotFound)
+	userRepo.On("GetMerge", ctx, uint(2)).Return(models.UserMerge{SourceID: 2, TargetID: 1}, nil)
+
+	usecase := userUsecase{
+		userRepo: userRepo,
+		log:      log.NewLog(),
+	}
+
+	_, err := usecase.GetByID(ctx, 2)
+
+	var merged domain.MergedError
+	if !errors.As(err, &merged) || merged.TargetID != 1 {
+		t.Errorf("userUsecase.GetByID() error = %v, want merged into 1", err)
+	}
+}
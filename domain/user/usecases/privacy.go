@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"prototype/domain/user/models"
+	"prototype/lib/masking"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Export bundle every personal data of the user into a zip archive, one json
+// file for the profile, one for its history and one per data owner
+func (usecase userUsecase) Export(ctx context.Context, id uint) (result []byte, err error) {
+	user, err := usecase.userRepo.GetByID(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
+	history, err := usecase.userRepo.FetchHistoryChain(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.FetchHistoryChain Error", err)
+		return
+	}
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+
+	// data owner still get the plain user, only the profile file is masked
+	profile := usecase.masking.Mask(masking.Role(ctx, user.ID), user)
+
+	if err = writeJSON(writer, "user.json", profile); err != nil {
+		return
+	}
+
+	if err = writeJSON(writer, "history.json", history); err != nil {
+		return
+	}
+
+	for _, owner := range usecase.registry.Owners() {
+		data, ownerErr := owner.Export(ctx, user)
+		if ownerErr != nil {
+			err = ownerErr
+			usecase.log.Error(ctx, "owner.Export Error "+owner.Name(), err)
+			return
+		}
+
+		if err = writeJSON(writer, owner.Name()+".json", data); err != nil {
+			return
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		return
+	}
+
+	usecase.log.Info(ctx, "User Data Exported", map[string]interface{}{
+		"user_id": id,
+	})
+
+	result = archive.Bytes()
+	return
+}
+
+// Erase remove the personal data of the user from every data owner before the
+// user itself, a failed erasure can be retried as long as the user remain. An
+// archived user is erased together with its archive record
+func (usecase userUsecase) Erase(ctx context.Context, id uint) (result models.Erasure, err error) {
+	archived := false
+
+	user, err := usecase.userRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if archive, archiveErr := usecase.userRepo.GetArchive(ctx, id); archiveErr == nil {
+			user, archived, err = archive.User, true, nil
+		}
+	}
+
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
+	result = models.Erasure{UserID: id, Owners: []string{}}
+
+	for _, owner := range usecase.registry.Owners() {
+		if err = owner.Erase(ctx, user); err != nil {
+			usecase.log.Error(ctx, "owner.Erase Error "+owner.Name(), err)
+			return
+		}
+
+		result.Owners = append(result.Owners, owner.Name())
+	}
+
+	if archived {
+		if err = usecase.userRepo.PurgeArchive(ctx, id); err != nil {
+			usecase.log.Error(ctx, "usecase.userRepo.PurgeArchive Error", err)
+			return
+		}
+	} else if err = usecase.userRepo.Erase(ctx, id); err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.Erase Error", err)
+		return
+	}
+
+	usecase.removeAvatar(ctx, id, user.Avatar)
+
+	result.ErasedAt = time.Now()
+
+	// only the id is logged, the point is to not leave personal data behind
+	usecase.log.Info(ctx, "User Data Erased", map[string]interface{}{
+		"user_id": id,
+		"owners":  result.Owners,
+	})
+
+	return
+}
+
+func writeJSON(writer *zip.Writer, name string, data interface{}) error {
+	file, err := writer.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
@@ -0,0 +1,172 @@
+package usecases
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	domain "prototype/domain/user"
+	"prototype/domain/user/mocks"
+	"prototype/domain/user/models"
+	"prototype/lib/log"
+	"reflect"
+	"sort"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeOwner is a data owner keeping what it was asked to do
+type fakeOwner struct {
+	name   string
+	data   interface{}
+	err    error
+	erased []uint
+}
+
+func (owner *fakeOwner) Name() string {
+	return owner.name
+}
+
+func (owner *fakeOwner) Export(ctx context.Context, user models.User) (interface{}, error) {
+	return owner.data, owner.err
+}
+
+func (owner *fakeOwner) Erase(ctx context.Context, user models.User) error {
+	if owner.err != nil {
+		return owner.err
+	}
+
+	owner.erased = append(owner.erased, user.ID)
+	return nil
+}
+
+func Test_userUsecase_Export(t *testing.T) {
+	ctx := context.Background()
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", ctx, uint(1)).Return(models.User{ID: 1, Email: "test@mail.com"}, nil)
+	userRepo.On("FetchHistoryChain", ctx, uint(1)).Return([]models.UserAudit{{ID: 1, UserID: 1, Action: models.AuditActionCreate}}, nil)
+
+	tests := []struct {
+		name      string
+		owners    []domain.IDataOwner
+		wantFiles []string
+		wantErr   bool
+	}{
+		{
+			name:      "success",
+			owners:    []domain.IDataOwner{&fakeOwner{name: "preferences", data: []string{"theme"}}},
+			wantFiles: []string{"history.json", "preferences.json", "user.json"},
+		},
+		{
+			name:    "failed owner",
+			owners:  []domain.IDataOwner{&fakeOwner{name: "preferences", err: errors.New("database down")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := domain.NewDataRegistry()
+			for _, owner := range tt.owners {
+				registry.Register(owner)
+			}
+
+			usecase := userUsecase{
+				userRepo: userRepo,
+				registry: registry,
+				log:      log.NewLog(),
+			}
+			archive, err := usecase.Export(ctx, 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("userUsecase.Export() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+			if err != nil {
+				t.Fatalf("zip.NewReader() error = %v", err)
+			}
+
+			var files []string
+			for _, file := range reader.File {
+				files = append(files, file.Name)
+			}
+			sort.Strings(files)
+
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("userUsecase.Export() files = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func Test_userUsecase_Erase(t *testing.T) {
+	ctx := context.Background()
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", ctx, uint(1)).Return(models.User{ID: 1}, nil)
+	userRepo.On("Erase", ctx, uint(1)).Return(nil).Once()
+
+	t.Run("success", func(t *testing.T) {
+		preferences := &fakeOwner{name: "preferences"}
+		logins := &fakeOwner{name: "logins"}
+
+		registry := domain.NewDataRegistry()
+		registry.Register(preferences)
+		registry.Register(logins)
+
+		usecase := userUsecase{userRepo: userRepo, registry: registry, log: log.NewLog()}
+
+		result, err := usecase.Erase(ctx, 1)
+		if err != nil {
+			t.Fatalf("userUsecase.Erase() error = %v", err)
+		}
+
+		if !reflect.DeepEqual(result.Owners, []string{"preferences", "logins"}) {
+			t.Errorf("userUsecase.Erase() owners = %v", result.Owners)
+		}
+
+		if len(preferences.erased) != 1 || len(logins.erased) != 1 {
+			t.Errorf("userUsecase.Erase() owner not erased")
+		}
+	})
+
+	t.Run("failed owner keep the user", func(t *testing.T) {
+		registry := domain.NewDataRegistry()
+		registry.Register(&fakeOwner{name: "preferences", err: errors.New("database down")})
+
+		usecase := userUsecase{userRepo: userRepo, registry: registry, log: log.NewLog()}
+
+		if _, err := usecase.Erase(ctx, 1); err == nil {
+			t.Error("userUsecase.Erase() error = nil, want the owner error")
+		}
+	})
+
+	t.Run("success archived user", func(t *testing.T) {
+		userRepo.On("GetByID", ctx, uint(2)).Return(models.User{}, gorm.ErrRecordNotFound)
+		userRepo.On("GetArchive", ctx, uint(2)).Return(models.UserArchive{UserID: 2, User: models.User{ID: 2}}, nil)
+		userRepo.On("PurgeArchive", ctx, uint(2)).Return(nil).Once()
+
+		preferences := &fakeOwner{name: "preferences"}
+		registry := domain.NewDataRegistry()
+		registry.Register(preferences)
+
+		usecase := userUsecase{userRepo: userRepo, registry: registry, log: log.NewLog()}
+
+		if _, err := usecase.Erase(ctx, 2); err != nil {
+			t.Fatalf("userUsecase.Erase() error = %v", err)
+		}
+
+		if len(preferences.erased) != 1 || preferences.erased[0] != 2 {
+			t.Errorf("userUsecase.Erase() owner not erased")
+		}
+
+		userRepo.AssertCalled(t, "PurgeArchive", ctx, uint(2))
+	})
+
+	userRepo.AssertNumberOfCalls(t, "Erase", 1)
+}
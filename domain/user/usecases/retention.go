@@ -0,0 +1,146 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/user"
+	"prototype/domain/user/models"
+	"time"
+)
+
+// RetentionConfig archive user not seen for ArchiveAfter and purge archived
+// user for good after PurgeAfter, a zero duration disable the step
+type RetentionConfig struct {
+	ArchiveAfter time.Duration
+	PurgeAfter   time.Duration
+
+	// Batch is how many user are handled per query, a dry run report at most
+	// one batch per step
+	Batch int
+}
+
+// Retention archive the inactive user then purge the expired archive, a dry
+// run only report the user it would archive and purge
+func (usecase userUsecase) Retention(ctx context.Context, dryRun bool) (report models.RetentionReport, err error) {
+	now := time.Now()
+	report = models.RetentionReport{DryRun: dryRun, Archived: []uint{}, Purged: []uint{}}
+
+	if usecase.retention.ArchiveAfter > 0 {
+		report.ArchiveBefore = now.Add(-usecase.retention.ArchiveAfter)
+		if report.Archived, err = usecase.archiveInactive(ctx, report.ArchiveBefore, dryRun); err != nil {
+			return
+		}
+	}
+
+	if usecase.retention.PurgeAfter > 0 {
+		report.PurgeBefore = now.Add(-usecase.retention.PurgeAfter)
+		if report.Purged, err = usecase.purgeArchive(ctx, report.PurgeBefore, dryRun); err != nil {
+			return
+		}
+	}
+
+	if !dryRun && len(report.Archived)+len(report.Purged) > 0 {
+		usecase.log.Info(ctx, "User Retention", map[string]interface{}{
+			"archived": len(report.Archived),
+			"purged":   len(report.Purged),
+		})
+	}
+
+	return
+}
+
+func (usecase userUsecase) archiveInactive(ctx context.Context, before time.Time, dryRun bool) (archived []uint, err error) {
+	archived = []uint{}
+
+	for {
+		ids, err := usecase.userRepo.FetchInactive(ctx, before, usecase.retention.Batch)
+		if err != nil {
+			usecase.log.Error(ctx, "usecase.userRepo.FetchInactive Error", err)
+			return archived, err
+		}
+
+		if dryRun {
+			return append(archived, ids...), nil
+		}
+
+		for _, id := range ids {
+			// seen again since it was fetched, FetchInactive does not return it
+			// anymore either
+			err := usecase.userRepo.Archive(ctx, id, before)
+			if errors.Is(err, domain.ErrUserNotInactive) {
+				continue
+			}
+
+			if err != nil {
+				usecase.log.Error(ctx, "usecase.userRepo.Archive Error", err)
+				return archived, err
+			}
+
+			archived = append(archived, id)
+		}
+
+		if len(ids) < usecase.retention.Batch {
+			return archived, nil
+		}
+	}
+}
+
+// purgeArchive erase the archived user from every data owner like Erase does
+// before dropping the archive itself
+func (usecase userUsecase) purgeArchive(ctx context.Context, before time.Time, dryRun bool) (purged []uint, err error) {
+	purged = []uint{}
+
+	for {
+		archives, _, err := usecase.userRepo.FetchArchive(ctx, models.ArchiveFilter{Before: &before}, 1, usecase.retention.Batch)
+		if err != nil {
+			usecase.log.Error(ctx, "usecase.userRepo.FetchArchive Error", err)
+			return purged, err
+		}
+
+		for _, archive := range archives {
+			if dryRun {
+				purged = append(purged, archive.UserID)
+				continue
+			}
+
+			for _, owner := range usecase.registry.Owners() {
+				if err := owner.Erase(ctx, archive.User); err != nil {
+					usecase.log.Error(ctx, "owner.Erase Error "+owner.Name(), err)
+					return purged, err
+				}
+			}
+
+			if err := usecase.userRepo.PurgeArchive(ctx, archive.UserID); err != nil {
+				usecase.log.Error(ctx, "usecase.userRepo.PurgeArchive Error", err)
+				return purged, err
+			}
+
+			usecase.removeAvatar(ctx, archive.UserID, archive.User.Avatar)
+			purged = append(purged, archive.UserID)
+		}
+
+		if dryRun || len(archives) < usecase.retention.Batch {
+			return purged, nil
+		}
+	}
+}
+
+func (usecase userUsecase) FetchArchive(ctx context.Context, page, limit int) (result []models.UserArchive, total int64, err error) {
+	result, total, err = usecase.userRepo.FetchArchive(ctx, models.ArchiveFilter{}, page, limit)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.FetchArchive Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase userUsecase) Restore(ctx context.Context, id uint) (result models.User, err error) {
+	result, err = usecase.userRepo.Restore(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.Restore Error", err)
+		return
+	}
+
+	return
+}
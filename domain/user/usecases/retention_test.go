@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	domain "prototype/domain/user"
+	"prototype/domain/user/mocks"
+	"prototype/domain/user/models"
+	"prototype/lib/log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecase_Retention(t *testing.T) {
+	ctx := context.Background()
+	config := RetentionConfig{ArchiveAfter: 180 * 24 * time.Hour, PurgeAfter: 365 * 24 * time.Hour, Batch: 10}
+	archived := []models.UserArchive{{UserID: 7, User: models.User{ID: 7, Email: "old@mail.com"}}}
+
+	tests := []struct {
+		name         string
+		dryRun       bool
+		archiveErr   error
+		seen         uint
+		wantArchived []uint
+		wantPurged   []uint
+		wantErased   int
+		wantErr      bool
+	}{
+		{
+			name:         "dry run only report",
+			dryRun:       true,
+			wantArchived: []uint{3, 4},
+			wantPurged:   []uint{7},
+		},
+		{
+			name:         "run archive and purge",
+			wantArchived: []uint{3, 4},
+			wantPurged:   []uint{7},
+			wantErased:   1,
+		},
+		{
+			name:         "user seen again is left alone",
+			seen:         3,
+			wantArchived: []uint{4},
+			wantPurged:   []uint{7},
+			wantErased:   1,
+		},
+		{
+			name:       "failed archive stop the run",
+			archiveErr: errors.New("database down"),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(mocks.UserRepository)
+			userRepo.On("FetchInactive", ctx, mock.AnythingOfType("time.Time"), 10).Return([]uint{3, 4}, nil)
+			if tt.seen != 0 {
+				userRepo.On("Archive", ctx, tt.seen, mock.AnythingOfType("time.Time")).Return(domain.ErrUserNotInactive)
+			}
+			userRepo.On("Archive", ctx, mock.Anything, mock.AnythingOfType("time.Time")).Return(tt.archiveErr)
+			userRepo.On("FetchArchive", ctx, mock.AnythingOfType("models.ArchiveFilter"), 1, 10).Return(archived, int64(1), nil)
+			userRepo.On("PurgeArchive", ctx, uint(7)).Return(nil)
+
+			owner := &fakeOwner{name: "preferences"}
+			registry := domain.NewDataRegistry()
+			registry.Register(owner)
+
+			usecase := userUsecase{userRepo: userRepo, registry: registry, retention: config, log: log.NewLog()}
+
+			report, err := usecase.Retention(ctx, tt.dryRun)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("userUsecase.Retention() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(report.Archived, tt.wantArchived) || !reflect.DeepEqual(report.Purged, tt.wantPurged) {
+				t.Errorf("userUsecase.Retention() = %+v", report)
+			}
+
+			if len(owner.erased) != tt.wantErased {
+				t.Errorf("owner erased %d user, want %d", len(owner.erased), tt.wantErased)
+			}
+
+			if tt.dryRun {
+				userRepo.AssertNotCalled(t, "Archive", ctx, mock.Anything, mock.Anything)
+				userRepo.AssertNotCalled(t, "PurgeArchive", ctx, mock.Anything)
+			}
+		})
+	}
+}
@@ -2,22 +2,76 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	domain "prototype/domain/user"
 	"prototype/domain/user/models"
 	"prototype/lib/log"
+	"prototype/lib/masking"
+	"prototype/lib/notify"
+	"prototype/lib/validation"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type userUsecase struct {
-	userRepo domain.IUserMysqlRepository
-	log      log.ILogs
+	userRepo   domain.IUserMysqlRepository
+	attributes AttributesConfig
+	avatar     AvatarConfig
+	registry   *domain.DataRegistry
+	masking    masking.Policy
+	retention  RetentionConfig
+	notifier   notify.INotifier
+	log        log.ILogs
+}
+
+// AttributesConfig describe which user attributes are accepted and which of
+// them are indexed and therefore filterable
+type AttributesConfig struct {
+	Schema  *validation.Schema
+	Indexed []string
+}
+
+// statusTransitions list the allowed target status for every account status
+var statusTransitions = map[string][]string{
+	models.StatusPending:     {models.StatusActive, models.StatusDeactivated},
+	models.StatusActive:      {models.StatusSuspended, models.StatusLocked, models.StatusDeactivated},
+	models.StatusSuspended:   {models.StatusActive, models.StatusDeactivated},
+	models.StatusLocked:      {models.StatusActive, models.StatusDeactivated},
+	models.StatusDeactivated: {models.StatusActive},
+}
+
+func NewUserUsecase(userRepo domain.IUserMysqlRepository, attributes AttributesConfig, avatar AvatarConfig, registry *domain.DataRegistry, masking masking.Policy, retention RetentionConfig, notifier notify.INotifier, log log.ILogs) domain.IUserUsecase {
+	return &userUsecase{userRepo, attributes, avatar, registry, masking, retention, notifier, log}
 }
 
-func NewUserUsecase(userRepo domain.IUserMysqlRepository, log log.ILogs) domain.IUserMysqlRepository {
-	return &userUsecase{userRepo, log}
+func canTransition(from, to string) bool {
+	for _, status := range statusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
 }
 
-func (usecase userUsecase) Fetch(ctx context.Context) (result []models.User, err error) {
-	result, err = usecase.userRepo.Fetch(ctx)
+func (usecase userUsecase) Fetch(ctx context.Context, filter models.UserFilter) (result []models.User, err error) {
+	for _, status := range filter.Status {
+		if !models.IsValidStatus(status) {
+			err = fmt.Errorf("%w: %s", domain.ErrInvalidStatus, status)
+			return
+		}
+	}
+
+	for key := range filter.Attributes {
+		if !usecase.isIndexedAttribute(key) {
+			err = fmt.Errorf("%w: %s", domain.ErrAttributeFilter, key)
+			return
+		}
+	}
+
+	result, err = usecase.userRepo.Fetch(ctx, filter)
 
 	if err != nil {
 		usecase.log.Error(ctx, "usecase.userRepo.Fetch Error", err)
@@ -28,6 +82,30 @@ func (usecase userUsecase) Fetch(ctx context.Context) (result []models.User, err
 }
 
 func (usecase userUsecase) Create(ctx context.Context, user models.User) (result models.User, err error) {
+	// new account can only start as pending or active, other status need a transition
+	switch user.Status {
+	case "":
+		user.Status = models.StatusActive
+	case models.StatusPending, models.StatusActive:
+	default:
+		err = fmt.Errorf("%w: %s", domain.ErrInvalidStatus, user.Status)
+		return
+	}
+
+	if err = usecase.attributes.Schema.Validate("attributes", user.Attributes); err != nil {
+		return
+	}
+
+	// role, account history and avatar are never taken from the request, an
+	// email is only verified through an invitation
+	user.Role = models.RoleUser
+	user.EmailVerifiedAt = nil
+	user.Avatar = nil
+	user.StatusReason = ""
+	user.StatusChangedAt = nil
+	user.LastLoginAt = nil
+	user.LastSeenAt = nil
+
 	result, err = usecase.userRepo.Create(ctx, user)
 	if err != nil {
 		return
@@ -37,16 +115,29 @@ func (usecase userUsecase) Create(ctx context.Context, user models.User) (result
 }
 
 func (usecase userUsecase) Update(ctx context.Context, user models.User) (result models.User, err error) {
-	userData, err := usecase.userRepo.GetByID(ctx, user.ID)
+	userData, err := usecase.userRepo.GetByID(domain.Uncached(ctx), user.ID)
 	if err != nil {
 		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
 		return
 	}
 
+	// a new email is no longer verified
+	if userData.Email != user.Email {
+		userData.EmailVerifiedAt = nil
+	}
+
 	userData.FirstName = user.FirstName
 	userData.LastName = user.LastName
 	userData.Email = user.Email
 
+	if user.Attributes != nil {
+		if err = usecase.attributes.Schema.Validate("attributes", user.Attributes); err != nil {
+			return
+		}
+
+		userData.Attributes = user.Attributes
+	}
+
 	result, err = usecase.userRepo.Update(ctx, userData)
 	if err != nil {
 		usecase.log.Error(ctx, "usecase.userRepo.Update Error", err)
@@ -56,8 +147,17 @@ func (usecase userUsecase) Update(ctx context.Context, user models.User) (result
 	return
 }
 
+// GetByID report a merged user as MergedError so the caller can follow it to
+// the surviving user
 func (usecase userUsecase) GetByID(ctx context.Context, id uint) (result models.User, err error) {
 	result, err = usecase.userRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if merge, mergeErr := usecase.userRepo.GetMerge(ctx, id); mergeErr == nil {
+			err = domain.MergedError{SourceID: merge.SourceID, TargetID: merge.TargetID}
+			return
+		}
+	}
+
 	if err != nil {
 		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
 		return
@@ -67,10 +167,167 @@ func (usecase userUsecase) GetByID(ctx context.Context, id uint) (result models.
 }
 
 func (usecase userUsecase) Delete(ctx context.Context, id uint) (err error) {
+	userData, err := usecase.userRepo.GetByID(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
 	if err = usecase.userRepo.Delete(ctx, id); err != nil {
 		usecase.log.Error(ctx, "usecase.userRepo.Delete Error", err)
 		return
 	}
 
+	usecase.removeAvatar(ctx, id, userData.Avatar)
+
+	return
+}
+
+func (usecase userUsecase) ChangeStatus(ctx context.Context, id uint, status, reason string) (result models.User, err error) {
+	if !models.IsValidStatus(status) {
+		err = fmt.Errorf("%w: %s", domain.ErrInvalidStatus, status)
+		return
+	}
+
+	userData, err := usecase.userRepo.GetByID(domain.Uncached(ctx), id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
+	from := userData.Status
+	if !canTransition(from, status) {
+		err = fmt.Errorf("%w: %s to %s", domain.ErrInvalidTransition, from, status)
+		usecase.log.Warning(ctx, "usecase.ChangeStatus Rejected", err.Error())
+		return
+	}
+
+	changedAt := time.Now()
+	userData.Status = status
+	userData.StatusReason = reason
+	userData.StatusChangedAt = &changedAt
+
+	result, err = usecase.userRepo.Update(ctx, userData)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.Update Error", err)
+		return
+	}
+
+	usecase.log.Info(ctx, "User Status Transition", map[string]interface{}{
+		"user_id": id,
+		"from":    from,
+		"to":      status,
+		"reason":  reason,
+	})
+
+	// the open socket of a user who can no longer sign in are closed
+	if from == models.StatusActive && usecase.notifier != nil {
+		err := usecase.notifier.Notify(ctx, id, notify.Payload{
+			Topic: notify.TopicSessionRevoked,
+			Data:  models.StatusChange{Status: status, Reason: reason},
+		})
+		if err != nil {
+			usecase.log.Error(ctx, "usecase.notifier.Notify Error", err)
+		}
+	}
+
+	return
+}
+
+func (usecase userUsecase) Authenticate(ctx context.Context, id uint) (result models.User, err error) {
+	result, err = usecase.userRepo.GetByID(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
+	if result.Status != models.StatusActive {
+		err = fmt.Errorf("%w: %s", domain.ErrUserInactive, result.Status)
+		usecase.log.Warning(ctx, "usecase.Authenticate Rejected", map[string]interface{}{
+			"user_id": id,
+			"status":  result.Status,
+		})
+		result = models.User{}
+		return
+	}
+
+	return
+}
+
+func (usecase userUsecase) PatchAttributes(ctx context.Context, id uint, patch models.Attributes) (result models.User, err error) {
+	userData, err := usecase.userRepo.GetByID(domain.Uncached(ctx), id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.GetByID Error", err)
+		return
+	}
+
+	attributes := userData.Attributes.Merge(patch)
+	if err = usecase.attributes.Schema.Validate("attributes", attributes); err != nil {
+		return
+	}
+
+	userData.Attributes = attributes
+
+	result, err = usecase.userRepo.Update(ctx, userData)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.Update Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase userUsecase) isIndexedAttribute(key string) bool {
+	for _, indexed := range usecase.attributes.Indexed {
+		if indexed == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (usecase userUsecase) History(ctx context.Context, userID uint, page, limit int) (result []models.UserAudit, total int64, err error) {
+	result, total, err = usecase.userRepo.FetchHistory(ctx, userID, page, limit)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.FetchHistory Error", err)
+		return
+	}
+
+	return
+}
+
+// VerifyHistory walk the audit chain of the user and report the first entry
+// whose hash does not match its content or its predecessor
+func (usecase userUsecase) VerifyHistory(ctx context.Context, userID uint) (result models.AuditVerification, err error) {
+	entries, err := usecase.userRepo.FetchHistoryChain(ctx, userID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.userRepo.FetchHistoryChain Error", err)
+		return
+	}
+
+	result = models.AuditVerification{
+		UserID: userID,
+		Valid:  true,
+	}
+
+	prevHash := ""
+	for _, entry := range entries {
+		result.Checked++
+
+		if entry.PrevHash != prevHash || entry.ComputeHash() != entry.Hash {
+			result.Valid = false
+			result.BrokenAt = entry.ID
+
+			usecase.log.Warning(ctx, "User Audit Chain Broken", map[string]interface{}{
+				"user_id":  userID,
+				"audit_id": entry.ID,
+			})
+			return
+		}
+
+		prevHash = entry.Hash
+	}
+
 	return
 }
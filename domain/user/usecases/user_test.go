@@ -6,8 +6,13 @@ import (
 	domain "prototype/domain/user"
 	"prototype/domain/user/mocks"
 	"prototype/domain/user/models"
+	"prototype/lib/log"
+	"prototype/lib/validation"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
 )
 
 func Test_userUsecase_Fetch(t *testing.T) {
@@ -24,10 +29,10 @@ func Test_userUsecase_Fetch(t *testing.T) {
 	}
 
 	userRepoSuccess := new(mocks.UserRepository)
-	userRepoSuccess.On("Fetch", ctx).Return(user, nil)
+	userRepoSuccess.On("Fetch", ctx, models.UserFilter{}).Return(user, nil)
 
 	userRepoError := new(mocks.UserRepository)
-	userRepoError.On("Fetch", ctx).Return([]models.User{}, errors.New("data tidak ditemukan"))
+	userRepoError.On("Fetch", ctx, models.UserFilter{}).Return([]models.User{}, errors.New("data tidak ditemukan"))
 
 	type fields struct {
 		userRepo domain.IUserMysqlRepository
@@ -65,8 +70,9 @@ func Test_userUsecase_Fetch(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			usecase := userUsecase{
 				userRepo: tt.fields.userRepo,
+				log:      log.NewLog(),
 			}
-			gotResult, err := usecase.Fetch(tt.args.ctx)
+			gotResult, err := usecase.Fetch(tt.args.ctx, models.UserFilter{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("userUsecase.Fetch() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -87,6 +93,8 @@ func Test_userUsecase_Create(t *testing.T) {
 		Username:  "test",
 		FirstName: "test",
 		LastName:  "test",
+		Role:      models.RoleUser,
+		Status:    models.StatusActive,
 	}
 
 	userRepoSuccess := new(mocks.UserRepository)
@@ -138,6 +146,7 @@ func Test_userUsecase_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			usecase := userUsecase{
 				userRepo: tt.fields.userRepo,
+				log:      log.NewLog(),
 			}
 			gotResult, err := usecase.Create(tt.args.ctx, tt.args.user)
 			if (err != nil) != tt.wantErr {
@@ -163,11 +172,11 @@ func Test_userUsecase_Update(t *testing.T) {
 	}
 
 	userRepoSuccess := new(mocks.UserRepository)
-	userRepoSuccess.On("GetByID", ctx, uint(1)).Return(user, nil)
+	userRepoSuccess.On("GetByID", domain.Uncached(ctx), uint(1)).Return(user, nil)
 	userRepoSuccess.On("Update", ctx, user).Return(user, nil)
 
 	userRepoError := new(mocks.UserRepository)
-	userRepoError.On("GetByID", ctx, uint(1)).Return(models.User{}, errors.New("data tidak ditemukan"))
+	userRepoError.On("GetByID", domain.Uncached(ctx), uint(1)).Return(models.User{}, errors.New("data tidak ditemukan"))
 	userRepoError.On("Update", ctx, user).Return(models.User{}, errors.New("data tidak ditemukan"))
 
 	type fields struct {
@@ -213,6 +222,7 @@ func Test_userUsecase_Update(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			usecase := userUsecase{
 				userRepo: tt.fields.userRepo,
+				log:      log.NewLog(),
 			}
 			gotResult, err := usecase.Update(tt.args.ctx, tt.args.user)
 			if (err != nil) != tt.wantErr {
@@ -226,6 +236,52 @@ func Test_userUsecase_Update(t *testing.T) {
 	}
 }
 
+func Test_userUsecase_EmailVerified(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+
+	verified := models.User{ID: 1, Email: "test@gmail.com", EmailVerifiedAt: &verifiedAt}
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("Create", ctx, mock.Anything).Return(models.User{}, nil)
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(1)).Return(verified, nil)
+	userRepo.On("Update", ctx, mock.Anything).Return(models.User{}, nil)
+
+	usecase := userUsecase{
+		userRepo: userRepo,
+		log:      log.NewLog(),
+	}
+
+	// a client can not create an already verified user
+	usecase.Create(ctx, models.User{Email: "test@gmail.com", EmailVerifiedAt: &verifiedAt})
+	if created := userRepo.Calls[0].Arguments.Get(1).(models.User); created.EmailVerifiedAt != nil {
+		t.Errorf("userUsecase.Create() kept email_verified_at %v", created.EmailVerifiedAt)
+	}
+
+	tests := []struct {
+		name         string
+		email        string
+		wantVerified bool
+	}{
+		{name: "same email stay verified", email: "test@gmail.com", wantVerified: true},
+		{name: "new email is no longer verified", email: "new@gmail.com", wantVerified: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo.Calls = nil
+
+			if _, err := usecase.Update(ctx, models.User{ID: 1, Email: tt.email}); err != nil {
+				t.Fatalf("userUsecase.Update() error = %v", err)
+			}
+
+			updated := userRepo.Calls[1].Arguments.Get(1).(models.User)
+			if (updated.EmailVerifiedAt != nil) != tt.wantVerified {
+				t.Errorf("userUsecase.Update() email_verified_at = %v, want verified %v", updated.EmailVerifiedAt, tt.wantVerified)
+			}
+		})
+	}
+}
+
 func Test_userUsecase_GetByID(t *testing.T) {
 	ctx := context.Background()
 
@@ -286,6 +342,7 @@ func Test_userUsecase_GetByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			usecase := userUsecase{
 				userRepo: tt.fields.userRepo,
+				log:      log.NewLog(),
 			}
 			gotResult, err := usecase.GetByID(tt.args.ctx, tt.args.id)
 			if (err != nil) != tt.wantErr {
@@ -302,10 +359,18 @@ func Test_userUsecase_GetByID(t *testing.T) {
 func Test_userUsecase_Delete(t *testing.T) {
 	ctx := context.Background()
 
+	user := models.User{
+		ID:       1,
+		Email:    "test@gmail.com",
+		Username: "test",
+	}
+
 	userRepoSuccess := new(mocks.UserRepository)
+	userRepoSuccess.On("GetByID", ctx, uint(1)).Return(user, nil)
 	userRepoSuccess.On("Delete", ctx, uint(1)).Return(nil)
 
 	userRepoError := new(mocks.UserRepository)
+	userRepoError.On("GetByID", ctx, uint(1)).Return(user, nil)
 	userRepoError.On("Delete", ctx, uint(1)).Return(errors.New("data tidak ditemukan"))
 
 	type fields struct {
@@ -348,6 +413,7 @@ func Test_userUsecase_Delete(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			usecase := userUsecase{
 				userRepo: tt.fields.userRepo,
+				log:      log.NewLog(),
 			}
 			if err := usecase.Delete(tt.args.ctx, tt.args.id); (err != nil) != tt.wantErr {
 				t.Errorf("userUsecase.Delete() error = %v, wantErr %v", err, tt.wantErr)
@@ -355,3 +421,393 @@ func Test_userUsecase_Delete(t *testing.T) {
 		})
 	}
 }
+
+func Test_userUsecase_ChangeStatus(t *testing.T) {
+	ctx := context.Background()
+
+	activeUser := models.User{
+		ID:        1,
+		Email:     "test@gmail.com",
+		Username:  "test",
+		FirstName: "test",
+		LastName:  "test",
+		Status:    models.StatusActive,
+	}
+
+	deactivatedUser := activeUser
+	deactivatedUser.ID = 2
+	deactivatedUser.Status = models.StatusDeactivated
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(1)).Return(activeUser, nil)
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(2)).Return(deactivatedUser, nil)
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(3)).Return(models.User{}, errors.New("data tidak ditemukan"))
+	changedAt := time.Now()
+
+	suspendedUser := activeUser
+	suspendedUser.Status = models.StatusSuspended
+	suspendedUser.StatusReason = "fraud investigation"
+	suspendedUser.StatusChangedAt = &changedAt
+
+	reactivatedUser := deactivatedUser
+	reactivatedUser.Status = models.StatusActive
+	reactivatedUser.StatusReason = "rejoined"
+	reactivatedUser.StatusChangedAt = &changedAt
+
+	userRepo.On("Update", ctx, mock.MatchedBy(func(user models.User) bool {
+		return user.ID == 1 && user.Status == models.StatusSuspended && user.StatusChangedAt != nil
+	})).Return(suspendedUser, nil)
+	userRepo.On("Update", ctx, mock.MatchedBy(func(user models.User) bool {
+		return user.ID == 2 && user.Status == models.StatusActive && user.StatusChangedAt != nil
+	})).Return(reactivatedUser, nil)
+
+	type args struct {
+		ctx    context.Context
+		id     uint
+		status string
+		reason string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantStatus string
+		wantErr    error
+	}{
+		{
+			name: "success suspend active user",
+			args: args{
+				ctx:    ctx,
+				id:     1,
+				status: models.StatusSuspended,
+				reason: "fraud investigation",
+			},
+			wantStatus: models.StatusSuspended,
+		},
+		{
+			name: "success reactivate deactivated user",
+			args: args{
+				ctx:    ctx,
+				id:     2,
+				status: models.StatusActive,
+				reason: "rejoined",
+			},
+			wantStatus: models.StatusActive,
+		},
+		{
+			name: "failed lock deactivated user",
+			args: args{
+				ctx:    ctx,
+				id:     2,
+				status: models.StatusLocked,
+				reason: "too many attempts",
+			},
+			wantErr: domain.ErrInvalidTransition,
+		},
+		{
+			name: "failed move to same status",
+			args: args{
+				ctx:    ctx,
+				id:     1,
+				status: models.StatusActive,
+				reason: "noop",
+			},
+			wantErr: domain.ErrInvalidTransition,
+		},
+		{
+			name: "failed unknown status",
+			args: args{
+				ctx:    ctx,
+				id:     1,
+				status: "banned",
+				reason: "spam",
+			},
+			wantErr: domain.ErrInvalidStatus,
+		},
+		{
+			name: "failed user not found",
+			args: args{
+				ctx:    ctx,
+				id:     3,
+				status: models.StatusSuspended,
+				reason: "spam",
+			},
+			wantErr: errors.New("data tidak ditemukan"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := userUsecase{
+				userRepo: userRepo,
+				log:      log.NewLog(),
+			}
+			gotResult, err := usecase.ChangeStatus(tt.args.ctx, tt.args.id, tt.args.status, tt.args.reason)
+			if tt.wantErr != nil {
+				if err == nil || (!errors.Is(err, tt.wantErr) && err.Error() != tt.wantErr.Error()) {
+					t.Errorf("userUsecase.ChangeStatus() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("userUsecase.ChangeStatus() unexpected error = %v", err)
+				return
+			}
+			if gotResult.Status != tt.wantStatus || gotResult.StatusReason != tt.args.reason || gotResult.StatusChangedAt == nil {
+				t.Errorf("userUsecase.ChangeStatus() = %v, want status %v reason %v", gotResult, tt.wantStatus, tt.args.reason)
+			}
+		})
+	}
+}
+
+func Test_userUsecase_Authenticate(t *testing.T) {
+	ctx := context.Background()
+
+	activeUser := models.User{
+		ID:     1,
+		Email:  "test@gmail.com",
+		Status: models.StatusActive,
+	}
+
+	suspendedUser := models.User{
+		ID:     2,
+		Email:  "suspended@gmail.com",
+		Status: models.StatusSuspended,
+	}
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", ctx, uint(1)).Return(activeUser, nil)
+	userRepo.On("GetByID", ctx, uint(2)).Return(suspendedUser, nil)
+
+	tests := []struct {
+		name       string
+		id         uint
+		wantResult models.User
+		wantErr    error
+	}{
+		{
+			name:       "success active user",
+			id:         1,
+			wantResult: activeUser,
+		},
+		{
+			name:       "failed suspended user",
+			id:         2,
+			wantResult: models.User{},
+			wantErr:    domain.ErrUserInactive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := userUsecase{
+				userRepo: userRepo,
+				log:      log.NewLog(),
+			}
+			gotResult, err := usecase.Authenticate(ctx, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("userUsecase.Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("userUsecase.Authenticate() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_userUsecase_VerifyHistory(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	chain := func(entries ...models.UserAudit) []models.UserAudit {
+		prevHash := ""
+		for i := range entries {
+			entries[i].PrevHash = prevHash
+			entries[i].CreatedAt = createdAt
+			entries[i].Hash = entries[i].ComputeHash()
+			prevHash = entries[i].Hash
+		}
+		return entries
+	}
+
+	validChain := chain(
+		models.UserAudit{ID: 1, UserID: 1, Action: models.AuditActionCreate, Changes: models.AuditChanges{{Field: "username", After: "test"}}},
+		models.UserAudit{ID: 2, UserID: 1, Action: models.AuditActionUpdate, Changes: models.AuditChanges{{Field: "status", Before: "active", After: "locked"}}},
+		models.UserAudit{ID: 3, UserID: 1, Action: models.AuditActionDelete},
+	)
+
+	tamperedChain := chain(
+		models.UserAudit{ID: 4, UserID: 2, Action: models.AuditActionCreate, Changes: models.AuditChanges{{Field: "username", After: "test"}}},
+		models.UserAudit{ID: 5, UserID: 2, Action: models.AuditActionUpdate, Changes: models.AuditChanges{{Field: "status", Before: "active", After: "locked"}}},
+	)
+	tamperedChain[1].ActorID = 99
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("FetchHistoryChain", ctx, uint(1)).Return(validChain, nil)
+	userRepo.On("FetchHistoryChain", ctx, uint(2)).Return(tamperedChain, nil)
+	userRepo.On("FetchHistoryChain", ctx, uint(3)).Return(nil, errors.New("data tidak ditemukan"))
+
+	tests := []struct {
+		name       string
+		id         uint
+		wantResult models.AuditVerification
+		wantErr    bool
+	}{
+		{
+			name:       "success valid chain",
+			id:         1,
+			wantResult: models.AuditVerification{UserID: 1, Valid: true, Checked: 3},
+		},
+		{
+			name:       "success tampered chain",
+			id:         2,
+			wantResult: models.AuditVerification{UserID: 2, Valid: false, Checked: 2, BrokenAt: 5},
+		},
+		{
+			name:       "failed",
+			id:         3,
+			wantResult: models.AuditVerification{},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := userUsecase{
+				userRepo: userRepo,
+				log:      log.NewLog(),
+			}
+			gotResult, err := usecase.VerifyHistory(ctx, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("userUsecase.VerifyHistory() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("userUsecase.VerifyHistory() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_userUsecase_PatchAttributes(t *testing.T) {
+	ctx := context.Background()
+
+	schema, err := validation.NewSchema("../../../schema/user-attributes.json")
+	if err != nil {
+		t.Fatalf("validation.NewSchema() error = %v", err)
+	}
+
+	user := models.User{
+		ID:     1,
+		Email:  "test@gmail.com",
+		Status: models.StatusActive,
+		Attributes: models.Attributes{
+			"locale":     "en-US",
+			"department": "finance",
+		},
+	}
+
+	patchedUser := user
+	patchedUser.Attributes = models.Attributes{
+		"locale":   "en-US",
+		"timezone": "Asia/Jakarta",
+	}
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByID", domain.Uncached(ctx), uint(1)).Return(user, nil)
+	userRepo.On("Update", ctx, patchedUser).Return(patchedUser, nil)
+
+	tests := []struct {
+		name       string
+		patch      models.Attributes
+		wantResult models.User
+		wantFields []validation.FieldError
+	}{
+		{
+			name: "success merge and remove attribute",
+			patch: models.Attributes{
+				"timezone":   "Asia/Jakarta",
+				"department": nil,
+			},
+			wantResult: patchedUser,
+		},
+		{
+			name: "failed unknown attribute",
+			patch: models.Attributes{
+				"shoe_size": 42,
+			},
+			wantResult: models.User{},
+			wantFields: []validation.FieldError{
+				{Field: "attributes.shoe_size", Message: "unknown attribute"},
+			},
+		},
+		{
+			name: "failed invalid attribute",
+			patch: models.Attributes{
+				"phone":  "0812",
+				"locale": 1,
+			},
+			wantResult: models.User{},
+			wantFields: []validation.FieldError{
+				{Field: "attributes.locale", Message: "expected string, but got number"},
+				{Field: "attributes.phone", Message: `does not match pattern '^\\+[1-9][0-9]{6,14}$'`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := userUsecase{
+				userRepo:   userRepo,
+				attributes: AttributesConfig{Schema: schema},
+				log:        log.NewLog(),
+			}
+			gotResult, err := usecase.PatchAttributes(ctx, 1, tt.patch)
+
+			var validationErr validation.Error
+			errors.As(err, &validationErr)
+
+			if !reflect.DeepEqual(validationErr.Fields, tt.wantFields) {
+				t.Errorf("userUsecase.PatchAttributes() error = %v, want fields %v", err, tt.wantFields)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("userUsecase.PatchAttributes() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_userUsecase_FetchByAttributes(t *testing.T) {
+	ctx := context.Background()
+
+	filter := models.UserFilter{Attributes: map[string]string{"department": "finance"}}
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("Fetch", ctx, filter).Return([]models.User{{ID: 1}}, nil)
+
+	tests := []struct {
+		name    string
+		filter  models.UserFilter
+		wantErr error
+	}{
+		{
+			name:   "success indexed attribute",
+			filter: filter,
+		},
+		{
+			name:    "failed attribute not indexed",
+			filter:  models.UserFilter{Attributes: map[string]string{"phone": "+6281234567"}},
+			wantErr: domain.ErrAttributeFilter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := userUsecase{
+				userRepo:   userRepo,
+				attributes: AttributesConfig{Indexed: []string{"department", "locale"}},
+				log:        log.NewLog(),
+			}
+			_, err := usecase.Fetch(ctx, tt.filter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("userUsecase.Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
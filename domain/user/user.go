@@ -2,23 +2,87 @@ package domain
 
 import (
 	"context"
+	"io"
 	"prototype/domain/user/models"
+	"time"
 )
 
 // interface for repository
 type IUserMysqlRepository interface {
-	Fetch(ctx context.Context) ([]models.User, error)
+	Fetch(ctx context.Context, filter models.UserFilter) ([]models.User, error)
 	Create(ctx context.Context, user models.User) (models.User, error)
 	Update(ctx context.Context, user models.User) (models.User, error)
 	GetByID(ctx context.Context, id uint) (models.User, error)
 	Delete(ctx context.Context, id uint) error
+
+	FetchHistory(ctx context.Context, userID uint, page, limit int) ([]models.UserAudit, int64, error)
+	FetchHistoryChain(ctx context.Context, userID uint) ([]models.UserAudit, error)
+
+	Merge(ctx context.Context, sourceID uint, target models.User, dryRun bool) (models.MergeResult, error)
+	GetMerge(ctx context.Context, sourceID uint) (models.UserMerge, error)
+
+	// Erase remove the user, its audit chain and the tombstone merged into it,
+	// a new chain is started with a single erase entry
+	Erase(ctx context.Context, id uint) error
+
+	// Reencrypt seal again up to limit user and archived user after progress
+	// stored in plaintext or under a key which is not current, it return
+	// progress moved past every row it looked at
+	Reencrypt(ctx context.Context, progress models.Reencryption, limit int) (models.Reencryption, error)
+
+	// FetchInactive return the id of user last seen, or created when never
+	// seen, before before
+	FetchInactive(ctx context.Context, before time.Time, limit int) ([]uint, error)
+	// Archive move the user to the archive when it is still inactive since
+	// before, ErrUserNotInactive otherwise
+	Archive(ctx context.Context, id uint, before time.Time) error
+	FetchArchive(ctx context.Context, filter models.ArchiveFilter, page, limit int) ([]models.UserArchive, int64, error)
+	GetArchive(ctx context.Context, id uint) (models.UserArchive, error)
+	Restore(ctx context.Context, id uint) (models.User, error)
+	PurgeArchive(ctx context.Context, id uint) error
 }
 
 // interface for usecase
 type IUserUsecase interface {
-	Fetch(ctx context.Context) ([]models.User, error)
+	Fetch(ctx context.Context, filter models.UserFilter) ([]models.User, error)
 	Create(ctx context.Context, user models.User) (models.User, error)
 	Update(ctx context.Context, user models.User) (models.User, error)
 	GetByID(ctx context.Context, id uint) (models.User, error)
 	Delete(ctx context.Context, id uint) error
+
+	ChangeStatus(ctx context.Context, id uint, status, reason string) (models.User, error)
+	Authenticate(ctx context.Context, id uint) (models.User, error)
+	PatchAttributes(ctx context.Context, id uint, patch models.Attributes) (models.User, error)
+	UploadAvatar(ctx context.Context, id uint, file io.Reader) (models.User, error)
+
+	History(ctx context.Context, userID uint, page, limit int) ([]models.UserAudit, int64, error)
+	VerifyHistory(ctx context.Context, userID uint) (models.AuditVerification, error)
+
+	Merge(ctx context.Context, targetID uint, request models.MergeRequest) (models.MergeResult, error)
+
+	Export(ctx context.Context, id uint) ([]byte, error)
+	Erase(ctx context.Context, id uint) (models.Erasure, error)
+
+	// Reencrypt bring every user of the tenant in ctx under the current key
+	Reencrypt(ctx context.Context) (models.Reencryption, error)
+
+	// Retention archive inactive user and purge expired archive of the
+	// tenant in ctx
+	Retention(ctx context.Context, dryRun bool) (models.RetentionReport, error)
+	FetchArchive(ctx context.Context, page, limit int) ([]models.UserArchive, int64, error)
+	Restore(ctx context.Context, id uint) (models.User, error)
+}
+
+type uncachedKey struct{}
+
+// Uncached mark ctx so the user is read from the database and not from a
+// cache, a user read to be changed and updated must not be a stale copy
+func Uncached(ctx context.Context) context.Context {
+	return context.WithValue(ctx, uncachedKey{}, true)
+}
+
+// IsUncached report whether ctx was marked by Uncached
+func IsUncached(ctx context.Context) bool {
+	uncached, _ := ctx.Value(uncachedKey{}).(bool)
+	return uncached
 }
@@ -0,0 +1,12 @@
+package domain
+
+import "errors"
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidURL       = errors.New("invalid webhook url")
+	ErrInvalidEvent     = errors.New("invalid webhook event type")
+	ErrInvalidStatus    = errors.New("invalid webhook status")
+	ErrDeliveryPending  = errors.New("webhook delivery is still pending")
+)
@@ -0,0 +1,279 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/webhook/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookRepository struct {
+	mock.Mock
+}
+
+func (m *WebhookRepository) Fetch(ctx context.Context, page int, limit int) ([]models.Webhook, int64, error) {
+	ret := m.Called(ctx, page, limit)
+
+	var (
+		r0 []models.Webhook
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *WebhookRepository) Create(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	ret := m.Called(ctx, webhook)
+
+	var (
+		r0 models.Webhook
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookRepository) Update(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	ret := m.Called(ctx, webhook)
+
+	var (
+		r0 models.Webhook
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookRepository) GetByID(ctx context.Context, id uint) (models.Webhook, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Webhook
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookRepository) Delete(ctx context.Context, id uint) error {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *WebhookRepository) Active(ctx context.Context) ([]models.Webhook, error) {
+	ret := m.Called(ctx)
+
+	var (
+		r0 []models.Webhook
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookRepository) RecordSuccess(ctx context.Context, id uint) error {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *WebhookRepository) RecordFailure(ctx context.Context, id uint, threshold int, reason string) (bool, error) {
+	ret := m.Called(ctx, id, threshold, reason)
+
+	var (
+		r0 bool
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookRepository) FetchDeliveries(ctx context.Context, filter models.DeliveryFilter, page int, limit int) ([]models.Delivery, int64, error) {
+	ret := m.Called(ctx, filter, page, limit)
+
+	var (
+		r0 []models.Delivery
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Delivery)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.Delivery) ([]models.Delivery, error) {
+	ret := m.Called(ctx, deliveries)
+
+	var (
+		r0 []models.Delivery
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Delivery)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookRepository) UpdateDelivery(ctx context.Context, delivery models.Delivery) (models.Delivery, error) {
+	ret := m.Called(ctx, delivery)
+
+	var (
+		r0 models.Delivery
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Delivery)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookRepository) GetDelivery(ctx context.Context, id uint) (models.Delivery, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Delivery
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Delivery)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Delivery, error) {
+	ret := m.Called(ctx, now, lease, limit)
+
+	var (
+		r0 []models.Delivery
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Delivery)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookRepository) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	ret := m.Called(ctx, before)
+
+	var (
+		r0 int64
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
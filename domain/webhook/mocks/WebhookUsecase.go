@@ -0,0 +1,227 @@
+package mocks
+
+import (
+	"context"
+	"prototype/domain/webhook/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookUsecase struct {
+	mock.Mock
+}
+
+func (m *WebhookUsecase) Fetch(ctx context.Context, page int, limit int) ([]models.Webhook, int64, error) {
+	ret := m.Called(ctx, page, limit)
+
+	var (
+		r0 []models.Webhook
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *WebhookUsecase) Create(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	ret := m.Called(ctx, webhook)
+
+	var (
+		r0 models.Webhook
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookUsecase) Update(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	ret := m.Called(ctx, webhook)
+
+	var (
+		r0 models.Webhook
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookUsecase) GetByID(ctx context.Context, id uint) (models.Webhook, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Webhook
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookUsecase) Delete(ctx context.Context, id uint) error {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *WebhookUsecase) RotateSecret(ctx context.Context, id uint) (models.Webhook, error) {
+	ret := m.Called(ctx, id)
+
+	var (
+		r0 models.Webhook
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookUsecase) Dispatch(ctx context.Context, eventID string, eventType string, payload []byte) (int, error) {
+	ret := m.Called(ctx, eventID, eventType, payload)
+
+	var (
+		r0 int
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(int)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookUsecase) Deliver(ctx context.Context) (int, error) {
+	ret := m.Called(ctx)
+
+	var (
+		r0 int
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(int)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookUsecase) FetchDeliveries(ctx context.Context, filter models.DeliveryFilter, page int, limit int) ([]models.Delivery, int64, error) {
+	ret := m.Called(ctx, filter, page, limit)
+
+	var (
+		r0 []models.Delivery
+		r1 int64
+		r2 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Delivery)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *WebhookUsecase) Replay(ctx context.Context, webhookID uint, deliveryID uint) (models.Delivery, error) {
+	ret := m.Called(ctx, webhookID, deliveryID)
+
+	var (
+		r0 models.Delivery
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(models.Delivery)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *WebhookUsecase) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	ret := m.Called(ctx, before)
+
+	var (
+		r0 int64
+		r1 error
+	)
+
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
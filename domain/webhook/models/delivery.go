@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// delivery status, a pending delivery is attempted once NextAttemptAt is
+// reached, a failed one exhausted its attempts and is only sent again by a
+// replay
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery is one event posted to a webhook with the outcome of its last
+// attempt. EventID is the id the receiver see, it is kept by a replay so the
+// receiver can tell it already handled the event
+type Delivery struct {
+	ID             uint       `json:"id"`
+	TenantID       uint       `gorm:"not null;index" json:"-"`
+	WebhookID      uint       `gorm:"not null;index" json:"webhook_id"`
+	EventID        string     `gorm:"size:64;not null;index" json:"event_id"`
+	EventType      string     `gorm:"size:64;not null" json:"event_type"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"size:20;not null;index:idx_delivery_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_delivery_due,priority:2" json:"next_attempt_at"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	Duration       int64      `json:"duration_ms,omitempty"`
+	ReplayOf       uint       `json:"replay_of,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (Delivery) TableName() string {
+	return "webhook_delivery"
+}
+
+// DeliveryFilter
+type DeliveryFilter struct {
+	WebhookID uint
+	EventID   string
+	Status    []string
+}
+
+func IsValidDeliveryStatus(status string) bool {
+	switch status {
+	case DeliveryPending, DeliverySucceeded, DeliveryFailed:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// webhook status, a webhook is disabled by an admin or once its delivery
+// failed DisableAfter time in a row
+const (
+	StatusActive   = "active"
+	StatusDisabled = "disabled"
+)
+
+// FieldSecret is the encrypted field of webhook, the name is bound to the
+// ciphertext
+const FieldSecret = "secret"
+
+// EventAll subscribe a webhook to every event type
+const EventAll = "*"
+
+// Webhook is a partner endpoint user event are posted to. Secret is only
+// returned when it is created or rotated
+type Webhook struct {
+	ID             uint       `json:"id"`
+	TenantID       uint       `gorm:"not null;index" json:"-"`
+	URL            string     `gorm:"size:2048;not null" json:"url" binding:"required,url"`
+	Events         Events     `gorm:"type:json" json:"events" binding:"required,min=1"`
+	Description    string     `gorm:"size:255" json:"description,omitempty"`
+	Secret         string     `gorm:"size:512;not null" json:"secret,omitempty"`
+	Status         string     `gorm:"size:20;not null;index" json:"status"`
+	Failures       int        `gorm:"not null;default:0" json:"failures"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `gorm:"size:255" json:"disabled_reason,omitempty"`
+	CreatedBy      uint       `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// KeyID and DataKey hold the wrapped key Secret is encrypted with, empty
+	// while the row is still plaintext
+	KeyID   string `gorm:"size:64" json:"-"`
+	DataKey string `gorm:"size:512" json:"-"`
+}
+
+func (Webhook) TableName() string {
+	return "webhook"
+}
+
+// Events is the event type a webhook is subscribed to
+type Events []string
+
+func (events Events) Value() (driver.Value, error) {
+	if events == nil {
+		return "[]", nil
+	}
+
+	value, err := json.Marshal(events)
+	return string(value), err
+}
+
+func (events *Events) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, events)
+	case string:
+		return json.Unmarshal([]byte(data), events)
+	case nil:
+		*events = nil
+		return nil
+	}
+
+	return errors.New("unsupported type for Events")
+}
+
+// Subscribed tell whether the webhook want event of eventType
+func (webhook Webhook) Subscribed(eventType string) bool {
+	for _, event := range webhook.Events {
+		if event == EventAll || event == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusDisabled:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,344 @@
+package repository_mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	domain "prototype/domain/webhook"
+	"prototype/domain/webhook/models"
+	"prototype/lib/crypt"
+	"prototype/lib/log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type webhookMysqlRepository struct {
+	DB     *gorm.DB
+	cipher *crypt.FieldCipher
+	log    log.ILogs
+}
+
+// NewMysqlWebhookRepo store the secret of webhook encrypted with cipher, a nil
+// cipher store it in plaintext
+func NewMysqlWebhookRepo(DB *gorm.DB, cipher *crypt.FieldCipher, log log.ILogs) domain.IWebhookMysqlRepository {
+	return webhookMysqlRepository{DB, cipher, log}
+}
+
+func (repo webhookMysqlRepository) Fetch(ctx context.Context, page, limit int) (result []models.Webhook, total int64, err error) {
+	query := repo.DB.WithContext(ctx).Model(&models.Webhook{})
+
+	if err = query.Count(&total).Error; err != nil {
+		repo.log.Error(ctx, "query.Count(&total)", err)
+		return
+	}
+
+	if err = query.Order("id ASC").Offset((page - 1) * limit).Limit(limit).Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Order('id ASC').Find(&result)", err)
+		return
+	}
+
+	err = repo.openAll(ctx, result)
+	return
+}
+
+func (repo webhookMysqlRepository) Create(ctx context.Context, webhook models.Webhook) (result models.Webhook, err error) {
+	sealed, err := repo.seal(ctx, webhook)
+	if err != nil {
+		repo.log.Error(ctx, "repo.seal(ctx, webhook)", err)
+		return
+	}
+
+	if err = repo.DB.WithContext(ctx).Create(&sealed).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Create(&sealed)", err)
+		return
+	}
+
+	sealed.Secret = webhook.Secret
+	result = sealed
+	return
+}
+
+func (repo webhookMysqlRepository) Update(ctx context.Context, webhook models.Webhook) (result models.Webhook, err error) {
+	sealed, err := repo.seal(ctx, webhook)
+	if err != nil {
+		repo.log.Error(ctx, "repo.seal(ctx, webhook)", err)
+		return
+	}
+
+	if err = repo.DB.WithContext(ctx).Save(&sealed).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Save(&sealed)", err)
+		return
+	}
+
+	sealed.Secret = webhook.Secret
+	result = sealed
+	return
+}
+
+func (repo webhookMysqlRepository) GetByID(ctx context.Context, id uint) (result models.Webhook, err error) {
+	err = repo.DB.WithContext(ctx).Where("id = ?", id).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domain.ErrWebhookNotFound
+		return
+	}
+
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('id = ?', id).First(&result)", err)
+		return
+	}
+
+	if result, err = repo.open(ctx, result); err != nil {
+		repo.log.Error(ctx, "repo.open(ctx, result)", err)
+		return
+	}
+
+	return
+}
+
+// Delete remove the webhook together with its delivery log
+func (repo webhookMysqlRepository) Delete(ctx context.Context, id uint) (err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		remove := tx.Where("id = ?", id).Delete(&models.Webhook{})
+		if remove.Error != nil {
+			repo.log.Error(ctx, "tx.Where('id = ?', id).Delete(&models.Webhook{})", remove.Error)
+			return remove.Error
+		}
+
+		if remove.RowsAffected == 0 {
+			return domain.ErrWebhookNotFound
+		}
+
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.Delivery{}).Error; err != nil {
+			repo.log.Error(ctx, "tx.Where('webhook_id = ?', id).Delete(&models.Delivery{})", err)
+			return err
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func (repo webhookMysqlRepository) Active(ctx context.Context) (result []models.Webhook, err error) {
+	if err = repo.DB.WithContext(ctx).Where("status = ?", models.StatusActive).Order("id ASC").Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('status = ?', models.StatusActive).Find(&result)", err)
+		return
+	}
+
+	err = repo.openAll(ctx, result)
+	return
+}
+
+func (repo webhookMysqlRepository) RecordSuccess(ctx context.Context, id uint) (err error) {
+	err = repo.DB.WithContext(ctx).Model(&models.Webhook{}).Where("id = ? AND failures > 0", id).UpdateColumn("failures", 0).Error
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Model(&models.Webhook{}).UpdateColumn('failures', 0)", err)
+		return
+	}
+
+	return
+}
+
+func (repo webhookMysqlRepository) RecordFailure(ctx context.Context, id uint, threshold int, reason string) (disabled bool, err error) {
+	err = repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Webhook{}).Where("id = ?", id).UpdateColumn("failures", gorm.Expr("failures + 1")).Error
+		if err != nil {
+			repo.log.Error(ctx, "tx.Model(&models.Webhook{}).UpdateColumn('failures')", err)
+			return err
+		}
+
+		if threshold <= 0 {
+			return nil
+		}
+
+		now := time.Now()
+		disable := tx.Model(&models.Webhook{}).
+			Where("id = ? AND status = ? AND failures >= ?", id, models.StatusActive, threshold).
+			UpdateColumns(map[string]interface{}{
+				"status":          models.StatusDisabled,
+				"disabled_at":     now,
+				"disabled_reason": reason,
+				"updated_at":      now,
+			})
+		if disable.Error != nil {
+			repo.log.Error(ctx, "tx.Model(&models.Webhook{}).UpdateColumns(disable)", disable.Error)
+			return disable.Error
+		}
+
+		disabled = disable.RowsAffected > 0
+		return nil
+	})
+
+	return
+}
+
+func (repo webhookMysqlRepository) FetchDeliveries(ctx context.Context, filter models.DeliveryFilter, page, limit int) (result []models.Delivery, total int64, err error) {
+	query := repo.DB.WithContext(ctx).Model(&models.Delivery{})
+
+	if filter.WebhookID != 0 {
+		query = query.Where("webhook_id = ?", filter.WebhookID)
+	}
+
+	if filter.EventID != "" {
+		query = query.Where("event_id = ?", filter.EventID)
+	}
+
+	if len(filter.Status) > 0 {
+		query = query.Where("status IN ?", filter.Status)
+	}
+
+	if err = query.Count(&total).Error; err != nil {
+		repo.log.Error(ctx, "query.Count(&total)", err)
+		return
+	}
+
+	if err = query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&result).Error; err != nil {
+		repo.log.Error(ctx, "query.Order('id DESC').Find(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo webhookMysqlRepository) CreateDeliveries(ctx context.Context, deliveries []models.Delivery) (result []models.Delivery, err error) {
+	if len(deliveries) == 0 {
+		return
+	}
+
+	if err = repo.DB.WithContext(ctx).Create(&deliveries).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Create(&deliveries)", err)
+		return
+	}
+
+	result = deliveries
+	return
+}
+
+func (repo webhookMysqlRepository) UpdateDelivery(ctx context.Context, delivery models.Delivery) (result models.Delivery, err error) {
+	if err = repo.DB.WithContext(ctx).Save(&delivery).Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Save(&delivery)", err)
+		return
+	}
+
+	result = delivery
+	return
+}
+
+func (repo webhookMysqlRepository) GetDelivery(ctx context.Context, id uint) (result models.Delivery, err error) {
+	err = repo.DB.WithContext(ctx).Where("id = ?", id).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domain.ErrDeliveryNotFound
+		return
+	}
+
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('id = ?', id).First(&result)", err)
+		return
+	}
+
+	return
+}
+
+func (repo webhookMysqlRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) (result []models.Delivery, err error) {
+	active := repo.DB.WithContext(ctx).Model(&models.Webhook{}).Select("id").Where("status = ?", models.StatusActive)
+
+	var due []models.Delivery
+	err = repo.DB.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ? AND webhook_id IN (?)", models.DeliveryPending, now, active).
+		Order("id ASC").Limit(limit).Find(&due).Error
+	if err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('status = ? AND next_attempt_at <= ?').Find(&due)", err)
+		return
+	}
+
+	// the due condition is checked again so only one replica win a delivery
+	for _, delivery := range due {
+		claim := repo.DB.WithContext(ctx).Model(&models.Delivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", delivery.ID, models.DeliveryPending, now).
+			UpdateColumn("next_attempt_at", now.Add(lease))
+		if claim.Error != nil {
+			repo.log.Error(ctx, "repo.DB.WithContext(ctx).Model(&models.Delivery{}).UpdateColumn('next_attempt_at')", claim.Error)
+			err = claim.Error
+			return
+		}
+
+		if claim.RowsAffected > 0 {
+			delivery.NextAttemptAt = now.Add(lease)
+			result = append(result, delivery)
+		}
+	}
+
+	return
+}
+
+func (repo webhookMysqlRepository) PurgeDeliveries(ctx context.Context, before time.Time) (count int64, err error) {
+	remove := repo.DB.WithContext(ctx).
+		Where("status IN ? AND updated_at < ?", []string{models.DeliverySucceeded, models.DeliveryFailed}, before).
+		Delete(&models.Delivery{})
+	if err = remove.Error; err != nil {
+		repo.log.Error(ctx, "repo.DB.WithContext(ctx).Where('status IN ? AND updated_at < ?').Delete(&models.Delivery{})", err)
+		return
+	}
+
+	count = remove.RowsAffected
+	return
+}
+
+// seal return the copy of webhook stored in database, the secret is
+// encrypted with a new data key when a cipher is configured
+func (repo webhookMysqlRepository) seal(ctx context.Context, webhook models.Webhook) (sealed models.Webhook, err error) {
+	sealed = webhook
+	sealed.KeyID, sealed.DataKey = "", ""
+
+	if repo.cipher == nil {
+		return
+	}
+
+	dataKey, keyID, wrapped, err := repo.cipher.NewDataKey(ctx)
+	if err != nil {
+		return
+	}
+
+	if sealed.Secret, err = dataKey.Seal(models.FieldSecret, webhook.Secret); err != nil {
+		return
+	}
+
+	sealed.KeyID, sealed.DataKey = keyID, wrapped
+	return
+}
+
+// open decrypt the secret of a stored webhook, row without key id were
+// written before encryption and are returned as is
+func (repo webhookMysqlRepository) open(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	if webhook.KeyID == "" {
+		return webhook, nil
+	}
+
+	if repo.cipher == nil {
+		return webhook, fmt.Errorf("%w: %s, encryption is not configured", crypt.ErrUnknownKey, webhook.KeyID)
+	}
+
+	dataKey, err := repo.cipher.OpenDataKey(ctx, webhook.KeyID, webhook.DataKey)
+	if err != nil {
+		return webhook, err
+	}
+
+	if webhook.Secret, err = dataKey.Open(models.FieldSecret, webhook.Secret); err != nil {
+		return webhook, fmt.Errorf("webhook %d %s: %w", webhook.ID, models.FieldSecret, err)
+	}
+
+	return webhook, nil
+}
+
+// openAll decrypt webhooks in place
+func (repo webhookMysqlRepository) openAll(ctx context.Context, webhooks []models.Webhook) (err error) {
+	for i := range webhooks {
+		if webhooks[i], err = repo.open(ctx, webhooks[i]); err != nil {
+			repo.log.Error(ctx, "repo.open(ctx, webhook)", err)
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,151 @@
+package repository_mysql
+
+import (
+	"context"
+	"prototype/domain/webhook/models"
+	"prototype/lib/crypt"
+	"prototype/lib/log"
+	"prototype/lib/tenant"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupWebhookDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&models.Webhook{}, &models.Delivery{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func newTestCipher(t *testing.T) *crypt.FieldCipher {
+	file, err := crypt.GenerateKeyringFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, indexKey, err := crypt.NewLocalKeyring(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return crypt.NewFieldCipher(keyring, indexKey)
+}
+
+func Test_webhookMysqlRepository_Secret(t *testing.T) {
+	db := setupWebhookDB(t)
+	repo := NewMysqlWebhookRepo(db, newTestCipher(t), log.NewLog())
+	ctx := tenant.WithID(context.Background(), 1)
+
+	created, err := repo.Create(ctx, models.Webhook{URL: "https://partner.example", Events: models.Events{"*"}, Secret: "whsec_plain", Status: models.StatusActive})
+	if err != nil {
+		t.Fatalf("repo.Create() error = %v", err)
+	}
+
+	if created.Secret != "whsec_plain" {
+		t.Errorf("repo.Create() secret = %q, want the plaintext back", created.Secret)
+	}
+
+	var stored models.Webhook
+	if err := db.WithContext(ctx).First(&stored, created.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Secret == "whsec_plain" || stored.KeyID == "" {
+		t.Errorf("stored secret = %q with key %q, want it encrypted", stored.Secret, stored.KeyID)
+	}
+
+	got, err := repo.GetByID(ctx, created.ID)
+	if err != nil || got.Secret != "whsec_plain" {
+		t.Errorf("repo.GetByID() = %q, %v, want the decrypted secret", got.Secret, err)
+	}
+}
+
+func Test_webhookMysqlRepository_ClaimDeliveries(t *testing.T) {
+	repo := NewMysqlWebhookRepo(setupWebhookDB(t), nil, log.NewLog())
+	ctx := tenant.WithID(context.Background(), 1)
+	now := time.Now()
+
+	active, _ := repo.Create(ctx, models.Webhook{URL: "https://a.example", Secret: "a", Status: models.StatusActive})
+	disabled, _ := repo.Create(ctx, models.Webhook{URL: "https://b.example", Secret: "b", Status: models.StatusDisabled})
+
+	_, err := repo.CreateDeliveries(ctx, []models.Delivery{
+		{WebhookID: active.ID, EventID: "due", Status: models.DeliveryPending, NextAttemptAt: now.Add(-time.Second)},
+		{WebhookID: active.ID, EventID: "later", Status: models.DeliveryPending, NextAttemptAt: now.Add(time.Hour)},
+		{WebhookID: active.ID, EventID: "done", Status: models.DeliverySucceeded, NextAttemptAt: now.Add(-time.Second)},
+		{WebhookID: disabled.ID, EventID: "disabled", Status: models.DeliveryPending, NextAttemptAt: now.Add(-time.Second)},
+	})
+	if err != nil {
+		t.Fatalf("repo.CreateDeliveries() error = %v", err)
+	}
+
+	claimed, err := repo.ClaimDeliveries(ctx, now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("repo.ClaimDeliveries() error = %v", err)
+	}
+
+	if len(claimed) != 1 || claimed[0].EventID != "due" {
+		t.Fatalf("repo.ClaimDeliveries() = %v, want only the due delivery of the active webhook", claimed)
+	}
+
+	// the lease keep it from another replica
+	if again, _ := repo.ClaimDeliveries(ctx, now, time.Minute, 10); len(again) != 0 {
+		t.Errorf("repo.ClaimDeliveries() again = %v, want none while leased", again)
+	}
+
+	if other, _ := repo.ClaimDeliveries(tenant.WithID(context.Background(), 2), now.Add(2*time.Minute), time.Minute, 10); len(other) != 0 {
+		t.Errorf("repo.ClaimDeliveries() of another tenant = %v, want none", other)
+	}
+
+	if expired, _ := repo.ClaimDeliveries(ctx, now.Add(2*time.Minute), time.Minute, 10); len(expired) != 1 {
+		t.Errorf("repo.ClaimDeliveries() after the lease = %v, want the delivery back", expired)
+	}
+}
+
+func Test_webhookMysqlRepository_RecordFailure(t *testing.T) {
+	repo := NewMysqlWebhookRepo(setupWebhookDB(t), nil, log.NewLog())
+	ctx := tenant.WithID(context.Background(), 1)
+
+	webhook, _ := repo.Create(ctx, models.Webhook{URL: "https://a.example", Secret: "a", Status: models.StatusActive})
+
+	// a success in between start the count again
+	for i, step := range []struct {
+		success      bool
+		wantDisabled bool
+	}{
+		{}, {}, {success: true}, {}, {}, {wantDisabled: true},
+	} {
+		if step.success {
+			if err := repo.RecordSuccess(ctx, webhook.ID); err != nil {
+				t.Fatalf("repo.RecordSuccess() error = %v", err)
+			}
+			continue
+		}
+
+		disabled, err := repo.RecordFailure(ctx, webhook.ID, 3, "down")
+		if err != nil {
+			t.Fatalf("repo.RecordFailure() error = %v", err)
+		}
+
+		if disabled != step.wantDisabled {
+			t.Errorf("repo.RecordFailure() step %d = %v, want %v", i, disabled, step.wantDisabled)
+		}
+	}
+
+	got, _ := repo.GetByID(ctx, webhook.ID)
+	if got.Status != models.StatusDisabled || got.DisabledAt == nil || got.DisabledReason != "down" || got.Failures != 3 {
+		t.Errorf("repo.GetByID() = %+v, want disabled after 3 failure in a row", got)
+	}
+}
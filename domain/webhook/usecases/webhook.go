@@ -0,0 +1,440 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	domain "prototype/domain/webhook"
+	"prototype/domain/webhook/models"
+	"prototype/lib/log"
+	webhookLib "prototype/lib/webhook"
+	"time"
+)
+
+// secretPrefix make a webhook secret recognisable when it leak
+const secretPrefix = "whsec_"
+
+type webhookUsecase struct {
+	webhookRepo domain.IWebhookMysqlRepository
+	sender      webhookLib.ISender
+	config      WebhookConfig
+	log         log.ILogs
+}
+
+// WebhookConfig of delivery. Events is the event type a webhook can subscribe
+// to. A failed attempt is retried after MinBackoff doubled on every attempt
+// up to MaxBackoff until MaxAttempts, a webhook is disabled once DisableAfter
+// attempt failed in a row. Lease is how long a claimed delivery is kept from
+// the other replica, it must outlast an attempt
+type WebhookConfig struct {
+	Events       []string
+	Batch        int
+	MaxAttempts  int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	DisableAfter int
+	Lease        time.Duration
+}
+
+func NewWebhookUsecase(webhookRepo domain.IWebhookMysqlRepository, sender webhookLib.ISender, config WebhookConfig, log log.ILogs) domain.IWebhookUsecase {
+	return &webhookUsecase{webhookRepo, sender, config, log}
+}
+
+// backoff return the delay before the next attempt of a delivery which
+// failed attempts time
+func (config WebhookConfig) backoff(attempts int) time.Duration {
+	delay := config.MinBackoff
+	for i := 1; i < attempts && delay < config.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > config.MaxBackoff {
+		delay = config.MaxBackoff
+	}
+
+	return delay
+}
+
+func (usecase webhookUsecase) Fetch(ctx context.Context, page, limit int) (result []models.Webhook, total int64, err error) {
+	result, total, err = usecase.webhookRepo.Fetch(ctx, page, limit)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.Fetch Error", err)
+		return
+	}
+
+	for i := range result {
+		result[i].Secret = ""
+	}
+
+	return
+}
+
+// Create store the webhook with a new secret, it is the only time the secret
+// is returned besides a rotation
+func (usecase webhookUsecase) Create(ctx context.Context, webhook models.Webhook) (result models.Webhook, err error) {
+	if err = usecase.validate(webhook); err != nil {
+		return
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return
+	}
+
+	createdBy, _ := ctx.Value("user-id").(uint)
+
+	webhook.ID = 0
+	webhook.Secret = secret
+	webhook.Status = models.StatusActive
+	webhook.Failures = 0
+	webhook.DisabledAt = nil
+	webhook.DisabledReason = ""
+	webhook.CreatedBy = createdBy
+
+	result, err = usecase.webhookRepo.Create(ctx, webhook)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.Create Error", err)
+		return
+	}
+
+	return
+}
+
+// Update change the url, event, description and status of a webhook. Enabling
+// it again reset its failure so it get DisableAfter new attempt
+func (usecase webhookUsecase) Update(ctx context.Context, webhook models.Webhook) (result models.Webhook, err error) {
+	if err = usecase.validate(webhook); err != nil {
+		return
+	}
+
+	current, err := usecase.webhookRepo.GetByID(ctx, webhook.ID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.GetByID Error", err)
+		return
+	}
+
+	current.URL = webhook.URL
+	current.Events = webhook.Events
+	current.Description = webhook.Description
+
+	if webhook.Status != "" && webhook.Status != current.Status {
+		if !models.IsValidStatus(webhook.Status) {
+			err = fmt.Errorf("%w: %s", domain.ErrInvalidStatus, webhook.Status)
+			return
+		}
+
+		current.Status = webhook.Status
+		if current.Status == models.StatusActive {
+			current.Failures = 0
+			current.DisabledAt = nil
+			current.DisabledReason = ""
+		} else {
+			now := time.Now()
+			current.DisabledAt = &now
+			current.DisabledReason = "disabled manually"
+		}
+	}
+
+	result, err = usecase.webhookRepo.Update(ctx, current)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.Update Error", err)
+		return
+	}
+
+	result.Secret = ""
+	return
+}
+
+func (usecase webhookUsecase) GetByID(ctx context.Context, id uint) (result models.Webhook, err error) {
+	result, err = usecase.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.GetByID Error", err)
+		return
+	}
+
+	result.Secret = ""
+	return
+}
+
+func (usecase webhookUsecase) Delete(ctx context.Context, id uint) (err error) {
+	if err = usecase.webhookRepo.Delete(ctx, id); err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.Delete Error", err)
+		return
+	}
+
+	return
+}
+
+// RotateSecret replace the secret of a webhook, the delivery from now on are
+// signed with the new secret only
+func (usecase webhookUsecase) RotateSecret(ctx context.Context, id uint) (result models.Webhook, err error) {
+	webhook, err := usecase.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.GetByID Error", err)
+		return
+	}
+
+	if webhook.Secret, err = newSecret(); err != nil {
+		return
+	}
+
+	result, err = usecase.webhookRepo.Update(ctx, webhook)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.Update Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase webhookUsecase) Dispatch(ctx context.Context, eventID, eventType string, payload []byte) (count int, err error) {
+	webhooks, err := usecase.webhookRepo.Active(ctx)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.Active Error", err)
+		return
+	}
+
+	now := time.Now()
+
+	var deliveries []models.Delivery
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(eventType) {
+			continue
+		}
+
+		deliveries = append(deliveries, models.Delivery{
+			WebhookID:     webhook.ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+
+	if _, err = usecase.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.CreateDeliveries Error", err)
+		return
+	}
+
+	count = len(deliveries)
+	return
+}
+
+// Deliver attempt a batch of due delivery one after the other, a failed
+// attempt is stored on the delivery and does not stop the batch
+func (usecase webhookUsecase) Deliver(ctx context.Context) (delivered int, err error) {
+	now := time.Now()
+
+	deliveries, err := usecase.webhookRepo.ClaimDeliveries(ctx, now, usecase.config.Lease, usecase.config.Batch)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.ClaimDeliveries Error", err)
+		return
+	}
+
+	webhooks := map[uint]models.Webhook{}
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			if webhook, err = usecase.webhookRepo.GetByID(ctx, delivery.WebhookID); err != nil {
+				usecase.log.Error(ctx, "usecase.webhookRepo.GetByID Error", err)
+				return
+			}
+			webhooks[webhook.ID] = webhook
+		}
+
+		// a webhook disabled by an earlier delivery of the batch keep the rest
+		if webhook.Status != models.StatusActive {
+			continue
+		}
+
+		succeeded, disabled, err := usecase.attempt(ctx, webhook, delivery)
+		if err != nil {
+			return delivered, err
+		}
+
+		if succeeded {
+			delivered++
+			webhook.Failures = 0
+		} else {
+			webhook.Failures++
+		}
+
+		if disabled {
+			webhook.Status = models.StatusDisabled
+			usecase.log.Warning(ctx, "usecase.Deliver", fmt.Sprintf("webhook %d disabled after %d failed delivery in a row", webhook.ID, usecase.config.DisableAfter))
+		}
+
+		webhooks[webhook.ID] = webhook
+	}
+
+	return
+}
+
+// attempt send one delivery and store its outcome
+func (usecase webhookUsecase) attempt(ctx context.Context, webhook models.Webhook, delivery models.Delivery) (succeeded, disabled bool, err error) {
+	result, sendErr := usecase.sender.Send(ctx, webhookLib.Request{
+		URL:    webhook.URL,
+		Secret: webhook.Secret,
+		ID:     delivery.EventID,
+		Event:  delivery.EventType,
+		Body:   []byte(delivery.Payload),
+	})
+
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.ResponseStatus = result.StatusCode
+	delivery.Duration = result.Duration.Milliseconds()
+
+	if sendErr == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = sendErr.Error()
+		if delivery.Attempts >= usecase.config.MaxAttempts {
+			delivery.Status = models.DeliveryFailed
+		} else {
+			delivery.NextAttemptAt = now.Add(usecase.config.backoff(delivery.Attempts))
+		}
+	}
+
+	if _, err = usecase.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.UpdateDelivery Error", err)
+		return
+	}
+
+	if sendErr == nil {
+		succeeded = true
+		if webhook.Failures > 0 {
+			if err = usecase.webhookRepo.RecordSuccess(ctx, webhook.ID); err != nil {
+				usecase.log.Error(ctx, "usecase.webhookRepo.RecordSuccess Error", err)
+			}
+		}
+		return
+	}
+
+	reason := fmt.Sprintf("%d delivery failed in a row, last: %s", usecase.config.DisableAfter, sendErr)
+	if disabled, err = usecase.webhookRepo.RecordFailure(ctx, webhook.ID, usecase.config.DisableAfter, reason); err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.RecordFailure Error", err)
+		return
+	}
+
+	return
+}
+
+func (usecase webhookUsecase) FetchDeliveries(ctx context.Context, filter models.DeliveryFilter, page, limit int) (result []models.Delivery, total int64, err error) {
+	for _, status := range filter.Status {
+		if !models.IsValidDeliveryStatus(status) {
+			err = fmt.Errorf("%w: %s", domain.ErrInvalidStatus, status)
+			return
+		}
+	}
+
+	if _, err = usecase.webhookRepo.GetByID(ctx, filter.WebhookID); err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.GetByID Error", err)
+		return
+	}
+
+	result, total, err = usecase.webhookRepo.FetchDeliveries(ctx, filter, page, limit)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.FetchDeliveries Error", err)
+		return
+	}
+
+	return
+}
+
+// Replay queue the event of a finished delivery again as a new delivery, the
+// event id is kept so a receiver which already handled it can tell
+func (usecase webhookUsecase) Replay(ctx context.Context, webhookID, deliveryID uint) (result models.Delivery, err error) {
+	delivery, err := usecase.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.GetDelivery Error", err)
+		return
+	}
+
+	if delivery.WebhookID != webhookID {
+		err = domain.ErrDeliveryNotFound
+		return
+	}
+
+	if delivery.Status == models.DeliveryPending {
+		err = fmt.Errorf("%w: %d", domain.ErrDeliveryPending, delivery.ID)
+		return
+	}
+
+	created, err := usecase.webhookRepo.CreateDeliveries(ctx, []models.Delivery{{
+		WebhookID:     delivery.WebhookID,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+		ReplayOf:      delivery.ID,
+	}})
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.CreateDeliveries Error", err)
+		return
+	}
+
+	result = created[0]
+	return
+}
+
+func (usecase webhookUsecase) PurgeDeliveries(ctx context.Context, before time.Time) (count int64, err error) {
+	count, err = usecase.webhookRepo.PurgeDeliveries(ctx, before)
+	if err != nil {
+		usecase.log.Error(ctx, "usecase.webhookRepo.PurgeDeliveries Error", err)
+		return
+	}
+
+	return
+}
+
+// validate check the url is an absolute http url and every event can be
+// subscribed to
+func (usecase webhookUsecase) validate(webhook models.Webhook) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: %s", domain.ErrInvalidURL, webhook.URL)
+	}
+
+	if len(webhook.Events) == 0 {
+		return fmt.Errorf("%w: at least one is required", domain.ErrInvalidEvent)
+	}
+
+	for _, event := range webhook.Events {
+		if !usecase.isEvent(event) {
+			return fmt.Errorf("%w: %s", domain.ErrInvalidEvent, event)
+		}
+	}
+
+	return nil
+}
+
+func (usecase webhookUsecase) isEvent(event string) bool {
+	if event == models.EventAll {
+		return true
+	}
+
+	for _, known := range usecase.config.Events {
+		if known == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return secretPrefix + hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,315 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	domain "prototype/domain/webhook"
+	"prototype/domain/webhook/mocks"
+	"prototype/domain/webhook/models"
+	"prototype/lib/log"
+	webhookLib "prototype/lib/webhook"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+var testConfig = WebhookConfig{
+	Events:       []string{"user.created", "user.deleted"},
+	Batch:        10,
+	MaxAttempts:  3,
+	MinBackoff:   time.Minute,
+	MaxBackoff:   time.Hour,
+	DisableAfter: 5,
+	Lease:        time.Minute,
+}
+
+// receiver is a local partner endpoint checking the signature of every
+// delivery, it answer status
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	received []string
+	invalid  []error
+}
+
+// loopback let the client reach the receiver of the test
+var loopback, _ = webhookLib.ParseNetworks("127.0.0.0/8", "::1")
+
+func newReceiver(t *testing.T, secret string) *receiver {
+	r := &receiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if err := webhookLib.Verify(secret, req.Header, body, time.Minute, time.Now()); err != nil {
+			r.invalid = append(r.invalid, err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		r.received = append(r.received, req.Header.Get(webhookLib.HeaderID))
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func Test_webhookUsecase_Create(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "user-id", uint(7))
+
+	webhookRepo := new(mocks.WebhookRepository)
+	webhookRepo.On("Create", ctx, mock.MatchedBy(func(webhook models.Webhook) bool {
+		return strings.HasPrefix(webhook.Secret, secretPrefix) && webhook.Status == models.StatusActive && webhook.CreatedBy == 7
+	})).Return(models.Webhook{ID: 1, Secret: secretPrefix + "returned", Status: models.StatusActive}, nil)
+
+	usecase := NewWebhookUsecase(webhookRepo, nil, testConfig, log.NewLog())
+
+	tests := []struct {
+		name    string
+		webhook models.Webhook
+		wantErr error
+	}{
+		{
+			name:    "success",
+			webhook: models.Webhook{URL: "https://partner.example/hook", Events: models.Events{"user.created"}},
+		},
+		{
+			name:    "success every event",
+			webhook: models.Webhook{URL: "http://partner.example/hook", Events: models.Events{models.EventAll}},
+		},
+		{
+			name:    "failed relative url",
+			webhook: models.Webhook{URL: "/hook", Events: models.Events{"user.created"}},
+			wantErr: domain.ErrInvalidURL,
+		},
+		{
+			name:    "failed other scheme",
+			webhook: models.Webhook{URL: "ftp://partner.example/hook", Events: models.Events{"user.created"}},
+			wantErr: domain.ErrInvalidURL,
+		},
+		{
+			name:    "failed unknown event",
+			webhook: models.Webhook{URL: "https://partner.example/hook", Events: models.Events{"user.renamed"}},
+			wantErr: domain.ErrInvalidEvent,
+		},
+		{
+			name:    "failed without event",
+			webhook: models.Webhook{URL: "https://partner.example/hook"},
+			wantErr: domain.ErrInvalidEvent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := usecase.Create(ctx, tt.webhook)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("webhookUsecase.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && !strings.HasPrefix(got.Secret, secretPrefix) {
+				t.Errorf("webhookUsecase.Create() secret = %q, want it returned once", got.Secret)
+			}
+		})
+	}
+}
+
+func Test_webhookUsecase_Dispatch(t *testing.T) {
+	ctx := context.Background()
+
+	webhookRepo := new(mocks.WebhookRepository)
+	webhookRepo.On("Active", ctx).Return([]models.Webhook{
+		{ID: 1, Events: models.Events{"user.created"}},
+		{ID: 2, Events: models.Events{"user.deleted"}},
+		{ID: 3, Events: models.Events{models.EventAll}},
+	}, nil)
+	webhookRepo.On("CreateDeliveries", ctx, mock.Anything).Return(nil, nil)
+
+	usecase := NewWebhookUsecase(webhookRepo, nil, testConfig, log.NewLog())
+
+	count, err := usecase.Dispatch(ctx, "event-1", "user.created", []byte(`{}`))
+	if err != nil {
+		t.Fatalf("webhookUsecase.Dispatch() error = %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("webhookUsecase.Dispatch() = %d, want 2", count)
+	}
+
+	webhookRepo.AssertCalled(t, "CreateDeliveries", ctx, mock.MatchedBy(func(deliveries []models.Delivery) bool {
+		return len(deliveries) == 2 && deliveries[0].WebhookID == 1 && deliveries[1].WebhookID == 3 &&
+			deliveries[0].EventID == "event-1" && deliveries[0].Status == models.DeliveryPending
+	}))
+}
+
+func Test_webhookUsecase_Deliver(t *testing.T) {
+	ctx := context.Background()
+	const secret = "whsec_test"
+
+	tests := []struct {
+		name          string
+		status        int
+		attempts      int
+		failures      int
+		disable       bool
+		wantDelivered int
+		wantStatus    string
+		wantAttempts  int
+		wantReceived  int
+	}{
+		{
+			name:          "succeeded",
+			status:        http.StatusNoContent,
+			failures:      2,
+			wantDelivered: 2,
+			wantStatus:    models.DeliverySucceeded,
+			wantAttempts:  1,
+			wantReceived:  2,
+		},
+		{
+			name:         "failed is retried later",
+			status:       http.StatusInternalServerError,
+			wantStatus:   models.DeliveryPending,
+			wantAttempts: 1,
+			wantReceived: 2,
+		},
+		{
+			name:         "failed on the last attempt",
+			status:       http.StatusInternalServerError,
+			attempts:     2,
+			wantStatus:   models.DeliveryFailed,
+			wantAttempts: 3,
+			wantReceived: 2,
+		},
+		{
+			name:         "disabled webhook keep the rest of the batch",
+			status:       http.StatusBadGateway,
+			disable:      true,
+			wantStatus:   models.DeliveryPending,
+			wantAttempts: 1,
+			wantReceived: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partner := newReceiver(t, secret)
+			partner.status = tt.status
+
+			webhookRepo := new(mocks.WebhookRepository)
+			webhookRepo.On("ClaimDeliveries", ctx, mock.Anything, testConfig.Lease, testConfig.Batch).Return([]models.Delivery{
+				{ID: 1, WebhookID: 1, EventID: "event-1", EventType: "user.created", Payload: `{"user_id":1}`, Status: models.DeliveryPending, Attempts: tt.attempts},
+				{ID: 2, WebhookID: 1, EventID: "event-2", EventType: "user.created", Payload: `{"user_id":2}`, Status: models.DeliveryPending, Attempts: tt.attempts},
+			}, nil)
+			webhookRepo.On("GetByID", ctx, uint(1)).Return(models.Webhook{
+				ID: 1, URL: partner.URL, Secret: secret, Status: models.StatusActive, Failures: tt.failures,
+			}, nil).Once()
+			webhookRepo.On("UpdateDelivery", ctx, mock.Anything).Return(models.Delivery{}, nil)
+			webhookRepo.On("RecordSuccess", ctx, uint(1)).Return(nil)
+			webhookRepo.On("RecordFailure", ctx, uint(1), testConfig.DisableAfter, mock.Anything).Return(tt.disable, nil)
+
+			usecase := NewWebhookUsecase(webhookRepo, webhookLib.NewClient(webhookLib.ClientConfig{Timeout: time.Second, Allow: loopback}), testConfig, log.NewLog())
+
+			start := time.Now()
+			delivered, err := usecase.Deliver(ctx)
+			if err != nil {
+				t.Fatalf("webhookUsecase.Deliver() error = %v", err)
+			}
+
+			if delivered != tt.wantDelivered {
+				t.Errorf("webhookUsecase.Deliver() = %d, want %d", delivered, tt.wantDelivered)
+			}
+
+			if len(partner.invalid) > 0 {
+				t.Fatalf("receiver refused the signature: %v", partner.invalid)
+			}
+
+			if len(partner.received) != tt.wantReceived || partner.received[0] != "event-1" {
+				t.Errorf("receiver got %v, want %d delivery starting with event-1", partner.received, tt.wantReceived)
+			}
+
+			webhookRepo.AssertCalled(t, "UpdateDelivery", ctx, mock.MatchedBy(func(delivery models.Delivery) bool {
+				if delivery.ID != 1 || delivery.Status != tt.wantStatus || delivery.Attempts != tt.wantAttempts || delivery.ResponseStatus != tt.status {
+					return false
+				}
+
+				switch delivery.Status {
+				case models.DeliverySucceeded:
+					return delivery.DeliveredAt != nil && delivery.LastError == ""
+				case models.DeliveryPending:
+					// the first retry wait MinBackoff
+					return delivery.LastError != "" && !delivery.NextAttemptAt.Before(start.Add(testConfig.MinBackoff))
+				}
+				return delivery.LastError != ""
+			}))
+
+			if tt.wantDelivered > 0 {
+				webhookRepo.AssertNumberOfCalls(t, "RecordSuccess", 1)
+				webhookRepo.AssertNotCalled(t, "RecordFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				webhookRepo.AssertNumberOfCalls(t, "RecordFailure", tt.wantReceived)
+			}
+		})
+	}
+}
+
+func TestWebhookConfig_backoff(t *testing.T) {
+	config := WebhookConfig{MinBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 30: 10 * time.Second} {
+		if got := config.backoff(attempts); got != want {
+			t.Errorf("WebhookConfig.backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func Test_webhookUsecase_Replay(t *testing.T) {
+	ctx := context.Background()
+
+	webhookRepo := new(mocks.WebhookRepository)
+	webhookRepo.On("GetDelivery", ctx, uint(1)).Return(models.Delivery{ID: 1, WebhookID: 1, EventID: "event-1", Status: models.DeliveryFailed, Attempts: 3}, nil)
+	webhookRepo.On("GetDelivery", ctx, uint(2)).Return(models.Delivery{ID: 2, WebhookID: 1, Status: models.DeliveryPending}, nil)
+	webhookRepo.On("GetDelivery", ctx, uint(3)).Return(models.Delivery{}, domain.ErrDeliveryNotFound)
+	webhookRepo.On("CreateDeliveries", ctx, mock.MatchedBy(func(deliveries []models.Delivery) bool {
+		return len(deliveries) == 1 && deliveries[0].ReplayOf == 1 && deliveries[0].EventID == "event-1" &&
+			deliveries[0].Status == models.DeliveryPending && deliveries[0].Attempts == 0
+	})).Return([]models.Delivery{{ID: 10, WebhookID: 1, EventID: "event-1", Status: models.DeliveryPending, ReplayOf: 1}}, nil)
+
+	usecase := NewWebhookUsecase(webhookRepo, nil, testConfig, log.NewLog())
+
+	tests := []struct {
+		name       string
+		webhookID  uint
+		deliveryID uint
+		wantErr    error
+	}{
+		{name: "success", webhookID: 1, deliveryID: 1},
+		{name: "failed still pending", webhookID: 1, deliveryID: 2, wantErr: domain.ErrDeliveryPending},
+		{name: "failed delivery of another webhook", webhookID: 2, deliveryID: 1, wantErr: domain.ErrDeliveryNotFound},
+		{name: "failed not found", webhookID: 1, deliveryID: 3, wantErr: domain.ErrDeliveryNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := usecase.Replay(ctx, tt.webhookID, tt.deliveryID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("webhookUsecase.Replay() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got.ID != 10 || got.ReplayOf != tt.deliveryID || got.EventID != "event-1" || got.Status != models.DeliveryPending || got.Attempts != 0 {
+				t.Errorf("webhookUsecase.Replay() = %+v, want a new pending delivery of event-1", got)
+			}
+		})
+	}
+}
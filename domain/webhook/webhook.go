@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"prototype/domain/webhook/models"
+	"time"
+)
+
+// interface for repository
+type IWebhookMysqlRepository interface {
+	Fetch(ctx context.Context, page, limit int) ([]models.Webhook, int64, error)
+	Create(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	Update(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	GetByID(ctx context.Context, id uint) (models.Webhook, error)
+	Delete(ctx context.Context, id uint) error
+
+	// Active return every active webhook, the usecase pick the subscribed one
+	Active(ctx context.Context) ([]models.Webhook, error)
+
+	// RecordSuccess reset the consecutive failure of a webhook
+	RecordSuccess(ctx context.Context, id uint) error
+
+	// RecordFailure count a failed attempt and disable the webhook once it
+	// failed threshold time in a row, it return whether it got disabled
+	RecordFailure(ctx context.Context, id uint, threshold int, reason string) (bool, error)
+
+	FetchDeliveries(ctx context.Context, filter models.DeliveryFilter, page, limit int) ([]models.Delivery, int64, error)
+	CreateDeliveries(ctx context.Context, deliveries []models.Delivery) ([]models.Delivery, error)
+	UpdateDelivery(ctx context.Context, delivery models.Delivery) (models.Delivery, error)
+	GetDelivery(ctx context.Context, id uint) (models.Delivery, error)
+
+	// ClaimDeliveries return up to limit pending delivery of active webhook
+	// which are due at now, they are pushed back by lease so another replica
+	// does not attempt them at the same time
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Delivery, error)
+
+	// PurgeDeliveries delete the finished delivery last updated before
+	PurgeDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// interface for usecase
+type IWebhookUsecase interface {
+	Fetch(ctx context.Context, page, limit int) ([]models.Webhook, int64, error)
+	Create(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	Update(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	GetByID(ctx context.Context, id uint) (models.Webhook, error)
+	Delete(ctx context.Context, id uint) error
+	RotateSecret(ctx context.Context, id uint) (models.Webhook, error)
+
+	// Dispatch queue a delivery of an event to every webhook subscribed to
+	// its type
+	Dispatch(ctx context.Context, eventID, eventType string, payload []byte) (int, error)
+
+	// Deliver attempt the due delivery of the tenant in ctx, it return how
+	// many succeeded
+	Deliver(ctx context.Context) (int, error)
+
+	FetchDeliveries(ctx context.Context, filter models.DeliveryFilter, page, limit int) ([]models.Delivery, int64, error)
+
+	// Replay queue a new delivery of the event of a finished delivery
+	Replay(ctx context.Context, webhookID, deliveryID uint) (models.Delivery, error)
+
+	PurgeDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Config bound the cache, an entry is evicted from the least recently used
+// once MaxEntries or MaxBytes is reached and expire after TTL. A zero bound
+// is unlimited
+type Config struct {
+	MaxEntries int
+	MaxBytes   int64
+	TTL        time.Duration
+}
+
+// Stats of the cache since it was created, Coalesced count the call which
+// shared the load of another one
+type Stats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Coalesced     uint64 `json:"coalesced"`
+	Evictions     uint64 `json:"evictions"`
+	Expirations   uint64 `json:"expirations"`
+	Invalidations uint64 `json:"invalidations"`
+	Entries       int    `json:"entries"`
+	Bytes         int64  `json:"bytes"`
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// LRU is an in-process cache of serialized value, concurrent load of the
+// same key are coalesced into a single one
+type LRU struct {
+	config Config
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+	bytes int64
+
+	// generation change on every invalidation, a load started before is
+	// returned but not kept
+	generation uint64
+
+	group singleflight.Group
+	stats Stats
+}
+
+// New return an empty cache bounded by config
+func New(config Config) *LRU {
+	return &LRU{
+		config: config,
+		order:  list.New(),
+		items:  map[string]*list.Element{},
+	}
+}
+
+// Get return the value of key when it is cached and not expired
+func (lru *LRU) Get(key string) ([]byte, bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	element, ok := lru.items[key]
+	if !ok {
+		lru.stats.Misses++
+		return nil, false
+	}
+
+	item := element.Value.(*entry)
+	if !item.expires.IsZero() && time.Now().After(item.expires) {
+		lru.remove(element)
+		lru.stats.Expirations++
+		lru.stats.Misses++
+		return nil, false
+	}
+
+	lru.order.MoveToFront(element)
+	lru.stats.Hits++
+	return item.value, true
+}
+
+// Set cache value under key, a value larger than MaxBytes is not kept
+func (lru *LRU) Set(key string, value []byte) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	lru.set(key, value)
+}
+
+func (lru *LRU) set(key string, value []byte) {
+	if lru.config.MaxBytes > 0 && int64(len(value)) > lru.config.MaxBytes {
+		return
+	}
+
+	if element, ok := lru.items[key]; ok {
+		lru.remove(element)
+	}
+
+	item := &entry{key: key, value: value}
+	if lru.config.TTL > 0 {
+		item.expires = time.Now().Add(lru.config.TTL)
+	}
+
+	lru.items[key] = lru.order.PushFront(item)
+	lru.bytes += int64(len(value))
+
+	for (lru.config.MaxEntries > 0 && lru.order.Len() > lru.config.MaxEntries) ||
+		(lru.config.MaxBytes > 0 && lru.bytes > lru.config.MaxBytes) {
+		lru.remove(lru.order.Back())
+		lru.stats.Evictions++
+	}
+}
+
+func (lru *LRU) remove(element *list.Element) {
+	item := lru.order.Remove(element).(*entry)
+	delete(lru.items, item.key)
+	lru.bytes -= int64(len(item.value))
+}
+
+// Delete invalidate key, a load of it in flight is not kept
+func (lru *LRU) Delete(keys ...string) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := lru.items[key]; ok {
+			lru.remove(element)
+		}
+		lru.group.Forget(key)
+		lru.stats.Invalidations++
+	}
+	lru.generation++
+}
+
+// Do return the cached value of key or load it, concurrent call for the same
+// key wait for the first load and share its result. An error is not cached
+func (lru *LRU) Do(key string, load func() ([]byte, error)) ([]byte, error) {
+	if value, ok := lru.Get(key); ok {
+		return value, nil
+	}
+
+	lru.mu.Lock()
+	generation := lru.generation
+	lru.mu.Unlock()
+
+	value, err, shared := lru.group.Do(key, func() (interface{}, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		lru.mu.Lock()
+		if lru.generation == generation {
+			lru.set(key, value)
+		}
+		lru.mu.Unlock()
+
+		return value, nil
+	})
+
+	if shared {
+		lru.mu.Lock()
+		lru.stats.Coalesced++
+		lru.mu.Unlock()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]byte), nil
+}
+
+// Stats return a snapshot of the cache statistics
+func (lru *LRU) Stats() Stats {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	stats := lru.stats
+	stats.Entries = lru.order.Len()
+	stats.Bytes = lru.bytes
+	return stats
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"prototype/lib/log"
+	"prototype/lib/redis"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestLRU_Eviction(t *testing.T) {
+	t.Run("least recently used is evicted", func(t *testing.T) {
+		lru := New(Config{MaxEntries: 2})
+		lru.Set("a", []byte("1"))
+		lru.Set("b", []byte("2"))
+		lru.Get("a")
+		lru.Set("c", []byte("3"))
+
+		if _, ok := lru.Get("b"); ok {
+			t.Error("lru.Get(b) found, want evicted")
+		}
+
+		if _, ok := lru.Get("a"); !ok {
+			t.Error("lru.Get(a) not found, want kept")
+		}
+	})
+
+	t.Run("size bound", func(t *testing.T) {
+		lru := New(Config{MaxBytes: 10})
+		lru.Set("a", []byte("123456"))
+		lru.Set("b", []byte("123456"))
+		lru.Set("large", []byte("12345678901"))
+
+		stats := lru.Stats()
+		if stats.Entries != 1 || stats.Bytes != 6 || stats.Evictions != 1 {
+			t.Errorf("lru.Stats() = %+v, want only b kept", stats)
+		}
+	})
+
+	t.Run("expired entry is a miss", func(t *testing.T) {
+		lru := New(Config{TTL: time.Millisecond})
+		lru.Set("a", []byte("1"))
+		time.Sleep(5 * time.Millisecond)
+
+		if _, ok := lru.Get("a"); ok {
+			t.Error("lru.Get(a) found, want expired")
+		}
+
+		if stats := lru.Stats(); stats.Expirations != 1 || stats.Misses != 1 {
+			t.Errorf("lru.Stats() = %+v", stats)
+		}
+	})
+}
+
+func TestLRU_Do(t *testing.T) {
+	t.Run("concurrent load are coalesced", func(t *testing.T) {
+		lru := New(Config{})
+
+		var loads int32
+		load := func() ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			time.Sleep(20 * time.Millisecond)
+			return []byte("value"), nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if value, err := lru.Do("key", load); err != nil || string(value) != "value" {
+					t.Errorf("lru.Do() = %s, %v", value, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if loads != 1 {
+			t.Errorf("load called %d times, want 1", loads)
+		}
+
+		if _, err := lru.Do("key", load); err != nil || loads != 1 {
+			t.Errorf("lru.Do() cached = %v, load called %d times", err, loads)
+		}
+	})
+
+	t.Run("error is not cached", func(t *testing.T) {
+		lru := New(Config{})
+		lru.Do("key", func() ([]byte, error) { return nil, errors.New("not found") })
+
+		if _, ok := lru.Get("key"); ok {
+			t.Error("lru.Get() found, want error not cached")
+		}
+	})
+
+	t.Run("load invalidated in flight is not kept", func(t *testing.T) {
+		lru := New(Config{})
+		lru.Do("key", func() ([]byte, error) {
+			lru.Delete("key")
+			return []byte("stale"), nil
+		})
+
+		if _, ok := lru.Get("key"); ok {
+			t.Error("lru.Get() found, want stale load dropped")
+		}
+	})
+}
+
+func TestInvalidator(t *testing.T) {
+	server := miniredis.RunT(t)
+	client, err := redis.New(redis.Config{Hosts: []string{server.Addr()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	local, remote := New(Config{}), New(Config{})
+	localInvalidator := NewInvalidator(local, client, "invalidate", log.NewLog())
+	remoteInvalidator := NewInvalidator(remote, client, "invalidate", log.NewLog())
+	go remoteInvalidator.Listen(ctx)
+
+	// wait for the subscription before publishing
+	for server.PubSubNumSub("invalidate")["invalidate"] == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	local.Set("key", []byte("1"))
+	remote.Set("key", []byte("1"))
+	localInvalidator.Invalidate(ctx, "key")
+
+	if _, ok := local.Get("key"); ok {
+		t.Error("local.Get() found, want invalidated")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := remote.Get("key"); !ok {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("remote.Get() still found, want invalidated")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"prototype/lib/log"
+	"prototype/lib/redis"
+)
+
+// Invalidator drop key from the local cache and broadcast them on a Redis
+// channel so every replica drop them too. A message lost while a replica is
+// disconnected leave its entry stale up to the TTL
+type Invalidator struct {
+	lru     *LRU
+	client  redis.IRedis
+	channel string
+	origin  string
+	log     log.ILogs
+}
+
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// NewInvalidator return an invalidator of lru, without client invalidation
+// stay local to the replica
+func NewInvalidator(lru *LRU, client redis.IRedis, channel string, log log.ILogs) *Invalidator {
+	origin := make([]byte, 8)
+	rand.Read(origin)
+
+	return &Invalidator{
+		lru:     lru,
+		client:  client,
+		channel: channel,
+		origin:  hex.EncodeToString(origin),
+		log:     log,
+	}
+}
+
+// Invalidate drop keys locally then on the other replica
+func (invalidator *Invalidator) Invalidate(ctx context.Context, keys ...string) {
+	invalidator.lru.Delete(keys...)
+
+	if invalidator.client == nil {
+		return
+	}
+
+	message, err := json.Marshal(invalidation{Origin: invalidator.origin, Keys: keys})
+	if err != nil {
+		invalidator.log.Error(ctx, "json.Marshal Error", err)
+		return
+	}
+
+	if err := invalidator.client.Publish(ctx, invalidator.channel, message).Err(); err != nil {
+		invalidator.log.Warning(ctx, "invalidator.client.Publish Error", err.Error())
+	}
+}
+
+// Listen drop the key invalidated by the other replica until ctx is done
+func (invalidator *Invalidator) Listen(ctx context.Context) {
+	if invalidator.client == nil {
+		return
+	}
+
+	subscription := invalidator.client.Subscribe(ctx, invalidator.channel)
+	defer subscription.Close()
+
+	messages := subscription.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var received invalidation
+			if err := json.Unmarshal([]byte(message.Payload), &received); err != nil {
+				invalidator.log.Warning(ctx, "json.Unmarshal Error", err.Error())
+				continue
+			}
+
+			if received.Origin != invalidator.origin {
+				invalidator.lru.Delete(received.Keys...)
+			}
+		}
+	}
+}
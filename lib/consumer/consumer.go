@@ -0,0 +1,260 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"prototype/lib/log"
+	"prototype/lib/outbox"
+	"prototype/lib/pubsub"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrPermanent mark a failure retrying can not fix, the message is dead
+// lettered without further attempt
+var ErrPermanent = errors.New("permanent failure")
+
+// Permanent wrap err as a permanent failure
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %s", ErrPermanent, err)
+}
+
+// attribute added to a dead lettered message
+const (
+	AttributeConsumer = "dead_letter_consumer"
+	AttributeAttempts = "dead_letter_attempts"
+	AttributeError    = "dead_letter_error"
+)
+
+// RetryPolicy of a consumer, a failed message is retried after MinBackoff
+// doubled on every attempt up to MaxBackoff and dead lettered once it failed
+// MaxAttempts time
+type RetryPolicy struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.MinBackoff
+	for i := 1; i < attempt && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+
+	return delay
+}
+
+// Handler handle the decoded payload of a message, payload has the type of
+// the Payload of its consumer
+type Handler func(ctx context.Context, message pubsub.Message, payload interface{}) error
+
+// Consumer declare how a domain handle the message of a subscription. The
+// json data of a message is decoded into a new value of the type of Payload,
+// a message which can not be decoded is dead lettered and a []byte Payload
+// receive the data as is. A handler run for at
+// most Timeout and is left to finish on shutdown
+type Consumer struct {
+	Name         string
+	Subscription string
+	Payload      interface{}
+	Handler      Handler
+	Retry        RetryPolicy
+	DeadLetter   string
+	Timeout      time.Duration
+}
+
+// Registry run the registered consumer
+type Registry struct {
+	broker    pubsub.IPubSub
+	store     IStore
+	log       log.ILogs
+	consumers []Consumer
+}
+
+func NewRegistry(broker pubsub.IPubSub, store IStore, log log.ILogs) *Registry {
+	return &Registry{broker: broker, store: store, log: log}
+}
+
+// Register add a consumer, it must be called before Run
+func (registry *Registry) Register(consumer Consumer) error {
+	switch {
+	case consumer.Name == "" || consumer.Subscription == "":
+		return errors.New("consumer name and subscription are required")
+	case consumer.Payload == nil || consumer.Handler == nil:
+		return fmt.Errorf("consumer %s: payload and handler are required", consumer.Name)
+	}
+
+	for _, registered := range registry.consumers {
+		if registered.Name == consumer.Name {
+			return fmt.Errorf("consumer %s is already registered", consumer.Name)
+		}
+	}
+
+	if consumer.Retry.MaxAttempts <= 0 {
+		consumer.Retry.MaxAttempts = 1
+	}
+
+	if consumer.Timeout <= 0 {
+		consumer.Timeout = time.Minute
+	}
+
+	registry.consumers = append(registry.consumers, consumer)
+	return nil
+}
+
+// Run receive the message of every consumer until ctx is done, it return
+// once every message in flight was handled
+func (registry *Registry) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, consumer := range registry.consumers {
+		wg.Add(1)
+		go func(consumer Consumer) {
+			defer wg.Done()
+
+			err := registry.broker.Receive(ctx, consumer.Subscription, func(receiveCtx context.Context, message pubsub.Message) error {
+				return registry.handle(receiveCtx, consumer, message)
+			})
+			if err != nil {
+				registry.log.Error(ctx, "consumer "+consumer.Name+" registry.broker.Receive Error", err)
+			}
+		}(consumer)
+	}
+
+	wg.Wait()
+}
+
+// messageID identify a message across redelivery, an event of the outbox
+// keep its id when it is published again
+func messageID(message pubsub.Message) string {
+	if id := message.Attributes[outbox.AttributeEventID]; id != "" {
+		return id
+	}
+
+	return message.ID
+}
+
+// decode data into a new value of the type of payload, a []byte payload
+// receive data as is
+func decode(payload interface{}, data []byte) (interface{}, error) {
+	if _, raw := payload.([]byte); raw {
+		return data, nil
+	}
+
+	value := reflect.New(reflect.TypeOf(payload))
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, err
+	}
+
+	return value.Elem().Interface(), nil
+}
+
+// handle return nil to ack message, the handler get a context of its own so
+// a shutdown does not cancel it
+func (registry *Registry) handle(receiveCtx context.Context, consumer Consumer, message pubsub.Message) error {
+	ctx := context.Background()
+	if traceID := receiveCtx.Value("trace-id"); traceID != nil {
+		ctx = context.WithValue(ctx, "trace-id", traceID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, consumer.Timeout)
+	defer cancel()
+
+	id := messageID(message)
+	actName := "consumer " + consumer.Name
+
+	// the lease outlive the handler so a redelivery never run concurrently
+	attempt, ok, err := registry.store.Claim(ctx, consumer.Name, id, 2*consumer.Timeout)
+	if err != nil {
+		if !errors.Is(err, ErrLocked) {
+			registry.log.Error(ctx, actName+" registry.store.Claim Error", err)
+		}
+		return err
+	}
+
+	if !ok {
+		registry.log.Info(ctx, actName+" duplicate", map[string]interface{}{"message_id": id})
+		return nil
+	}
+
+	payload, err := decode(consumer.Payload, message.Data)
+	if err != nil {
+		err = Permanent(err)
+	} else {
+		err = consumer.Handler(ctx, message, payload)
+	}
+
+	if err == nil {
+		if err := registry.store.Finish(ctx, consumer.Name, id, StateDone, ""); err != nil {
+			registry.log.Error(ctx, actName+" registry.store.Finish Error", err)
+		}
+		return nil
+	}
+
+	registry.log.Warning(ctx, actName+" failed", map[string]interface{}{
+		"message_id": id,
+		"attempt":    attempt,
+		"error":      err.Error(),
+	})
+
+	if errors.Is(err, ErrPermanent) || attempt >= consumer.Retry.MaxAttempts {
+		if deadErr := registry.deadLetter(ctx, consumer, message, attempt, err); deadErr != nil {
+			registry.log.Error(ctx, actName+" registry.deadLetter Error", deadErr)
+		} else {
+			registry.store.Finish(ctx, consumer.Name, id, StateDead, err.Error())
+			return nil
+		}
+	}
+
+	// the lease of the claim only cover the handler, the message stay locked
+	// for the backoff so a redelivery never retry it early however long the
+	// backoff is
+	backoff := consumer.Retry.backoff(attempt)
+	if retryErr := registry.store.Retry(ctx, consumer.Name, id, err.Error(), time.Now().Add(backoff)); retryErr != nil {
+		registry.log.Error(ctx, actName+" registry.store.Retry Error", retryErr)
+	}
+
+	// the message is nacked once the backoff elapsed, at once on shutdown as
+	// its redelivery is refused until then anyway
+	select {
+	case <-time.After(backoff):
+	case <-receiveCtx.Done():
+	}
+
+	return err
+}
+
+// deadLetter publish message to the dead letter topic of consumer, without
+// one the message is only logged
+func (registry *Registry) deadLetter(ctx context.Context, consumer Consumer, message pubsub.Message, attempt int, cause error) error {
+	if consumer.DeadLetter == "" {
+		registry.log.Error(ctx, "consumer "+consumer.Name+" dropped", map[string]interface{}{
+			"message_id": messageID(message),
+			"data":       string(message.Data),
+			"error":      cause.Error(),
+		})
+		return nil
+	}
+
+	attributes := map[string]string{}
+	for key, value := range message.Attributes {
+		attributes[key] = value
+	}
+	attributes[AttributeConsumer] = consumer.Name
+	attributes[AttributeAttempts] = strconv.Itoa(attempt)
+	attributes[AttributeError] = cause.Error()
+
+	_, err := registry.broker.Publish(ctx, consumer.DeadLetter, pubsub.Message{
+		Data:        message.Data,
+		Attributes:  attributes,
+		OrderingKey: message.OrderingKey,
+	})
+	return err
+}
@@ -0,0 +1,236 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"prototype/lib/log"
+	"prototype/lib/outbox"
+	"prototype/lib/pubsub"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type offboarded struct {
+	UserID uint `json:"user_id"`
+}
+
+func setupRegistry(t *testing.T) (*Registry, pubsub.IPubSub) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&Processed{}); err != nil {
+		t.Fatal(err)
+	}
+
+	broker := pubsub.NewMemory(pubsub.Config{Bindings: map[string]string{"hr": "hr", "hr-dead": "hr-dead"}})
+	return NewRegistry(broker, NewStore(db), log.NewLog()), broker
+}
+
+// run the registry until done is closed or the test time out
+func run(t *testing.T, registry *Registry, done <-chan struct{}) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	registry.Run(ctx)
+	if ctx.Err() == context.DeadlineExceeded {
+		t.Fatal("registry.Run() timed out")
+	}
+}
+
+func TestRegistry_Run(t *testing.T) {
+	retry := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	t.Run("typed payload is handled once per event", func(t *testing.T) {
+		registry, broker := setupRegistry(t)
+		done := make(chan struct{})
+
+		var handled int32
+		registry.Register(Consumer{
+			Name: "offboarding", Subscription: "hr", Payload: offboarded{}, Retry: retry,
+			Handler: func(ctx context.Context, message pubsub.Message, payload interface{}) error {
+				if payload.(offboarded).UserID != 7 || ctx.Value("trace-id") != "trace-1" {
+					t.Errorf("payload = %+v, trace id %v", payload, ctx.Value("trace-id"))
+				}
+
+				atomic.AddInt32(&handled, 1)
+				return nil
+			},
+		})
+
+		ctx := context.WithValue(context.Background(), "trace-id", "trace-1")
+		event := pubsub.Message{Data: []byte(`{"user_id": 7}`), Attributes: map[string]string{outbox.AttributeEventID: "event-1"}}
+		broker.Publish(ctx, "hr", event)
+		broker.Publish(ctx, "hr", event)
+		broker.Publish(ctx, "hr", pubsub.Message{Data: []byte(`{"user_id": 7}`)})
+
+		go func() {
+			for atomic.LoadInt32(&handled) < 2 {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(20 * time.Millisecond)
+			close(done)
+		}()
+		run(t, registry, done)
+
+		if handled != 2 {
+			t.Errorf("handled = %d, want the duplicate event skipped", handled)
+		}
+	})
+
+	t.Run("failure is retried then dead lettered", func(t *testing.T) {
+		registry, broker := setupRegistry(t)
+		done := make(chan struct{})
+
+		var attempts int32
+		registry.Register(Consumer{
+			Name: "offboarding", Subscription: "hr", Payload: offboarded{}, Retry: retry, DeadLetter: "hr-dead",
+			Handler: func(ctx context.Context, message pubsub.Message, payload interface{}) error {
+				atomic.AddInt32(&attempts, 1)
+				return errors.New("database down")
+			},
+		})
+
+		var dead []pubsub.Message
+		var mu sync.Mutex
+		registry.Register(Consumer{
+			Name: "dead", Subscription: "hr-dead", Payload: []byte{},
+			Handler: func(ctx context.Context, message pubsub.Message, payload interface{}) error {
+				mu.Lock()
+				dead = append(dead, message)
+				mu.Unlock()
+				close(done)
+				return nil
+			},
+		})
+
+		broker.Publish(context.Background(), "hr", pubsub.Message{Data: []byte(`{"user_id": 7}`)})
+		run(t, registry, done)
+
+		if attempts != 3 {
+			t.Errorf("attempts = %d, want 3", attempts)
+		}
+
+		if len(dead) != 1 || dead[0].Attributes[AttributeAttempts] != "3" || dead[0].Attributes[AttributeConsumer] != "offboarding" {
+			t.Errorf("dead letter = %+v", dead)
+		}
+	})
+
+	t.Run("undecodable and permanent failure are not retried", func(t *testing.T) {
+		registry, broker := setupRegistry(t)
+		done := make(chan struct{})
+
+		var attempts, dead int32
+		registry.Register(Consumer{
+			Name: "offboarding", Subscription: "hr", Payload: offboarded{}, Retry: retry, DeadLetter: "hr-dead",
+			Handler: func(ctx context.Context, message pubsub.Message, payload interface{}) error {
+				atomic.AddInt32(&attempts, 1)
+				return Permanent(errors.New("user not found"))
+			},
+		})
+		registry.Register(Consumer{
+			Name: "dead", Subscription: "hr-dead", Payload: []byte{},
+			Handler: func(ctx context.Context, message pubsub.Message, payload interface{}) error {
+				if atomic.AddInt32(&dead, 1) == 2 {
+					close(done)
+				}
+				return nil
+			},
+		})
+
+		broker.Publish(context.Background(), "hr", pubsub.Message{Data: []byte(`not json`)})
+		broker.Publish(context.Background(), "hr", pubsub.Message{Data: []byte(`{"user_id": 7}`)})
+		run(t, registry, done)
+
+		if attempts != 1 {
+			t.Errorf("attempts = %d, want a single attempt of the decoded message", attempts)
+		}
+	})
+
+	t.Run("failed message stay locked for a backoff longer than its lease", func(t *testing.T) {
+		registry, broker := setupRegistry(t)
+		failed := make(chan struct{})
+		done := make(chan struct{})
+
+		var attempts int32
+		registry.Register(Consumer{
+			Name: "offboarding", Subscription: "hr", Payload: offboarded{}, Timeout: 10 * time.Millisecond,
+			Retry: RetryPolicy{MaxAttempts: 3, MinBackoff: 200 * time.Millisecond, MaxBackoff: 200 * time.Millisecond},
+			Handler: func(ctx context.Context, message pubsub.Message, payload interface{}) error {
+				if atomic.AddInt32(&attempts, 1) == 1 {
+					close(failed)
+					return errors.New("database down")
+				}
+				close(done)
+				return nil
+			},
+		})
+
+		broker.Publish(context.Background(), "hr", pubsub.Message{Data: []byte(`{"user_id": 7}`), Attributes: map[string]string{outbox.AttributeEventID: "event-1"}})
+
+		go func() {
+			<-failed
+			// well past the lease of 2 timeout
+			time.Sleep(50 * time.Millisecond)
+
+			if _, _, err := registry.store.Claim(context.Background(), "offboarding", "event-1", time.Minute); !errors.Is(err, ErrLocked) {
+				t.Errorf("store.Claim() during the backoff error = %v, want %v", err, ErrLocked)
+			}
+		}()
+		run(t, registry, done)
+
+		if attempts != 2 {
+			t.Errorf("attempts = %d, want the retry once the backoff elapsed", attempts)
+		}
+	})
+
+	t.Run("shutdown drain the message in flight", func(t *testing.T) {
+		registry, broker := setupRegistry(t)
+		done := make(chan struct{})
+
+		var finished int32
+		registry.Register(Consumer{
+			Name: "offboarding", Subscription: "hr", Payload: offboarded{}, Retry: retry,
+			Handler: func(ctx context.Context, message pubsub.Message, payload interface{}) error {
+				close(done)
+				time.Sleep(50 * time.Millisecond)
+				if ctx.Err() == nil {
+					atomic.StoreInt32(&finished, 1)
+				}
+				return nil
+			},
+		})
+
+		broker.Publish(context.Background(), "hr", pubsub.Message{Data: []byte(`{"user_id": 7}`)})
+		run(t, registry, done)
+
+		if finished != 1 {
+			t.Error("registry.Run() returned before the handler finished")
+		}
+	})
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry, _ := setupRegistry(t)
+	handler := func(ctx context.Context, message pubsub.Message, payload interface{}) error { return nil }
+
+	if err := registry.Register(Consumer{Name: "a", Subscription: "hr"}); err == nil {
+		t.Error("registry.Register() without handler error = nil")
+	}
+
+	registry.Register(Consumer{Name: "a", Subscription: "hr", Payload: offboarded{}, Handler: handler})
+	if err := registry.Register(Consumer{Name: "a", Subscription: "hr", Payload: offboarded{}, Handler: handler}); err == nil {
+		t.Error("registry.Register() duplicate error = nil")
+	}
+}
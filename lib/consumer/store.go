@@ -0,0 +1,133 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// processing state of a message
+const (
+	StateProcessing = "processing"
+	StateFailed     = "failed"
+	StateDone       = "done"
+	StateDead       = "dead"
+)
+
+// Processed track a message per consumer so a redelivered message is handled
+// once, the table is not tenant scoped
+type Processed struct {
+	Consumer    string    `gorm:"primaryKey;size:100" json:"consumer"`
+	MessageID   string    `gorm:"primaryKey;column:message_id;size:100" json:"message_id"`
+	State       string    `gorm:"size:20;not null" json:"state"`
+	Attempts    int       `gorm:"not null;default:0" json:"attempts"`
+	LockedUntil time.Time `json:"locked_until"`
+	LastError   string    `gorm:"size:1000" json:"last_error,omitempty"`
+	UpdatedAt   time.Time `gorm:"index" json:"updated_at"`
+}
+
+func (Processed) TableName() string {
+	return "consumer_message"
+}
+
+// ErrLocked is returned by Claim for a message still handled by another
+// delivery or waiting for its next attempt
+var ErrLocked = errors.New("message is handled by another delivery")
+
+// IStore keep the processing state of message
+type IStore interface {
+	// Claim lock the message for lease and return its attempt number, it
+	// return false for a message already done or dead and ErrLocked for a
+	// message still locked by another delivery or by its backoff
+	Claim(ctx context.Context, consumer, messageID string, lease time.Duration) (attempt int, ok bool, err error)
+	// Finish record the outcome of the claimed message
+	Finish(ctx context.Context, consumer, messageID, state, lastError string) error
+	// Retry record the failed attempt of the claimed message and keep it
+	// locked until its next attempt is due
+	Retry(ctx context.Context, consumer, messageID, lastError string, next time.Time) error
+	// Purge forget the message done or dead before before
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+// NewStore keep the processing state in db, always on the primary database
+func NewStore(db *gorm.DB) IStore {
+	return store{db}
+}
+
+func (store store) Claim(ctx context.Context, consumer, messageID string, lease time.Duration) (attempt int, ok bool, err error) {
+	err = store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var processed Processed
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("consumer = ? AND message_id = ?", consumer, messageID).First(&processed).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			attempt, ok = 1, true
+			return tx.Create(&Processed{
+				Consumer:    consumer,
+				MessageID:   messageID,
+				State:       StateProcessing,
+				Attempts:    attempt,
+				LockedUntil: time.Now().Add(lease),
+			}).Error
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case processed.State == StateDone, processed.State == StateDead:
+			return nil
+		case processed.State == StateProcessing && processed.LockedUntil.After(time.Now()),
+			processed.State == StateFailed && processed.LockedUntil.After(time.Now()):
+			return ErrLocked
+		}
+
+		attempt, ok = processed.Attempts+1, true
+		return tx.Model(&Processed{}).Where("consumer = ? AND message_id = ?", consumer, messageID).Updates(map[string]interface{}{
+			"state":        StateProcessing,
+			"attempts":     attempt,
+			"locked_until": time.Now().Add(lease),
+		}).Error
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return
+}
+
+func (store store) Finish(ctx context.Context, consumer, messageID, state, lastError string) error {
+	if len(lastError) > 1000 {
+		lastError = lastError[:1000]
+	}
+
+	return store.db.WithContext(ctx).Model(&Processed{}).Where("consumer = ? AND message_id = ?", consumer, messageID).Updates(map[string]interface{}{
+		"state":        state,
+		"last_error":   lastError,
+		"locked_until": time.Time{},
+	}).Error
+}
+
+func (store store) Retry(ctx context.Context, consumer, messageID, lastError string, next time.Time) error {
+	if len(lastError) > 1000 {
+		lastError = lastError[:1000]
+	}
+
+	return store.db.WithContext(ctx).Model(&Processed{}).Where("consumer = ? AND message_id = ?", consumer, messageID).Updates(map[string]interface{}{
+		"state":        StateFailed,
+		"last_error":   lastError,
+		"locked_until": next,
+	}).Error
+}
+
+func (store store) Purge(ctx context.Context, before time.Time) (int64, error) {
+	remove := store.db.WithContext(ctx).Where("state IN ? AND updated_at < ?", []string{StateDone, StateDead}, before).Delete(&Processed{})
+	return remove.RowsAffected, remove.Error
+}
@@ -0,0 +1,116 @@
+package crypt
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// FieldCipher encrypt column value with envelope encryption, a record get
+// its own data key which is stored wrapped by the keyring next to the data
+type FieldCipher struct {
+	keyring  IKeyring
+	indexKey []byte
+}
+
+// DataKey is the plain data key of a record, never stored as is
+type DataKey struct {
+	aead cipher.AEAD
+}
+
+func NewFieldCipher(keyring IKeyring, indexKey []byte) *FieldCipher {
+	return &FieldCipher{keyring, indexKey}
+}
+
+// Current return the keyring key new data key are wrapped with, empty when
+// encryption is disabled
+func (fieldCipher *FieldCipher) Current() string {
+	if fieldCipher == nil {
+		return ""
+	}
+
+	return fieldCipher.keyring.Current()
+}
+
+// NewDataKey generate a data key and return it with its wrapped form
+func (fieldCipher *FieldCipher) NewDataKey(ctx context.Context) (dataKey DataKey, keyID, wrapped string, err error) {
+	key, err := randomBytes(32)
+	if err != nil {
+		return
+	}
+
+	keyID = fieldCipher.keyring.Current()
+	sealed, err := fieldCipher.keyring.Encrypt(ctx, keyID, key)
+	if err != nil {
+		return
+	}
+
+	dataKey.aead, err = newAEAD(key)
+	wrapped = base64.StdEncoding.EncodeToString(sealed)
+	return
+}
+
+// OpenDataKey unwrap a data key stored by NewDataKey
+func (fieldCipher *FieldCipher) OpenDataKey(ctx context.Context, keyID, wrapped string) (dataKey DataKey, err error) {
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return
+	}
+
+	key, err := fieldCipher.keyring.Decrypt(ctx, keyID, sealed)
+	if err != nil {
+		return
+	}
+
+	dataKey.aead, err = newAEAD(key)
+	return
+}
+
+// Seal encrypt value of field, the field name is authenticated so a value
+// can not be moved to another column
+func (dataKey DataKey) Seal(field, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	sealed, err := seal(dataKey.aead, []byte(value), []byte(field))
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (dataKey DataKey) Open(field, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataKey.aead, sealed, []byte(field))
+	return string(plaintext), err
+}
+
+// Index return the blind index of value, a keyed hash allowing equality
+// lookup without storing the value. Without cipher it fall back to a plain
+// hash so the index column stay usable
+func (fieldCipher *FieldCipher) Index(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if fieldCipher == nil {
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])
+	}
+
+	mac := hmac.New(sha256.New, fieldCipher.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
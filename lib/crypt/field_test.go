@@ -0,0 +1,96 @@
+package crypt
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newTestCipher(t *testing.T) (*FieldCipher, KeyringFile) {
+	file, err := GenerateKeyringFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, indexKey, err := NewLocalKeyring(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewFieldCipher(keyring, indexKey), file
+}
+
+func TestFieldCipher_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	fieldCipher, file := newTestCipher(t)
+
+	dataKey, keyID, wrapped, err := fieldCipher.NewDataKey(ctx)
+	if err != nil {
+		t.Fatalf("NewDataKey() error = %v", err)
+	}
+
+	if keyID != file.Current {
+		t.Errorf("NewDataKey() keyID = %v, want %v", keyID, file.Current)
+	}
+
+	sealed, err := dataKey.Seal("email", "user@mail.com")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	opened, err := fieldCipher.OpenDataKey(ctx, keyID, wrapped)
+	if err != nil {
+		t.Fatalf("OpenDataKey() error = %v", err)
+	}
+
+	t.Run("open with the same field", func(t *testing.T) {
+		value, err := opened.Open("email", sealed)
+		if err != nil || value != "user@mail.com" {
+			t.Errorf("Open() = %v, %v, want user@mail.com", value, err)
+		}
+	})
+
+	t.Run("field is authenticated", func(t *testing.T) {
+		if _, err := opened.Open("firstname", sealed); err == nil {
+			t.Error("Open() with another field error = nil")
+		}
+	})
+
+	t.Run("empty stay empty", func(t *testing.T) {
+		if value, _ := dataKey.Seal("email", ""); value != "" {
+			t.Errorf("Seal('') = %v, want empty", value)
+		}
+	})
+}
+
+func TestFieldCipher_Rotate(t *testing.T) {
+	ctx := context.Background()
+	fieldCipher, file := newTestCipher(t)
+
+	_, oldKeyID, wrapped, err := fieldCipher.NewDataKey(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file.Keys["next"] = file.Keys[oldKeyID]
+	file.Current = "next"
+	keyring, indexKey, err := NewLocalKeyring(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := NewFieldCipher(keyring, indexKey)
+
+	if _, err := rotated.OpenDataKey(ctx, oldKeyID, wrapped); err != nil {
+		t.Errorf("OpenDataKey() with previous key error = %v", err)
+	}
+
+	if rotated.Index("User@Mail.com ") != fieldCipher.Index("user@mail.com") {
+		t.Error("Index() changed with rotation or case")
+	}
+
+	delete(file.Keys, oldKeyID)
+	keyring, _, _ = NewLocalKeyring(file)
+	if _, err := NewFieldCipher(keyring, indexKey).OpenDataKey(ctx, oldKeyID, wrapped); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("OpenDataKey() with removed key error = %v, want %v", err, ErrUnknownKey)
+	}
+}
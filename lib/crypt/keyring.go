@@ -0,0 +1,193 @@
+package crypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// ErrUnknownKey is returned for data encrypted with a key the keyring does
+// not hold anymore
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// IKeyring hold the key encryption key, it is the seam for a KMS where the
+// key never leave the service and only wrap and unwrap small data key
+type IKeyring interface {
+	// Current return the id of the key new data is encrypted with
+	Current() string
+	Encrypt(ctx context.Context, keyID string, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// KeyringFile is the json layout of a local keyring, every key is 32 bytes
+// base64 encoded and old key stay listed until no data use them anymore
+type KeyringFile struct {
+	Current  string            `json:"current"`
+	IndexKey string            `json:"index_key"`
+	Keys     map[string]string `json:"keys"`
+}
+
+type localKeyring struct {
+	current  string
+	indexKey []byte
+	keys     map[string]cipher.AEAD
+}
+
+// LoadLocalKeyring read the keyring file at path, it return the keyring and
+// the key of blind index
+func LoadLocalKeyring(path string) (IKeyring, []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file KeyringFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, nil, fmt.Errorf("keyring %s: %w", path, err)
+	}
+
+	return NewLocalKeyring(file)
+}
+
+func NewLocalKeyring(file KeyringFile) (IKeyring, []byte, error) {
+	keyring := localKeyring{current: file.Current, keys: map[string]cipher.AEAD{}}
+
+	for keyID, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, nil, fmt.Errorf("keyring key %s must be 32 bytes base64", keyID)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keyring.keys[keyID] = aead
+	}
+
+	if _, ok := keyring.keys[file.Current]; !ok {
+		return nil, nil, fmt.Errorf("%w: current key %q", ErrUnknownKey, file.Current)
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(file.IndexKey)
+	if err != nil || len(indexKey) < 32 {
+		return nil, nil, errors.New("keyring index_key must be at least 32 bytes base64")
+	}
+
+	return keyring, indexKey, nil
+}
+
+func (keyring localKeyring) Current() string {
+	return keyring.current
+}
+
+func (keyring localKeyring) Encrypt(ctx context.Context, keyID string, plaintext []byte) ([]byte, error) {
+	aead, ok := keyring.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+
+	return seal(aead, plaintext, []byte(keyID))
+}
+
+func (keyring localKeyring) Decrypt(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error) {
+	aead, ok := keyring.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+
+	return open(aead, ciphertext, []byte(keyID))
+}
+
+// GenerateKeyringFile return a new keyring with a single fresh key
+func GenerateKeyringFile() (KeyringFile, error) {
+	file := KeyringFile{Keys: map[string]string{}}
+
+	indexKey, err := randomBytes(32)
+	if err != nil {
+		return file, err
+	}
+	file.IndexKey = base64.StdEncoding.EncodeToString(indexKey)
+
+	return file, file.Rotate()
+}
+
+// Rotate add a new key and make it current, the previous key are kept so
+// existing data can still be decrypted until it is re-encrypted
+func (file *KeyringFile) Rotate() error {
+	key, err := randomBytes(32)
+	if err != nil {
+		return err
+	}
+
+	suffix, err := randomBytes(4)
+	if err != nil {
+		return err
+	}
+
+	// sortable by creation, the suffix keep two rotation in a second apart
+	keyID := time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+
+	file.Keys[keyID] = base64.StdEncoding.EncodeToString(key)
+	file.Current = keyID
+	return nil
+}
+
+// KeyIDs return the id of every key in the file, oldest first
+func (file KeyringFile) KeyIDs() []string {
+	keyIDs := make([]string, 0, len(file.Keys))
+	for keyID := range file.Keys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+
+	return keyIDs
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal return nonce followed by the ciphertext
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, additional []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additional)
+}
+
+func randomBytes(size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(rand.Reader, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -72,3 +73,17 @@ func Interface(key string, def interface{}) interface{} {
 	value := viper.Get(key)
 	return value
 }
+
+func Duration(key string, def time.Duration) time.Duration {
+	if value, ok := viper.Get(key).(string); ok {
+		durationVal, err := time.ParseDuration(value)
+		if err != nil {
+			logrus.Warn(fmt.Sprintf("Error while convert to duration %s", key))
+			return def
+		}
+		return durationVal
+	}
+	logrus.Warn(fmt.Sprintf("Error while reading config file %s", "Invalid type assertion - from = "+key))
+
+	return def
+}
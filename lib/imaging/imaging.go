@@ -0,0 +1,95 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrInvalidImage    = errors.New("invalid image")
+	ErrTooLarge        = errors.New("image dimension too large")
+)
+
+// allowed content type and the image format they decode as
+var formats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// Decode sniff and fully decode data, metadata such as exif is dropped
+// since only the pixel are kept. Dimension is checked before decoding
+func Decode(data []byte, maxDimension int) (image.Image, string, error) {
+	format, ok := formats[http.DetectContentType(data)]
+	if !ok {
+		return nil, "", ErrUnsupportedType
+	}
+
+	config, configFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || configFormat != format {
+		return nil, "", ErrInvalidImage
+	}
+
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return nil, "", ErrTooLarge
+	}
+
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", ErrInvalidImage
+	}
+
+	return img, format, nil
+}
+
+// Thumbnail crop the center square of img and scale it to size x size
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	crop := image.Rect(x, y, x+side, y+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+
+	return dst
+}
+
+// Encode write img as jpeg, or png when the source format may carry
+// transparency. It return the content type and file extension
+func Encode(img image.Image, format string) (data []byte, contentType, ext string, err error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case "png", "gif":
+		err = png.Encode(&buf, img)
+		contentType, ext = "image/png", "png"
+	default:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		contentType, ext = "image/jpeg", "jpg"
+	}
+
+	return buf.Bytes(), contentType, ext, err
+}
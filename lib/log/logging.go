@@ -3,6 +3,7 @@ package log
 import (
 	"context"
 	"runtime"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -19,6 +20,9 @@ type (
 		Fatal(ctx context.Context, actName string, data interface{})
 
 		Http(ctx context.Context, actName, url, method string, header, req, res interface{})
+		// Redis log a single command, outcome is ok, miss or error and an
+		// error outcome is logged at error level
+		Redis(ctx context.Context, actName, command, key, outcome string, duration time.Duration, req, res interface{})
 	}
 )
 
@@ -114,3 +118,37 @@ func (lib *logs) Http(ctx context.Context, actName, url, method string, header,
 	}
 	logrus.WithFields(item).Info(actName)
 }
+
+func (lib *logs) Redis(ctx context.Context, actName, command, key, outcome string, duration time.Duration, req, res interface{}) {
+	pc, file, line, _ := runtime.Caller(1)
+	item := logrus.Fields{
+		"redis_command":   command,
+		"redis_key":       key,
+		"redis_outcome":   outcome,
+		"redis_duration":  duration.String(),
+		"redis_request":   req,
+		"redis_response":  res,
+		"service_name":    serviceName,
+		"service_type":    serviceType,
+		"service_code":    serviceCode,
+		"version_release": versionRelease,
+		"version_type":    versionType,
+		"trace_id":        ctx.Value("trace-id"),
+		"package":         runtime.FuncForPC(pc).Name(),
+		"file":            file,
+		"line":            line,
+	}
+
+	if outcome == "error" {
+		if ActiveLogFile {
+			log.WithFields(item).Error(actName)
+		}
+		logrus.WithFields(item).Error(actName)
+		return
+	}
+
+	if ActiveLogFile {
+		log.WithFields(item).Info(actName)
+	}
+	logrus.WithFields(item).Info(actName)
+}
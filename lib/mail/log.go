@@ -0,0 +1,26 @@
+package mail
+
+import (
+	"context"
+	"prototype/lib/log"
+)
+
+type logMailer struct {
+	log log.ILogs
+}
+
+// NewLog return a mailer writing every message to the log instead of sending
+// it, meant for local development
+func NewLog(log log.ILogs) IMailer {
+	return logMailer{log}
+}
+
+func (mailer logMailer) Send(ctx context.Context, message Message) error {
+	mailer.log.Info(ctx, "Mail", map[string]interface{}{
+		"to":      message.To,
+		"subject": message.Subject,
+		"body":    message.Body,
+	})
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package mail
+
+import "context"
+
+// Message is a plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// IMailer deliver email
+type IMailer interface {
+	Send(ctx context.Context, message Message) error
+}
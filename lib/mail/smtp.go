@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+type ConfigSMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpMailer struct {
+	config ConfigSMTP
+	auth   smtp.Auth
+}
+
+func NewSMTP(config ConfigSMTP) IMailer {
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+
+	return smtpMailer{config, auth}
+}
+
+func (mailer smtpMailer) Send(ctx context.Context, message Message) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", mailer.config.From)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&body, "Subject: %s\r\n", message.Subject)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(message.Body)
+
+	address := net.JoinHostPort(mailer.config.Host, mailer.config.Port)
+	return smtp.SendMail(address, mailer.auth, mailer.config.From, message.To, []byte(body.String()))
+}
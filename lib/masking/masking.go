@@ -0,0 +1,132 @@
+package masking
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// field rule
+const (
+	RuleFull    = "full"
+	RulePartial = "partial"
+	RuleOmit    = "omit"
+)
+
+// caller role without a user role in context
+const (
+	// RolePublic is an anonymous caller, its rules also apply to role the
+	// policy does not list
+	RolePublic = "public"
+	// RoleSelf is a caller reading its own record, it is not masked unless
+	// the policy list it
+	RoleSelf = "self"
+)
+
+const partialMask = "***"
+
+// Policy map a caller role to the rule of a field by json name, field not
+// listed are returned as is. An empty policy mask nothing
+type Policy map[string]map[string]string
+
+// Validate report the first unknown rule
+func (policy Policy) Validate() error {
+	for role, rules := range policy {
+		for field, rule := range rules {
+			switch rule {
+			case RuleFull, RulePartial, RuleOmit:
+			default:
+				return fmt.Errorf("masking %s.%s: unknown rule %q", role, field, rule)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Role return the role a record owned by ownerID is masked for, the owner
+// read its own record as RoleSelf
+func Role(ctx context.Context, ownerID uint) string {
+	if userID, ok := ctx.Value("user-id").(uint); ok && userID == ownerID {
+		return RoleSelf
+	}
+
+	if role, ok := ctx.Value("user-role").(string); ok && role != "" {
+		return role
+	}
+
+	return RolePublic
+}
+
+// Rules return the rule of every field for role
+func (policy Policy) Rules(role string) map[string]string {
+	if len(policy) == 0 {
+		return nil
+	}
+
+	if rules, ok := policy[role]; ok || role == RoleSelf {
+		return rules
+	}
+
+	return policy[RolePublic]
+}
+
+// Mask return value for role as a json object without its omitted field and
+// with its partial one masked, a partial rule on a field which is not a
+// string omit it. value is returned as is when role has no rule and nil when
+// it is not a json object, so nothing unmasked get out by mistake
+func (policy Policy) Mask(role string, value interface{}) interface{} {
+	rules := policy.Rules(role)
+	if len(rules) == 0 {
+		return value
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	// number are kept as written, an id does not go through a float
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil || object == nil {
+		return nil
+	}
+
+	for field, rule := range rules {
+		switch rule {
+		case RulePartial:
+			if text, ok := object[field].(string); ok {
+				object[field] = Partial(text)
+				continue
+			}
+
+			delete(object, field)
+		case RuleOmit:
+			delete(object, field)
+		}
+	}
+
+	return object
+}
+
+// Partial keep the first character of value, the domain of an email is kept
+// as well so j***@example.com is still recognisable
+func Partial(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	local, domain := value, ""
+	if at := strings.LastIndex(value, "@"); at > 0 {
+		local, domain = value[:at], value[at:]
+	}
+
+	first, _ := utf8.DecodeRuneInString(local)
+	return string(first) + partialMask + domain
+}
@@ -0,0 +1,156 @@
+package masking
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPartial(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "empty", value: "", want: ""},
+		{name: "email keep its domain", value: "jane@example.com", want: "j***@example.com"},
+		{name: "no at", value: "Jane", want: "J***"},
+		{name: "leading at is not a domain", value: "@jane", want: "@***"},
+		{name: "last at split the domain", value: "ja@ne@example.com", want: "j***@example.com"},
+		{name: "multibyte first character", value: "Élodie", want: "É***"},
+		{name: "multibyte email", value: "小林@example.jp", want: "小***@example.jp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Partial(tt.value); got != tt.want {
+				t.Errorf("Partial(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Rules(t *testing.T) {
+	policy := Policy{
+		"admin":    {},
+		"support":  {"email": RulePartial},
+		RolePublic: {"email": RuleOmit},
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		role   string
+		want   map[string]string
+	}{
+		{name: "listed role", policy: policy, role: "support", want: map[string]string{"email": RulePartial}},
+		{name: "listed role without rule", policy: policy, role: "admin", want: map[string]string{}},
+		{name: "role not listed fall back to public", policy: policy, role: "user", want: map[string]string{"email": RuleOmit}},
+		{name: "self not listed is not masked", policy: policy, role: RoleSelf, want: nil},
+		{name: "self listed", policy: Policy{RoleSelf: {"email": RulePartial}}, role: RoleSelf, want: map[string]string{"email": RulePartial}},
+		{name: "empty policy", policy: Policy{}, role: "user", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Rules(tt.role); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Policy.Rules(%q) = %v, want %v", tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{name: "empty", policy: Policy{}},
+		{name: "known rule", policy: Policy{"support": {"email": RulePartial, "lastname": RuleOmit, "firstname": RuleFull}}},
+		{name: "unknown rule", policy: Policy{"support": {"email": "hash"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Policy.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRole(t *testing.T) {
+	signed := context.WithValue(context.WithValue(context.Background(), "user-id", uint(1)), "user-role", "support")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		ownerID uint
+		want    string
+	}{
+		{name: "owner", ctx: signed, ownerID: 1, want: RoleSelf},
+		{name: "other user", ctx: signed, ownerID: 2, want: "support"},
+		{name: "anonymous", ctx: context.Background(), ownerID: 1, want: RolePublic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Role(tt.ctx, tt.ownerID); got != tt.want {
+				t.Errorf("Role() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Mask(t *testing.T) {
+	type profile struct {
+		ID         uint              `json:"id"`
+		Email      string            `json:"email"`
+		FirstName  string            `json:"firstname"`
+		LastName   string            `json:"lastname"`
+		Attributes map[string]string `json:"attributes"`
+	}
+
+	value := profile{ID: 1, Email: "jane@example.com", FirstName: "Jane", Attributes: map[string]string{"team": "sales"}}
+
+	policy := Policy{
+		"support":  {"email": RulePartial, "attributes": RulePartial, "lastname": RuleFull},
+		RolePublic: {"email": RuleOmit, "firstname": RuleOmit, "lastname": RuleOmit, "attributes": RuleOmit},
+	}
+
+	tests := []struct {
+		name string
+		role string
+		want string
+	}{
+		{
+			name: "partial string is masked and partial object omitted, empty field is kept",
+			role: "support",
+			want: `{"email":"j***@example.com","firstname":"Jane","id":1,"lastname":""}`,
+		},
+		{
+			name: "omitted field are left out",
+			role: "user",
+			want: `{"id":1}`,
+		},
+		{
+			name: "role without rule get the value as is",
+			role: RoleSelf,
+			want: `{"id":1,"email":"jane@example.com","firstname":"Jane","lastname":"","attributes":{"team":"sales"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(policy.Mask(tt.role, value))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("Policy.Mask() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if got := policy.Mask("support", "not an object"); got != nil {
+		t.Errorf("Policy.Mask() of a string = %v, want nil", got)
+	}
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"prototype/lib/log"
+	"prototype/lib/pubsub"
+	"time"
+)
+
+// Feed deliver every notification of subscription to the connection of this
+// instance until ctx is done, then close the hub so open connection end with
+// the process. Every instance need its own subscription so each get every
+// notification
+func (hub *Hub) Feed(ctx context.Context, subscriber pubsub.ISubscriber, subscription string, log log.ILogs) {
+	defer hub.Close()
+
+	for {
+		err := subscriber.Receive(ctx, subscription, func(ctx context.Context, message pubsub.Message) error {
+			var notification envelope
+			if err := json.Unmarshal(message.Data, &notification); err != nil {
+				log.Error(ctx, "json.Unmarshal(message.Data) Error", err)
+				return nil
+			}
+
+			hub.Deliver(notification.TenantID, notification.UserID, notification.Notification)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			log.Error(ctx, "subscriber.Receive Error", err)
+		}
+
+		// the subscription went away, it is received again after a pause
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
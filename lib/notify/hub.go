@@ -0,0 +1,234 @@
+package notify
+
+import (
+	"sort"
+	"sync"
+)
+
+// Config of a hub. MaxConnections bound the open connection of one user,
+// SendBuffer how many notification can wait for a slow connection before it
+// is dropped
+type Config struct {
+	MaxConnections int
+	SendBuffer     int
+}
+
+// Stats of a hub
+type Stats struct {
+	Users       int    `json:"users"`
+	Connections int    `json:"connections"`
+	Delivered   uint64 `json:"delivered"`
+	Dropped     uint64 `json:"dropped"`
+	Rejected    uint64 `json:"rejected"`
+}
+
+// Hub hold the open connection of every user of this instance, a user is
+// identified by its tenant as well since id are only unique in a tenant
+type Hub struct {
+	config Config
+
+	mu     sync.Mutex
+	users  map[userKey]map[*Conn]struct{}
+	closed bool
+	stats  Stats
+}
+
+type userKey struct {
+	tenantID uint
+	userID   uint
+}
+
+// reason a connection was closed by the hub
+const (
+	closeNone = iota
+	closeLagged
+	closeRevoked
+	closeShutdown
+)
+
+// Conn is one connection of a user, it receive on Send the notification of
+// the topic it subscribed to. Send is closed once the hub drop the connection
+type Conn struct {
+	Send <-chan Notification
+
+	send   chan Notification
+	key    userKey
+	reason int
+
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+func NewHub(config Config) *Hub {
+	if config.MaxConnections < 1 {
+		config.MaxConnections = 1
+	}
+
+	if config.SendBuffer < 1 {
+		config.SendBuffer = 1
+	}
+
+	return &Hub{
+		config: config,
+		users:  map[userKey]map[*Conn]struct{}{},
+	}
+}
+
+// Register open a connection for the user, it is subscribed to nothing yet
+func (hub *Hub) Register(tenantID, userID uint) (*Conn, error) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return nil, ErrClosed
+	}
+
+	key := userKey{tenantID, userID}
+	if len(hub.users[key]) >= hub.config.MaxConnections {
+		hub.stats.Rejected++
+		return nil, ErrTooManyConnections
+	}
+
+	send := make(chan Notification, hub.config.SendBuffer)
+	conn := &Conn{Send: send, send: send, key: key, topics: map[string]bool{}}
+
+	if hub.users[key] == nil {
+		hub.users[key] = map[*Conn]struct{}{}
+	}
+	hub.users[key][conn] = struct{}{}
+
+	return conn, nil
+}
+
+// Unregister remove a connection which went away
+func (hub *Hub) Unregister(conn *Conn) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.drop(conn, closeNone)
+}
+
+// Deliver hand notification to the connection of the user subscribed to its
+// topic and return how many got it. A connection which does not keep up is
+// dropped, a revoked session drop every connection of the user once they got
+// the notification
+func (hub *Hub) Deliver(tenantID, userID uint, notification Notification) (delivered int) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	revoked := notification.Topic == TopicSessionRevoked
+
+	for conn := range hub.users[userKey{tenantID, userID}] {
+		if !revoked && !conn.Subscribed(notification.Topic) {
+			continue
+		}
+
+		select {
+		case conn.send <- notification:
+			delivered++
+			hub.stats.Delivered++
+		default:
+			if !revoked {
+				hub.drop(conn, closeLagged)
+				hub.stats.Dropped++
+				continue
+			}
+		}
+
+		if revoked {
+			hub.drop(conn, closeRevoked)
+		}
+	}
+
+	return
+}
+
+// Close drop every connection and refuse new one
+func (hub *Hub) Close() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return
+	}
+
+	hub.closed = true
+	for _, conns := range hub.users {
+		for conn := range conns {
+			hub.drop(conn, closeShutdown)
+		}
+	}
+}
+
+func (hub *Hub) Stats() Stats {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	stats := hub.stats
+	stats.Users = len(hub.users)
+	for _, conns := range hub.users {
+		stats.Connections += len(conns)
+	}
+
+	return stats
+}
+
+func (hub *Hub) drop(conn *Conn, reason int) {
+	conns := hub.users[conn.key]
+	if _, ok := conns[conn]; !ok {
+		return
+	}
+
+	delete(conns, conn)
+	if len(conns) == 0 {
+		delete(hub.users, conn.key)
+	}
+
+	conn.reason = reason
+	close(conn.send)
+}
+
+// Subscribe add topics to the connection and return every topic it is now
+// subscribed to
+func (conn *Conn) Subscribe(topics ...string) []string {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	for _, topic := range topics {
+		conn.topics[topic] = true
+	}
+
+	return conn.list()
+}
+
+// Unsubscribe remove topics from the connection and return every topic it is
+// still subscribed to
+func (conn *Conn) Unsubscribe(topics ...string) []string {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	for _, topic := range topics {
+		delete(conn.topics, topic)
+	}
+
+	return conn.list()
+}
+
+// Subscribed tell whether the connection want the notification of topic
+func (conn *Conn) Subscribed(topic string) bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	return conn.topics[topic] || conn.topics[TopicAll]
+}
+
+func (conn *Conn) list() []string {
+	topics := make([]string, 0, len(conn.topics))
+	for topic := range conn.topics {
+		topics = append(topics, topic)
+	}
+
+	sort.Strings(topics)
+
+	return topics
+}
@@ -0,0 +1,219 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"prototype/lib/log"
+	"prototype/lib/pubsub"
+	"prototype/lib/tenant"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// drain read what is waiting for conn without blocking, closed tell whether
+// the hub dropped it
+func drain(conn *Conn) (topics []string, closed bool) {
+	for {
+		select {
+		case notification, ok := <-conn.Send:
+			if !ok {
+				return topics, true
+			}
+			topics = append(topics, notification.Topic)
+		default:
+			return topics, false
+		}
+	}
+}
+
+func TestHub_Register(t *testing.T) {
+	hub := NewHub(Config{MaxConnections: 2, SendBuffer: 10})
+
+	first, _ := hub.Register(1, 10)
+	if _, err := hub.Register(1, 10); err != nil {
+		t.Fatalf("hub.Register() second connection error = %v", err)
+	}
+
+	if _, err := hub.Register(1, 10); !errors.Is(err, ErrTooManyConnections) {
+		t.Errorf("hub.Register() over the limit error = %v, want %v", err, ErrTooManyConnections)
+	}
+
+	// the same user id of another tenant is another user
+	if _, err := hub.Register(2, 10); err != nil {
+		t.Errorf("hub.Register() other tenant error = %v", err)
+	}
+
+	hub.Unregister(first)
+	if _, err := hub.Register(1, 10); err != nil {
+		t.Errorf("hub.Register() after hub.Unregister() error = %v", err)
+	}
+
+	if stats := hub.Stats(); stats.Users != 2 || stats.Connections != 3 || stats.Rejected != 1 {
+		t.Errorf("hub.Stats() = %+v", stats)
+	}
+}
+
+func TestHub_Deliver(t *testing.T) {
+	hub := NewHub(Config{MaxConnections: 5, SendBuffer: 10})
+
+	role, _ := hub.Register(1, 10)
+	role.Subscribe("role.changed", "other")
+	role.Unsubscribe("other")
+
+	all, _ := hub.Register(1, 10)
+	all.Subscribe(TopicAll)
+
+	none, _ := hub.Register(1, 10)
+	otherTenant, _ := hub.Register(2, 10)
+	otherTenant.Subscribe(TopicAll)
+
+	for _, topic := range []string{"role.changed", "other", TopicSessionRevoked} {
+		hub.Deliver(1, 10, Notification{Topic: topic})
+	}
+
+	tests := []struct {
+		name       string
+		conn       *Conn
+		wantTopics []string
+		wantClosed bool
+	}{
+		{name: "subscribed topic", conn: role, wantTopics: []string{"role.changed", TopicSessionRevoked}, wantClosed: true},
+		{name: "every topic", conn: all, wantTopics: []string{"role.changed", "other", TopicSessionRevoked}, wantClosed: true},
+		{name: "no subscription still get the revocation", conn: none, wantTopics: []string{TopicSessionRevoked}, wantClosed: true},
+		{name: "other tenant", conn: otherTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topics, closed := drain(tt.conn)
+			if !reflect.DeepEqual(topics, tt.wantTopics) || closed != tt.wantClosed {
+				t.Errorf("conn got %v, closed %v, want %v, %v", topics, closed, tt.wantTopics, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestHub_Backpressure(t *testing.T) {
+	hub := NewHub(Config{MaxConnections: 5, SendBuffer: 1})
+
+	slow, _ := hub.Register(1, 10)
+	slow.Subscribe(TopicAll)
+	fast, _ := hub.Register(1, 10)
+	fast.Subscribe(TopicAll)
+
+	hub.Deliver(1, 10, Notification{Topic: "first"})
+	drain(fast)
+	hub.Deliver(1, 10, Notification{Topic: "second"})
+
+	if topics, closed := drain(slow); !reflect.DeepEqual(topics, []string{"first"}) || !closed || slow.reason != closeLagged {
+		t.Errorf("slow conn got %v, closed %v, want first then dropped", topics, closed)
+	}
+
+	if topics, closed := drain(fast); !reflect.DeepEqual(topics, []string{"second"}) || closed {
+		t.Errorf("fast conn got %v, closed %v", topics, closed)
+	}
+
+	if stats := hub.Stats(); stats.Dropped != 1 || stats.Connections != 1 {
+		t.Errorf("hub.Stats() = %+v", stats)
+	}
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub(Config{MaxConnections: 5, SendBuffer: 1})
+	conn, _ := hub.Register(1, 10)
+
+	hub.Close()
+
+	if _, closed := drain(conn); !closed || conn.reason != closeShutdown {
+		t.Error("conn still open after hub.Close()")
+	}
+
+	if _, err := hub.Register(1, 10); !errors.Is(err, ErrClosed) {
+		t.Errorf("hub.Register() after hub.Close() error = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), 1)
+
+	hub := NewHub(Config{MaxConnections: 5, SendBuffer: 10})
+	conn, _ := hub.Register(1, 10)
+	conn.Subscribe(TopicAll)
+
+	notifier := NewNotifier(hub, nil, "")
+
+	if err := notifier.Notify(ctx, 10, Payload{Topic: "role.changed", Data: map[string]string{"role": "admin"}}); err != nil {
+		t.Fatalf("notifier.Notify() error = %v", err)
+	}
+
+	select {
+	case notification := <-conn.Send:
+		if notification.ID == "" || string(notification.Data) != `{"role":"admin"}` {
+			t.Errorf("conn got %+v", notification)
+		}
+	default:
+		t.Error("conn got no notification")
+	}
+
+	if err := notifier.Notify(context.Background(), 10, Payload{Topic: "role.changed"}); !errors.Is(err, tenant.ErrMissing) {
+		t.Errorf("notifier.Notify() without tenant error = %v, want %v", err, tenant.ErrMissing)
+	}
+
+	if err := notifier.Notify(ctx, 10, Payload{}); !errors.Is(err, ErrMissingTopic) {
+		t.Errorf("notifier.Notify() without topic error = %v, want %v", err, ErrMissingTopic)
+	}
+}
+
+func TestHub_Feed(t *testing.T) {
+	// every instance has its own subscription of the notification topic
+	broker := pubsub.NewMemory(pubsub.Config{Bindings: map[string]string{
+		"notification-a": "notification",
+		"notification-b": "notification",
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var conns []*Conn
+	var done []chan struct{}
+	for _, subscription := range []string{"notification-a", "notification-b"} {
+		hub := NewHub(Config{MaxConnections: 5, SendBuffer: 10})
+		conn, _ := hub.Register(1, 10)
+		conn.Subscribe(TopicAll)
+		conns = append(conns, conn)
+
+		stopped := make(chan struct{})
+		done = append(done, stopped)
+		go func(subscription string) {
+			hub.Feed(ctx, broker, subscription, log.NewLog())
+			close(stopped)
+		}(subscription)
+	}
+
+	// the notifier of any instance reach the connection of every instance
+	notifier := NewNotifier(NewHub(Config{}), broker, "notification")
+	if err := notifier.Notify(tenant.WithID(ctx, 1), 10, Payload{Topic: "role.changed"}); err != nil {
+		t.Fatalf("notifier.Notify() error = %v", err)
+	}
+
+	for i, conn := range conns {
+		select {
+		case notification := <-conn.Send:
+			if notification.Topic != "role.changed" {
+				t.Errorf("conn %d got %v", i, notification.Topic)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("conn %d got no notification from the broker", i)
+		}
+	}
+
+	cancel()
+	for _, stopped := range done {
+		<-stopped
+	}
+
+	for i, conn := range conns {
+		if _, closed := drain(conn); !closed {
+			t.Errorf("conn %d still open once the feed stopped", i)
+		}
+	}
+}
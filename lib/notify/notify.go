@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"prototype/lib/pubsub"
+	"prototype/lib/tenant"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// TopicAll subscribe a connection to every topic
+	TopicAll = "*"
+
+	// TopicSessionRevoked is sent to every connection of the user whatever
+	// they subscribed to, the connection is closed right after
+	TopicSessionRevoked = "session.revoked"
+)
+
+var (
+	ErrTooManyConnections = errors.New("too many connections for the user")
+	ErrClosed             = errors.New("notification hub closed")
+	ErrMissingTopic       = errors.New("notification topic is required")
+)
+
+// Payload of a notification, Data is sent to the client as JSON
+type Payload struct {
+	Topic string
+	Data  interface{}
+}
+
+// Notification as the client receive it
+type Notification struct {
+	ID     string          `json:"id"`
+	Topic  string          `json:"topic"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	SentAt time.Time       `json:"sent_at"`
+}
+
+// envelope carry a notification to every instance through the bus
+type envelope struct {
+	TenantID     uint         `json:"tenant_id"`
+	UserID       uint         `json:"user_id"`
+	Notification Notification `json:"notification"`
+}
+
+// INotifier send a notification to the open connection of a user of the
+// tenant of ctx, whichever instance they are connected to
+type INotifier interface {
+	Notify(ctx context.Context, userID uint, payload Payload) error
+}
+
+type Notifier struct {
+	hub       *Hub
+	publisher pubsub.IPublisher
+	topic     string
+}
+
+// NewNotifier return a notifier publishing on topic, every instance then
+// deliver it from its own subscription. Without topic the notification is
+// only delivered to the connection of this instance
+func NewNotifier(hub *Hub, publisher pubsub.IPublisher, topic string) *Notifier {
+	return &Notifier{hub, publisher, topic}
+}
+
+// Notify never wait on the client, a user without open connection simply miss
+// the notification
+func (notifier *Notifier) Notify(ctx context.Context, userID uint, payload Payload) error {
+	if payload.Topic == "" {
+		return ErrMissingTopic
+	}
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return tenant.ErrMissing
+	}
+
+	data, err := json.Marshal(payload.Data)
+	if err != nil {
+		return err
+	}
+
+	message := envelope{
+		TenantID: tenantID,
+		UserID:   userID,
+		Notification: Notification{
+			ID:     uuid.NewString(),
+			Topic:  payload.Topic,
+			Data:   data,
+			SentAt: time.Now(),
+		},
+	}
+
+	if notifier.topic == "" {
+		notifier.hub.Deliver(message.TenantID, message.UserID, message.Notification)
+		return nil
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	// the notification of a user keep their order
+	_, err = notifier.publisher.Publish(ctx, notifier.topic, pubsub.Message{
+		Data:        body,
+		OrderingKey: strconv.FormatUint(uint64(tenantID), 10) + "/" + strconv.FormatUint(uint64(userID), 10),
+	})
+
+	return err
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"prototype/lib/log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// message type exchanged on the socket
+const (
+	MessageSubscribe    = "subscribe"
+	MessageUnsubscribe  = "unsubscribe"
+	MessagePing         = "ping"
+	MessagePong         = "pong"
+	MessageSubscribed   = "subscribed"
+	MessageNotification = "notification"
+	MessageError        = "error"
+)
+
+// SocketConfig of a socket. A ping is sent every PingInterval and the client
+// has PongWait to answer before the connection is considered dead, a write
+// must complete within WriteWait and a client message is at most MaxMessage
+// byte
+type SocketConfig struct {
+	PingInterval time.Duration
+	PongWait     time.Duration
+	WriteWait    time.Duration
+	MaxMessage   int64
+}
+
+// ClientMessage is what the client send, Topics is the topic to subscribe to
+// or unsubscribe from
+type ClientMessage struct {
+	Type   string   `json:"type"`
+	Topics []string `json:"topics,omitempty"`
+}
+
+// ServerMessage is what the client receive, a notification has its field at
+// the top level next to the type
+type ServerMessage struct {
+	Type string `json:"type"`
+	*Notification
+	Topics []string `json:"topics,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// Serve run the socket of conn until either side close it. Only the writer
+// write on the socket, the reply to the client message go through it as well
+func Serve(ctx context.Context, socket *websocket.Conn, conn *Conn, config SocketConfig, log log.ILogs) {
+	replies := make(chan ServerMessage, 8)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		read(ctx, socket, conn, config, replies, stop, log)
+	}()
+
+	write(ctx, socket, conn, config, replies, done, log)
+
+	close(stop)
+	socket.Close()
+	<-done
+}
+
+func read(ctx context.Context, socket *websocket.Conn, conn *Conn, config SocketConfig, replies chan<- ServerMessage, stop <-chan struct{}, log log.ILogs) {
+	socket.SetReadLimit(config.MaxMessage)
+	socket.SetReadDeadline(time.Now().Add(config.PongWait))
+	socket.SetPongHandler(func(string) error {
+		return socket.SetReadDeadline(time.Now().Add(config.PongWait))
+	})
+
+	for {
+		_, data, err := socket.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Warning(ctx, "socket.ReadMessage Error", err.Error())
+			}
+			return
+		}
+
+		// a message from the client show it is alive as well as a pong
+		socket.SetReadDeadline(time.Now().Add(config.PongWait))
+
+		var message ClientMessage
+		reply := ServerMessage{Type: MessageError, Error: "message must be a JSON object"}
+
+		if json.Unmarshal(data, &message) == nil {
+			switch message.Type {
+			case MessageSubscribe:
+				reply = ServerMessage{Type: MessageSubscribed, Topics: conn.Subscribe(message.Topics...)}
+			case MessageUnsubscribe:
+				reply = ServerMessage{Type: MessageSubscribed, Topics: conn.Unsubscribe(message.Topics...)}
+			case MessagePing:
+				reply = ServerMessage{Type: MessagePong}
+			default:
+				reply = ServerMessage{Type: MessageError, Error: "unknown message type " + message.Type}
+			}
+		}
+
+		select {
+		case replies <- reply:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func write(ctx context.Context, socket *websocket.Conn, conn *Conn, config SocketConfig, replies <-chan ServerMessage, done <-chan struct{}, log log.ILogs) {
+	ping := time.NewTicker(config.PingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-done:
+			return
+		case notification, ok := <-conn.Send:
+			if !ok {
+				closeSocket(socket, conn.reason, config)
+				return
+			}
+
+			socket.SetWriteDeadline(time.Now().Add(config.WriteWait))
+			err = socket.WriteJSON(ServerMessage{Type: MessageNotification, Notification: &notification})
+		case reply := <-replies:
+			socket.SetWriteDeadline(time.Now().Add(config.WriteWait))
+			err = socket.WriteJSON(reply)
+		case <-ping.C:
+			err = socket.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.WriteWait))
+		}
+
+		if err != nil {
+			log.Warning(ctx, "socket.Write Error", err.Error())
+			return
+		}
+	}
+}
+
+// closeSocket tell the client why the hub dropped its connection
+func closeSocket(socket *websocket.Conn, reason int, config SocketConfig) {
+	var message []byte
+
+	switch reason {
+	case closeLagged:
+		message = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "connection did not keep up")
+	case closeRevoked:
+		message = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked")
+	case closeShutdown:
+		message = websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	default:
+		message = websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	}
+
+	socket.WriteControl(websocket.CloseMessage, message, time.Now().Add(config.WriteWait))
+}
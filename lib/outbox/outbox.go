@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// message attribute set on every published event
+const (
+	AttributeEventID   = "event_id"
+	AttributeEventType = "event_type"
+)
+
+// event status, a pending event is published once NextAttemptAt is reached,
+// a failed one exhausted its attempts and is left for an operator
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Event wait in the outbox of its tenant until the relay published it, ID
+// give the publish order and EventID identify it to consumer
+type Event struct {
+	ID          uint64 `gorm:"primaryKey" json:"id"`
+	TenantID    uint   `gorm:"not null;index:idx_outbox_pending,priority:1;index:idx_outbox_due,priority:1" json:"-"`
+	EventID     string `gorm:"column:event_id;size:36;not null;uniqueIndex" json:"event_id"`
+	Topic       string `gorm:"size:100;not null" json:"topic"`
+	Type        string `gorm:"size:100;not null" json:"type"`
+	OrderingKey string `gorm:"size:100" json:"ordering_key,omitempty"`
+	Payload     string `gorm:"type:text;not null" json:"payload"`
+	TraceID     string `gorm:"size:64" json:"trace_id,omitempty"`
+
+	Status        string     `gorm:"size:20;not null;default:pending;index:idx_outbox_due,priority:2" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_outbox_due,priority:3" json:"next_attempt_at"`
+	LastError     string     `gorm:"size:1000" json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `gorm:"index:idx_outbox_pending,priority:2" json:"delivered_at,omitempty"`
+}
+
+func (Event) TableName() string {
+	return "outbox"
+}
+
+// Write add an event to the outbox inside the running transaction tx, it is
+// published only once tx commit. Event sharing orderingKey are published in
+// the order they were written
+func Write(ctx context.Context, tx *gorm.DB, topic, eventType, orderingKey string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	event := Event{
+		EventID:       uuid.NewString(),
+		Topic:         topic,
+		Type:          eventType,
+		OrderingKey:   orderingKey,
+		Payload:       string(data),
+		Status:        StatusPending,
+		NextAttemptAt: time.Now(),
+	}
+
+	if traceID, ok := ctx.Value("trace-id").(string); ok {
+		event.TraceID = traceID
+	}
+
+	return tx.Create(&event).Error
+}
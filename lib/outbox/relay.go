@@ -0,0 +1,150 @@
+package outbox
+
+import (
+	"context"
+	"prototype/lib/log"
+	"prototype/lib/pubsub"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RelayConfig of the relay, a failed event is retried after MinBackoff
+// doubled on every attempt up to MaxBackoff and marked failed once it failed
+// MaxAttempts time, zero retry it forever
+type RelayConfig struct {
+	Batch       int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	MaxAttempts int
+}
+
+// Relay publish the outbox, an event is published at least once since a
+// crash between publish and commit publish it again
+type Relay struct {
+	db        *gorm.DB
+	publisher pubsub.IPublisher
+	config    RelayConfig
+	log       log.ILogs
+}
+
+func NewRelay(db *gorm.DB, publisher pubsub.IPublisher, config RelayConfig, log log.ILogs) *Relay {
+	return &Relay{db, publisher, config, log}
+}
+
+// backoff return the delay before the next attempt of an event which failed
+// attempts time
+func (config RelayConfig) backoff(attempts int) time.Duration {
+	delay := config.MinBackoff
+	for i := 1; i < attempts && delay < config.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > config.MaxBackoff {
+		delay = config.MaxBackoff
+	}
+
+	return delay
+}
+
+// Flush publish the due event of the tenant in ctx in order, an event
+// waiting for its retry hold back the later event of its ordering key only
+// and a failed event no longer hold back anything. It return how many were
+// delivered
+func (relay *Relay) Flush(ctx context.Context) (delivered int, err error) {
+	err = relay.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// other replica wait for the lock so event are never published out of
+		// order, an event behind one of its key still waiting is not due yet
+		var events []Event
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+			Where("NOT EXISTS (SELECT 1 FROM outbox waiting WHERE waiting.tenant_id = outbox.tenant_id AND waiting.ordering_key = outbox.ordering_key"+
+				" AND waiting.id < outbox.id AND waiting.status = ? AND waiting.next_attempt_at > ?)", StatusPending, now).
+			Order("id ASC").Limit(relay.config.Batch).Find(&events).Error
+		if err != nil {
+			relay.log.Error(ctx, "tx.Where('status = ? AND next_attempt_at <= ?').Find(&events)", err)
+			return err
+		}
+
+		held := map[string]bool{}
+
+		for _, event := range events {
+			if held[event.OrderingKey] {
+				continue
+			}
+
+			publishCtx := ctx
+			if event.TraceID != "" {
+				publishCtx = context.WithValue(ctx, "trace-id", event.TraceID)
+			}
+
+			_, err := relay.publisher.Publish(publishCtx, event.Topic, pubsub.Message{
+				Data:        []byte(event.Payload),
+				OrderingKey: event.OrderingKey,
+				Attributes: map[string]string{
+					AttributeEventID:   event.EventID,
+					AttributeEventType: event.Type,
+				},
+			})
+			if err != nil {
+				held[event.OrderingKey] = true
+
+				lastError := err.Error()
+				if len(lastError) > 1000 {
+					lastError = lastError[:1000]
+				}
+
+				attempt := map[string]interface{}{
+					"attempts":        event.Attempts + 1,
+					"next_attempt_at": now.Add(relay.config.backoff(event.Attempts + 1)),
+					"last_error":      lastError,
+				}
+
+				// an event which can not be published stop holding back its key
+				if relay.config.MaxAttempts > 0 && event.Attempts+1 >= relay.config.MaxAttempts {
+					attempt["status"] = StatusFailed
+					relay.log.Error(publishCtx, "relay.publisher.Publish Failed "+event.EventID, err)
+				} else {
+					relay.log.Warning(publishCtx, "relay.publisher.Publish Error", map[string]interface{}{
+						"event_id": event.EventID,
+						"attempts": event.Attempts + 1,
+						"error":    err.Error(),
+					})
+				}
+
+				if err := tx.Model(&Event{}).Where("id = ?", event.ID).Updates(attempt).Error; err != nil {
+					relay.log.Error(ctx, "tx.Model(&Event{}).Updates(attempt)", err)
+					return err
+				}
+				continue
+			}
+
+			err = tx.Model(&Event{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+				"status":       StatusDelivered,
+				"delivered_at": now,
+			}).Error
+			if err != nil {
+				relay.log.Error(ctx, "tx.Model(&Event{}).Updates(delivered)", err)
+				return err
+			}
+			delivered++
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// Purge delete the event of the tenant in ctx delivered before before
+func (relay *Relay) Purge(ctx context.Context, before time.Time) (int64, error) {
+	remove := relay.db.WithContext(ctx).Where("delivered_at < ?", before).Delete(&Event{})
+	if remove.Error != nil {
+		relay.log.Error(ctx, "relay.db.Where('delivered_at < ?').Delete(&Event{})", remove.Error)
+	}
+
+	return remove.RowsAffected, remove.Error
+}
@@ -0,0 +1,206 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"prototype/lib/log"
+	"prototype/lib/pubsub"
+	"prototype/lib/tenant"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&Event{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// flakyPublisher fail the message of the ordering key in fail
+type flakyPublisher struct {
+	fail      map[string]bool
+	published []pubsub.Message
+}
+
+func (publisher *flakyPublisher) Publish(ctx context.Context, topic string, message pubsub.Message) (string, error) {
+	if publisher.fail[message.OrderingKey] {
+		return "", errors.New("broker down")
+	}
+
+	publisher.published = append(publisher.published, message)
+	return message.Attributes[AttributeEventID], nil
+}
+
+func write(t *testing.T, db *gorm.DB, ctx context.Context, orderingKey, payload string) {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return Write(ctx, tx, "user", "user.updated", orderingKey, payload)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRelay_Flush(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.WithValue(tenant.WithID(context.Background(), 1), "trace-id", "trace-1")
+
+	write(t, db, ctx, "a", "a1")
+	write(t, db, ctx, "b", "b1")
+	write(t, db, ctx, "a", "a2")
+
+	// rolled back with its transaction
+	db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		Write(ctx, tx, "user", "user.updated", "a", "rolled back")
+		return errors.New("rollback")
+	})
+
+	publisher := &flakyPublisher{fail: map[string]bool{"a": true}}
+	relay := NewRelay(db, publisher, RelayConfig{Batch: 10, MinBackoff: time.Minute, MaxBackoff: time.Hour}, log.NewLog())
+
+	t.Run("failed key hold back its later event only", func(t *testing.T) {
+		delivered, err := relay.Flush(ctx)
+		if err != nil || delivered != 1 {
+			t.Fatalf("relay.Flush() = %v, %v, want 1", delivered, err)
+		}
+
+		var first Event
+		db.WithContext(ctx).Where("payload = ?", `"a1"`).First(&first)
+		if first.Attempts != 1 || first.LastError == "" || !first.NextAttemptAt.After(time.Now()) {
+			t.Errorf("failed event = %+v, want a retry scheduled", first)
+		}
+	})
+
+	t.Run("event is published in order once due", func(t *testing.T) {
+		publisher.fail = nil
+		db.WithContext(ctx).Model(&Event{}).Where("delivered_at IS NULL").Update("next_attempt_at", time.Now())
+
+		delivered, err := relay.Flush(ctx)
+		if err != nil || delivered != 2 {
+			t.Fatalf("relay.Flush() = %v, %v, want 2", delivered, err)
+		}
+
+		var payloads []string
+		for _, message := range publisher.published {
+			payloads = append(payloads, string(message.Data))
+		}
+
+		if len(payloads) != 3 || payloads[1] != `"a1"` || payloads[2] != `"a2"` {
+			t.Errorf("published = %v, want a1 before a2", payloads)
+		}
+
+		if message := publisher.published[0]; message.Attributes[AttributeEventType] != "user.updated" || message.Attributes[AttributeEventID] == "" {
+			t.Errorf("attributes = %v", message.Attributes)
+		}
+
+		if delivered, _ := relay.Flush(ctx); delivered != 0 {
+			t.Errorf("relay.Flush() again = %v, want 0", delivered)
+		}
+	})
+
+	t.Run("other tenant is untouched", func(t *testing.T) {
+		write(t, db, tenant.WithID(context.Background(), 2), "a", "other")
+
+		if delivered, _ := relay.Flush(ctx); delivered != 0 {
+			t.Errorf("relay.Flush() = %v, want 0", delivered)
+		}
+	})
+
+	t.Run("purge delivered event", func(t *testing.T) {
+		purged, err := relay.Purge(ctx, time.Now().Add(time.Second))
+		if err != nil || purged != 3 {
+			t.Errorf("relay.Purge() = %v, %v, want 3", purged, err)
+		}
+	})
+}
+
+func TestRelay_FlushPoison(t *testing.T) {
+	db := setupDB(t)
+	ctx := tenant.WithID(context.Background(), 1)
+
+	write(t, db, ctx, "poison", "p1")
+	write(t, db, ctx, "poison", "p2")
+	write(t, db, ctx, "b", "b1")
+
+	publisher := &flakyPublisher{fail: map[string]bool{"poison": true}}
+	relay := NewRelay(db, publisher, RelayConfig{Batch: 10, MinBackoff: time.Minute, MaxBackoff: time.Hour, MaxAttempts: 2}, log.NewLog())
+
+	due := func() {
+		db.WithContext(ctx).Model(&Event{}).Where("status = ?", StatusPending).Update("next_attempt_at", time.Now())
+	}
+
+	if delivered, err := relay.Flush(ctx); err != nil || delivered != 1 {
+		t.Fatalf("relay.Flush() = %v, %v, want 1", delivered, err)
+	}
+
+	t.Run("event waiting for its retry hold back its key", func(t *testing.T) {
+		var waiting []Event
+		db.WithContext(ctx).Where("status = ?", StatusPending).Order("id ASC").Find(&waiting)
+		if len(waiting) != 2 || waiting[0].Attempts != 1 || waiting[1].Attempts != 0 {
+			t.Fatalf("pending = %+v, want p1 failed once and p2 untouched", waiting)
+		}
+
+		// p2 is due but p1 is not
+		db.WithContext(ctx).Model(&Event{}).Where("id = ?", waiting[1].ID).Update("next_attempt_at", time.Now())
+
+		if delivered, _ := relay.Flush(ctx); delivered != 0 {
+			t.Errorf("relay.Flush() = %v, want 0", delivered)
+		}
+	})
+
+	t.Run("event failed after its last attempt", func(t *testing.T) {
+		due()
+
+		if delivered, err := relay.Flush(ctx); err != nil || delivered != 0 {
+			t.Fatalf("relay.Flush() = %v, %v, want 0", delivered, err)
+		}
+
+		var poison Event
+		db.WithContext(ctx).Where("payload = ?", `"p1"`).First(&poison)
+		if poison.Status != StatusFailed || poison.Attempts != 2 || poison.DeliveredAt != nil {
+			t.Errorf("poison event = %+v, want failed after 2 attempts", poison)
+		}
+	})
+
+	t.Run("failed event no longer hold back its key", func(t *testing.T) {
+		publisher.fail = nil
+		due()
+
+		if delivered, err := relay.Flush(ctx); err != nil || delivered != 1 {
+			t.Fatalf("relay.Flush() = %v, %v, want 1", delivered, err)
+		}
+
+		if last := publisher.published[len(publisher.published)-1]; string(last.Data) != `"p2"` {
+			t.Errorf("published = %s, want p2", last.Data)
+		}
+
+		var poison Event
+		db.WithContext(ctx).Where("payload = ?", `"p1"`).First(&poison)
+		if poison.Status != StatusFailed {
+			t.Errorf("poison event status = %v, want it left failed", poison.Status)
+		}
+	})
+}
+
+func TestRelayConfig_backoff(t *testing.T) {
+	config := RelayConfig{MinBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 10: 10 * time.Second} {
+		if got := config.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package pubsub
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	gpubsub "cloud.google.com/go/pubsub"
+	"google.golang.org/api/option"
+)
+
+type google struct {
+	client *gpubsub.Client
+	config Config
+
+	mu     sync.Mutex
+	topics map[string]*gpubsub.Topic
+}
+
+// NewGoogle connect to Google Pub/Sub, Credential is either the path of a
+// service account file or its content. PUBSUB_EMULATOR_HOST point the
+// client to the emulator
+func NewGoogle(ctx context.Context, config Config, opts ...option.ClientOption) (IPubSub, error) {
+	switch credential := strings.TrimSpace(config.Credential); {
+	case strings.HasPrefix(credential, "{"):
+		opts = append(opts, option.WithCredentialsJSON([]byte(credential)))
+	case credential != "":
+		opts = append(opts, option.WithCredentialsFile(credential))
+	}
+
+	client, err := gpubsub.NewClient(ctx, config.ProjectID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &google{client: client, config: config, topics: map[string]*gpubsub.Topic{}}, nil
+}
+
+// topic keep a single handle per topic so message are batched together
+func (lib *google) topic(name string) *gpubsub.Topic {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	topic, ok := lib.topics[name]
+	if !ok {
+		topic = lib.client.Topic(lib.config.topicID(name))
+		topic.EnableMessageOrdering = true
+		lib.topics[name] = topic
+	}
+
+	return topic
+}
+
+func (lib *google) Publish(ctx context.Context, topic string, message Message) (string, error) {
+	handle := lib.topic(topic)
+
+	id, err := handle.Publish(ctx, &gpubsub.Message{
+		Data:        message.Data,
+		Attributes:  outgoing(ctx, message),
+		OrderingKey: message.OrderingKey,
+	}).Get(ctx)
+
+	// a failed ordering key is paused until resumed, the caller retry it
+	if err != nil && message.OrderingKey != "" {
+		handle.ResumePublish(message.OrderingKey)
+	}
+
+	return id, err
+}
+
+func (lib *google) Receive(ctx context.Context, subscription string, handler Handler) error {
+	handle := lib.client.Subscription(lib.config.subscriptionID(subscription))
+	handle.ReceiveSettings.MaxOutstandingMessages = lib.config.maxOutstanding()
+
+	return handle.Receive(ctx, func(ctx context.Context, received *gpubsub.Message) {
+		message := Message{
+			ID:          received.ID,
+			Data:        received.Data,
+			Attributes:  received.Attributes,
+			OrderingKey: received.OrderingKey,
+			PublishTime: received.PublishTime,
+		}
+
+		if received.DeliveryAttempt != nil {
+			message.DeliveryAttempt = *received.DeliveryAttempt
+		}
+
+		if err := handler(incoming(ctx, message), message); err != nil {
+			received.Nack()
+			return
+		}
+
+		received.Ack()
+	})
+}
+
+func (lib *google) Close() error {
+	lib.mu.Lock()
+	for _, topic := range lib.topics {
+		topic.Stop()
+	}
+	lib.mu.Unlock()
+
+	return lib.client.Close()
+}
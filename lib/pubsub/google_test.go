@@ -0,0 +1,97 @@
+package pubsub
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	gpubsub "cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// setupGoogle connect to the emulator of PUBSUB_EMULATOR_HOST or to an in
+// process fake server, and create the topic and subscription
+func setupGoogle(t *testing.T, config Config) IPubSub {
+	ctx := context.Background()
+
+	var opts []option.ClientOption
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		server := pstest.NewServer()
+		t.Cleanup(func() { server.Close() })
+
+		conn, err := grpc.Dial(server.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+
+		opts = append(opts, option.WithGRPCConn(conn))
+	}
+
+	admin, err := gpubsub.NewClient(ctx, config.ProjectID, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	for subscription, topic := range config.Bindings {
+		handle, err := admin.CreateTopic(ctx, config.topicID(topic))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := admin.CreateSubscription(ctx, config.subscriptionID(subscription), gpubsub.SubscriptionConfig{
+			Topic:                 handle,
+			EnableMessageOrdering: true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lib, err := NewGoogle(ctx, config, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lib.Close() })
+
+	return lib
+}
+
+func TestGoogle_Receive(t *testing.T) {
+	lib := setupGoogle(t, Config{
+		ProjectID:     "prototype-test",
+		Topics:        map[string]string{"user": "user-events"},
+		Subscriptions: map[string]string{"user-audit": "user-events-audit"},
+		Bindings:      map[string]string{"user-audit": "user"},
+	})
+
+	ctx := context.WithValue(context.Background(), "trace-id", "trace-1")
+	for _, data := range []string{"1", "2", "3"} {
+		if _, err := lib.Publish(ctx, "user", Message{Data: []byte(data), OrderingKey: "user-1"}); err != nil {
+			t.Fatalf("lib.Publish() error = %v", err)
+		}
+	}
+
+	var received []string
+	nacked := false
+	receive(t, lib, "user-audit", 3, func(ctx context.Context, message Message) error {
+		if ctx.Value("trace-id") != "trace-1" {
+			t.Errorf("trace id = %v, want trace-1", ctx.Value("trace-id"))
+		}
+
+		if string(message.Data) == "1" && !nacked {
+			nacked = true
+			return context.DeadlineExceeded
+		}
+
+		received = append(received, string(message.Data))
+		return nil
+	})
+
+	if len(received) != 3 || received[0] != "1" || received[2] != "3" {
+		t.Errorf("received = %v, want publish order", received)
+	}
+}
@@ -0,0 +1,181 @@
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// memory deliver message in process, it stand for the broker in test and
+// local run
+type memory struct {
+	config Config
+
+	mu            sync.Mutex
+	subscriptions map[string]*memorySubscription
+}
+
+type memorySubscription struct {
+	topic string
+
+	mu      sync.Mutex
+	pending []Message
+	// ordering key with a message in flight, the next one of the key wait
+	inflight map[string]bool
+	notify   chan struct{}
+}
+
+// NewMemory return an in process broker, a subscription receive the message
+// published once it exist so the subscription of Bindings are created
+// upfront
+func NewMemory(config Config) IPubSub {
+	lib := &memory{config: config, subscriptions: map[string]*memorySubscription{}}
+	for subscription := range config.Bindings {
+		lib.subscription(subscription)
+	}
+
+	return lib
+}
+
+func (lib *memory) subscription(name string) *memorySubscription {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	id := lib.config.subscriptionID(name)
+	subscription, ok := lib.subscriptions[id]
+	if !ok {
+		topic := name
+		if bound, ok := lib.config.Bindings[name]; ok {
+			topic = bound
+		}
+
+		subscription = &memorySubscription{
+			topic:    lib.config.topicID(topic),
+			inflight: map[string]bool{},
+			notify:   make(chan struct{}, 1),
+		}
+		lib.subscriptions[id] = subscription
+	}
+
+	return subscription
+}
+
+func (lib *memory) Publish(ctx context.Context, topic string, message Message) (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	message.ID = hex.EncodeToString(id)
+	message.Attributes = outgoing(ctx, message)
+	message.PublishTime = time.Now()
+
+	topicID := lib.config.topicID(topic)
+
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	for _, subscription := range lib.subscriptions {
+		if subscription.topic == topicID {
+			subscription.push(message)
+		}
+	}
+
+	return message.ID, nil
+}
+
+func (subscription *memorySubscription) push(message Message) {
+	subscription.mu.Lock()
+	subscription.pending = append(subscription.pending, message)
+	subscription.mu.Unlock()
+
+	subscription.wake()
+}
+
+func (subscription *memorySubscription) wake() {
+	select {
+	case subscription.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next take the first pending message whose ordering key is free
+func (subscription *memorySubscription) next() (Message, bool) {
+	subscription.mu.Lock()
+	defer subscription.mu.Unlock()
+
+	for i, message := range subscription.pending {
+		if message.OrderingKey != "" && subscription.inflight[message.OrderingKey] {
+			continue
+		}
+
+		subscription.pending = append(subscription.pending[:i:i], subscription.pending[i+1:]...)
+		if message.OrderingKey != "" {
+			subscription.inflight[message.OrderingKey] = true
+		}
+
+		return message, true
+	}
+
+	return Message{}, false
+}
+
+// done release the ordering key of message, a nacked message is delivered
+// again before the later one of its key
+func (subscription *memorySubscription) done(message Message, ack bool) {
+	subscription.mu.Lock()
+	if !ack {
+		if message.OrderingKey != "" {
+			subscription.pending = append([]Message{message}, subscription.pending...)
+		} else {
+			subscription.pending = append(subscription.pending, message)
+		}
+	}
+	delete(subscription.inflight, message.OrderingKey)
+	subscription.mu.Unlock()
+
+	subscription.wake()
+}
+
+func (lib *memory) Receive(ctx context.Context, name string, handler Handler) error {
+	subscription := lib.subscription(name)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	slots := make(chan struct{}, lib.config.maxOutstanding())
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case slots <- struct{}{}:
+		}
+
+		message, ok := subscription.next()
+		for !ok {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-subscription.notify:
+			}
+			message, ok = subscription.next()
+		}
+
+		message.DeliveryAttempt++
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := handler(incoming(ctx, message), message)
+			subscription.done(message, err == nil)
+		}()
+	}
+}
+
+func (lib *memory) Close() error {
+	return nil
+}
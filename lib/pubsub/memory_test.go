@@ -0,0 +1,133 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// receive run Receive until want message were acked or the test time out
+func receive(t *testing.T, lib IPubSub, subscription string, want int, handler Handler) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var acked int32
+	err := lib.Receive(ctx, subscription, func(ctx context.Context, message Message) error {
+		if err := handler(ctx, message); err != nil {
+			return err
+		}
+
+		if atomic.AddInt32(&acked, 1) == int32(want) {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("lib.Receive() error = %v", err)
+	}
+
+	if int(acked) != want {
+		t.Fatalf("lib.Receive() acked %d message, want %d", acked, want)
+	}
+}
+
+func TestMemory_Receive(t *testing.T) {
+	t.Run("trace id and attributes reach the handler", func(t *testing.T) {
+		lib := NewMemory(Config{Bindings: map[string]string{"user-audit": "user"}})
+
+		ctx := context.WithValue(context.Background(), "trace-id", "trace-1")
+		if _, err := lib.Publish(ctx, "user", Message{Data: []byte("created"), Attributes: map[string]string{"type": "user.created"}}); err != nil {
+			t.Fatalf("lib.Publish() error = %v", err)
+		}
+
+		receive(t, lib, "user-audit", 1, func(ctx context.Context, message Message) error {
+			if ctx.Value("trace-id") != "trace-1" || message.Attributes["type"] != "user.created" || string(message.Data) != "created" {
+				t.Errorf("message = %+v, trace id %v", message, ctx.Value("trace-id"))
+			}
+			return nil
+		})
+	})
+
+	t.Run("every bound subscription receive the message", func(t *testing.T) {
+		lib := NewMemory(Config{Bindings: map[string]string{"first": "user", "second": "user"}})
+		lib.Publish(context.Background(), "user", Message{Data: []byte("1")})
+
+		noop := func(ctx context.Context, message Message) error { return nil }
+		receive(t, lib, "first", 1, noop)
+		receive(t, lib, "second", 1, noop)
+	})
+
+	t.Run("nacked message is redelivered", func(t *testing.T) {
+		lib := NewMemory(Config{Bindings: map[string]string{"user": "user"}})
+		lib.Publish(context.Background(), "user", Message{Data: []byte("1")})
+
+		receive(t, lib, "user", 1, func(ctx context.Context, message Message) error {
+			if message.DeliveryAttempt < 3 {
+				return errors.New("not yet")
+			}
+			return nil
+		})
+	})
+
+	t.Run("ordering key is delivered in order one at a time", func(t *testing.T) {
+		lib := NewMemory(Config{Bindings: map[string]string{"user": "user"}, MaxOutstanding: 5})
+		for _, data := range []string{"1", "2", "3", "4"} {
+			lib.Publish(context.Background(), "user", Message{Data: []byte(data), OrderingKey: "user-1"})
+		}
+
+		var (
+			mu       sync.Mutex
+			received []string
+			failed   bool
+		)
+		receive(t, lib, "user", 4, func(ctx context.Context, message Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			// the second message fail once and must still come before the third
+			if string(message.Data) == "2" && !failed {
+				failed = true
+				return errors.New("retry")
+			}
+
+			received = append(received, string(message.Data))
+			return nil
+		})
+
+		if len(received) != 4 || received[1] != "2" || received[2] != "3" {
+			t.Errorf("received = %v, want publish order", received)
+		}
+	})
+
+	t.Run("concurrency is bounded", func(t *testing.T) {
+		lib := NewMemory(Config{Bindings: map[string]string{"user": "user"}, MaxOutstanding: 2})
+		for i := 0; i < 6; i++ {
+			lib.Publish(context.Background(), "user", Message{})
+		}
+
+		var running, peak int32
+		receive(t, lib, "user", 6, func(ctx context.Context, message Message) error {
+			current := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+
+			for {
+				seen := atomic.LoadInt32(&peak)
+				if current <= seen || atomic.CompareAndSwapInt32(&peak, seen, current) {
+					break
+				}
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+
+		if peak > 2 {
+			t.Errorf("peak concurrency = %d, want at most 2", peak)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package pubsub
+
+import (
+	"context"
+	"time"
+)
+
+// AttributeTraceID carry the trace id of the publisher to the subscriber
+const AttributeTraceID = "trace_id"
+
+// Message published to a topic, message sharing an OrderingKey are delivered
+// in publish order one at a time
+type Message struct {
+	ID          string
+	Data        []byte
+	Attributes  map[string]string
+	OrderingKey string
+
+	// set on delivery only
+	PublishTime     time.Time
+	DeliveryAttempt int
+}
+
+// Handler process a delivered message, returning nil ack it and an error
+// nack it for redelivery
+type Handler func(ctx context.Context, message Message) error
+
+// Config mirror the PubSub block of env.json. Topic and subscription are
+// named by the service and mapped to their id, a name missing from the map
+// is used as id
+type Config struct {
+	ProjectID  string
+	Credential string
+
+	Topics        map[string]string
+	Subscriptions map[string]string
+
+	// Bindings map a subscription to the topic it receive from, it is only
+	// used by the in memory driver and default to the topic of the same name
+	Bindings map[string]string
+
+	// MaxOutstanding bound the message handled concurrently by a Receive
+	MaxOutstanding int
+}
+
+func (config Config) topicID(name string) string {
+	if id, ok := config.Topics[name]; ok && id != "" {
+		return id
+	}
+
+	return name
+}
+
+func (config Config) subscriptionID(name string) string {
+	if id, ok := config.Subscriptions[name]; ok && id != "" {
+		return id
+	}
+
+	return name
+}
+
+func (config Config) maxOutstanding() int {
+	if config.MaxOutstanding <= 0 {
+		return 10
+	}
+
+	return config.MaxOutstanding
+}
+
+// IPublisher publish message to a topic
+type IPublisher interface {
+	// Publish return the id of the message once the broker accepted it, the
+	// trace id of ctx is added to its attributes
+	Publish(ctx context.Context, topic string, message Message) (string, error)
+}
+
+// ISubscriber receive message of a subscription
+type ISubscriber interface {
+	// Receive run handler on the message of subscription until ctx is done,
+	// it return once every handler in flight returned
+	Receive(ctx context.Context, subscription string, handler Handler) error
+}
+
+// IPubSub is a publisher and subscriber on the same broker
+type IPubSub interface {
+	IPublisher
+	ISubscriber
+	Close() error
+}
+
+// outgoing copy the attributes of message with the trace id of ctx
+func outgoing(ctx context.Context, message Message) map[string]string {
+	attributes := make(map[string]string, len(message.Attributes)+1)
+	for key, value := range message.Attributes {
+		attributes[key] = value
+	}
+
+	if traceID, ok := ctx.Value("trace-id").(string); ok && traceID != "" {
+		if _, set := attributes[AttributeTraceID]; !set {
+			attributes[AttributeTraceID] = traceID
+		}
+	}
+
+	return attributes
+}
+
+// incoming return the context a message is handled with, carrying the trace
+// id of its publisher
+func incoming(ctx context.Context, message Message) context.Context {
+	if traceID := message.Attributes[AttributeTraceID]; traceID != "" {
+		return context.WithValue(ctx, "trace-id", traceID)
+	}
+
+	return ctx
+}
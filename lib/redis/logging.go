@@ -0,0 +1,179 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"prototype/lib/log"
+	"reflect"
+	"strings"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// command class switched by the Logging.Redis flags
+const (
+	ClassSave   = "save"
+	ClassGet    = "get"
+	ClassDelete = "delete"
+)
+
+// command outcome
+const (
+	OutcomeOK    = "ok"
+	OutcomeMiss  = "miss"
+	OutcomeError = "error"
+)
+
+// commandClass list the logged command, anything else like ping or publish
+// is never logged
+var commandClass = map[string]string{
+	"set": ClassSave, "setex": ClassSave, "setnx": ClassSave, "psetex": ClassSave, "mset": ClassSave,
+	"getset": ClassSave, "incr": ClassSave, "incrby": ClassSave, "decr": ClassSave, "decrby": ClassSave,
+	"hset": ClassSave, "hmset": ClassSave, "hsetnx": ClassSave, "hincrby": ClassSave,
+	"lpush": ClassSave, "rpush": ClassSave, "sadd": ClassSave, "zadd": ClassSave,
+	"expire": ClassSave, "pexpire": ClassSave, "expireat": ClassSave,
+
+	"get": ClassGet, "mget": ClassGet, "exists": ClassGet, "ttl": ClassGet, "pttl": ClassGet,
+	"hget": ClassGet, "hmget": ClassGet, "hgetall": ClassGet, "hexists": ClassGet,
+	"lrange": ClassGet, "smembers": ClassGet, "sismember": ClassGet, "zrange": ClassGet, "zscore": ClassGet,
+
+	"del": ClassDelete, "unlink": ClassDelete, "hdel": ClassDelete, "srem": ClassDelete,
+	"zrem": ClassDelete, "lrem": ClassDelete, "getdel": ClassDelete,
+}
+
+// LogConfig choose the command class logged, logged value longer than
+// MaxValue are truncated and a MaxValue of 0 redact them entirely
+type LogConfig struct {
+	Save     bool
+	Get      bool
+	Delete   bool
+	MaxValue int
+}
+
+type logHook struct {
+	log    log.ILogs
+	config LogConfig
+}
+
+type startKey struct{}
+
+// NewLogHook return a hook logging every enabled command with its trace id,
+// key, duration and outcome
+func NewLogHook(log log.ILogs, config LogConfig) goredis.Hook {
+	return logHook{log, config}
+}
+
+func (hook logHook) enabled(cmd goredis.Cmder) bool {
+	switch commandClass[cmd.Name()] {
+	case ClassSave:
+		return hook.config.Save
+	case ClassGet:
+		return hook.config.Get
+	case ClassDelete:
+		return hook.config.Delete
+	}
+
+	return false
+}
+
+func (hook logHook) BeforeProcess(ctx context.Context, cmd goredis.Cmder) (context.Context, error) {
+	if !hook.enabled(cmd) {
+		return ctx, nil
+	}
+
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (hook logHook) AfterProcess(ctx context.Context, cmd goredis.Cmder) error {
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		hook.write(ctx, cmd, time.Since(start))
+	}
+
+	return nil
+}
+
+func (hook logHook) BeforeProcessPipeline(ctx context.Context, cmds []goredis.Cmder) (context.Context, error) {
+	for _, cmd := range cmds {
+		if hook.enabled(cmd) {
+			return context.WithValue(ctx, startKey{}, time.Now()), nil
+		}
+	}
+
+	return ctx, nil
+}
+
+// AfterProcessPipeline log every command of the pipeline with the duration of
+// the whole pipeline
+func (hook logHook) AfterProcessPipeline(ctx context.Context, cmds []goredis.Cmder) error {
+	start, ok := ctx.Value(startKey{}).(time.Time)
+	if !ok {
+		return nil
+	}
+
+	duration := time.Since(start)
+	for _, cmd := range cmds {
+		if hook.enabled(cmd) {
+			hook.write(ctx, cmd, duration)
+		}
+	}
+
+	return nil
+}
+
+func (hook logHook) write(ctx context.Context, cmd goredis.Cmder, duration time.Duration) {
+	args := cmd.Args()
+
+	var key string
+	if len(args) > 1 {
+		key = fmt.Sprint(args[1])
+	}
+
+	var request interface{}
+	if len(args) > 2 {
+		values := make([]string, 0, len(args)-2)
+		for _, arg := range args[2:] {
+			values = append(values, hook.value(fmt.Sprint(arg)))
+		}
+		request = values
+	}
+
+	outcome := OutcomeOK
+	var response interface{}
+
+	switch err := cmd.Err(); {
+	case errors.Is(err, goredis.Nil):
+		outcome = OutcomeMiss
+	case err != nil:
+		outcome = OutcomeError
+		response = err.Error()
+	default:
+		response = hook.value(result(cmd))
+	}
+
+	hook.log.Redis(ctx, "Redis "+strings.ToUpper(cmd.Name()), cmd.Name(), key, outcome, duration, request, response)
+}
+
+// value apply the redaction of config to a logged value
+func (hook logHook) value(value string) string {
+	switch {
+	case hook.config.MaxValue <= 0:
+		return fmt.Sprintf("[redacted %d bytes]", len(value))
+	case len(value) > hook.config.MaxValue:
+		return fmt.Sprintf("%s...[%d bytes]", value[:hook.config.MaxValue], len(value))
+	}
+
+	return value
+}
+
+// result format the value of any command type, every go-redis command expose
+// its result through Val
+func result(cmd goredis.Cmder) string {
+	method := reflect.ValueOf(cmd).MethodByName("Val")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() == 0 {
+		return ""
+	}
+
+	return fmt.Sprint(method.Call(nil)[0].Interface())
+}
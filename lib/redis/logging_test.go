@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"prototype/lib/log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+type redisEntry struct {
+	command, key, outcome string
+	req, res              interface{}
+}
+
+// fakeLogs keep the redis entry, any other level is unused by the hook
+type fakeLogs struct {
+	log.ILogs
+	entries []redisEntry
+}
+
+func (logs *fakeLogs) Redis(ctx context.Context, actName, command, key, outcome string, duration time.Duration, req, res interface{}) {
+	logs.entries = append(logs.entries, redisEntry{command, key, outcome, req, res})
+}
+
+func process(hook goredis.Hook, cmd goredis.Cmder) {
+	ctx, _ := hook.BeforeProcess(context.Background(), cmd)
+	hook.AfterProcess(ctx, cmd)
+}
+
+func TestLogHook(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("log only enabled class", func(t *testing.T) {
+		logs := &fakeLogs{}
+		hook := NewLogHook(logs, LogConfig{Get: true, MaxValue: 16})
+
+		process(hook, goredis.NewStatusCmd(ctx, "set", "user:1", "value"))
+		process(hook, goredis.NewIntCmd(ctx, "del", "user:1"))
+		process(hook, goredis.NewStatusCmd(ctx, "ping"))
+
+		get := goredis.NewStringCmd(ctx, "get", "user:1")
+		get.SetVal("value")
+		process(hook, get)
+
+		want := []redisEntry{{command: "get", key: "user:1", outcome: OutcomeOK, res: "value"}}
+		if !reflect.DeepEqual(logs.entries, want) {
+			t.Errorf("entries = %+v, want %+v", logs.entries, want)
+		}
+	})
+
+	t.Run("miss and error outcome", func(t *testing.T) {
+		logs := &fakeLogs{}
+		hook := NewLogHook(logs, LogConfig{Get: true, MaxValue: 16})
+
+		miss := goredis.NewStringCmd(ctx, "get", "missing")
+		miss.SetErr(goredis.Nil)
+		process(hook, miss)
+
+		failed := goredis.NewStringCmd(ctx, "get", "failed")
+		failed.SetErr(errors.New("connection refused"))
+		process(hook, failed)
+
+		if len(logs.entries) != 2 || logs.entries[0].outcome != OutcomeMiss || logs.entries[1].outcome != OutcomeError {
+			t.Errorf("entries = %+v, want a miss then an error", logs.entries)
+		}
+	})
+
+	t.Run("value are truncated", func(t *testing.T) {
+		logs := &fakeLogs{}
+		hook := NewLogHook(logs, LogConfig{Save: true, MaxValue: 4})
+
+		process(hook, goredis.NewStatusCmd(ctx, "set", "user:1", "secret-value"))
+
+		req := logs.entries[0].req.([]string)
+		if len(req) != 1 || !strings.HasPrefix(req[0], "secr...") || strings.Contains(req[0], "value") {
+			t.Errorf("request = %v, want truncated value", req)
+		}
+	})
+
+	t.Run("value are redacted without max", func(t *testing.T) {
+		logs := &fakeLogs{}
+		hook := NewLogHook(logs, LogConfig{Save: true})
+
+		process(hook, goredis.NewStatusCmd(ctx, "set", "user:1", "secret-value"))
+
+		if req := logs.entries[0].req.([]string); strings.Contains(req[0], "secret") {
+			t.Errorf("request = %v, want redacted value", req)
+		}
+	})
+
+	t.Run("pipeline log every command", func(t *testing.T) {
+		logs := &fakeLogs{}
+		hook := NewLogHook(logs, LogConfig{Save: true, Delete: true, MaxValue: 16})
+
+		cmds := []goredis.Cmder{
+			goredis.NewStatusCmd(ctx, "set", "user:1", "value"),
+			goredis.NewIntCmd(ctx, "del", "user:2"),
+			goredis.NewStringCmd(ctx, "get", "user:3"),
+		}
+		pipeCtx, _ := hook.BeforeProcessPipeline(ctx, cmds)
+		hook.AfterProcessPipeline(pipeCtx, cmds)
+
+		if len(logs.entries) != 2 || logs.entries[0].key != "user:1" || logs.entries[1].key != "user:2" {
+			t.Errorf("entries = %+v, want set and del", logs.entries)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// client mode, chosen from the config the same way go-redis does
+const (
+	ModeSingle   = "single"
+	ModeSentinel = "sentinel"
+	ModeCluster  = "cluster"
+)
+
+// Nil is returned by a get on a missing key
+const Nil = goredis.Nil
+
+// Config mirror the RedisUniv block of env.json, a MasterName make it a
+// sentinel client and more than one host a cluster client
+type Config struct {
+	Hosts    []string
+	DB       int
+	ReadOnly bool
+
+	Username         string
+	Password         string
+	SentinelUsername string
+	SentinelPassword string
+
+	MaxRetries   int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	PoolSize           int
+	MinIdleConns       int
+	MaxConnAge         time.Duration
+	PoolTimeout        time.Duration
+	IdleTimeout        time.Duration
+	IdleCheckFrequency time.Duration
+
+	MaxRedirects   int
+	RouteByLatency bool
+	RouteRandomly  bool
+	MasterName     string
+
+	// TLSCAPath enable TLS trusting only the CA in the file
+	TLSCAPath string
+}
+
+// IRedis is the client domains depend on
+type IRedis interface {
+	goredis.UniversalClient
+
+	// Mode return single, sentinel or cluster
+	Mode() string
+	// Health ping every node the client talk to
+	Health(ctx context.Context) Health
+}
+
+type Health struct {
+	Mode  string       `json:"mode"`
+	Up    bool         `json:"up"`
+	Nodes []NodeHealth `json:"nodes"`
+}
+
+type NodeHealth struct {
+	Addr    string `json:"addr"`
+	Up      bool   `json:"up"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency,omitempty"`
+}
+
+type client struct {
+	goredis.UniversalClient
+	mode string
+}
+
+// New build the client, no connection is made before the first command
+func New(config Config) (IRedis, error) {
+	options, err := config.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{goredis.NewUniversalClient(options), config.Mode()}, nil
+}
+
+// Mode return the client config build
+func (config Config) Mode() string {
+	switch {
+	case config.MasterName != "":
+		return ModeSentinel
+	case len(config.Hosts) > 1:
+		return ModeCluster
+	default:
+		return ModeSingle
+	}
+}
+
+// Options translate config to go-redis options
+func (config Config) Options() (*goredis.UniversalOptions, error) {
+	if len(config.Hosts) == 0 {
+		return nil, errors.New("redis: no host configured")
+	}
+
+	options := &goredis.UniversalOptions{
+		Addrs:    config.Hosts,
+		DB:       config.DB,
+		ReadOnly: config.ReadOnly,
+
+		Username:         config.Username,
+		Password:         config.Password,
+		SentinelUsername: config.SentinelUsername,
+		SentinelPassword: config.SentinelPassword,
+
+		MaxRetries:   config.MaxRetries,
+		DialTimeout:  config.DialTimeout,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+
+		PoolSize:           config.PoolSize,
+		MinIdleConns:       config.MinIdleConns,
+		MaxConnAge:         config.MaxConnAge,
+		PoolTimeout:        config.PoolTimeout,
+		IdleTimeout:        config.IdleTimeout,
+		IdleCheckFrequency: config.IdleCheckFrequency,
+
+		MaxRedirects:   config.MaxRedirects,
+		RouteByLatency: config.RouteByLatency,
+		RouteRandomly:  config.RouteRandomly,
+		MasterName:     config.MasterName,
+	}
+
+	if config.TLSCAPath != "" {
+		tlsConfig, err := loadTLS(config.TLSCAPath)
+		if err != nil {
+			return nil, err
+		}
+
+		options.TLSConfig = tlsConfig
+	}
+
+	return options, nil
+}
+
+func loadTLS(path string) (*tls.Config, error) {
+	ca, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("redis: no certificate in %s", path)
+	}
+
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+func (client *client) Mode() string {
+	return client.mode
+}
+
+func (client *client) Health(ctx context.Context) Health {
+	health := Health{Mode: client.mode, Nodes: []NodeHealth{}}
+
+	cluster, ok := client.UniversalClient.(*goredis.ClusterClient)
+	if !ok {
+		node := ping(ctx, client.UniversalClient)
+		health.Nodes = append(health.Nodes, node)
+		health.Up = node.Up
+		return health
+	}
+
+	var mu sync.Mutex
+	err := cluster.ForEachShard(ctx, func(ctx context.Context, shard *goredis.Client) error {
+		node := ping(ctx, shard)
+
+		mu.Lock()
+		health.Nodes = append(health.Nodes, node)
+		mu.Unlock()
+
+		if !node.Up {
+			return errors.New(node.Error)
+		}
+
+		return nil
+	})
+
+	health.Up = err == nil && len(health.Nodes) > 0
+	if len(health.Nodes) == 0 && err != nil {
+		health.Nodes = append(health.Nodes, NodeHealth{Error: err.Error()})
+	}
+
+	return health
+}
+
+type pinger interface {
+	Ping(ctx context.Context) *goredis.StatusCmd
+}
+
+func ping(ctx context.Context, node pinger) NodeHealth {
+	var health NodeHealth
+	if client, ok := node.(*goredis.Client); ok {
+		health.Addr = client.Options().Addr
+	}
+
+	start := time.Now()
+	if err := node.Ping(ctx).Err(); err != nil {
+		health.Error = err.Error()
+		return health
+	}
+
+	health.Up = true
+	health.Latency = time.Since(start).String()
+	return health
+}
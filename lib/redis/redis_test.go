@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfig_Mode(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{name: "single host", config: Config{Hosts: []string{"127.0.0.1:6379"}}, want: ModeSingle},
+		{name: "many host", config: Config{Hosts: []string{"10.0.0.1:6379", "10.0.0.2:6379"}}, want: ModeCluster},
+		{name: "master name", config: Config{Hosts: []string{"10.0.0.1:26379", "10.0.0.2:26379"}, MasterName: "main"}, want: ModeSentinel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.Mode(); got != tt.want {
+				t.Errorf("Config.Mode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Options(t *testing.T) {
+	dir := t.TempDir()
+	invalidCA := filepath.Join(dir, "invalid.pem")
+	os.WriteFile(invalidCA, []byte("not a certificate"), 0600)
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "no host", config: Config{}, wantErr: true},
+		{name: "plain", config: Config{Hosts: []string{"127.0.0.1:6379"}, DialTimeout: time.Second}},
+		{name: "missing ca file", config: Config{Hosts: []string{"127.0.0.1:6379"}, TLSCAPath: filepath.Join(dir, "missing.pem")}, wantErr: true},
+		{name: "invalid ca file", config: Config{Hosts: []string{"127.0.0.1:6379"}, TLSCAPath: invalidCA}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := tt.config.Options()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Config.Options() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && (options.DialTimeout != tt.config.DialTimeout || options.TLSConfig != nil) {
+				t.Errorf("Config.Options() = %+v", options)
+			}
+		})
+	}
+}
+
+func TestClient_HealthDown(t *testing.T) {
+	client, err := New(Config{Hosts: []string{"127.0.0.1:1"}, MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	health := client.Health(context.Background())
+	if health.Up || len(health.Nodes) != 1 || health.Nodes[0].Error == "" || health.Mode != ModeSingle {
+		t.Errorf("client.Health() = %+v, want a single node down", health)
+	}
+}
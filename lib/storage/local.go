@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type local struct {
+	root    string
+	baseURL string
+}
+
+// NewLocal store blob as file below root, they are expected to be served
+// under baseURL
+func NewLocal(root, baseURL string) (IStorage, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+
+	return &local{root, baseURL}, nil
+}
+
+func (lib *local) path(key string) (string, error) {
+	path := filepath.Join(lib.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(lib.root)+string(filepath.Separator)) {
+		return "", errors.New("storage key escape root")
+	}
+
+	return path, nil
+}
+
+func (lib *local) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := lib.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so reader never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (lib *local) Delete(ctx context.Context, key string) error {
+	path, err := lib.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (lib *local) URL(key string) string {
+	return joinURL(lib.baseURL, key)
+}
+
+func (lib *local) Key(url string) (string, bool) {
+	return splitURL(lib.baseURL, url)
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type (
+	ConfigS3 struct {
+		Endpoint  string
+		AccessKey string
+		SecretKey string
+		Bucket    string
+		Region    string
+		UseSSL    bool
+
+		// PublicURL default to the path style url of the bucket
+		PublicURL string
+	}
+
+	s3 struct {
+		client  *minio.Client
+		bucket  string
+		baseURL string
+	}
+)
+
+// NewS3 store blob in an s3 compatible bucket, e.g aws s3 or a local minio
+func NewS3(cfg ConfigS3) (IStorage, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := cfg.PublicURL
+	if baseURL == "" {
+		scheme := "http"
+		if cfg.UseSSL {
+			scheme = "https"
+		}
+		baseURL = fmt.Sprintf("%s://%s/%s", scheme, cfg.Endpoint, cfg.Bucket)
+	}
+
+	return &s3{client, cfg.Bucket, baseURL}, nil
+}
+
+func (lib *s3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	_, err := lib.client.PutObject(ctx, lib.bucket, key, body, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+
+	return err
+}
+
+func (lib *s3) Delete(ctx context.Context, key string) error {
+	return lib.client.RemoveObject(ctx, lib.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (lib *s3) URL(key string) string {
+	return joinURL(lib.baseURL, key)
+}
+
+func (lib *s3) Key(url string) (string, bool) {
+	return splitURL(lib.baseURL, url)
+}
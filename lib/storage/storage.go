@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// IStorage is a flat blob store addressed by key
+type IStorage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+
+	// URL return the public url of key, Key is its reverse
+	URL(key string) string
+	Key(url string) (string, bool)
+}
+
+func joinURL(baseURL, key string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(key, "/")
+}
+
+func splitURL(baseURL, url string) (string, bool) {
+	prefix := strings.TrimRight(baseURL, "/") + "/"
+	if !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(url, prefix), true
+}
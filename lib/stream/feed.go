@@ -0,0 +1,46 @@
+package stream
+
+import (
+	"context"
+	"prototype/lib/log"
+	"prototype/lib/pubsub"
+	"time"
+)
+
+// Decoder turn a message of the bus into an event
+type Decoder func(message pubsub.Message) (Event, error)
+
+// Feed publish every message of subscription on the hub until ctx is done,
+// then close the hub so open stream end with the process. A message which
+// can not be decoded is logged and acknowledged. Every replica need its own
+// subscription so each get every message
+func (hub *Hub) Feed(ctx context.Context, subscriber pubsub.ISubscriber, subscription string, decode Decoder, log log.ILogs) {
+	defer hub.Close()
+
+	for {
+		err := subscriber.Receive(ctx, subscription, func(ctx context.Context, message pubsub.Message) error {
+			event, err := decode(message)
+			if err != nil {
+				log.Error(ctx, "decode(message) Error", err)
+				return nil
+			}
+
+			hub.Publish(event)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			log.Error(ctx, "subscriber.Receive Error", err)
+		}
+
+		// the subscription went away, it is received again after a pause
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
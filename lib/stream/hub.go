@@ -0,0 +1,206 @@
+package stream
+
+import (
+	"sync"
+)
+
+// Event is one server-sent event. Name and Data are what the client get,
+// Payload is the decoded data filter look at
+type Event struct {
+	ID      string
+	Name    string
+	Data    []byte
+	Payload interface{}
+}
+
+// Filter tell whether a client want event
+type Filter func(event Event) bool
+
+// Config of a hub. Replay is how many of the latest event are kept for a
+// client resuming with Last-Event-ID, ClientBuffer how many event can wait
+// for a slow client before it is dropped
+type Config struct {
+	Replay       int
+	ClientBuffer int
+}
+
+// Stats of a hub
+type Stats struct {
+	Clients   int    `json:"clients"`
+	Buffered  int    `json:"buffered"`
+	Published uint64 `json:"published"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+// Hub fan event out to every subscribed client, a client never hold back
+// the others: one whose buffer is full is dropped and resume from the replay
+// buffer once it reconnect
+type Hub struct {
+	config Config
+
+	mu      sync.Mutex
+	replay  []Event
+	next    int
+	clients map[*Client]struct{}
+	closed  bool
+	stats   Stats
+}
+
+// Client receive the event of its filter on Events, which is closed once the
+// client is dropped or the hub closed
+type Client struct {
+	Events <-chan Event
+
+	events chan Event
+	filter Filter
+	lagged bool
+}
+
+// Lagged tell whether the client was dropped for not keeping up, it is only
+// meaningful once Events is closed
+func (client *Client) Lagged() bool {
+	return client.lagged
+}
+
+func NewHub(config Config) *Hub {
+	if config.Replay < 0 {
+		config.Replay = 0
+	}
+
+	if config.ClientBuffer < 1 {
+		config.ClientBuffer = 1
+	}
+
+	return &Hub{
+		config:  config,
+		replay:  make([]Event, 0, config.Replay),
+		clients: map[*Client]struct{}{},
+	}
+}
+
+// Publish keep event for replay and hand it to every client whose filter
+// match it
+func (hub *Hub) Publish(event Event) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return
+	}
+
+	hub.stats.Published++
+
+	if hub.config.Replay > 0 {
+		if len(hub.replay) < hub.config.Replay {
+			hub.replay = append(hub.replay, event)
+		} else {
+			hub.replay[hub.next] = event
+			hub.next = (hub.next + 1) % hub.config.Replay
+		}
+	}
+
+	for client := range hub.clients {
+		if client.filter != nil && !client.filter(event) {
+			continue
+		}
+
+		select {
+		case client.events <- event:
+		default:
+			client.lagged = true
+			hub.drop(client)
+			hub.stats.Dropped++
+		}
+	}
+}
+
+// Subscribe register a client, lastEventID is the id of the last event the
+// client got before it reconnected. The event published after it that
+// filter match are returned to be sent first, resumed is false when
+// lastEventID is no longer in the replay buffer and the client missed event.
+// A client without lastEventID only get what is published from now on
+func (hub *Hub) Subscribe(filter Filter, lastEventID string) (client *Client, replay []Event, resumed bool) {
+	events := make(chan Event, hub.config.ClientBuffer)
+	client = &Client{Events: events, events: events, filter: filter}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		close(events)
+		return client, nil, false
+	}
+
+	// registering under the same lock as the replay leave no gap between both
+	hub.clients[client] = struct{}{}
+
+	// a new client start from now
+	if lastEventID == "" {
+		return client, nil, true
+	}
+
+	for _, event := range hub.ordered() {
+		if !resumed {
+			resumed = event.ID == lastEventID
+			continue
+		}
+
+		if filter == nil || filter(event) {
+			replay = append(replay, event)
+		}
+	}
+
+	return
+}
+
+// Unsubscribe remove a client which went away
+func (hub *Hub) Unsubscribe(client *Client) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.drop(client)
+}
+
+// Close end every client and refuse new one
+func (hub *Hub) Close() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return
+	}
+
+	hub.closed = true
+	for client := range hub.clients {
+		hub.drop(client)
+	}
+}
+
+func (hub *Hub) Stats() Stats {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	stats := hub.stats
+	stats.Clients = len(hub.clients)
+	stats.Buffered = len(hub.replay)
+
+	return stats
+}
+
+func (hub *Hub) drop(client *Client) {
+	if _, ok := hub.clients[client]; !ok {
+		return
+	}
+
+	delete(hub.clients, client)
+	close(client.events)
+}
+
+// ordered return the replay buffer oldest first
+func (hub *Hub) ordered() []Event {
+	if len(hub.replay) < hub.config.Replay {
+		return hub.replay
+	}
+
+	return append(append([]Event{}, hub.replay[hub.next:]...), hub.replay[:hub.next]...)
+}
@@ -0,0 +1,175 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"prototype/lib/log"
+	"prototype/lib/pubsub"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func publish(hub *Hub, ids ...string) {
+	for _, id := range ids {
+		hub.Publish(Event{ID: id, Name: "user", Data: []byte(id), Payload: id})
+	}
+}
+
+func ids(events []Event) (result []string) {
+	for _, event := range events {
+		result = append(result, event.ID)
+	}
+	return
+}
+
+// drain read what is waiting for client without blocking
+func drain(client *Client) (result []string) {
+	for {
+		select {
+		case event, ok := <-client.Events:
+			if !ok {
+				return
+			}
+			result = append(result, event.ID)
+		default:
+			return
+		}
+	}
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub(Config{Replay: 10, ClientBuffer: 10})
+
+	all, _, _ := hub.Subscribe(nil, "")
+	even, _, _ := hub.Subscribe(func(event Event) bool {
+		var id int
+		fmt.Sscan(event.ID, &id)
+		return id%2 == 0
+	}, "")
+
+	publish(hub, "1", "2", "3", "4")
+
+	if got := drain(all); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+		t.Errorf("unfiltered client got %v", got)
+	}
+
+	if got := drain(even); !reflect.DeepEqual(got, []string{"2", "4"}) {
+		t.Errorf("filtered client got %v", got)
+	}
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	hub := NewHub(Config{Replay: 3, ClientBuffer: 10})
+	publish(hub, "1", "2", "3", "4", "5")
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		filter      Filter
+		wantReplay  []string
+		wantResumed bool
+	}{
+		{name: "new client get no replay", wantResumed: true},
+		{name: "resume after the last event received", lastEventID: "3", wantReplay: []string{"4", "5"}, wantResumed: true},
+		{name: "resume with filter", lastEventID: "3", filter: func(event Event) bool { return event.ID != "4" }, wantReplay: []string{"5"}, wantResumed: true},
+		{name: "up to date", lastEventID: "5", wantResumed: true},
+		{name: "evicted from the replay buffer", lastEventID: "1", wantResumed: false},
+		{name: "unknown id", lastEventID: "other", wantResumed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, replay, resumed := hub.Subscribe(tt.filter, tt.lastEventID)
+			defer hub.Unsubscribe(client)
+
+			if resumed != tt.wantResumed || !reflect.DeepEqual(ids(replay), tt.wantReplay) {
+				t.Errorf("hub.Subscribe() = %v, %v, want %v, %v", ids(replay), resumed, tt.wantReplay, tt.wantResumed)
+			}
+		})
+	}
+}
+
+func TestHub_Backpressure(t *testing.T) {
+	hub := NewHub(Config{Replay: 10, ClientBuffer: 2})
+
+	slow, _, _ := hub.Subscribe(nil, "")
+	fast, _, _ := hub.Subscribe(nil, "")
+
+	publish(hub, "1", "2")
+	drain(fast)
+	publish(hub, "3")
+
+	// the slow client is dropped, the fast one keep receiving
+	if got := drain(slow); !reflect.DeepEqual(got, []string{"1", "2"}) || !slow.Lagged() {
+		t.Errorf("slow client got %v, lagged %v, want 1 2 then dropped", got, slow.Lagged())
+	}
+
+	if got := drain(fast); !reflect.DeepEqual(got, []string{"3"}) || fast.Lagged() {
+		t.Errorf("fast client got %v, lagged %v", got, fast.Lagged())
+	}
+
+	// it catch up from where it stopped once it reconnect
+	_, replay, resumed := hub.Subscribe(nil, "2")
+	if !resumed || !reflect.DeepEqual(ids(replay), []string{"3"}) {
+		t.Errorf("hub.Subscribe() after lag = %v, %v, want 3", ids(replay), resumed)
+	}
+
+	if stats := hub.Stats(); stats.Dropped != 1 || stats.Clients != 2 || stats.Published != 3 {
+		t.Errorf("hub.Stats() = %+v", stats)
+	}
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub(Config{Replay: 10, ClientBuffer: 2})
+	client, _, _ := hub.Subscribe(nil, "")
+
+	hub.Close()
+
+	if _, ok := <-client.Events; ok || client.Lagged() {
+		t.Error("client.Events still open after hub.Close()")
+	}
+
+	late, _, _ := hub.Subscribe(nil, "")
+	if _, ok := <-late.Events; ok {
+		t.Error("hub.Subscribe() after hub.Close() return an open client")
+	}
+}
+
+func TestHub_Feed(t *testing.T) {
+	broker := pubsub.NewMemory(pubsub.Config{Bindings: map[string]string{"stream": "user"}})
+	hub := NewHub(Config{Replay: 10, ClientBuffer: 10})
+	client, _, _ := hub.Subscribe(nil, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hub.Feed(ctx, broker, "stream", func(message pubsub.Message) (Event, error) {
+			if string(message.Data) == "broken" {
+				return Event{}, errors.New("broken")
+			}
+			return Event{ID: message.Attributes["id"], Data: message.Data}, nil
+		}, log.NewLog())
+		close(done)
+	}()
+
+	for _, data := range []string{"broken", "ok"} {
+		broker.Publish(context.Background(), "user", pubsub.Message{Data: []byte(data), Attributes: map[string]string{"id": data}})
+	}
+
+	select {
+	case event := <-client.Events:
+		if event.ID != "ok" {
+			t.Errorf("client got %v, want the decoded message", event.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event fed from the broker")
+	}
+
+	cancel()
+	<-done
+
+	if _, ok := <-client.Events; ok {
+		t.Error("client.Events still open once the feed stopped")
+	}
+}
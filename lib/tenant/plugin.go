@@ -0,0 +1,106 @@
+package tenant
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// field is the struct field marking a model as tenant scoped
+const field = "TenantID"
+
+// Plugin scope every statement on a model with a TenantID field to the tenant
+// of the statement context, create fill the field and query, update and
+// delete get an extra where clause. Statement without a tenant fail with
+// ErrMissing instead of silently reading across tenants
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "tenant"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	if err := callback.Create().Before("gorm:create").Register("tenant:create", assign); err != nil {
+		return err
+	}
+
+	if err := callback.Query().Before("gorm:query").Register("tenant:query", scope); err != nil {
+		return err
+	}
+
+	if err := callback.Row().Before("gorm:row").Register("tenant:row", scope); err != nil {
+		return err
+	}
+
+	if err := callback.Update().Before("gorm:update").Register("tenant:update", func(db *gorm.DB) {
+		assign(db)
+		scope(db)
+	}); err != nil {
+		return err
+	}
+
+	return callback.Delete().Before("gorm:delete").Register("tenant:delete", scope)
+}
+
+func lookup(db *gorm.DB) (*schema.Field, uint, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, 0, false
+	}
+
+	tenantField := db.Statement.Schema.LookUpField(field)
+	if tenantField == nil {
+		return nil, 0, false
+	}
+
+	id, ok := FromContext(db.Statement.Context)
+	if !ok {
+		db.AddError(ErrMissing)
+		return nil, 0, false
+	}
+
+	return tenantField, id, true
+}
+
+func scope(db *gorm.DB) {
+	tenantField, id, ok := lookup(db)
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: tenantField.DBName}, Value: id},
+	}})
+}
+
+// assign overwrite the tenant of the written record with the context tenant
+func assign(db *gorm.DB) {
+	tenantField, id, ok := lookup(db)
+	if !ok {
+		return
+	}
+
+	ctx := db.Statement.Context
+	value := db.Statement.ReflectValue
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := tenantField.Set(ctx, reflect.Indirect(value.Index(i)), id); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if !value.CanAddr() {
+			return
+		}
+
+		if err := tenantField.Set(ctx, value, id); err != nil {
+			db.AddError(err)
+		}
+	}
+}
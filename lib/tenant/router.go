@@ -0,0 +1,332 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RouterConfig tell the router where the database of a tenant live
+type RouterConfig struct {
+	// DSN return the dedicated database of the tenant, an empty DSN mean the
+	// tenant share the primary database
+	DSN func(ctx context.Context, id uint) (string, error)
+
+	// Open connect to a dedicated database and bring its schema up to date
+	Open func(ctx context.Context, id uint, dsn string) (*gorm.DB, error)
+
+	// IdleTimeout close a dedicated database unused for that long
+	IdleTimeout time.Duration
+}
+
+// Router is a gorm.ConnPool sending every statement to the database of the
+// tenant of the statement context. Dedicated database are opened on first use
+// and closed again once idle, statement without a tenant go to the primary
+type Router struct {
+	primary *sql.DB
+	config  RouterConfig
+
+	mu    sync.Mutex
+	conns map[uint]*conn
+
+	done chan struct{}
+	once sync.Once
+}
+
+type conn struct {
+	ready chan struct{}
+
+	db       *gorm.DB
+	sqlDB    *sql.DB
+	err      error
+	lastUsed time.Time
+}
+
+// Health is the state of the primary database and of every open dedicated one
+type Health struct {
+	Primary DatabaseHealth   `json:"primary"`
+	Tenants []DatabaseHealth `json:"tenants"`
+}
+
+type DatabaseHealth struct {
+	TenantID uint      `json:"tenant_id,omitempty"`
+	Up       bool      `json:"up"`
+	Error    string    `json:"error,omitempty"`
+	LastUsed time.Time `json:"last_used,omitempty"`
+}
+
+func NewRouter(primary *sql.DB, config RouterConfig) *Router {
+	router := &Router{
+		primary: primary,
+		config:  config,
+		conns:   map[uint]*conn{},
+		done:    make(chan struct{}),
+	}
+
+	if config.IdleTimeout > 0 {
+		go router.janitor()
+	}
+
+	return router
+}
+
+// DB return the gorm connection of the tenant in ctx, nil when the tenant
+// share the primary database
+func (router *Router) DB(ctx context.Context) (*gorm.DB, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	entry, err := router.conn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.db, nil
+}
+
+func (router *Router) pick(ctx context.Context) (*sql.DB, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return router.primary, nil
+	}
+
+	entry, err := router.conn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.sqlDB == nil {
+		return router.primary, nil
+	}
+
+	return entry.sqlDB, nil
+}
+
+// conn return the connection of the tenant, opening it when needed, a
+// concurrent caller wait for the first one instead of opening twice
+func (router *Router) conn(ctx context.Context, id uint) (*conn, error) {
+	router.mu.Lock()
+	entry, found := router.conns[id]
+	if found {
+		router.mu.Unlock()
+		<-entry.ready
+	} else {
+		entry = &conn{ready: make(chan struct{})}
+		router.conns[id] = entry
+		router.mu.Unlock()
+
+		entry.db, entry.sqlDB, entry.err = router.open(ctx, id)
+		close(entry.ready)
+	}
+
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	if entry.err != nil {
+		// forget the failure so the next request try again
+		if router.conns[id] == entry {
+			delete(router.conns, id)
+		}
+
+		return nil, entry.err
+	}
+
+	entry.lastUsed = time.Now()
+	return entry, nil
+}
+
+func (router *Router) open(ctx context.Context, id uint) (*gorm.DB, *sql.DB, error) {
+	dsn, err := router.config.DSN(ctx, id)
+	if err != nil || dsn == "" {
+		return nil, nil, err
+	}
+
+	db, err := router.config.Open(ctx, id, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return db, sqlDB, nil
+}
+
+func (router *Router) janitor() {
+	ticker := time.NewTicker(router.config.IdleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-router.done:
+			return
+		case now := <-ticker.C:
+			router.Evict(now.Add(-router.config.IdleTimeout))
+		}
+	}
+}
+
+// Evict close every tenant connection unused since before
+func (router *Router) Evict(before time.Time) {
+	router.mu.Lock()
+	var idle []*conn
+	for id, entry := range router.conns {
+		select {
+		case <-entry.ready:
+		default:
+			continue
+		}
+
+		if entry.lastUsed.Before(before) {
+			idle = append(idle, entry)
+			delete(router.conns, id)
+		}
+	}
+	router.mu.Unlock()
+
+	for _, entry := range idle {
+		if entry.sqlDB != nil {
+			entry.sqlDB.Close()
+		}
+	}
+}
+
+// Health ping the primary and every open dedicated database
+func (router *Router) Health(ctx context.Context) Health {
+	health := Health{Primary: ping(ctx, router.primary)}
+
+	type opened struct {
+		id       uint
+		sqlDB    *sql.DB
+		lastUsed time.Time
+	}
+
+	router.mu.Lock()
+	var open []opened
+	for id, entry := range router.conns {
+		select {
+		case <-entry.ready:
+			if entry.sqlDB != nil {
+				open = append(open, opened{id, entry.sqlDB, entry.lastUsed})
+			}
+		default:
+		}
+	}
+	router.mu.Unlock()
+
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].id < open[j].id
+	})
+
+	health.Tenants = []DatabaseHealth{}
+	for _, entry := range open {
+		tenantHealth := ping(ctx, entry.sqlDB)
+		tenantHealth.TenantID = entry.id
+		tenantHealth.LastUsed = entry.lastUsed
+		health.Tenants = append(health.Tenants, tenantHealth)
+	}
+
+	return health
+}
+
+func ping(ctx context.Context, db *sql.DB) DatabaseHealth {
+	if err := db.PingContext(ctx); err != nil {
+		return DatabaseHealth{Error: err.Error()}
+	}
+
+	return DatabaseHealth{Up: true}
+}
+
+// Close stop the eviction and close every dedicated database, the primary is
+// left to its owner
+func (router *Router) Close() error {
+	router.once.Do(func() {
+		close(router.done)
+	})
+
+	router.Evict(time.Now().Add(time.Hour))
+	return nil
+}
+
+func (router *Router) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	db, err := router.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.PrepareContext(ctx, query)
+}
+
+func (router *Router) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db, err := router.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExecContext(ctx, query, args...)
+}
+
+func (router *Router) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db, err := router.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.QueryContext(ctx, query, args...)
+}
+
+func (router *Router) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	db, err := router.pick(ctx)
+	if err != nil {
+		// *sql.Row can only carry an error coming from a database, the row
+		// hold nothing else so the database is closed right away
+		failed := sql.OpenDB(failConnector{err})
+		defer failed.Close()
+
+		return failed.QueryRowContext(ctx, query, args...)
+	}
+
+	return db.QueryRowContext(ctx, query, args...)
+}
+
+func (router *Router) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	db, err := router.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.BeginTx(ctx, opts)
+}
+
+// GetDBConn expose the primary to gorm.DB.DB
+func (router *Router) GetDBConn() (*sql.DB, error) {
+	return router.primary, nil
+}
+
+// failConnector is a database whose every connection attempt fail with err
+type failConnector struct {
+	err error
+}
+
+func (connector failConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, connector.err
+}
+
+func (connector failConnector) Driver() driver.Driver {
+	return failDriver(connector)
+}
+
+type failDriver failConnector
+
+func (d failDriver) Open(string) (driver.Conn, error) {
+	return nil, d.err
+}
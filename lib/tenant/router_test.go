@@ -0,0 +1,214 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type record struct {
+	ID       uint
+	TenantID uint
+	Name     string
+}
+
+func setupRouter(t *testing.T) (*gorm.DB, *Router, map[uint]string, *int32) {
+	dir := t.TempDir()
+
+	primary, err := sql.Open("sqlite", filepath.Join(dir, "primary.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { primary.Close() })
+
+	dsn := map[uint]string{
+		2: filepath.Join(dir, "tenant-2.db"),
+		3: filepath.Join(dir, "missing", "tenant-3.db"),
+	}
+	opened := new(int32)
+
+	router := NewRouter(primary, RouterConfig{
+		DSN: func(ctx context.Context, id uint) (string, error) {
+			return dsn[id], nil
+		},
+		Open: func(ctx context.Context, id uint, dsn string) (*gorm.DB, error) {
+			atomic.AddInt32(opened, 1)
+
+			db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+			if err != nil {
+				return nil, err
+			}
+
+			return db, db.AutoMigrate(&record{})
+		},
+	})
+	t.Cleanup(func() { router.Close() })
+
+	db, err := gorm.Open(&sqlite.Dialector{Conn: router}, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Use(Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&record{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return db, router, dsn, opened
+}
+
+func count(t *testing.T, path string) (total int64) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Model(&record{}).Count(&total).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestRouter(t *testing.T) {
+	db, router, dsn, opened := setupRouter(t)
+
+	shared := WithID(context.Background(), 1)
+	dedicated := WithID(context.Background(), 2)
+
+	if atomic.LoadInt32(opened) != 0 {
+		t.Fatalf("opened = %d before any tenant statement, want 0", *opened)
+	}
+
+	t.Run("statement reach the tenant database", func(t *testing.T) {
+		if err := db.WithContext(shared).Create(&record{Name: "shared"}).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := db.WithContext(dedicated).Create(&record{Name: "dedicated"}).Error; err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := count(t, dsn[2]); got != 5 {
+			t.Errorf("dedicated database hold %d record, want 5", got)
+		}
+
+		var records []record
+		if err := db.WithContext(shared).Find(&records).Error; err != nil || len(records) != 1 {
+			t.Errorf("shared tenant see %v, %v, want its single record", records, err)
+		}
+
+		if got := atomic.LoadInt32(opened); got != 1 {
+			t.Errorf("opened = %d, want the dedicated database opened once", got)
+		}
+	})
+
+	t.Run("transaction stay on the tenant database", func(t *testing.T) {
+		err := db.WithContext(dedicated).Transaction(func(tx *gorm.DB) error {
+			return tx.Create(&record{Name: "in transaction"}).Error
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := count(t, dsn[2]); got != 6 {
+			t.Errorf("dedicated database hold %d record, want 6", got)
+		}
+	})
+
+	t.Run("health report open database", func(t *testing.T) {
+		health := router.Health(context.Background())
+		if !health.Primary.Up || len(health.Tenants) != 1 || health.Tenants[0].TenantID != 2 || !health.Tenants[0].Up {
+			t.Errorf("router.Health() = %+v, want primary and tenant 2 up", health)
+		}
+	})
+
+	t.Run("idle database is closed and reopened on use", func(t *testing.T) {
+		router.Evict(time.Now().Add(time.Minute))
+
+		if health := router.Health(context.Background()); len(health.Tenants) != 0 {
+			t.Errorf("router.Health() = %+v, want no open tenant database", health)
+		}
+
+		var total int64
+		if err := db.WithContext(dedicated).Model(&record{}).Count(&total).Error; err != nil || total != 6 {
+			t.Errorf("count = %d, %v, want 6", total, err)
+		}
+
+		if got := atomic.LoadInt32(opened); got != 2 {
+			t.Errorf("opened = %d, want 2", got)
+		}
+	})
+
+	t.Run("open failure is returned and retried", func(t *testing.T) {
+		broken := WithID(context.Background(), 3)
+
+		for attempt := 1; attempt <= 2; attempt++ {
+			if err := db.WithContext(broken).Create(&record{Name: "broken"}).Error; err == nil {
+				t.Errorf("attempt %d error = nil, want the open error", attempt)
+			}
+		}
+
+		if got := atomic.LoadInt32(opened); got != 4 {
+			t.Errorf("opened = %d, want every attempt to retry the open", got)
+		}
+
+		var version string
+		err := router.QueryRowContext(broken, "SELECT 1").Scan(&version)
+		if err == nil || errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("QueryRowContext().Scan() error = %v, want the open error", err)
+		}
+	})
+}
+
+func TestRouter_QueryRowFailure(t *testing.T) {
+	primary, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "primary.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+
+	router := NewRouter(primary, RouterConfig{
+		DSN: func(ctx context.Context, id uint) (string, error) {
+			return "", errors.New("registry down")
+		},
+	})
+	defer router.Close()
+
+	ctx := WithID(context.Background(), 2)
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 100; i++ {
+		var version string
+		if err := router.QueryRowContext(ctx, "SELECT 1").Scan(&version); err == nil || err.Error() != "registry down" {
+			t.Fatalf("QueryRowContext().Scan() error = %v, want the registry error", err)
+		}
+	}
+
+	// the opener goroutine of a closed database exit on its own
+	time.Sleep(50 * time.Millisecond)
+
+	if after := runtime.NumGoroutine(); after > before+5 {
+		t.Errorf("goroutines = %d after 100 failed query, was %d", after, before)
+	}
+}
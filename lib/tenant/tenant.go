@@ -0,0 +1,34 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+// contextKey follow the raw string key used for "user-id" and "trace-id"
+const contextKey = "tenant-id"
+
+// ErrMissing is returned for a query on a tenant scoped model when the
+// context carry no tenant
+var ErrMissing = errors.New("tenant missing in context")
+
+// WithID return a copy of ctx scoped to the tenant
+func WithID(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, contextKey, id)
+}
+
+// WithoutID return a copy of ctx not scoped to any tenant, statement run with
+// it reach the primary database
+func WithoutID(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey, uint(0))
+}
+
+// FromContext return the tenant ctx is scoped to
+func FromContext(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+
+	id, ok := ctx.Value(contextKey).(uint)
+	return id, ok && id != 0
+}
@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+type (
+	FieldError struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+
+	// Error is returned when a document does not satisfy its schema,
+	// it carry every offending field so it can be reported at once
+	Error struct {
+		Fields []FieldError
+	}
+
+	// Schema validate json document against a json schema, a nil schema
+	// accept no property at all
+	Schema struct {
+		schema *jsonschema.Schema
+	}
+)
+
+func (err Error) Error() string {
+	messages := make([]string, 0, len(err.Fields))
+	for _, field := range err.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// NewSchema compile the json schema at path, empty path return nil schema
+func NewSchema(path string) (*Schema, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(path, file); err != nil {
+		return nil, err
+	}
+
+	schema, err := compiler.Compile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{schema}, nil
+}
+
+// Validate check doc against the schema, unknown top level properties are
+// always rejected. Field name in the returned Error is prefixed with prefix
+func (s *Schema) Validate(prefix string, doc map[string]interface{}) error {
+	var fields []FieldError
+
+	known := map[string]interface{}{}
+	for key, value := range doc {
+		if s == nil || s.schema.Properties[key] == nil {
+			fields = append(fields, FieldError{
+				Field:   fieldName(prefix, key),
+				Message: "unknown attribute",
+			})
+			continue
+		}
+
+		known[key] = value
+	}
+
+	if s != nil {
+		// normalize go values to their json representation before validating
+		raw, err := json.Marshal(known)
+		if err != nil {
+			return err
+		}
+
+		var instance interface{}
+		if err := json.Unmarshal(raw, &instance); err != nil {
+			return err
+		}
+
+		if err := s.schema.Validate(instance); err != nil {
+			validationErr, ok := err.(*jsonschema.ValidationError)
+			if !ok {
+				return err
+			}
+
+			for _, basic := range validationErr.BasicOutput().Errors {
+				if basic.Error == "" || strings.HasPrefix(basic.Error, "doesn't validate with") {
+					continue
+				}
+
+				fields = append(fields, FieldError{
+					Field:   fieldName(prefix, strings.ReplaceAll(strings.TrimPrefix(basic.InstanceLocation, "/"), "/", ".")),
+					Message: basic.Error,
+				})
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Field < fields[j].Field
+	})
+
+	return Error{Fields: fields}
+}
+
+// Has report whether the schema declare the top level property
+func (s *Schema) Has(key string) bool {
+	return s != nil && s.schema.Properties[key] != nil
+}
+
+func fieldName(prefix, key string) string {
+	switch {
+	case prefix == "":
+		return key
+	case key == "":
+		return prefix
+	default:
+		return fmt.Sprintf("%s.%s", prefix, key)
+	}
+}
@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when the receiver resolve to an address of
+// the internal network, which a tenant must not be able to reach
+var ErrForbiddenAddress = errors.New("webhook receiver address is not allowed")
+
+// forbiddenNetworks are not covered by the net.IP predicates
+var forbiddenNetworks = parseNetworks(
+	"0.0.0.0/8",     // this network
+	"100.64.0.0/10", // carrier grade nat
+	"192.0.0.0/24",  // protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"64:ff9b::/96",  // nat64 reach any ipv4 address
+)
+
+// maxDrain is how much of the response is read so the connection is reused
+const maxDrain = 64 << 10
+
+// Request is one delivery attempt, ID identify the delivery and stay the
+// same on every attempt so the receiver can drop a duplicate
+type Request struct {
+	URL    string
+	Secret string
+	ID     string
+	Event  string
+	Body   []byte
+}
+
+// Result of an attempt, the response body is never kept since the receiver
+// is chosen by the tenant
+type Result struct {
+	StatusCode int
+	Duration   time.Duration
+}
+
+// ISender deliver a webhook request
+type ISender interface {
+	Send(ctx context.Context, request Request) (Result, error)
+}
+
+// ClientConfig of a client, Allow list the internal network a receiver may
+// still be in, which is only meant for test and trusted deployment
+type ClientConfig struct {
+	Timeout time.Duration
+	Allow   []*net.IPNet
+}
+
+// Client post signed delivery, any status but 2xx is a failure and redirect
+// are not followed. The address is checked when the connection is made so a
+// name resolving to an internal address is refused whenever it is resolved
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(config ClientConfig) ISender {
+	dialer := &net.Dialer{
+		Timeout: config.Timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || (forbidden(ip) && !contains(config.Allow, ip)) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+
+			return nil
+		},
+	}
+
+	return &Client{
+		http: &http.Client{
+			Timeout: config.Timeout,
+			// a proxy would be the one checked instead of the receiver
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: config.Timeout,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send post request signed at the current time
+func (client *Client) Send(ctx context.Context, request Request) (result Result, err error) {
+	now := time.Now()
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Body))
+	if err != nil {
+		return
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set(HeaderID, request.ID)
+	httpRequest.Header.Set(HeaderEvent, request.Event)
+	httpRequest.Header.Set(HeaderTimestamp, fmt.Sprint(now.Unix()))
+	httpRequest.Header.Set(HeaderSignature, Sign(request.Secret, request.ID, now, request.Body))
+
+	response, err := client.http.Do(httpRequest)
+	result.Duration = time.Since(now)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	result.StatusCode = response.StatusCode
+
+	// drain the response so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxDrain))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		err = fmt.Errorf("receiver answered %d", response.StatusCode)
+	}
+
+	return
+}
+
+// forbidden tell whether ip is in the internal network: loopback, private,
+// link local, unspecified or multicast
+func forbidden(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+
+	return contains(forbiddenNetworks, ip)
+}
+
+func contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseNetworks parse CIDR, a single address is taken as a network of its own
+func ParseNetworks(values ...string) (networks []*net.IPNet, err error) {
+	for _, value := range values {
+		if ip := net.ParseIP(value); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, parseErr := net.ParseCIDR(value)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		networks = append(networks, network)
+	}
+
+	return
+}
+
+func parseNetworks(values ...string) []*net.IPNet {
+	networks, err := ParseNetworks(values...)
+	if err != nil {
+		panic(err)
+	}
+
+	return networks
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// header sent with every delivery
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// signatureVersion prefix the signature so the scheme can change without
+// breaking receiver
+const signatureVersion = "v1"
+
+var (
+	ErrSignature = errors.New("webhook signature does not match")
+	ErrTimestamp = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// Sign return the signature of a delivery, a HMAC-SHA256 with secret of
+// id, the unix timestamp and body joined by a dot
+func Sign(secret, id string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id + "." + strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(body)
+
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify check the signature header of a delivery received at now, a
+// delivery signed more than tolerance away from now is refused so a captured
+// request can not be replayed later. The signature header may hold several
+// space separated signature while a secret is rotated
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	unix, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrTimestamp
+	}
+
+	timestamp := time.Unix(unix, 0)
+	if timestamp.Before(now.Add(-tolerance)) || timestamp.After(now.Add(tolerance)) {
+		return ErrTimestamp
+	}
+
+	expected := Sign(secret, header.Get(HeaderID), timestamp, body)
+	for _, signature := range strings.Fields(header.Get(HeaderSignature)) {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrSignature
+}
@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signedHeader(secret, id string, timestamp time.Time, body []byte) http.Header {
+	header := http.Header{}
+	header.Set(HeaderID, id)
+	header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	header.Set(HeaderSignature, Sign(secret, id, timestamp, body))
+	return header
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"type":"user.created"}`)
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    []byte
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			header: signedHeader("secret", "1", now, body),
+			body:   body,
+		},
+		{
+			name: "valid with a rotated secret next to it",
+			header: func() http.Header {
+				header := signedHeader("secret", "1", now, body)
+				header.Set(HeaderSignature, Sign("old", "1", now, body)+" "+header.Get(HeaderSignature))
+				return header
+			}(),
+			body: body,
+		},
+		{
+			name:    "wrong secret",
+			header:  signedHeader("other", "1", now, body),
+			body:    body,
+			wantErr: ErrSignature,
+		},
+		{
+			name:    "tampered body",
+			header:  signedHeader("secret", "1", now, body),
+			body:    []byte(`{"type":"user.deleted"}`),
+			wantErr: ErrSignature,
+		},
+		{
+			name: "id moved to another delivery",
+			header: func() http.Header {
+				header := signedHeader("secret", "1", now, body)
+				header.Set(HeaderID, "2")
+				return header
+			}(),
+			body:    body,
+			wantErr: ErrSignature,
+		},
+		{
+			name:    "replayed after the tolerance",
+			header:  signedHeader("secret", "1", now.Add(-10*time.Minute), body),
+			body:    body,
+			wantErr: ErrTimestamp,
+		},
+		{
+			name:    "missing timestamp",
+			header:  http.Header{HeaderSignature: []string{Sign("secret", "1", now, body)}},
+			body:    body,
+			wantErr: ErrTimestamp,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify("secret", tt.header, tt.body, 5*time.Minute, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_Send(t *testing.T) {
+	var received error
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if received = Verify("secret", r.Header, body, time.Minute, time.Now()); received != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Header.Get(HeaderEvent) {
+		case "redirect":
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		case "fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer receiver.Close()
+
+	// the receiver of the test is on loopback, which is refused by default
+	client := NewClient(ClientConfig{Timeout: time.Second, Allow: parseNetworks("127.0.0.0/8")})
+
+	tests := []struct {
+		name       string
+		event      string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "delivered", event: "user.created", wantStatus: http.StatusOK},
+		{name: "failure", event: "fail", wantStatus: http.StatusInternalServerError, wantErr: true},
+		{name: "redirect is not followed", event: "redirect", wantStatus: http.StatusFound, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := client.Send(context.Background(), Request{
+				URL:    receiver.URL,
+				Secret: "secret",
+				ID:     "delivery-1",
+				Event:  tt.event,
+				Body:   []byte(`{}`),
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("client.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if received != nil {
+				t.Fatalf("receiver Verify() error = %v", received)
+			}
+
+			if result.StatusCode != tt.wantStatus {
+				t.Errorf("client.Send() = %d, want %d", result.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestClient_SendForbiddenAddress(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("receiver on the internal network was reached")
+	}))
+	defer receiver.Close()
+
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(receiver.URL, "http://"))
+
+	client := NewClient(ClientConfig{Timeout: time.Second})
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "loopback address", url: receiver.URL},
+		{name: "name resolving to loopback", url: "http://localhost:" + port},
+		{name: "metadata address", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "private address", url: "http://10.0.0.1:" + port},
+		{name: "unspecified address", url: "http://0.0.0.0:" + port},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Send(context.Background(), Request{URL: tt.url, Secret: "secret", ID: "delivery-1", Event: "user.created", Body: []byte(`{}`)})
+			if !errors.Is(err, ErrForbiddenAddress) {
+				t.Errorf("client.Send() error = %v, want %v", err, ErrForbiddenAddress)
+			}
+		})
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks("10.1.0.0/16", "192.0.2.7", "::1")
+	if err != nil {
+		t.Fatalf("ParseNetworks() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "10.1.2.3", want: true},
+		{ip: "10.2.0.1", want: false},
+		{ip: "192.0.2.7", want: true},
+		{ip: "192.0.2.8", want: false},
+		{ip: "::1", want: true},
+	} {
+		if got := contains(networks, net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("contains(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+
+	if _, err := ParseNetworks("internal"); err == nil {
+		t.Error("ParseNetworks() accepted an invalid network")
+	}
+}
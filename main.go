@@ -1,12 +1,60 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"prototype/config"
 	"prototype/lib/env"
+	"syscall"
+	"time"
 )
 
 func main() {
 	cfg := config.NewConfig()
 
-	cfg.Router.Run(env.String("MainSetup.ServerHost", "3000"))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// consumer finish the message in flight before the process exit
+	drained := make(chan struct{})
+	go func() {
+		cfg.Consumers.Run(ctx)
+		close(drained)
+	}()
+
+	// the user stream end every open stream once ctx is done
+	go cfg.UserStream.Run(ctx)
+
+	// the notification socket are hijacked from the server, Shutdown does not
+	// wait on them and they are closed once ctx is done
+	go cfg.Notifications.Run(ctx)
+
+	server := &http.Server{
+		Addr:    env.String("MainSetup.ServerHost", "3000"),
+		Handler: cfg.Router,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), env.Duration("MainSetup.ShutdownTimeout", 30*time.Second))
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println(err)
+	}
+
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+	}
 }